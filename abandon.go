@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"go-game/game"
+	"go-game/store"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Games nobody wants anymore can be abandoned, which annuls them (finished, no winner,
+// never rated), or deleted outright, which also frees their storage
+// Players may only call off a game before its first move, or a hotseat game nobody else plays in;
+// once a game is underway, abandoning it resigns it and only admins may annul or delete it,
+// so nobody escapes a lost game or takes a win away from the opponent
+
+// Errors of abandoning and deleting games
+var (
+	errNotOwner     = errors.New("only the players of the game or an admin can do this")
+	errGameUnderway = errors.New("the game is underway or over, only an admin can delete it")
+)
+
+// checkOwner makes sure a request comes from a player of the game, by a seat token or
+// the account of a player, or from an admin
+// Games without seats or accounts (older and imported ones) belong to nobody, so only admins may
+func checkOwner(c echo.Context, board *game.Board) error {
+	if isAdmin(c) {
+		return nil
+	}
+	if !board.Seated() && board.Players == [3]string{} {
+		return errNotOwner
+	}
+	for player := 1; player <= 2; player++ {
+		if checkRequestSeat(c, board, player) == nil {
+			return nil
+		}
+	}
+	return errNotOwner
+}
+
+// undecided reports whether the players may still call a game off: it isn't over, and nothing
+// has been played yet or it is a hotseat game, which has no opponent and is never rated
+func undecided(board *game.Board) bool {
+	return board.Phase != game.PhaseFinished && (board.Hotseat || len(board.MoveHistory) == 0)
+}
+
+// forgetGame removes a live game from this server, with everything attached to it
+// Must be called with the game locked; requests waiting for its lock then find no game
+func forgetGame(gameID string) {
+	releaseBot(gameID)
+
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	delete(games, gameID)
+	delete(gameLocks, gameID)
+	delete(kibitz, gameID)
+	delete(predictions, gameID)
+	delete(conditionals, gameID)
+	delete(audience, gameID)
+	delete(deadlineWarned, gameID)
+	delete(lastActivity, gameID)
+	delete(sandboxGames, gameID)
+}
+
+// Annul a game nobody wants to finish, or resign it if it is underway
+func abandonGame(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	if err := checkOwner(c, board); err != nil {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
+
+	phase := board.Phase
+	var err error
+	if isAdmin(c) || undecided(board) {
+		err = board.Annul()
+	} else {
+		err = board.Resign(requestSeat(c, board))
+	}
+	if err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	saveGame(c.Request().Context(), gameID, board)
+
+	announceResult(gameID, board, phase)
+	return respondBoard(c, http.StatusOK, gameID, board.ViewFor(0))
+}
+
+// Delete a game from this server and the store
+func deleteGame(c echo.Context) error {
+	gameID := c.Param("id")
+	ctx := c.Request().Context()
+
+	// Find the game, falling back to the store for games not live on this server
+	board, unlock, live := lockGame(gameID)
+	if live {
+		defer unlock()
+	} else {
+		stored, err := gameStore.LoadGame(ctx, gameID)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+		case errors.Is(err, store.ErrCorrupted) && isAdmin(c):
+			// Admins may still clear out games that fail their integrity check
+		case err != nil:
+			log.Printf("loading game %s: %v", gameID, err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load the game"})
+		default:
+			board = stored
+		}
+	}
+
+	if board != nil {
+		if err := checkOwner(c, board); err != nil {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+		}
+		if !isAdmin(c) && !undecided(board) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": errGameUnderway.Error()})
+		}
+	}
+
+	if !isSandbox(gameID) {
+		if err := gameStore.DeleteGame(ctx, gameID); err != nil {
+			log.Printf("deleting game %s: %v", gameID, err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete the game"})
+		}
+	}
+	if err := chatStore.DeleteGame(ctx, gameID); err != nil {
+		log.Printf("deleting the chat of game %s: %v", gameID, err)
+	}
+	unrateGame(ctx, gameID)
+	unarchiveGame(ctx, gameID)
+	forgetGame(gameID)
+
+	hub.Broadcast(Event{Type: EventGameDeleted, GameID: gameID})
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"go-game/auth"
+	"go-game/game"
+	"go-game/users"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// userStore keeps the player accounts (Postgres if DATABASE_URL is set, memory otherwise)
+var userStore users.Store
+
+// newUserStoreFromEnv opens the account store next to the game store
+func newUserStoreFromEnv() (users.Store, error) {
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		return users.NewPostgresStore(url)
+	}
+	return users.NewMemoryStore(), nil
+}
+
+// userDirectory finds tournament entrants among the accounts (see AccountDirectory)
+type userDirectory struct {
+	store users.Store
+}
+
+func (d userDirectory) FindAccount(ctx context.Context, email, name string) (string, error) {
+	user, err := d.store.FindByEmail(ctx, email)
+	if errors.Is(err, users.ErrNotFound) && name != "" {
+		user, err = d.store.FindByUsername(ctx, name)
+	}
+	if errors.Is(err, users.ErrNotFound) {
+		return "", nil
+	}
+	return user.ID, err
+}
+
+// errWrongLogin rejects sign-ins
+var errWrongLogin = errors.New("wrong username or password")
+
+// Registration request structure
+type RegisterRequest struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	Email       string `json:"email"`       // Optional; matches the account with tournament rosters
+	DisplayName string `json:"displayName"` // Defaults to the username
+	Rank        string `json:"rank"`        // e.g. "5k" or "2d"
+}
+
+// Login request structure
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Profile update request structure; fields left out are kept
+type ProfileRequest struct {
+	DisplayName     *string `json:"displayName"`
+	Rank            *string `json:"rank"`
+	Email           *string `json:"email"`
+	Password        *string `json:"password"`        // A new password signs out every other browser and revokes every token
+	CurrentPassword string  `json:"currentPassword"` // Needed to change the password
+}
+
+// Sign-in response structure
+type SignInResponse struct {
+	User users.User `json:"user"`
+	auth.Tokens
+}
+
+// issueTokens signs the tokens of an account
+func issueTokens(user users.User) (auth.Tokens, error) {
+	return tokenIssuer.Issue(auth.User{ID: user.ID, Name: user.DisplayName}, time.Now())
+}
+
+// Create an account and sign in with it
+func register(c echo.Context) error {
+	var registerReq RegisterRequest
+	if err := c.Bind(&registerReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	var id [16]byte
+	rand.Read(id[:])
+	now := time.Now().UTC()
+	user := users.User{
+		ID:          hex.EncodeToString(id[:]),
+		Username:    registerReq.Username,
+		Email:       registerReq.Email,
+		DisplayName: registerReq.DisplayName,
+		Rank:        registerReq.Rank,
+		CreatedAt:   now,
+	}
+	if err := user.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if isSandbox(strings.ToLower(user.Username)) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Usernames starting with " + sandboxPrefix + " are kept for the sandbox"})
+	}
+	if err := user.SetPassword(registerReq.Password, now); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	err := userStore.Create(c.Request().Context(), user)
+	if errors.Is(err, users.ErrTaken) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	tokens, err := issueTokens(user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, SignInResponse{User: user, Tokens: tokens})
+}
+
+// checkLogin finds the account of a username and password
+// Unknown users and wrong passwords give the same error, so accounts can't be probed
+func checkLogin(c echo.Context, loginReq LoginRequest) (users.User, error) {
+	user, err := userStore.FindByUsername(c.Request().Context(), loginReq.Username)
+	if errors.Is(err, users.ErrNotFound) || (err == nil && !user.CheckPassword(loginReq.Password)) {
+		return users.User{}, errWrongLogin
+	}
+	return user, err
+}
+
+// Sign in with a username and password
+func login(c echo.Context) error {
+	var loginReq LoginRequest
+	if err := c.Bind(&loginReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	user, err := checkLogin(c, loginReq)
+	if errors.Is(err, errWrongLogin) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	tokens, err := issueTokens(user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, SignInResponse{User: user, Tokens: tokens})
+}
+
+// Get the public profile of an account
+func getUserProfile(c echo.Context) error {
+	user, err := userStore.Get(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, users.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	profile, err := ratedProfile(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, profile)
+}
+
+// Change the profile of the signed in account
+func updateProfile(c echo.Context) error {
+	var profileReq ProfileRequest
+	if err := c.Bind(&profileReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	user, ok, err := account(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Guests have no profile, register first"})
+	}
+
+	for _, change := range []struct {
+		value *string
+		field *string
+	}{{profileReq.DisplayName, &user.DisplayName}, {profileReq.Rank, &user.Rank}, {profileReq.Email, &user.Email}} {
+		if change.value != nil {
+			*change.field = *change.value
+		}
+	}
+	if err := user.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if profileReq.Password != nil {
+		if !user.CheckPassword(profileReq.CurrentPassword) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Wrong current password"})
+		}
+		if err := user.SetPassword(*profileReq.Password, time.Now().UTC()); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+
+	err = userStore.Update(c.Request().Context(), user)
+	if errors.Is(err, users.ErrTaken) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	// A new password signs out every other browser, and every client signed in with tokens
+	if profileReq.Password != nil {
+		current, _ := currentSession(c)
+		if err := sessionStore.DeleteUser(c.Request().Context(), user.ID, current.ID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		tokenIssuer.Revoke(user.ID, user.PasswordChangedAt)
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// account returns the account of the signed in user; ok is false for anonymous users and guests
+func account(c echo.Context) (user users.User, ok bool, err error) {
+	current, signedIn := currentUser(c)
+	if !signedIn {
+		return user, false, nil
+	}
+	return lookupAccount(c.Request().Context(), current)
+}
+
+// lookupAccount returns the account of an authenticated user, if it still exists
+func lookupAccount(ctx context.Context, current auth.User) (user users.User, ok bool, err error) {
+	user, err = userStore.Get(ctx, current.ID)
+	if errors.Is(err, users.ErrNotFound) {
+		return user, false, nil
+	}
+	return user, err == nil, err
+}
+
+// seatAccount records an account as the player of a color, and names the player in the game record after it
+func seatAccount(board *game.Board, player int, user users.User) error {
+	if err := board.SitDown(player, user.ID); err != nil {
+		return err
+	}
+
+	name, rank := &board.Info.BlackName, &board.Info.BlackRank
+	if player == 2 {
+		name, rank = &board.Info.WhiteName, &board.Info.WhiteRank
+	}
+	*name, *rank = user.DisplayName, user.Rank
+	return nil
+}
+
+// Take the seat of a seat token with the signed in account, e.g. after being sent the token by the game's creator
+// From then on the account plays that color without the token
+func claimSeat(c echo.Context) error {
+	gameID := c.Param("id")
+	user, ok, err := account(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Guests can't take seats, register first"})
+	}
+
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	seat := board.SeatOf(seatToken(c))
+	if seat == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid seat token"})
+	}
+	if isSandbox(user.ID) && !isSandbox(gameID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Sandbox accounts only play sandbox games"})
+	}
+	if err := seatAccount(board, seat, user); err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	saveGame(c.Request().Context(), gameID, board)
+
+	return respondBoard(c, http.StatusOK, gameID, board.ViewFor(seat))
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"errors"
+	"go-game/game"
+	"go-game/i18n"
+	"go-game/store"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Moderation: operators handling disputes and abuse look into the live games and the
+// WebSocket connections, end games that can't go on, annul results and close connections
+// Every action is logged with the reason given, as the players aren't asked
+
+// maxAdminReasonLength limits the reason of an admin action, in characters
+const maxAdminReasonLength = 500
+
+// AdminGame is a live game as admins see it
+type AdminGame struct {
+	GameSync
+	Players     [3]string `json:"players"`     // Accounts playing each color (index 1 = black, 2 = white)
+	Seated      bool      `json:"seated"`      // The seats are taken with tokens
+	Sandbox     bool      `json:"sandbox"`     // Never saved, purged after a while
+	Spectators  int       `json:"spectators"`  // Spectators following it over WebSocket
+	Connections int       `json:"connections"` // WebSocket connections following it, players included
+	IdleSince   time.Time `json:"idleSince"`   // Last change
+}
+
+// Admin game detail response structure
+type AdminGameDetail struct {
+	AdminGame
+	Board     *game.Board       `json:"board"`     // Everything, hidden stones included
+	Following []AdminConnection `json:"following"` // Connections following it
+}
+
+// AdminConnection is an open WebSocket connection
+type AdminConnection struct {
+	ID     string         `json:"id"`
+	UserID string         `json:"userId,omitempty"` // Signed in user (empty if anonymous)
+	Name   string         `json:"name,omitempty"`
+	IP     string         `json:"ip"`
+	Since  time.Time      `json:"since"`
+	Games  map[string]int `json:"games"` // Games followed and the seat taken in each (0 = spectator)
+}
+
+// Admin action request structure
+type AdminActionRequest struct {
+	Reason string `json:"reason"` // Why, for the log and the players
+}
+
+// bindAdminReason reads the reason of an admin action ("" with a message if invalid)
+// The body is optional, so actions can be taken with a bare request
+func bindAdminReason(c echo.Context) (string, string) {
+	var req AdminActionRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return "", "Invalid request format"
+		}
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if len([]rune(reason)) > maxAdminReasonLength {
+		return "", "Reason too long"
+	}
+	if reason == "" {
+		reason = "no reason given"
+	}
+	return reason, ""
+}
+
+// newAdminGame summarizes a live game for admins
+// Must be called with the game locked
+func newAdminGame(gameID string, board *game.Board, now time.Time) AdminGame {
+	return AdminGame{
+		GameSync:    summarizeGame(gameID, board, "", now),
+		Players:     board.Players,
+		Seated:      board.Seated(),
+		Sandbox:     isSandbox(gameID),
+		Spectators:  liveSpectators(gameID),
+		Connections: len(connectionsOf(gameID)),
+		IdleSince:   idleSince(gameID, now),
+	}
+}
+
+// List the live games, idle the longest first (?phase= keeps the games in one phase)
+func listAdminGames(c echo.Context) error {
+	phase := game.Phase(c.QueryParam("phase"))
+	now := time.Now()
+
+	summaries := make([]AdminGame, 0)
+	forEachGame(func(gameID string, board *game.Board) {
+		if phase == "" || board.Phase == phase {
+			summaries = append(summaries, newAdminGame(gameID, board, now))
+		}
+	})
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].IdleSince.Before(summaries[j].IdleSince)
+	})
+	return c.JSON(http.StatusOK, summaries)
+}
+
+// Inspect a live game: the whole board and the connections following it
+func getAdminGame(c echo.Context) error {
+	gameID := c.Param("id")
+
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	return c.JSON(http.StatusOK, AdminGameDetail{
+		AdminGame: newAdminGame(gameID, board, time.Now()),
+		Board:     board,
+		Following: connectionsOf(gameID),
+	})
+}
+
+// Terminate a live game: annul it if it is still going and take it off this server
+// It stays in the store, where its record can still be read
+func terminateGame(c echo.Context) error {
+	gameID := c.Param("id")
+	reason, message := bindAdminReason(c)
+	if message != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": message})
+	}
+
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	if phase := board.Phase; phase != game.PhaseFinished {
+		if err := board.Annul(); err != nil {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		saveGame(c.Request().Context(), gameID, board)
+		announceResult(gameID, board, phase)
+	}
+	forgetGame(gameID)
+	log.Printf("admin: terminated game %s: %s", gameID, reason)
+
+	return c.JSON(http.StatusOK, describeResult(board.Result, i18n.Default))
+}
+
+// Annul the result of a finished game; nobody wins and it is never rated
+// Finished games no longer live on this server are annulled in the store
+func annulResult(c echo.Context) error {
+	gameID := c.Param("id")
+	ctx := c.Request().Context()
+	reason, message := bindAdminReason(c)
+	if message != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": message})
+	}
+
+	board, unlock, live := lockGame(gameID)
+	if live {
+		defer unlock()
+	} else {
+		stored, err := gameStore.LoadGame(ctx, gameID)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+		case err != nil:
+			log.Printf("loading game %s: %v", gameID, err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load the game"})
+		}
+		board = stored
+	}
+
+	previous := board.Result
+	if err := board.AnnulResult(); err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if live {
+		saveGame(ctx, gameID, board)
+	} else if err := gameStore.SaveGame(ctx, gameID, board); err != nil {
+		log.Printf("saving game %s: %v", gameID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save the game"})
+	}
+	log.Printf("admin: annulled the result %s of game %s: %s", previous, gameID, reason)
+	unrateGame(ctx, gameID)
+	unarchiveGame(ctx, gameID)
+
+	description := describeResult(board.Result, i18n.Default)
+	hub.Broadcast(Event{Type: EventGameOver, GameID: gameID, Data: description})
+	return c.JSON(http.StatusOK, description)
+}
+
+// Score a game stuck in play or scoring with the current dead stones, as if both players accepted
+func forceScore(c echo.Context) error {
+	gameID := c.Param("id")
+	reason, message := bindAdminReason(c)
+	if message != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": message})
+	}
+
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	phase := board.Phase
+	if err := board.ForceScore(); err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	saveGame(c.Request().Context(), gameID, board)
+	log.Printf("admin: scored game %s as %s: %s", gameID, board.Result, reason)
+
+	hub.Broadcast(Event{Type: EventScoring, GameID: gameID, Data: newScoreResponse(board)})
+	announceResult(gameID, board, phase)
+	verifyScoreAsync(c.Request().Context(), gameID, board)
+	return c.JSON(http.StatusOK, describeResult(board.Result, i18n.Default))
+}
+
+// List the open WebSocket connections, longest open first (?user= or ?game= narrow it down)
+func listConnections(c echo.Context) error {
+	userID, gameID := c.QueryParam("user"), c.QueryParam("game")
+
+	connections := make([]AdminConnection, 0)
+	for _, connection := range allConnections() {
+		if userID != "" && connection.UserID != userID {
+			continue
+		}
+		if _, following := connection.Games[gameID]; gameID != "" && !following {
+			continue
+		}
+		connections = append(connections, connection)
+	}
+	return c.JSON(http.StatusOK, connections)
+}
+
+// Close a WebSocket connection
+func kickConnection(c echo.Context) error {
+	reason, message := bindAdminReason(c)
+	if message != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": message})
+	}
+
+	socketsMu.Lock()
+	s, found := sockets[c.Param("cid")]
+	socketsMu.Unlock()
+	if !found {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Connection not found"})
+	}
+
+	s.kick(reason)
+	log.Printf("admin: closed connection %s of %q from %s: %s", s.id, s.user.ID, s.ip, reason)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Close every WebSocket connection of a user
+func kickUser(c echo.Context) error {
+	userID := c.Param("uid")
+	reason, message := bindAdminReason(c)
+	if message != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": message})
+	}
+
+	userSocketsMu.Lock()
+	clients := make([]*socketClient, 0, len(userSockets[userID]))
+	for s := range userSockets[userID] {
+		clients = append(clients, s)
+	}
+	userSocketsMu.Unlock()
+
+	for _, s := range clients {
+		s.kick(reason)
+	}
+	log.Printf("admin: closed %d connections of user %s: %s", len(clients), userID, reason)
+	return c.JSON(http.StatusOK, map[string]int{"closed": len(clients)})
+}
+
+// allConnections describes every open WebSocket connection, longest open first
+func allConnections() []AdminConnection {
+	socketsMu.Lock()
+	clients := make([]*socketClient, 0, len(sockets))
+	for _, s := range sockets {
+		clients = append(clients, s)
+	}
+	socketsMu.Unlock()
+
+	connections := make([]AdminConnection, 0, len(clients))
+	for _, s := range clients {
+		connection := AdminConnection{ID: s.id, UserID: s.user.ID, Name: s.user.Name, IP: s.ip, Since: s.since, Games: make(map[string]int)}
+		s.mu.Lock()
+		for gameID, seat := range s.seats {
+			connection.Games[gameID] = seat
+		}
+		s.mu.Unlock()
+		connections = append(connections, connection)
+	}
+
+	sort.Slice(connections, func(i, j int) bool {
+		return connections[i].Since.Before(connections[j].Since)
+	})
+	return connections
+}
+
+// connectionsOf describes the WebSocket connections following a game
+func connectionsOf(gameID string) []AdminConnection {
+	connections := make([]AdminConnection, 0)
+	for _, connection := range allConnections() {
+		if _, following := connection.Games[gameID]; following {
+			connections = append(connections, connection)
+		}
+	}
+	return connections
+}
@@ -0,0 +1,76 @@
+package analysis
+
+import (
+	"go-game/game"
+	"slices"
+)
+
+// countReach is how far (in steps along the lines) stones claim the empty points around them
+const countReach = 3
+
+// Count estimates a position without playouts, from the living stones and the empty points
+// closer to one color than the other, within countReach of it; closer points are surer
+// It costs next to nothing, and stands in for Estimate when there is no time for playouts,
+// but it knows nothing about life and death beyond the stones marked dead
+func Count(board *game.Board) *Estimate {
+	estimate := &Estimate{
+		Ownership:  make([]float64, len(board.Grid)),
+		ScoreLead:  -board.Komi,
+		DeadStones: append(make([]int, 0, len(board.DeadStones)), board.DeadStones...),
+	}
+
+	// Distance from every point to the nearest living stone of each color, by breadth-first search
+	var distance [3][]int
+	for player := 1; player <= 2; player++ {
+		distance[player] = make([]int, len(board.Grid))
+		queue := make([]int, 0, len(board.Grid))
+		for pos := range board.Grid {
+			distance[player][pos] = -1
+			if board.GetStone(pos) == player && !slices.Contains(board.DeadStones, pos) {
+				distance[player][pos] = 0
+				queue = append(queue, pos)
+			}
+		}
+		for len(queue) > 0 {
+			pos := queue[0]
+			queue = queue[1:]
+			if distance[player][pos] == countReach {
+				continue
+			}
+			for _, neighbor := range board.GetNeighbors(pos) {
+				if distance[player][neighbor] < 0 && (board.IsEmpty(neighbor) || slices.Contains(board.DeadStones, neighbor)) {
+					distance[player][neighbor] = distance[player][pos] + 1
+					queue = append(queue, neighbor)
+				}
+			}
+		}
+	}
+
+	for pos := range board.Grid {
+		black, white := distance[1][pos], distance[2][pos]
+		var owner int
+		switch {
+		case black >= 0 && (white < 0 || black < white):
+			owner = 1
+		case white >= 0 && (black < 0 || white < black):
+			owner = 2
+		default:
+			continue // Out of reach, or as close to both
+		}
+
+		ownership := 1 - float64(max(distance[owner][pos]-1, 0))/countReach
+		if owner == 2 {
+			ownership = -ownership
+		}
+		estimate.Ownership[pos] = ownership
+		estimate.ScoreLead += float64(3 - 2*owner) // +1 for black, -1 for white
+	}
+
+	switch {
+	case estimate.ScoreLead > 0:
+		estimate.BlackWinRate = 1
+	case estimate.ScoreLead == 0:
+		estimate.BlackWinRate = 0.5
+	}
+	return estimate
+}
@@ -0,0 +1,93 @@
+// Package analysis estimates the score, ownership and dead stones of a position
+// by playing many random games (playouts) from it
+package analysis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoPlayouts is returned when the time budget ran out before any playout finished
+var ErrNoPlayouts = errors.New("no playouts completed within the time budget")
+
+// Engine runs playouts on a fixed number of worker goroutines
+// The pool is shared by every request, so heavy analysis can't take over all cores
+// and starve the HTTP server
+type Engine struct {
+	jobs    chan func()
+	workers int
+	queued  atomic.Int64 // Jobs submitted that haven't started yet
+}
+
+// NewEngine starts an engine with the given number of workers
+func NewEngine(workers int) *Engine {
+	if workers < 1 {
+		workers = 1
+	}
+
+	e := &Engine{jobs: make(chan func()), workers: workers}
+	for i := 0; i < workers; i++ {
+		go e.work()
+	}
+	return e
+}
+
+// Workers is how many playouts the engine runs at once
+func (e *Engine) Workers() int {
+	return e.workers
+}
+
+// Queued is how many playouts are waiting for a worker
+func (e *Engine) Queued() int {
+	return int(e.queued.Load())
+}
+
+// work runs jobs until the engine is closed
+func (e *Engine) work() {
+	for job := range e.jobs {
+		job()
+	}
+}
+
+// Close stops the workers once the queued jobs are done
+func (e *Engine) Close() {
+	close(e.jobs)
+}
+
+// run executes count jobs on the pool and waits for them
+// Submission stops as soon as ctx is done, and queued jobs that start after that are skipped
+func (e *Engine) run(ctx context.Context, count int, job func()) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		e.queued.Add(1)
+		task := func() {
+			defer wg.Done()
+			e.queued.Add(-1)
+			if ctx.Err() == nil {
+				job()
+			}
+		}
+
+		select {
+		case e.jobs <- task:
+		case <-ctx.Done():
+			e.queued.Add(-1)
+			wg.Done()
+			return
+		}
+	}
+}
+
+// withBudget limits ctx to the time budget (no limit if budget is 0)
+func withBudget(ctx context.Context, budget time.Duration) (context.Context, context.CancelFunc) {
+	if budget <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, budget)
+}
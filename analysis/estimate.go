@@ -0,0 +1,185 @@
+package analysis
+
+import (
+	"context"
+	"go-game/game"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Estimate is what the playouts say about a position
+// Playouts are counted with area scoring: stones plus surrounded points
+type Estimate struct {
+	// Playouts is how many playouts finished within the budget
+	Playouts int
+
+	// Ownership gives, for each intersection, how likely it ends up black (+1) or white (-1)
+	Ownership []float64
+
+	// ScoreLead is black's average lead in points, komi included (negative = white leads)
+	ScoreLead float64
+
+	// BlackWinRate is the fraction of playouts black won
+	BlackWinRate float64
+
+	// DeadStones are stones that end up owned by the opponent in most playouts
+	DeadStones []int
+
+	// WorkTime is the time the workers spent on playouts, interrupted ones included
+	WorkTime time.Duration
+}
+
+// deadThreshold is how strongly a stone's point must belong to the opponent for the stone to count as dead
+const deadThreshold = 0.5
+
+// Estimate plays up to playouts random games from the position and aggregates their outcomes
+// It stops early when ctx is cancelled or the time budget runs out, and then returns
+// what the finished playouts found (or ErrNoPlayouts if none finished)
+func (e *Engine) Estimate(ctx context.Context, board *game.Board, playouts int, budget time.Duration) (*Estimate, error) {
+	ctx, cancel := withBudget(ctx, budget)
+	defer cancel()
+
+	// Prepare the starting position once; every playout clones it
+	start := board.Clone()
+	start.Phase = game.PhasePlaying
+	start.Variant = game.VariantStandard
+	start.Clock = nil
+	start.Result = nil
+	start.PrecomputeLegalMoves = false
+	start.PlayResumedAt = len(start.MoveHistory) // Earlier passes don't count
+
+	var (
+		mu        sync.Mutex
+		finished  int
+		blackWins int
+		leadSum   float64
+		workTime  time.Duration
+		owned     = make([]float64, len(board.Grid))
+	)
+
+	e.run(ctx, playouts, func() {
+		began := time.Now()
+		ownership, ok := playout(ctx, start.Clone())
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		workTime += time.Since(began)
+		if !ok {
+			return // Interrupted, the position it reached says nothing
+		}
+
+		lead := -start.Komi
+		for _, owner := range ownership {
+			lead += float64(owner)
+		}
+
+		finished++
+		leadSum += lead
+		if lead > 0 {
+			blackWins++
+		}
+		for pos, owner := range ownership {
+			owned[pos] += float64(owner)
+		}
+	})
+
+	if finished == 0 {
+		return nil, ErrNoPlayouts
+	}
+
+	estimate := &Estimate{
+		Playouts:     finished,
+		Ownership:    owned,
+		ScoreLead:    leadSum / float64(finished),
+		BlackWinRate: float64(blackWins) / float64(finished),
+		DeadStones:   make([]int, 0),
+		WorkTime:     workTime,
+	}
+	for pos := range estimate.Ownership {
+		estimate.Ownership[pos] /= float64(finished)
+
+		// A black stone on a point owned by white (or the other way around) is dead
+		switch stone := board.GetStone(pos); {
+		case stone == 1 && estimate.Ownership[pos] < -deadThreshold,
+			stone == 2 && estimate.Ownership[pos] > deadThreshold:
+			estimate.DeadStones = append(estimate.DeadStones, pos)
+		}
+	}
+
+	return estimate, nil
+}
+
+// playout plays random moves until both players pass and returns who owns each point
+// (+1 black, -1 white, 0 neutral); ok is false if ctx was cancelled first
+func playout(ctx context.Context, board *game.Board) (ownership []int, ok bool) {
+	rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	maxMoves := 3 * len(board.Grid) // Guards against endless capture sequences
+
+	for moves := 0; board.Phase == game.PhasePlaying && moves < maxMoves; moves++ {
+		if moves%64 == 0 && ctx.Err() != nil {
+			return nil, false
+		}
+
+		if position, found := randomMove(board, rng); found {
+			board.MakeMove(position)
+		} else {
+			board.Pass()
+		}
+	}
+
+	return areaOwnership(board), true
+}
+
+// randomMove picks a random legal move that doesn't fill one of the player's own eyes
+// Scans from a random starting point so no extra slice is needed per move
+func randomMove(board *game.Board, rng *rand.Rand) (int, bool) {
+	size := len(board.Grid)
+	start := rng.IntN(size)
+
+	for i := 0; i < size; i++ {
+		position := (start + i) % size
+		if board.IsEmpty(position) && !isOwnEye(board, position, board.CurrentPlayer) && board.IsValidMove(position) {
+			return position, true
+		}
+	}
+	return -1, false
+}
+
+// isOwnEye checks if an empty point is completely surrounded by the player's stones
+func isOwnEye(board *game.Board, position int, player int) bool {
+	for _, neighbor := range board.GetNeighbors(position) {
+		if board.GetStone(neighbor) != player {
+			return false
+		}
+	}
+	return true
+}
+
+// areaOwnership assigns every point to the color of its stone, or for empty points to
+// the color surrounding them (at the end of a playout nearly every empty point is an eye)
+func areaOwnership(board *game.Board) []int {
+	ownership := make([]int, len(board.Grid))
+
+	for pos, stone := range board.Grid {
+		switch stone {
+		case 1:
+			ownership[pos] = 1
+		case 2:
+			ownership[pos] = -1
+		default:
+			var seen [3]bool
+			for _, neighbor := range board.GetNeighbors(pos) {
+				seen[board.GetStone(neighbor)] = true
+			}
+			if seen[1] && !seen[2] {
+				ownership[pos] = 1
+			} else if seen[2] && !seen[1] {
+				ownership[pos] = -1
+			}
+		}
+	}
+
+	return ownership
+}
@@ -0,0 +1,246 @@
+// Package v1 defines the responses of the /api/v1 routes
+// Unlike the legacy routes, which write the whole Board, they only carry what clients use;
+// the rest is added on request (see Includes)
+package v1
+
+import (
+	"go-game/game"
+	"strings"
+	"time"
+)
+
+// Expansions that can be asked for with ?include=
+const (
+	IncludeMoves   = "moves"   // The moves played so far
+	IncludeLegal   = "legal"   // Legal moves of the player to move
+	IncludeScoring = "scoring" // The position counted as it stands
+	IncludeInfo    = "info"    // Players, event and other record details
+)
+
+// Includes is a set of expansions
+type Includes map[string]bool
+
+// ParseIncludes reads a comma-separated ?include= value, ignoring unknown expansions
+func ParseIncludes(param string) Includes {
+	includes := make(Includes)
+	for _, name := range strings.Split(param, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case IncludeMoves, IncludeLegal, IncludeScoring, IncludeInfo:
+			includes[name] = true
+		}
+	}
+	return includes
+}
+
+// Game is the state of a game
+type Game struct {
+	ID            string   `json:"id"`
+	Size          int      `json:"size"`
+	Variant       string   `json:"variant"`
+	Phase         string   `json:"phase"`
+	MoveNumber    int      `json:"moveNumber"`         // Moves played so far
+	CurrentPlayer string   `json:"currentPlayer"`      // "black" or "white"
+	Rows          []string `json:"rows"`               // One string per row from the top, "." empty, "X" black, "O" white
+	LastMove      string   `json:"lastMove,omitempty"` // Coordinate of the last move, or "pass"
+	Captures      Captures `json:"captures"`
+	Komi          float64  `json:"komi"`
+	PositionHash  string   `json:"positionHash"`
+	Clock         *Clock   `json:"clock,omitempty"`  // Untimed games have none
+	Result        *Result  `json:"result,omitempty"` // Set once the game is over
+	Spectators    int      `json:"spectators"`       // Spectators following the game live
+
+	// Team games
+	NextMover    string        `json:"nextMover,omitempty"`    // Member who has to move
+	Consultation *Consultation `json:"consultation,omitempty"` // Games with consultation time
+	Violations   []Violation   `json:"violations,omitempty"`   // Moves attempted out of rotation
+
+	// Expansions
+	Moves      []Move   `json:"moves,omitempty"`
+	LegalMoves []string `json:"legalMoves,omitempty"`
+	Scoring    *Scoring `json:"scoring,omitempty"`
+	Info       *Info    `json:"info,omitempty"`
+}
+
+// Captures counts the stones each color has taken
+type Captures struct {
+	Black int `json:"black"`
+	White int `json:"white"`
+}
+
+// Clock is the time each player has left at the moment of the response
+type Clock struct {
+	Running        string  `json:"running,omitempty"` // Color whose clock is ticking
+	BlackTime      float64 `json:"blackTime"`         // Main time left, in seconds
+	WhiteTime      float64 `json:"whiteTime"`
+	BlackPeriods   int     `json:"blackPeriods"` // Byo-yomi periods left
+	WhitePeriods   int     `json:"whitePeriods"`
+	ByoYomiSeconds float64 `json:"byoYomiSeconds,omitempty"` // Length of a period
+}
+
+// Consultation is the consultation time each team has left at the moment of the response
+type Consultation struct {
+	Consulting   string  `json:"consulting,omitempty"` // Color of the team consulting
+	BlackSeconds float64 `json:"blackSeconds"`
+	WhiteSeconds float64 `json:"whiteSeconds"`
+}
+
+// Violation is an attempt by a team member to move out of rotation
+type Violation struct {
+	MoveNumber int       `json:"moveNumber"`
+	Color      string    `json:"color"`
+	Member     string    `json:"member"`   // Who tried to move
+	Expected   string    `json:"expected"` // Who had to move
+	At         time.Time `json:"at"`
+}
+
+// Result is how the game ended
+type Result struct {
+	Winner   string  `json:"winner,omitempty"` // "black" or "white"; empty for jigo and void games
+	Reason   string  `json:"reason"`
+	Margin   float64 `json:"margin,omitempty"`
+	Notation string  `json:"notation"` // e.g. "B+3.5"
+}
+
+// Scoring is the position counted as it stands
+type Scoring struct {
+	Black PlayerScore `json:"black"`
+	White PlayerScore `json:"white"`
+	Dame  int         `json:"dame"`
+}
+
+// PlayerScore is one color's count
+type PlayerScore struct {
+	Prisoners int     `json:"prisoners"` // Dead stones included
+	Territory int     `json:"territory"`
+	Score     float64 `json:"score"` // Komi included for white
+}
+
+// Move is a move of the game
+type Move struct {
+	Color      string `json:"color"`
+	Coordinate string `json:"coordinate"` // "pass" for a pass
+	Captures   int    `json:"captures,omitempty"`
+}
+
+// Info describes the players and the event
+type Info struct {
+	BlackName string   `json:"blackName,omitempty"`
+	WhiteName string   `json:"whiteName,omitempty"`
+	BlackRank string   `json:"blackRank,omitempty"`
+	WhiteRank string   `json:"whiteRank,omitempty"`
+	BlackTeam []string `json:"blackTeam,omitempty"`
+	WhiteTeam []string `json:"whiteTeam,omitempty"`
+	Event     string   `json:"event,omitempty"`
+	Date      string   `json:"date,omitempty"`
+	Handicap  int      `json:"handicap,omitempty"`
+	Hotseat   bool     `json:"hotseat,omitempty"`
+}
+
+// colorNames names the players by index (1 = black, 2 = white)
+var colorNames = [3]string{"", "black", "white"}
+
+// gridSymbols draws the grid values in Rows
+var gridSymbols = [3]byte{'.', 'X', 'O'}
+
+// NewGame describes a board; boards hiding stones must be turned into the view of the asking player first
+func NewGame(id string, board *game.Board, includes Includes, now time.Time) Game {
+	dto := Game{
+		ID:            id,
+		Size:          board.Size,
+		Variant:       board.Variant,
+		Phase:         string(board.Phase),
+		MoveNumber:    board.Version(),
+		CurrentPlayer: colorNames[board.CurrentPlayer],
+		Rows:          make([]string, board.Size),
+		Captures:      Captures{Black: board.CapturedStones[1], White: board.CapturedStones[2]},
+		Komi:          board.Komi,
+		PositionHash:  board.PositionHashHex(),
+	}
+
+	row := make([]byte, board.Size)
+	for r := range dto.Rows {
+		for col := range row {
+			row[col] = gridSymbols[board.Grid[r*board.Size+col]]
+		}
+		dto.Rows[r] = string(row)
+	}
+	if n := len(board.MoveHistory); n > 0 {
+		dto.LastMove = game.FormatCoordinate(board.MoveHistory[n-1].Position, board.Size)
+	}
+
+	if state := board.ClockState(now); state != nil {
+		dto.Clock = &Clock{
+			Running:        colorNames[state.Running],
+			BlackTime:      state.TimeLeft[1].Seconds(),
+			WhiteTime:      state.TimeLeft[2].Seconds(),
+			BlackPeriods:   state.PeriodsLeft[1],
+			WhitePeriods:   state.PeriodsLeft[2],
+			ByoYomiSeconds: board.Clock.ByoYomiTime.Seconds(),
+		}
+	}
+	dto.NextMover = board.NextMover()
+	if c := board.Consultation; c != nil {
+		dto.Consultation = &Consultation{
+			Consulting:   colorNames[c.Active],
+			BlackSeconds: board.ConsultationLeft(1, now).Seconds(),
+			WhiteSeconds: board.ConsultationLeft(2, now).Seconds(),
+		}
+	}
+	for _, violation := range board.Violations {
+		dto.Violations = append(dto.Violations, Violation{
+			MoveNumber: violation.MoveNumber,
+			Color:      colorNames[violation.Player],
+			Member:     violation.Member,
+			Expected:   violation.Expected,
+			At:         violation.At,
+		})
+	}
+	if board.Result != nil {
+		dto.Result = &Result{
+			Winner:   colorNames[board.Result.Winner],
+			Reason:   board.Result.Reason,
+			Margin:   board.Result.Margin,
+			Notation: board.Result.String(),
+		}
+	}
+
+	if includes[IncludeMoves] {
+		dto.Moves = make([]Move, len(board.MoveHistory))
+		for i, move := range board.MoveHistory {
+			dto.Moves[i] = Move{
+				Color:      colorNames[move.Player],
+				Coordinate: game.FormatCoordinate(move.Position, board.Size),
+				Captures:   len(move.CapturedPositions),
+			}
+		}
+	}
+	if includes[IncludeLegal] && board.Phase == game.PhasePlaying && !board.Concealed() {
+		dto.LegalMoves = make([]string, 0)
+		for _, position := range board.LegalMoves() {
+			dto.LegalMoves = append(dto.LegalMoves, game.FormatCoordinate(position, board.Size))
+		}
+	}
+	if includes[IncludeScoring] {
+		summary := board.ScoringSummary()
+		dto.Scoring = &Scoring{
+			Black: PlayerScore{Prisoners: summary.Black.Prisoners, Territory: summary.Black.Territory, Score: summary.Black.Score},
+			White: PlayerScore{Prisoners: summary.White.Prisoners, Territory: summary.White.Territory, Score: summary.White.Score},
+			Dame:  summary.Dame,
+		}
+	}
+	if includes[IncludeInfo] {
+		dto.Info = &Info{
+			BlackName: board.Info.BlackName,
+			WhiteName: board.Info.WhiteName,
+			BlackRank: board.Info.BlackRank,
+			WhiteRank: board.Info.WhiteRank,
+			BlackTeam: board.Teams[1],
+			WhiteTeam: board.Teams[2],
+			Event:     board.Info.Event,
+			Date:      board.Info.Date,
+			Handicap:  board.HandicapStones(),
+			Hotseat:   board.Hotseat,
+		}
+	}
+	return dto
+}
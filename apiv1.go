@@ -0,0 +1,34 @@
+package main
+
+import (
+	v1 "go-game/api/v1"
+	"go-game/game"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// apiVersionKey is the context key under which apiV1 records the API version of a route
+const apiVersionKey = "apiVersion"
+
+// apiV1 marks a route as part of /api/v1, whose handlers answer with the v1 responses
+// The handlers are shared with the legacy routes; respondBoard picks the response shape
+func apiV1(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Set(apiVersionKey, 1)
+		return next(c)
+	}
+}
+
+// apiVersion returns the API version of the route being served (0 = legacy routes)
+func apiVersion(c echo.Context) int {
+	version, _ := c.Get(apiVersionKey).(int)
+	return version
+}
+
+// newV1Game builds the v1 response of a board, with the expansions asked for in ?include=
+func newV1Game(c echo.Context, gameID string, board *game.Board) v1.Game {
+	g := v1.NewGame(gameID, board, v1.ParseIncludes(c.QueryParam("include")), time.Now())
+	g.Spectators = liveSpectators(gameID)
+	return g
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"go-game/archive"
+	"go-game/game"
+	"go-game/sgf"
+	"go-game/users"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// archivePageSize is how many stored games are read at a time while building an archive
+const archivePageSize = 100
+
+// archiveMatches checks if a finished game belongs in an archive for a player name
+// (either color or any team member) and/or an event; names are compared ignoring case
+func archiveMatches(board *game.Board, name, event string) bool {
+	if board.Phase != game.PhaseFinished {
+		return false
+	}
+	if event != "" && !strings.EqualFold(board.Info.Event, event) {
+		return false
+	}
+	if name == "" {
+		return true
+	}
+
+	players := []string{board.Info.BlackName, board.Info.WhiteName}
+	players = append(players, board.Teams[1]...)
+	players = append(players, board.Teams[2]...)
+	return slices.ContainsFunc(players, func(player string) bool { return strings.EqualFold(player, name) })
+}
+
+// writeArchiveGame adds a game to an archive as an SGF file; it returns an error only when
+// the archive itself can't be written, games that can't be exported are left out
+func writeArchiveGame(zipped *zip.Writer, gameID string, board *game.Board, modified time.Time) error {
+	root, err := sgf.FromBoard(board)
+	if err != nil {
+		log.Printf("exporting game %s to archive: %v", gameID, err)
+		return nil
+	}
+
+	file, err := zipped.CreateHeader(&zip.FileHeader{Name: gameID + ".sgf", Method: zip.Deflate, Modified: modified.UTC()})
+	if err != nil {
+		return err
+	}
+	_, err = file.Write([]byte(sgf.Format(root)))
+	return err
+}
+
+// Download the finished games of a player (?player= an account, or ?name= as recorded in the
+// games) and/or a tournament (?event=) as a zip of SGF files
+// The archive is written while the store, or the account's games, are paged through, so it
+// never sits in memory as a whole
+func exportArchive(c echo.Context) error {
+	name, event := strings.TrimSpace(c.QueryParam("name")), strings.TrimSpace(c.QueryParam("event"))
+	playerID := strings.TrimSpace(c.QueryParam("player"))
+	if name == "" && event == "" && playerID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Give a player, a player name or an event"})
+	}
+
+	ctx := c.Request().Context()
+	label := name
+	if playerID != "" {
+		player, err := userStore.Get(ctx, playerID)
+		if errors.Is(err, users.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		label = player.Username
+	}
+	if label == "" {
+		label = event
+	}
+	filename := safeFilename(label) + ".zip"
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/zip")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	res.WriteHeader(http.StatusOK)
+
+	// The status is sent already, so failures past this point can only cut the archive short
+	zipped := zip.NewWriter(res)
+	if playerID != "" {
+		var failed error
+		err := forEachPlayerGame(ctx, playerID, func(entry archive.Entry) bool {
+			board, ok := storedGame(ctx, entry.GameID)
+			if !ok || !archiveMatches(board, name, event) {
+				return true
+			}
+			failed = writeArchiveGame(zipped, entry.GameID, board, entry.FinishedAt)
+			return failed == nil
+		})
+		if err := errors.Join(err, failed); err != nil {
+			log.Printf("exporting archive: %v", err)
+			return nil
+		}
+	} else {
+		for offset := 0; ; offset += archivePageSize {
+			records, err := gameStore.ListGames(ctx, archivePageSize, offset)
+			if err != nil {
+				log.Printf("exporting archive: %v", err)
+				return nil
+			}
+
+			for _, record := range records {
+				if record.Corrupted || !archiveMatches(record.Board, name, event) {
+					continue
+				}
+				if err := writeArchiveGame(zipped, record.ID, record.Board, record.UpdatedAt); err != nil {
+					log.Printf("exporting archive: %v", err)
+					return nil
+				}
+			}
+			res.Flush()
+
+			if len(records) < archivePageSize {
+				break
+			}
+		}
+	}
+
+	if err := zipped.Close(); err != nil {
+		log.Printf("exporting archive: %v", err)
+	}
+	return nil
+}
+
+// safeFilename keeps the letters, digits, dashes and underscores of a name, for Content-Disposition headers
+func safeFilename(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '-', r == '_':
+			return r
+		case r == ' ':
+			return '_'
+		default:
+			return -1
+		}
+	}, name)
+	if safe == "" {
+		return "games-" + time.Now().UTC().Format("20060102")
+	}
+	return safe
+}
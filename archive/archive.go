@@ -0,0 +1,52 @@
+// Package archive indexes the finished games by player, so a player's games are found
+// without going through every stored game
+package archive
+
+import (
+	"context"
+	"time"
+)
+
+// Outcomes of a game for a player
+const (
+	OutcomeWin  = "win"
+	OutcomeLoss = "loss"
+	OutcomeDraw = "draw" // Jigo, or no winner
+)
+
+// Entry is a finished game as one of its players sees it
+type Entry struct {
+	GameID       string        `json:"gameId"`
+	UserID       string        `json:"userId"`
+	Color        int           `json:"color"`                  // Color the player had (1 = black, 2 = white)
+	Opponent     string        `json:"opponent,omitempty"`     // Account of the opponent ("" for anonymous players and bots)
+	OpponentName string        `json:"opponentName,omitempty"` // As recorded in the game
+	Outcome      string        `json:"outcome"`                // See the Outcome* constants
+	Result       string        `json:"result"`                 // Usual notation, e.g. "B+3.5" or "W+R"
+	Size         int           `json:"size"`
+	Moves        int           `json:"moves"`    // Passes included
+	Thinking     time.Duration `json:"thinking"` // Time both players spent on their moves
+	Rated        bool          `json:"rated"`
+	FinishedAt   time.Time     `json:"finishedAt"`
+}
+
+// Filter narrows down the games listed ("" fields match everything)
+type Filter struct {
+	Outcome  string
+	Opponent string // Account of the opponent
+}
+
+// matches checks if an entry passes the filter
+func (f Filter) matches(entry Entry) bool {
+	return (f.Outcome == "" || entry.Outcome == f.Outcome) && (f.Opponent == "" || entry.Opponent == f.Opponent)
+}
+
+// Store keeps the entries of the finished games
+type Store interface {
+	// Add records the entries of a game, replacing the ones it had
+	Add(ctx context.Context, gameID string, entries []Entry) error
+	// List returns a page of a player's games, most recently finished first
+	List(ctx context.Context, userID string, filter Filter, limit, offset int) ([]Entry, error)
+	// DeleteGame removes the entries of a game, e.g. when it is deleted or its result annulled
+	DeleteGame(ctx context.Context, gameID string) error
+}
@@ -0,0 +1,76 @@
+package archive
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryStore keeps the entries in memory, for servers without a database
+type MemoryStore struct {
+	mu      sync.Mutex
+	games   map[string][]Entry // By game
+	players map[string][]Entry // By player, most recently finished first
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{games: make(map[string][]Entry), players: make(map[string][]Entry)}
+}
+
+// remove takes a game out of the lists of its players; must be called with s.mu held
+func (s *MemoryStore) remove(gameID string) {
+	for _, entry := range s.games[gameID] {
+		list := s.players[entry.UserID]
+		for i := range list {
+			if list[i].GameID == gameID {
+				s.players[entry.UserID] = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+	}
+	delete(s.games, gameID)
+}
+
+func (s *MemoryStore) Add(ctx context.Context, gameID string, entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(gameID)
+	s.games[gameID] = entries
+	for _, entry := range entries {
+		list := append(s.players[entry.UserID], entry)
+		sort.SliceStable(list, func(i, j int) bool { return list[i].FinishedAt.After(list[j].FinishedAt) })
+		s.players[entry.UserID] = list
+	}
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, userID string, filter Filter, limit, offset int) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var page []Entry
+	for _, entry := range s.players[userID] {
+		if !filter.matches(entry) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if len(page) == limit {
+			break
+		}
+		page = append(page, entry)
+	}
+	return page, nil
+}
+
+func (s *MemoryStore) DeleteGame(ctx context.Context, gameID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(gameID)
+	return nil
+}
@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+
+	_ "github.com/lib/pq" // Postgres driver for database/sql
+)
+
+// schema creates the tables the store needs
+const schema = `
+CREATE TABLE IF NOT EXISTS player_games (
+	user_id       TEXT NOT NULL,
+	game_id       TEXT NOT NULL,
+	color         INTEGER NOT NULL,
+	opponent_id   TEXT NOT NULL,
+	opponent_name TEXT NOT NULL,
+	outcome       TEXT NOT NULL,
+	result        TEXT NOT NULL,
+	size          INTEGER NOT NULL,
+	moves         INTEGER NOT NULL,
+	thinking      BIGINT NOT NULL,
+	rated         BOOLEAN NOT NULL,
+	finished_at   TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (user_id, game_id)
+);
+CREATE INDEX IF NOT EXISTS player_games_finished ON player_games (user_id, finished_at DESC, game_id);
+CREATE INDEX IF NOT EXISTS player_games_game ON player_games (game_id);
+`
+
+// columns are the entry columns, in the order List reads them
+const columns = `game_id, user_id, color, opponent_id, opponent_name, outcome, result, size, moves, thinking, rated, finished_at`
+
+// PostgresStore keeps the entries in Postgres
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to the database and creates the schema
+func NewPostgresStore(url string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close releases the database connections
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks the database can be reached
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *PostgresStore) Add(ctx context.Context, gameID string, entries []Entry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM player_games WHERE game_id = $1`, gameID); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO player_games (`+columns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			gameID, entry.UserID, entry.Color, entry.Opponent, entry.OpponentName, entry.Outcome, entry.Result,
+			entry.Size, entry.Moves, entry.Thinking, entry.Rated, entry.FinishedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) List(ctx context.Context, userID string, filter Filter, limit, offset int) ([]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+columns+` FROM player_games
+		WHERE user_id = $1 AND ($2 = '' OR outcome = $2) AND ($3 = '' OR opponent_id = $3)
+		ORDER BY finished_at DESC, game_id LIMIT $4 OFFSET $5`,
+		userID, filter.Outcome, filter.Opponent, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Entry
+	for rows.Next() {
+		var entry Entry
+		if err := rows.Scan(&entry.GameID, &entry.UserID, &entry.Color, &entry.Opponent, &entry.OpponentName,
+			&entry.Outcome, &entry.Result, &entry.Size, &entry.Moves, &entry.Thinking, &entry.Rated, &entry.FinishedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, entry)
+	}
+	return list, rows.Err()
+}
+
+func (s *PostgresStore) DeleteGame(ctx context.Context, gameID string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM player_games WHERE game_id = $1`, gameID)
+	return err
+}
@@ -0,0 +1,65 @@
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LifecycleRule expires artifacts under a key prefix once they reach a certain age
+type LifecycleRule struct {
+	Prefix string        // Key prefix the rule applies to ("" = every artifact)
+	MaxAge time.Duration // Artifacts older than this are deleted
+}
+
+// ParseLifecycleRules reads rules formatted as "prefix=age,prefix=age", e.g. "renders/=168h,sgf/=720h"
+func ParseLifecycleRules(config string) ([]LifecycleRule, error) {
+	rules := make([]LifecycleRule, 0)
+
+	for _, entry := range strings.Split(config, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		prefix, age, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid lifecycle rule %q", entry)
+		}
+
+		maxAge, err := time.ParseDuration(age)
+		if err != nil || maxAge <= 0 {
+			return nil, fmt.Errorf("invalid age in lifecycle rule %q", entry)
+		}
+
+		rules = append(rules, LifecycleRule{Prefix: prefix, MaxAge: maxAge})
+	}
+
+	return rules, nil
+}
+
+// ApplyLifecycle deletes every artifact that has outlived its rule
+// Returns how many artifacts were deleted
+func ApplyLifecycle(ctx context.Context, store Store, rules []LifecycleRule, now time.Time) (int, error) {
+	deleted := 0
+
+	for _, rule := range rules {
+		objects, err := store.List(ctx, rule.Prefix)
+		if err != nil {
+			return deleted, err
+		}
+
+		for _, object := range objects {
+			if now.Sub(object.LastModified) <= rule.MaxAge {
+				continue
+			}
+			if err := store.Delete(ctx, object.Key); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,252 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config holds the connection settings of an S3-compatible bucket
+// (AWS S3, MinIO, Cloudflare R2, ...)
+type S3Config struct {
+	Endpoint  string // Base URL of the service, e.g. "https://s3.eu-west-1.amazonaws.com"
+	Bucket    string
+	Region    string // Defaults to "us-east-1", which most compatible services accept
+	AccessKey string
+	SecretKey string
+}
+
+// S3Store keeps artifacts in an S3-compatible bucket using path-style requests
+// signed with AWS Signature Version 4
+type S3Store struct {
+	config S3Config
+	client *http.Client
+}
+
+// NewS3Store creates a store for the configured bucket
+func NewS3Store(config S3Config) (*S3Store, error) {
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+		return nil, errors.New("S3 store needs an endpoint, bucket, access key and secret key")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+
+	return &S3Store{config: config, client: &http.Client{Timeout: 5 * time.Minute}}, nil
+}
+
+// Put uploads the artifact
+// The content is buffered because S3 needs the length up front
+func (s *S3Store) Put(ctx context.Context, key string, content io.Reader) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads the artifact
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the artifact (S3 treats deleting a missing key as success)
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// listResult is the part of the ListObjectsV2 response we need
+type listResult struct {
+	Contents []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// List pages through ListObjectsV2 for every key starting with prefix
+func (s *S3Store) List(ctx context.Context, prefix string) ([]Object, error) {
+	objects := make([]Object, 0)
+	token := ""
+
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, Object{Key: content.Key, Size: content.Size, LastModified: content.LastModified})
+		}
+
+		if !result.IsTruncated {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// do sends a signed request for a key in the bucket (an empty key targets the bucket itself)
+// Non-2xx responses are turned into errors, 404 into ErrNotFound
+func (s *S3Store) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	path := "/" + s.config.Bucket
+	if key != "" {
+		path += "/" + key
+	}
+
+	target := s.config.Endpoint + escapePath(path)
+	if len(query) > 0 {
+		target += "?" + canonicalQuery(query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, path, query, body, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("S3 %s %s failed: %s: %s", method, key, resp.Status, message)
+	}
+
+	return resp, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to a request
+func (s *S3Store) sign(req *http.Request, path string, query url.Values, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256.Sum256(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	// Canonical request: what the signature actually covers
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(path),
+		canonicalQuery(query),
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + req.Header.Get("X-Amz-Content-Sha256") + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	scope := day + "/" + s.config.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	// Derive the signing key for this day, region and service
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), day)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 computes HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapePath URI-encodes each segment of a path the way SigV4 expects
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery encodes query parameters sorted by name, as SigV4 expects
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, value := range query[name] {
+			pairs = append(pairs, escape(name)+"="+escape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escape percent-encodes everything except the RFC 3986 unreserved characters
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,76 @@
+// Package artifacts stores large files produced by the server (SGF archives,
+// analysis reports, rendered images) outside of the game store
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when an artifact doesn't exist
+var ErrNotFound = errors.New("artifact not found")
+
+// Object describes a stored artifact
+type Object struct {
+	Key          string    // Path-like name, e.g. "sgf/2024/game-1.sgf"
+	Size         int64     // Size in bytes
+	LastModified time.Time // When the artifact was written
+}
+
+// Store is a place to keep artifacts, such as a local directory or an S3 bucket
+type Store interface {
+	// Put writes an artifact, replacing any existing one with the same key
+	Put(ctx context.Context, key string, content io.Reader) error
+
+	// Get opens an artifact for reading; the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes an artifact (deleting a missing artifact is not an error)
+	Delete(ctx context.Context, key string) error
+
+	// List returns every artifact whose key starts with prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// validKey checks that a key is a relative path without tricks like ".."
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return fmt.Errorf("invalid artifact key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid artifact key %q", key)
+		}
+	}
+	return nil
+}
+
+// NewStoreFromEnv creates the artifact store selected by ARTIFACT_STORE
+//   - "disk" (default): files under ARTIFACT_DIR (default "artifacts-data")
+//   - "s3": an S3-compatible bucket configured by S3_ENDPOINT, S3_BUCKET, S3_REGION,
+//     S3_ACCESS_KEY and S3_SECRET_KEY
+func NewStoreFromEnv() (Store, error) {
+	switch kind := os.Getenv("ARTIFACT_STORE"); kind {
+	case "", "disk":
+		dir := os.Getenv("ARTIFACT_DIR")
+		if dir == "" {
+			dir = "artifacts-data"
+		}
+		return NewDiskStore(dir)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown artifact store %q", kind)
+	}
+}
@@ -0,0 +1,206 @@
+// Package auth issues and checks the JSON Web Tokens users authenticate with
+// Tokens are signed with HMAC-SHA256 (HS256); a short-lived access token goes with every
+// request, a longer-lived refresh token is traded for a new pair when it runs out
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token kinds
+const (
+	KindAccess  = "access"
+	KindRefresh = "refresh"
+)
+
+// Default token lifetimes
+const (
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, forged, expired or of the wrong kind
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// User is who a token speaks for
+type User struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Claims are the contents of a token
+type Claims struct {
+	Subject   string `json:"sub"`  // User ID
+	Name      string `json:"name"` // Display name at the time the token was issued
+	Kind      string `json:"kind"` // KindAccess or KindRefresh
+	ID        string `json:"jti"`  // Unique token ID
+	IssuedAt  int64  `json:"iat"`  // Unix seconds
+	ExpiresAt int64  `json:"exp"`  // Unix seconds
+}
+
+// User returns the user the claims speak for
+func (c Claims) User() User {
+	return User{ID: c.Subject, Name: c.Name}
+}
+
+// Tokens is a pair issued together
+type Tokens struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"` // When the access token runs out
+}
+
+// Issuer signs and verifies tokens
+// Refresh tokens can only be used once: their IDs are remembered until they expire,
+// so a stolen refresh token stops working once either party has used it
+type Issuer struct {
+	key        []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+
+	mu      sync.Mutex
+	used    map[string]time.Time // Spent refresh token IDs and when they expire
+	revoked map[string]time.Time // Users whose tokens issued before a time were revoked, and that time
+}
+
+// NewIssuer creates an issuer signing with the given key (at least 32 bytes)
+func NewIssuer(key []byte) (*Issuer, error) {
+	if len(key) < 32 {
+		return nil, errors.New("token signing keys need at least 32 bytes")
+	}
+	return &Issuer{
+		key:        key,
+		AccessTTL:  DefaultAccessTTL,
+		RefreshTTL: DefaultRefreshTTL,
+		used:       make(map[string]time.Time),
+		revoked:    make(map[string]time.Time),
+	}, nil
+}
+
+// header is the same for every token
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Issue creates a new token pair for a user
+func (i *Issuer) Issue(user User, now time.Time) (Tokens, error) {
+	access, err := i.sign(user, KindAccess, now, i.AccessTTL)
+	if err != nil {
+		return Tokens{}, err
+	}
+	refresh, err := i.sign(user, KindRefresh, now, i.RefreshTTL)
+	if err != nil {
+		return Tokens{}, err
+	}
+	return Tokens{AccessToken: access, RefreshToken: refresh, ExpiresAt: now.Add(i.AccessTTL)}, nil
+}
+
+// Refresh trades a refresh token for a new pair; the refresh token can't be used again
+func (i *Issuer) Refresh(token string, now time.Time) (Tokens, error) {
+	claims, err := i.Verify(token, KindRefresh, now)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	i.mu.Lock()
+	for id, expires := range i.used {
+		if now.After(expires) {
+			delete(i.used, id)
+		}
+	}
+	_, spent := i.used[claims.ID]
+	if !spent {
+		i.used[claims.ID] = time.Unix(claims.ExpiresAt, 0)
+	}
+	i.mu.Unlock()
+	if spent {
+		return Tokens{}, ErrInvalidToken
+	}
+
+	return i.Issue(claims.User(), now)
+}
+
+// Verify checks a token's signature, expiry and kind, and returns its claims
+func (i *Issuer) Verify(token, kind string, now time.Time) (Claims, error) {
+	var claims Claims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return claims, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, i.mac(parts[0]+"."+parts[1])) {
+		return claims, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(payload, &claims) != nil {
+		return claims, ErrInvalidToken
+	}
+	if claims.Kind != kind || claims.Subject == "" || now.Unix() >= claims.ExpiresAt {
+		return claims, ErrInvalidToken
+	}
+
+	i.mu.Lock()
+	revoked, found := i.revoked[claims.Subject]
+	i.mu.Unlock()
+	if found && claims.IssuedAt < revoked.Unix() {
+		return claims, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// Revoke invalidates every token of a user issued before a time, e.g. when the password changed
+// Revocations are forgotten when the server restarts, so refresh tokens should also be checked
+// against the account
+func (i *Issuer) Revoke(userID string, before time.Time) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for id, at := range i.revoked {
+		if before.Sub(at) > i.RefreshTTL {
+			delete(i.revoked, id) // Every token issued before has expired
+		}
+	}
+	i.revoked[userID] = before
+}
+
+// LooksLikeToken tells tokens apart from other bearer credentials, such as API keys
+func LooksLikeToken(credential string) bool {
+	return strings.Count(credential, ".") == 2
+}
+
+// sign creates a token of the given kind
+func (i *Issuer) sign(user User, kind string, now time.Time, ttl time.Duration) (string, error) {
+	var id [16]byte
+	rand.Read(id[:])
+
+	payload, err := json.Marshal(Claims{
+		Subject:   user.ID,
+		Name:      user.Name,
+		Kind:      kind,
+		ID:        hex.EncodeToString(id[:]),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(i.mac(unsigned)), nil
+}
+
+// mac is the HS256 signature of the signed part of a token
+func (i *Issuer) mac(unsigned string) []byte {
+	mac := hmac.New(sha256.New, i.key)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
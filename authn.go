@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"go-game/auth"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+// tokenIssuer signs the tokens users authenticate with; set up at startup from JWT_KEY
+var tokenIssuer *auth.Issuer
+
+// userKey is the context key under which authenticate stores the user of a request
+const userKey = "user"
+
+// maxUserNameLength is the longest display name, in characters
+const maxUserNameLength = 40
+
+// newTokenIssuer creates the token issuer with the key in the key provider
+// Without one a key is generated, so tokens issued before a restart stop working
+func newTokenIssuer(provider KeyProvider) (*auth.Issuer, error) {
+	key, err := provider.MasterKey()
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	return auth.NewIssuer(key)
+}
+
+// authenticate reads the access token or session cookie of a request, if any, and makes its
+// user available to the handlers (see currentUser); requests without either go through anonymously
+// Tokens come in the Authorization header, or in ?access_token= for WebSockets and event streams,
+// as browsers can't set headers on them; bearer credentials that aren't tokens (admin keys) are left alone
+func authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if token == "" && (c.IsWebSocket() || isEventStream(c)) {
+			token = c.QueryParam("access_token")
+		}
+		if token == "" {
+			return authenticateSession(c, next)
+		}
+		if !auth.LooksLikeToken(token) {
+			return next(c)
+		}
+
+		claims, err := tokenIssuer.Verify(token, auth.KindAccess, time.Now())
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		}
+		c.Set(userKey, claims.User())
+		return next(c)
+	}
+}
+
+// authenticateSession signs in a request without a token by its session cookie, if it has one
+func authenticateSession(c echo.Context, next echo.HandlerFunc) error {
+	user, ok, err := sessionUser(c)
+	if errors.Is(err, errCrossOrigin) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if ok {
+		c.Set(userKey, user)
+	}
+	return next(c)
+}
+
+// requireUser rejects anonymous requests
+func requireUser(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := currentUser(c); !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Sign in first"})
+		}
+		return next(c)
+	}
+}
+
+// currentUser returns the authenticated user of a request
+func currentUser(c echo.Context) (auth.User, bool) {
+	user, ok := c.Get(userKey).(auth.User)
+	return user, ok
+}
+
+// Guest token request structure
+type GuestRequest struct {
+	Name string `json:"name"` // Display name (defaults to "Guest")
+}
+
+// Refresh request structure
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Get tokens for a new guest user, who lasts as long as the tokens are refreshed
+func issueGuestTokens(c echo.Context) error {
+	var guestReq GuestRequest
+	if err := c.Bind(&guestReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	name := strings.TrimSpace(guestReq.Name)
+	if name == "" {
+		name = "Guest"
+	}
+	if utf8.RuneCountInString(name) > maxUserNameLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name too long"})
+	}
+
+	var id [8]byte
+	rand.Read(id[:])
+	tokens, err := tokenIssuer.Issue(auth.User{ID: "guest-" + hex.EncodeToString(id[:]), Name: name}, time.Now())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// Trade a refresh token for new tokens
+func refreshTokens(c echo.Context) error {
+	var refreshReq RefreshRequest
+	if err := c.Bind(&refreshReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	// The issuer forgets revoked tokens when the server restarts, the account remembers
+	// when its password changed
+	now := time.Now()
+	if claims, err := tokenIssuer.Verify(refreshReq.RefreshToken, auth.KindRefresh, now); err == nil {
+		user, ok, err := lookupAccount(c.Request().Context(), claims.User())
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if ok && claims.IssuedAt < user.PasswordChangedAt.Unix() {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": auth.ErrInvalidToken.Error()})
+		}
+	}
+
+	tokens, err := tokenIssuer.Refresh(refreshReq.RefreshToken, now)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// Get the authenticated user: the account, or the name and ID of a guest
+func getCurrentUser(c echo.Context) error {
+	user, ok, err := account(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !ok {
+		guest, _ := currentUser(c)
+		return c.JSON(http.StatusOK, guest)
+	}
+	return c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"go-game/auth"
+	"go-game/matchmaking"
+	"go-game/rating"
+	"go-game/users"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Automatch: signed in players wait in a queue with the games they'd play (board sizes, time
+// control, how far the opponent's rating may be from theirs) until the server finds someone
+// compatible, starts the game with both accounts seated, and tells both over WebSocket
+// Clients without a WebSocket poll GET /match instead
+
+// matchQueue holds the players waiting for a game
+var matchQueue = matchmaking.NewQueue()
+
+// Automatch settings
+const (
+	maxMatchWait        = 15 * time.Minute // How long a player waits before being taken out of the queue
+	matchNoticeLifetime = 10 * time.Minute // How long GET /match reports the game found
+	matchExpiryInterval = time.Minute      // How often players who waited too long are taken out
+)
+
+// Match statuses
+const (
+	MatchIdle    = "idle"    // Not waiting, no recent game
+	MatchQueued  = "queued"  // Waiting for an opponent
+	MatchMatched = "matched" // A game was started
+)
+
+// Match request structure
+type MatchRequest struct {
+	Sizes          []int   `json:"sizes"`          // Board sizes the player accepts, preferred first (default 19)
+	MainTime       int     `json:"mainTime"`       // Main time per player in seconds (0 = untimed)
+	ByoYomiTime    int     `json:"byoYomiTime"`    // Length of each byo-yomi period in seconds
+	ByoYomiPeriods int     `json:"byoYomiPeriods"` // Number of byo-yomi periods per player
+	RatingRange    float64 `json:"ratingRange"`    // Largest rating gap to the opponent (0 = anyone)
+}
+
+// Match is a game automatch started, as each player sees it
+type Match struct {
+	GameID   string        `json:"gameId"`
+	Color    int           `json:"color"` // 1 = black, 2 = white
+	Size     int           `json:"size"`
+	Opponent users.Profile `json:"opponent"`
+	Started  time.Time     `json:"started"`
+}
+
+// Match status response structure
+type MatchStatus struct {
+	Status string             `json:"status"`          // See the Match* constants
+	Entry  *matchmaking.Entry `json:"entry,omitempty"` // While queued
+	Match  *Match             `json:"match,omitempty"` // Once matched
+}
+
+// Games found recently, by user, for players polling GET /match
+var (
+	recentMatchesMu sync.Mutex
+	recentMatches   = make(map[string]Match)
+)
+
+// matchRating is the rating players are matched by: their rank's, or the initial rating
+func matchRating(user users.User) float64 {
+	if value, ok := rating.RankRating(user.Rank); ok {
+		return value
+	}
+	return rating.InitialRating
+}
+
+// Wait for an opponent; a player already waiting changes their preferences
+func joinMatchQueue(c echo.Context) error {
+	var req MatchRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	if len(req.Sizes) == 0 {
+		req.Sizes = []int{defaultBoardSize}
+	}
+	for _, size := range req.Sizes {
+		if !boardSizes.Allows(size) {
+			return c.JSON(http.StatusBadRequest, boardSizes.sizeError(size))
+		}
+	}
+	if req.MainTime < 0 || req.ByoYomiTime < 0 || req.ByoYomiPeriods < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid time control"})
+	}
+	if req.RatingRange < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid rating range"})
+	}
+
+	user, signedIn, err := account(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !signedIn {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Automatch needs an account"})
+	}
+
+	entry := matchmaking.Entry{
+		UserID: user.ID,
+		Rating: matchRating(user),
+		Sizes:  req.Sizes,
+		TimeControl: matchmaking.TimeControl{
+			MainTime:       req.MainTime,
+			ByoYomiTime:    req.ByoYomiTime,
+			ByoYomiPeriods: req.ByoYomiPeriods,
+		},
+		RatingRange: req.RatingRange,
+		Joined:      time.Now(),
+	}
+	if err := matchQueue.Join(entry); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	recentMatchesMu.Lock()
+	delete(recentMatches, user.ID)
+	recentMatchesMu.Unlock()
+
+	// The game outlives the request, so it isn't set up under its cancellation
+	pairPlayers(context.WithoutCancel(c.Request().Context()))
+	return c.JSON(http.StatusAccepted, matchStatus(user.ID, time.Now()))
+}
+
+// Stop waiting for an opponent
+func leaveMatchQueue(c echo.Context) error {
+	user, _ := currentUser(c)
+	if !matchQueue.Leave(user.ID) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Not waiting for a game"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Whether the user is waiting, and the game found for them
+func getMatchStatus(c echo.Context) error {
+	user, _ := currentUser(c)
+	return c.JSON(http.StatusOK, matchStatus(user.ID, time.Now()))
+}
+
+// matchStatus tells a user where they stand in automatch
+func matchStatus(userID string, now time.Time) MatchStatus {
+	if entry, waiting := matchQueue.Get(userID); waiting {
+		return MatchStatus{Status: MatchQueued, Entry: &entry}
+	}
+
+	recentMatchesMu.Lock()
+	defer recentMatchesMu.Unlock()
+	if match, found := recentMatches[userID]; found {
+		if now.Sub(match.Started) <= matchNoticeLifetime {
+			return MatchStatus{Status: MatchMatched, Match: &match}
+		}
+		delete(recentMatches, userID)
+	}
+	return MatchStatus{Status: MatchIdle}
+}
+
+// pairPlayers starts a game for every pair of compatible players in the queue
+func pairPlayers(ctx context.Context) {
+	for _, pairing := range matchQueue.Match() {
+		if err := startMatch(ctx, pairing); err != nil {
+			log.Printf("automatch: starting a game for %s and %s: %v", pairing.Black.UserID, pairing.White.UserID, err)
+		}
+	}
+}
+
+// startMatch creates the game of a pairing and tells both players
+// If either account is gone, the other player goes back in the queue
+func startMatch(ctx context.Context, pairing matchmaking.Pairing) error {
+	black, blackFound, err := lookupAccount(ctx, auth.User{ID: pairing.Black.UserID})
+	if err != nil {
+		return err
+	}
+	white, whiteFound, err := lookupAccount(ctx, auth.User{ID: pairing.White.UserID})
+	if err != nil {
+		return err
+	}
+	if !blackFound || !whiteFound {
+		if blackFound {
+			matchQueue.Join(pairing.Black)
+		}
+		if whiteFound {
+			matchQueue.Join(pairing.White)
+		}
+		return nil
+	}
+
+	timeControl := pairing.Black.TimeControl
+	gameID, _, _, unlock, err := createGame(ctx, NewGameRequest{
+		Size:           pairing.Size,
+		MainTime:       timeControl.MainTime,
+		ByoYomiTime:    timeControl.ByoYomiTime,
+		ByoYomiPeriods: timeControl.ByoYomiPeriods,
+		Color:          1,
+		opponent:       &white,
+	}, black, true)
+	if err != nil {
+		return err
+	}
+	unlock()
+
+	now := time.Now()
+	notifyMatch(black.ID, Match{GameID: gameID, Color: 1, Size: pairing.Size, Opponent: white.Profile(), Started: now})
+	notifyMatch(white.ID, Match{GameID: gameID, Color: 2, Size: pairing.Size, Opponent: black.Profile(), Started: now})
+	return nil
+}
+
+// notifyMatch remembers the game found for a player and tells their connections about it
+func notifyMatch(userID string, match Match) {
+	recentMatchesMu.Lock()
+	recentMatches[userID] = match
+	recentMatchesMu.Unlock()
+
+	notifyUser(userID, Event{Time: match.Started, Type: SocketMatched, GameID: match.GameID, Data: match})
+}
+
+// runMatchExpiry takes out the players who have waited too long, and forgets old matches,
+// until ctx is cancelled
+func runMatchExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			matchQueue.Expire(now, maxMatchWait)
+
+			recentMatchesMu.Lock()
+			for userID, match := range recentMatches {
+				if now.Sub(match.Started) > matchNoticeLifetime {
+					delete(recentMatches, userID)
+				}
+			}
+			recentMatchesMu.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
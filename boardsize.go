@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"go-game/game"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Board size limits
+const (
+	defaultBoardSize = 19
+	minBoardSize     = 5
+	maxBoardSize     = len(game.ColumnLetters) // Coordinates run out past 25
+)
+
+// BoardSizePolicy lists the board sizes new games may use
+type BoardSizePolicy struct {
+	Sizes  []int // Sizes always allowed
+	OddMin int   // Odd sizes from OddMin to OddMax are allowed too (0 = none)
+	OddMax int
+}
+
+// boardSizes is the policy for new games, set from BOARD_SIZES and BOARD_ODD_SIZES in main
+var boardSizes = BoardSizePolicy{Sizes: []int{9, 13, 19}}
+
+// parseBoardSizePolicy reads the allowed sizes ("9,13,19"; empty = those three)
+// and the optional range of odd sizes ("7-25")
+func parseBoardSizePolicy(sizes, oddSizes string) (BoardSizePolicy, error) {
+	policy := BoardSizePolicy{Sizes: []int{9, 13, 19}}
+
+	if strings.TrimSpace(sizes) != "" {
+		policy.Sizes = nil
+		for _, entry := range strings.Split(sizes, ",") {
+			size, err := strconv.Atoi(strings.TrimSpace(entry))
+			if err != nil || size < minBoardSize || size > maxBoardSize {
+				return policy, fmt.Errorf("invalid board size %q (sizes go from %d to %d)", entry, minBoardSize, maxBoardSize)
+			}
+			policy.Sizes = append(policy.Sizes, size)
+		}
+		slices.Sort(policy.Sizes)
+	}
+
+	if strings.TrimSpace(oddSizes) != "" {
+		low, high, found := strings.Cut(oddSizes, "-")
+		minSize, errMin := strconv.Atoi(strings.TrimSpace(low))
+		maxSize, errMax := strconv.Atoi(strings.TrimSpace(high))
+		if !found || errMin != nil || errMax != nil || minSize < minBoardSize || maxSize > maxBoardSize || minSize > maxSize {
+			return policy, fmt.Errorf("invalid odd board size range %q (sizes go from %d to %d)", oddSizes, minBoardSize, maxBoardSize)
+		}
+		policy.OddMin, policy.OddMax = minSize, maxSize
+	}
+	return policy, nil
+}
+
+// Allows checks if new games may be played on a board size
+func (p BoardSizePolicy) Allows(size int) bool {
+	if slices.Contains(p.Sizes, size) {
+		return true
+	}
+	return p.OddMin > 0 && size%2 == 1 && size >= p.OddMin && size <= p.OddMax
+}
+
+// BoardSizeError tells the client which sizes it may ask for instead
+type BoardSizeError struct {
+	Error    string `json:"error"`
+	Size     int    `json:"size"`               // The size that was asked for
+	Allowed  []int  `json:"allowed"`            // Sizes always allowed
+	OddSizes string `json:"oddSizes,omitempty"` // Range of odd sizes also allowed, e.g. "7-25"
+}
+
+// sizeError describes why a size was refused
+func (p BoardSizePolicy) sizeError(size int) BoardSizeError {
+	err := BoardSizeError{Error: fmt.Sprintf("Board size %d is not allowed", size), Size: size, Allowed: p.Sizes}
+	if p.OddMin > 0 {
+		err.OddSizes = fmt.Sprintf("%d-%d", p.OddMin, p.OddMax)
+	}
+	return err
+}
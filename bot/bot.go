@@ -0,0 +1,166 @@
+// Package bot implements the built-in computer opponent
+// It evaluates positions with the playout estimator, plays on the most contested
+// point it finds, and resigns once the game has been hopeless for a while
+package bot
+
+import (
+	"context"
+	"errors"
+	"go-game/analysis"
+	"go-game/game"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Settings configure a bot
+type Settings struct {
+	Playouts     int `json:"playouts"`     // Playouts per move
+	ThinkingTime int `json:"thinkingTime"` // Time per move in milliseconds
+
+	// Resignation: the bot resigns when its estimated win rate drops below ResignWinRate,
+	// or it trails by more than ResignScoreDeficit points, for ResignAfter moves in a row
+	// A threshold of 0 disables that check
+	ResignWinRate      float64 `json:"resignWinRate"`
+	ResignScoreDeficit float64 `json:"resignScoreDeficit"`
+	ResignAfter        int     `json:"resignAfter"`
+}
+
+// DefaultSettings are used for any setting left at zero, except the resignation thresholds
+var DefaultSettings = Settings{
+	Playouts:     200,
+	ThinkingTime: 1000,
+	ResignAfter:  3,
+}
+
+// Limits on what a bot may spend per move
+const (
+	maxPlayouts     = 2000
+	maxThinkingTime = 10000
+)
+
+// settledOwnership is how strongly a point must belong to one side to be considered settled
+// The bot passes once every empty point is settled
+const settledOwnership = 0.9
+
+// Validate checks the settings and fills in defaults
+func (s *Settings) Validate() error {
+	if s.Playouts == 0 {
+		s.Playouts = DefaultSettings.Playouts
+	}
+	if s.ThinkingTime == 0 {
+		s.ThinkingTime = DefaultSettings.ThinkingTime
+	}
+	if s.ResignAfter == 0 {
+		s.ResignAfter = DefaultSettings.ResignAfter
+	}
+
+	switch {
+	case s.Playouts < 0 || s.Playouts > maxPlayouts:
+		return errors.New("bot playouts out of range")
+	case s.ThinkingTime < 0 || s.ThinkingTime > maxThinkingTime:
+		return errors.New("bot thinking time out of range")
+	case s.ResignWinRate < 0 || s.ResignWinRate >= 1:
+		return errors.New("resign win rate must be between 0 and 1")
+	case s.ResignScoreDeficit < 0:
+		return errors.New("resign score deficit can't be negative")
+	case s.ResignAfter < 0:
+		return errors.New("resign after can't be negative")
+	}
+	return nil
+}
+
+// Bot plays one color of a game
+type Bot struct {
+	Color    int // 1 = black, 2 = white
+	Settings Settings
+
+	engine   *analysis.Engine // Runs the playouts
+	hopeless int              // Consecutive moves the position looked lost
+}
+
+// New creates a bot for a color that evaluates positions on the given engine
+func New(color int, settings Settings, engine *analysis.Engine) (*Bot, error) {
+	if color != 1 && color != 2 {
+		return nil, errors.New("bot color must be 1 (black) or 2 (white)")
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	return &Bot{Color: color, Settings: settings, engine: engine}, nil
+}
+
+// Decision is the move the bot chose
+type Decision struct {
+	Position int  // Where to play (only if neither Pass nor Resign)
+	Pass     bool // Pass instead of playing a stone
+	Resign   bool // Give up the game
+
+	WinRate  float64 // Estimated chance of winning for the bot
+	Hopeless bool    // The position crossed a resignation threshold
+}
+
+// Think evaluates the position and picks a move; it doesn't change the bot or the board
+// Call Commit with the result once the move is about to be played
+func (b *Bot) Think(ctx context.Context, board *game.Board) (Decision, error) {
+	budget := time.Duration(b.Settings.ThinkingTime) * time.Millisecond
+	estimate, err := b.engine.Estimate(ctx, board, b.Settings.Playouts, budget)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	// Look at the position from the bot's side
+	winRate, lead := estimate.BlackWinRate, estimate.ScoreLead
+	if b.Color == 2 {
+		winRate, lead = 1-winRate, -lead
+	}
+
+	decision := Decision{WinRate: winRate}
+	decision.Hopeless = (b.Settings.ResignWinRate > 0 && winRate < b.Settings.ResignWinRate) ||
+		(b.Settings.ResignScoreDeficit > 0 && -lead > b.Settings.ResignScoreDeficit)
+
+	decision.Position, decision.Pass = BestMove(board, estimate)
+	return decision, nil
+}
+
+// BestMove picks the legal move where the estimated outcome is most uncertain,
+// or a pass once everything is settled
+func BestMove(board *game.Board, estimate *analysis.Estimate) (position int, pass bool) {
+	best, bestOwnership := -1, settledOwnership
+	rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	for _, position := range board.LegalMoves() {
+		ownership := math.Abs(estimate.Ownership[position])
+		if ownership < bestOwnership || (ownership == bestOwnership && best >= 0 && rng.IntN(2) == 0) {
+			best, bestOwnership = position, ownership
+		}
+	}
+
+	if best < 0 {
+		return 0, true
+	}
+	return best, false
+}
+
+// Side is the color the bot plays
+func (b *Bot) Side() int {
+	return b.Color
+}
+
+// Close does nothing; the playout engine is shared
+func (b *Bot) Close() error {
+	return nil
+}
+
+// Commit records a decision that is about to be played and turns it into a resignation
+// once the position has been hopeless for ResignAfter moves in a row
+func (b *Bot) Commit(decision *Decision) {
+	if !decision.Hopeless {
+		b.hopeless = 0
+		return
+	}
+
+	b.hopeless++
+	if b.Settings.ResignAfter > 0 && b.hopeless >= b.Settings.ResignAfter {
+		decision.Resign = true
+	}
+}
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"go-game/game"
+	"go-game/gtp"
+	"sync"
+)
+
+// GTPPlayer lets an external engine (GNU Go, KataGo, Leela Zero, ...) play one color
+// The engine is started on its first move and kept running until Close
+type GTPPlayer struct {
+	color       int
+	commandLine string
+
+	mu     sync.Mutex
+	engine *gtp.Engine
+}
+
+// NewGTP creates a player for a color backed by the engine started with commandLine
+func NewGTP(color int, commandLine string) (*GTPPlayer, error) {
+	if color != 1 && color != 2 {
+		return nil, errors.New("bot color must be 1 (black) or 2 (white)")
+	}
+	return &GTPPlayer{color: color, commandLine: commandLine}, nil
+}
+
+// Side is the color the engine plays
+func (p *GTPPlayer) Side() int {
+	return p.color
+}
+
+// Think loads the whole game into the engine and asks it for a move
+// Reloading every time keeps the engine in sync even after moves it never saw (undo, resume, ...)
+func (p *GTPPlayer) Think(ctx context.Context, board *game.Board) (Decision, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.engine == nil {
+		// The engine outlives this move, so it must not be tied to ctx
+		engine, err := gtp.StartCommandLine(context.Background(), p.commandLine)
+		if err != nil {
+			return Decision{}, err
+		}
+		p.engine = engine
+	}
+
+	if err := p.engine.LoadBoard(ctx, board); err != nil {
+		p.reset()
+		return Decision{}, err
+	}
+
+	position, resigned, err := p.engine.GenMove(ctx, p.color, board.Size)
+	if err != nil {
+		p.reset()
+		return Decision{}, err
+	}
+
+	switch {
+	case resigned:
+		return Decision{Resign: true}, nil
+	case position < 0:
+		return Decision{Pass: true}, nil
+	default:
+		return Decision{Position: position}, nil
+	}
+}
+
+// Commit does nothing: engines decide on resignation themselves
+func (p *GTPPlayer) Commit(decision *Decision) {}
+
+// Close stops the engine
+func (p *GTPPlayer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.engine == nil {
+		return nil
+	}
+	err := p.engine.Close()
+	p.engine = nil
+	return err
+}
+
+// reset drops an engine that failed, so the next move starts a fresh one
+// A failed command (e.g. a cancelled context) leaves the engine killed or out of sync
+func (p *GTPPlayer) reset() {
+	p.engine.Close()
+	p.engine = nil
+}
@@ -0,0 +1,21 @@
+package bot
+
+import (
+	"context"
+	"go-game/game"
+)
+
+// Player is anything that can play one color of a game: the built-in bot or an external engine
+type Player interface {
+	// Side is the color the player plays (1 = black, 2 = white)
+	Side() int
+
+	// Think picks a move for the position without changing it
+	Think(ctx context.Context, board *game.Board) (Decision, error)
+
+	// Commit records a decision that is about to be played, and may turn it into a resignation
+	Commit(decision *Decision)
+
+	// Close releases whatever the player holds (e.g. an engine process)
+	Close() error
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go-game/bot"
+	"go-game/game"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Bot request structure: which color the bot plays and how
+type BotRequest struct {
+	Color  int    `json:"color"`  // 1 = black, 2 = white
+	Engine string `json:"engine"` // External engine from GTP_ENGINES; the built-in bot if empty
+	bot.Settings
+}
+
+// newBotPlayer creates the player described by a bot request
+func newBotPlayer(botReq *BotRequest) (bot.Player, error) {
+	if botReq.Engine == "" {
+		return bot.New(botReq.Color, botReq.Settings, analysisEngine)
+	}
+
+	commandLine, configured := gtpEngines[botReq.Engine]
+	if !configured {
+		return nil, fmt.Errorf("unknown engine %q", botReq.Engine)
+	}
+	return bot.NewGTP(botReq.Color, commandLine)
+}
+
+// bots holds the bot of each game that has one (guarded by gamesMu)
+var bots = make(map[string]bot.Player)
+
+// botOf returns the bot of a game (nil if it has none)
+func botOf(gameID string) bot.Player {
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	return bots[gameID]
+}
+
+// releaseBot stops a game's bot
+func releaseBot(gameID string) {
+	gamesMu.Lock()
+	player, exists := bots[gameID]
+	delete(bots, gameID)
+	gamesMu.Unlock()
+
+	if exists {
+		go player.Close() // Engines may take a moment to quit
+	}
+}
+
+// botMoveTimeout bounds a bot move, thinking included
+const botMoveTimeout = 30 * time.Second
+
+// scheduleBotMove lets the game's bot move if it is its turn
+// The bot thinks on a copy of the board without holding the game's lock; must be called with the game locked
+func scheduleBotMove(ctx context.Context, gameID string, board *game.Board) {
+	player := botOf(gameID)
+	if player == nil || board.Phase != game.PhasePlaying || board.CurrentPlayer != player.Side() {
+		return
+	}
+
+	position, version := board.Clone(), board.Version()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), botMoveTimeout)
+		defer cancel()
+
+		decision, err := player.Think(ctx, position)
+		if err != nil {
+			log.Printf("bot move in game %s: %v", gameID, err)
+			return
+		}
+
+		// The game may have moved on (or been replaced) while the bot was thinking
+		board, unlock, exists := lockGame(gameID)
+		if !exists {
+			return
+		}
+		defer unlock()
+		if botOf(gameID) != player || board.Version() != version || board.Phase != game.PhasePlaying {
+			return
+		}
+
+		player.Commit(&decision)
+		phase := board.Phase
+		if decision.Resign {
+			if err := board.Resign(player.Side()); err != nil {
+				log.Printf("bot resignation in game %s: %v", gameID, err)
+				return
+			}
+			saveGame(ctx, gameID, board)
+			announceResult(gameID, board, phase)
+			return
+		}
+
+		if err := applyMove(board, MoveRequest{Position: decision.Position, Pass: decision.Pass}); err != nil {
+			log.Printf("bot move in game %s: %v", gameID, err)
+			saveGame(ctx, gameID, board)
+			announceResult(gameID, board, phase)
+			return
+		}
+		saveGame(ctx, gameID, board)
+		announceMove(gameID, board)
+		playConditional(ctx, gameID, board)
+	}()
+}
+
+// Resign request structure
+type ResignRequest struct {
+	Player int `json:"player"` // Player resigning (1 = black, 2 = white)
+}
+
+// Resign the game
+func resignGame(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	// Parse the request
+	var resignReq ResignRequest
+	if err := c.Bind(&resignReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := checkRequestSeat(c, board, resignReq.Player); err != nil {
+		return c.JSON(seatStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	phase := board.Phase
+	if err := board.Resign(resignReq.Player); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	saveGame(c.Request().Context(), gameID, board)
+
+	announceResult(gameID, board, phase)
+	return respondBoard(c, http.StatusOK, gameID, board.ViewFor(resignReq.Player))
+}
@@ -0,0 +1,180 @@
+// Package budget accounts the time engines spend on analysis (hints, reviews, estimates),
+// so a deployment can cap it overall and per user
+package budget
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits are the engine time allowed per window (0 = unlimited)
+type Limits struct {
+	Global  time.Duration `json:"global"`  // For everyone together
+	PerUser time.Duration `json:"perUser"` // For each user
+}
+
+// Tiers are preset limits per hour for deployments of different sizes
+// A "small" server spares about a sixth of a core for analysis, a "medium" one a core,
+// a "large" one a GPU box; "unlimited" keeps no limits, as before budgets existed
+var Tiers = map[string]Limits{
+	"small":     {Global: 10 * time.Minute, PerUser: time.Minute},
+	"medium":    {Global: time.Hour, PerUser: 5 * time.Minute},
+	"large":     {Global: 8 * time.Hour, PerUser: 30 * time.Minute},
+	"unlimited": {},
+}
+
+// ParseLimits reads the limits of a tier ("" = unlimited), with the global and per user
+// limits overridden by durations if set (e.g. "2h" and "90s")
+func ParseLimits(tier, global, perUser string) (Limits, error) {
+	tier = strings.ToLower(strings.TrimSpace(tier))
+	if tier == "" {
+		tier = "unlimited"
+	}
+	limits, ok := Tiers[tier]
+	if !ok {
+		return limits, fmt.Errorf("unknown engine tier %q (small, medium, large or unlimited)", tier)
+	}
+
+	for _, override := range []struct {
+		value string
+		limit *time.Duration
+	}{{global, &limits.Global}, {perUser, &limits.PerUser}} {
+		if strings.TrimSpace(override.value) == "" {
+			continue
+		}
+		limit, err := time.ParseDuration(strings.TrimSpace(override.value))
+		if err != nil || limit < 0 {
+			return limits, fmt.Errorf("invalid engine budget %q", override.value)
+		}
+		*override.limit = limit
+	}
+	return limits, nil
+}
+
+// Grant is engine time set aside for one request; settle it once the work is done
+type Grant struct {
+	User   string
+	Time   time.Duration // How long the engines may work (0 = the budget is exhausted)
+	window int64         // Window the time was set aside in
+}
+
+// Usage is how much of the budgets a user has used in the current window
+type Usage struct {
+	Window      time.Duration `json:"window"`
+	ResetAt     time.Time     `json:"resetAt"`     // When the window ends and the budgets are refilled
+	Used        time.Duration `json:"used"`        // By the user
+	Limit       time.Duration `json:"limit"`       // Per user (0 = unlimited)
+	GlobalUsed  time.Duration `json:"globalUsed"`  // By everyone
+	GlobalLimit time.Duration `json:"globalLimit"` // For everyone (0 = unlimited)
+}
+
+// UserUsage is the engine time one user used in the current window
+type UserUsage struct {
+	User string        `json:"user"`
+	Used time.Duration `json:"used"`
+}
+
+// Ledger keeps the engine time used in fixed windows
+// Grants are charged when they are made and corrected when settled, so concurrent
+// requests can't all spend the same remaining time
+type Ledger struct {
+	mu     sync.Mutex
+	limits Limits
+	length time.Duration // Window length
+	window int64         // Current window, counted from the Unix epoch
+	global time.Duration
+	users  map[string]time.Duration
+}
+
+// NewLedger creates a ledger with the limits per window of the given length
+func NewLedger(limits Limits, window time.Duration) *Ledger {
+	return &Ledger{limits: limits, length: window, users: make(map[string]time.Duration)}
+}
+
+// Limits returns the limits the ledger enforces
+func (l *Ledger) Limits() Limits {
+	return l.limits
+}
+
+// roll starts a new window if the current one is over; must be called with mu held
+func (l *Ledger) roll(now time.Time) {
+	if window := now.UnixNano() / int64(l.length); window != l.window {
+		l.window, l.global = window, 0
+		clear(l.users)
+	}
+}
+
+// remaining is how much time a user may still use; must be called with mu held
+func (l *Ledger) remaining(user string) time.Duration {
+	remaining := time.Duration(1<<63 - 1)
+	if l.limits.Global > 0 {
+		remaining = min(remaining, max(l.limits.Global-l.global, 0))
+	}
+	if l.limits.PerUser > 0 {
+		remaining = min(remaining, max(l.limits.PerUser-l.users[user], 0))
+	}
+	return remaining
+}
+
+// Reserve sets aside up to want of engine time for a user, less if the budgets are running low
+func (l *Ledger) Reserve(user string, want time.Duration, now time.Time) Grant {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.roll(now)
+	granted := min(want, l.remaining(user))
+	l.global += granted
+	l.users[user] += granted
+	return Grant{User: user, Time: granted, window: l.window}
+}
+
+// Settle charges the time a grant actually used instead of the time it set aside
+// Work that ran over its grant is charged in full, so it comes out of the next requests
+func (l *Ledger) Settle(grant Grant, used time.Duration, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.roll(now)
+	charge := used
+	if grant.window == l.window {
+		charge -= grant.Time // Already charged when reserved
+	}
+	l.global += charge
+	l.users[grant.User] += charge
+}
+
+// Usage returns how much of the budgets a user has used
+func (l *Ledger) Usage(user string, now time.Time) Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.roll(now)
+	return Usage{
+		Window:      l.length,
+		ResetAt:     time.Unix(0, (l.window+1)*int64(l.length)),
+		Used:        l.users[user],
+		Limit:       l.limits.PerUser,
+		GlobalUsed:  l.global,
+		GlobalLimit: l.limits.Global,
+	}
+}
+
+// Top lists the users who used the most engine time in the current window, at most n
+func (l *Ledger) Top(n int, now time.Time) []UserUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.roll(now)
+	users := make([]UserUsage, 0, len(l.users))
+	for user, used := range l.users {
+		users = append(users, UserUsage{User: user, Used: used})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Used > users[j].Used })
+	if len(users) > n {
+		users = users[:n]
+	}
+	return users
+}
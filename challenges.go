@@ -0,0 +1,403 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"go-game/auth"
+	"go-game/game"
+	"go-game/users"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Challenges: a player sets up a game and sends it to someone, either directly to an account
+// or as a link anyone can open; the first player to accept is seated as the opponent and the
+// game starts. Direct challenges can be declined, and the challenger can cancel until then
+// The players involved hear of every change over WebSocket
+
+// Challenge settings
+const (
+	challengeLifetime  = 24 * time.Hour   // How long a challenge waits to be accepted
+	challengeRetention = 10 * time.Minute // How long a settled challenge can still be looked at
+	maxOpenChallenges  = 20               // Open challenges per player
+)
+
+// Challenge statuses
+const (
+	ChallengeOpen      = "open"
+	ChallengeAccepted  = "accepted"
+	ChallengeDeclined  = "declined"
+	ChallengeCancelled = "cancelled"
+	ChallengeExpired   = "expired"
+)
+
+// Challenge request structure
+type ChallengeRequest struct {
+	Size           int    `json:"size"` // 19 if not set
+	MainTime       int    `json:"mainTime"`
+	ByoYomiTime    int    `json:"byoYomiTime"`
+	ByoYomiPeriods int    `json:"byoYomiPeriods"`
+	DaysPerMove    int    `json:"daysPerMove"`
+	VacationDays   int    `json:"vacationDays"`
+	Variant        string `json:"variant"`
+	Color          int    `json:"color"`    // Color the challenger plays (1 = black, 2 = white, 0 = decided at random)
+	Opponent       string `json:"opponent"` // Username of the player challenged; empty for a link anyone can accept
+}
+
+// Challenge is a game waiting for its opponent
+type Challenge struct {
+	ID         string         `json:"id"`
+	Link       string         `json:"link"` // Where the challenge can be seen and accepted
+	Status     string         `json:"status"`
+	Challenger users.Profile  `json:"challenger"`
+	Opponent   *users.Profile `json:"opponent,omitempty"` // Only the challenged player may accept, if set
+
+	Size           int    `json:"size"`
+	MainTime       int    `json:"mainTime"`
+	ByoYomiTime    int    `json:"byoYomiTime"`
+	ByoYomiPeriods int    `json:"byoYomiPeriods"`
+	DaysPerMove    int    `json:"daysPerMove,omitempty"`
+	VacationDays   int    `json:"vacationDays,omitempty"`
+	Variant        string `json:"variant,omitempty"`
+	Color          int    `json:"color"` // Color the challenger plays (0 = decided at random when accepted)
+
+	Rematch   string    `json:"rematch,omitempty"` // Game this challenge is a rematch of
+	GameID    string    `json:"gameId,omitempty"`  // Once accepted
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	SettledAt time.Time `json:"settledAt,omitzero"` // When it stopped being open
+}
+
+// Challenges by ID, open and recently settled
+var (
+	challengesMu sync.Mutex
+	challenges   = make(map[string]*Challenge)
+)
+
+// newChallengeID generates the random ID that makes up a challenge's link
+func newChallengeID() string {
+	var id [12]byte
+	rand.Read(id[:])
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+// pruneChallenges expires the challenges nobody accepted in time and forgets the ones
+// settled long ago; must be called with challengesMu held
+func pruneChallenges(now time.Time) {
+	for id, challenge := range challenges {
+		if challenge.Status == ChallengeOpen && !now.Before(challenge.ExpiresAt) {
+			challenge.Status, challenge.SettledAt = ChallengeExpired, now
+		}
+		if challenge.Status != ChallengeOpen && now.Sub(challenge.SettledAt) > challengeRetention {
+			delete(challenges, id)
+		}
+	}
+}
+
+// Challenge a player, or make a link anyone can accept
+func createChallenge(c echo.Context) error {
+	var req ChallengeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	if req.Size == 0 {
+		req.Size = defaultBoardSize
+	}
+	if !boardSizes.Allows(req.Size) {
+		return c.JSON(http.StatusBadRequest, boardSizes.sizeError(req.Size))
+	}
+	if req.MainTime < 0 || req.ByoYomiTime < 0 || req.ByoYomiPeriods < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid time control"})
+	}
+	correspondence := NewGameRequest{
+		MainTime:       req.MainTime,
+		ByoYomiTime:    req.ByoYomiTime,
+		ByoYomiPeriods: req.ByoYomiPeriods,
+		DaysPerMove:    req.DaysPerMove,
+		VacationDays:   req.VacationDays,
+	}
+	if message := correspondence.correspondenceError(); message != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": message})
+	}
+	if req.Color < 0 || req.Color > 2 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid color"})
+	}
+	if req.Variant != "" {
+		if err := game.NewBoard(req.Size).SetVariant(req.Variant); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+
+	ctx := c.Request().Context()
+	challenger, signedIn, err := account(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !signedIn {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Challenges need an account"})
+	}
+
+	// A direct challenge names an existing account other than the challenger's
+	var opponent *users.Profile
+	if req.Opponent = strings.TrimSpace(req.Opponent); req.Opponent != "" {
+		user, err := userStore.FindByUsername(ctx, req.Opponent)
+		if errors.Is(err, users.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "No player with that username"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if user.ID == challenger.ID {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "You can't challenge yourself"})
+		}
+		profile := user.Profile()
+		opponent = &profile
+	}
+
+	now := time.Now()
+	challenge := &Challenge{
+		Challenger:     challenger.Profile(),
+		Opponent:       opponent,
+		Size:           req.Size,
+		MainTime:       req.MainTime,
+		ByoYomiTime:    req.ByoYomiTime,
+		ByoYomiPeriods: req.ByoYomiPeriods,
+		DaysPerMove:    req.DaysPerMove,
+		VacationDays:   req.VacationDays,
+		Variant:        req.Variant,
+		Color:          req.Color,
+	}
+	snapshot, ok := addChallenge(challenge, now)
+	if !ok {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Too many open challenges, cancel one first"})
+	}
+	c.Response().Header().Set(echo.HeaderLocation, snapshot.Link)
+	return c.JSON(http.StatusCreated, snapshot)
+}
+
+// addChallenge opens a new challenge and tells the player challenged, if any
+// False if the challenger has too many open challenges already
+func addChallenge(challenge *Challenge, now time.Time) (Challenge, bool) {
+	challenge.ID = newChallengeID()
+	challenge.Link = "/challenges/" + challenge.ID
+	challenge.Status = ChallengeOpen
+	challenge.CreatedAt, challenge.ExpiresAt = now, now.Add(challengeLifetime)
+
+	challengesMu.Lock()
+	pruneChallenges(now)
+	open := 0
+	for _, other := range challenges {
+		if other.Status == ChallengeOpen && other.Challenger.ID == challenge.Challenger.ID {
+			open++
+		}
+	}
+	if open >= maxOpenChallenges {
+		challengesMu.Unlock()
+		return Challenge{}, false
+	}
+	challenges[challenge.ID] = challenge
+	snapshot := *challenge
+	challengesMu.Unlock()
+
+	if snapshot.Opponent != nil {
+		notifyChallenge(snapshot.Opponent.ID, snapshot)
+	}
+	return snapshot, true
+}
+
+// List the open challenges the user sent or received, newest first
+func listChallenges(c echo.Context) error {
+	user, _ := currentUser(c)
+
+	challengesMu.Lock()
+	pruneChallenges(time.Now())
+	list := make([]Challenge, 0)
+	for _, challenge := range challenges {
+		sent := challenge.Challenger.ID == user.ID
+		received := challenge.Opponent != nil && challenge.Opponent.ID == user.ID
+		if challenge.Status == ChallengeOpen && (sent || received) {
+			list = append(list, *challenge)
+		}
+	}
+	challengesMu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return c.JSON(http.StatusOK, list)
+}
+
+// Look at a challenge, e.g. when opening its link
+func getChallenge(c echo.Context) error {
+	challengesMu.Lock()
+	defer challengesMu.Unlock()
+
+	pruneChallenges(time.Now())
+	challenge, found := challenges[c.Param("cid")]
+	if !found {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Challenge not found"})
+	}
+	return c.JSON(http.StatusOK, *challenge)
+}
+
+// Accept a challenge: the game starts with the user seated as the opponent
+func acceptChallenge(c echo.Context) error {
+	ctx := context.WithoutCancel(c.Request().Context()) // The game outlives the request
+	opponent, signedIn, err := account(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !signedIn {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Challenges need an account"})
+	}
+
+	challenge, status, err := acceptChallengeAs(ctx, c.Param("cid"), opponent)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+	setGameLocation(c, challenge.GameID)
+	return c.JSON(http.StatusOK, challenge)
+}
+
+// acceptChallengeAs starts the game of a challenge with an account as the opponent and
+// tells the challenger; on failure the status says why
+func acceptChallengeAs(ctx context.Context, id string, opponent users.User) (Challenge, int, error) {
+	// Settle the challenge first, so only one player gets to accept it
+	challenge, status, message := settleChallenge(id, opponent.ID, ChallengeAccepted)
+	if status != http.StatusOK {
+		return Challenge{}, status, errors.New(message)
+	}
+
+	gameID, color, err := startChallenge(ctx, challenge, opponent)
+	if err != nil {
+		reopenChallenge(challenge.ID)
+		return Challenge{}, http.StatusInternalServerError, err
+	}
+
+	challengesMu.Lock()
+	stored := challenges[challenge.ID]
+	stored.GameID, stored.Color = gameID, color
+	if stored.Opponent == nil {
+		profile := opponent.Profile()
+		stored.Opponent = &profile
+	}
+	challenge = *stored
+	challengesMu.Unlock()
+
+	notifyChallenge(challenge.Challenger.ID, challenge)
+	return challenge, http.StatusOK, nil
+}
+
+// Decline a challenge sent to the user
+func declineChallenge(c echo.Context) error {
+	user, _ := currentUser(c)
+	challenge, status, message := settleChallenge(c.Param("cid"), user.ID, ChallengeDeclined)
+	if status != http.StatusOK {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	notifyChallenge(challenge.Challenger.ID, challenge)
+	return c.JSON(http.StatusOK, challenge)
+}
+
+// Cancel one of the user's challenges
+func cancelChallenge(c echo.Context) error {
+	user, _ := currentUser(c)
+	challenge, status, message := settleChallenge(c.Param("cid"), user.ID, ChallengeCancelled)
+	if status != http.StatusOK {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	if challenge.Opponent != nil {
+		notifyChallenge(challenge.Opponent.ID, challenge)
+	}
+	return c.JSON(http.StatusOK, challenge)
+}
+
+// settleChallenge moves an open challenge to a new status on behalf of a user, if they may,
+// and returns it; the status and message say why not otherwise
+func settleChallenge(id, userID, status string) (Challenge, int, string) {
+	challengesMu.Lock()
+	defer challengesMu.Unlock()
+
+	now := time.Now()
+	pruneChallenges(now)
+	challenge, found := challenges[id]
+	if !found {
+		return Challenge{}, http.StatusNotFound, "Challenge not found"
+	}
+
+	challenger := challenge.Challenger.ID == userID
+	addressee := challenge.Opponent != nil && challenge.Opponent.ID == userID
+	switch {
+	case status == ChallengeCancelled && !challenger:
+		return Challenge{}, http.StatusForbidden, "Only the challenger can cancel a challenge"
+	case status == ChallengeDeclined && !addressee:
+		return Challenge{}, http.StatusForbidden, "Only the player challenged can decline a challenge"
+	case status == ChallengeAccepted && challenger:
+		return Challenge{}, http.StatusForbidden, "You can't accept your own challenge"
+	case status == ChallengeAccepted && challenge.Opponent != nil && !addressee:
+		return Challenge{}, http.StatusForbidden, "This challenge is for another player"
+	case challenge.Status != ChallengeOpen:
+		return Challenge{}, http.StatusConflict, "The challenge was " + challenge.Status
+	}
+
+	challenge.Status, challenge.SettledAt = status, now
+	return *challenge, http.StatusOK, ""
+}
+
+// reopenChallenge puts back a challenge whose game couldn't be started
+func reopenChallenge(id string) {
+	challengesMu.Lock()
+	defer challengesMu.Unlock()
+
+	if challenge, found := challenges[id]; found {
+		challenge.Status, challenge.SettledAt = ChallengeOpen, time.Time{}
+	}
+}
+
+// startChallenge creates the game of an accepted challenge and returns it with the
+// challenger's color
+func startChallenge(ctx context.Context, challenge Challenge, opponent users.User) (string, int, error) {
+	challenger, found, err := lookupAccount(ctx, auth.User{ID: challenge.Challenger.ID})
+	if err != nil {
+		return "", 0, err
+	}
+	if !found {
+		return "", 0, errors.New("the challenger's account is gone")
+	}
+
+	color := challenge.Color
+	if color == 0 {
+		n, _ := rand.Int(rand.Reader, big.NewInt(2))
+		color = 1 + int(n.Int64())
+	}
+
+	gameID, _, _, unlock, err := createGame(ctx, NewGameRequest{
+		Size:           challenge.Size,
+		MainTime:       challenge.MainTime,
+		ByoYomiTime:    challenge.ByoYomiTime,
+		ByoYomiPeriods: challenge.ByoYomiPeriods,
+		DaysPerMove:    challenge.DaysPerMove,
+		VacationDays:   challenge.VacationDays,
+		Variant:        challenge.Variant,
+		Color:          color,
+		opponent:       &opponent,
+	}, challenger, true)
+	if err != nil {
+		return "", 0, err
+	}
+	unlock()
+	return gameID, color, nil
+}
+
+// notifyChallenge tells a player's connections that a challenge involving them changed
+func notifyChallenge(userID string, challenge Challenge) {
+	notifyUser(userID, Event{Time: time.Now(), Type: SocketChallenge, GameID: challenge.GameID, Data: challenge})
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"go-game/chat"
+	"go-game/game"
+	"go-game/store"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+// In-game chat: the players talk in their channel, which anyone may read, and the spectators
+// in theirs, which the players don't see until the game is over. Messages are kept in the
+// chat store next to the games, so they show up in reviews
+
+// chatStore keeps the chat of games (Postgres if DATABASE_URL is set, memory otherwise)
+var chatStore chat.Store
+
+// Chat limits
+const (
+	maxChatLength  = 500  // Characters per message
+	maxChatPerGame = 2000 // Messages per game, both channels together
+)
+
+// newChatStoreFromEnv opens the chat store next to the games, keeping the messages
+// encrypted if GAME_DATA_KEY is set (see sealer)
+func newChatStoreFromEnv() (chat.Store, error) {
+	var store chat.Store = chat.NewMemoryStore()
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		postgres, err := chat.NewPostgresStore(url)
+		if err != nil {
+			return nil, err
+		}
+		store = postgres
+	}
+	if sealer != nil {
+		store = chat.NewSealedStore(store, sealer)
+	}
+	return store, nil
+}
+
+// Chat request structure
+type ChatRequest struct {
+	Channel string `json:"channel"` // "players" or "spectators"
+	Text    string `json:"text"`
+	Player  int    `json:"player"` // Color of the player talking, in the players' channel
+	Author  string `json:"author"` // Name of a spectator without an account
+}
+
+// Say something in a game's chat
+func postChat(c echo.Context) error {
+	gameID := c.Param("id")
+
+	var req ChatRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" || utf8.RuneCountInString(text) > maxChatLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid message"})
+	}
+
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	user, signedIn := currentUser(c)
+	message := chat.Message{GameID: gameID, Channel: req.Channel, Text: text, MoveNumber: len(board.MoveHistory), Time: time.Now()}
+	eventType := EventChat
+	switch req.Channel {
+	case chat.ChannelPlayers:
+		if req.Player != 1 && req.Player != 2 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid player"})
+		}
+		if err := checkRequestSeat(c, board, req.Player); err != nil {
+			return c.JSON(seatStatus(err), map[string]string{"error": err.Error()})
+		}
+		message.Player, message.UserID = req.Player, board.Players[req.Player]
+		message.Author = playerName(board, req.Player)
+
+	case chat.ChannelSpectators:
+		if board.Phase != game.PhaseFinished && isPlayerOf(c, board) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Players can't use the spectators' channel during the game"})
+		}
+		author := strings.TrimSpace(req.Author)
+		if signedIn {
+			author = user.Name
+		}
+		if author == "" || utf8.RuneCountInString(author) > maxKibitzNameSize {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid author"})
+		}
+		message.UserID, message.Author = user.ID, author
+		eventType = EventSpectatorChat
+		noteSpectator(gameID, author)
+
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid channel"})
+	}
+
+	ctx := c.Request().Context()
+	count, err := chatStore.Count(ctx, gameID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if count >= maxChatPerGame {
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "Too many messages in this game"})
+	}
+	if message, err = chatStore.Add(ctx, message); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	hub.Broadcast(Event{Type: eventType, GameID: gameID, Data: message})
+	return c.JSON(http.StatusOK, message)
+}
+
+// List the messages of a game's chat channel (?channel=, the players' if omitted)
+func listChat(c echo.Context) error {
+	gameID := c.Param("id")
+	ctx := c.Request().Context()
+	channel := c.QueryParam("channel")
+	if channel == "" {
+		channel = chat.ChannelPlayers
+	}
+	if channel != chat.ChannelPlayers && channel != chat.ChannelSpectators {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid channel"})
+	}
+
+	// The spectators' channel is kept from the players while they play
+	if channel == chat.ChannelSpectators {
+		board, unlock, exists := lockGame(gameID)
+		if exists {
+			defer unlock()
+		} else {
+			stored, err := gameStore.LoadGame(ctx, gameID)
+			if errors.Is(err, store.ErrNotFound) {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+			}
+			if err != nil {
+				log.Printf("loading game %s: %v", gameID, err)
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load the game"})
+			}
+			board = stored
+		}
+		if board.Phase != game.PhaseFinished && isPlayerOf(c, board) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Players can't read the spectators' channel during the game"})
+		}
+	}
+
+	messages, err := chatStore.List(ctx, gameID, channel)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if messages == nil {
+		messages = []chat.Message{}
+	}
+	return c.JSON(http.StatusOK, messages)
+}
+
+// isPlayerOf checks whether a request comes from a player of a game, by seat token or account
+func isPlayerOf(c echo.Context, board *game.Board) bool {
+	return requestSeat(c, board) != 0
+}
+
+// playerName is how a player appears in the chat: their name in the game record, or their color
+func playerName(board *game.Board, player int) string {
+	if player == 1 {
+		if board.Info.BlackName != "" {
+			return board.Info.BlackName
+		}
+		return "Black"
+	}
+	if board.Info.WhiteName != "" {
+		return board.Info.WhiteName
+	}
+	return "White"
+}
@@ -0,0 +1,39 @@
+// Package chat keeps the chat of games, in two channels: the players talk in one, the
+// spectators in the other, so the audience can discuss the game without the players reading it
+package chat
+
+import (
+	"context"
+	"time"
+)
+
+// Channels of a game's chat
+const (
+	ChannelPlayers    = "players"    // The players of the game; anyone may read it
+	ChannelSpectators = "spectators" // The audience; hidden from the players while the game is played
+)
+
+// Message is something said in a game's chat
+type Message struct {
+	ID         int64     `json:"id"` // Increasing, so clients can tell what they have
+	GameID     string    `json:"gameId"`
+	Channel    string    `json:"channel"` // See the Channel* constants
+	UserID     string    `json:"userId,omitempty"`
+	Author     string    `json:"author"`
+	Player     int       `json:"player,omitempty"` // Color of the author, in the players' channel
+	Text       string    `json:"text"`
+	MoveNumber int       `json:"moveNumber"` // Moves played when it was said
+	Time       time.Time `json:"time"`
+}
+
+// Store keeps chat messages
+type Store interface {
+	// Add records a message and returns it with its ID
+	Add(ctx context.Context, message Message) (Message, error)
+	// List returns the messages of a game's channel, oldest first
+	List(ctx context.Context, gameID, channel string) ([]Message, error)
+	// Count returns how many messages a game has in all channels
+	Count(ctx context.Context, gameID string) (int, error)
+	// DeleteGame removes the chat of a game
+	DeleteGame(ctx context.Context, gameID string) error
+}
@@ -0,0 +1,56 @@
+package chat
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore keeps chat messages in memory, for servers without a database
+type MemoryStore struct {
+	mu       sync.Mutex
+	lastID   int64
+	messages map[string][]Message // By game, oldest first
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{messages: make(map[string][]Message)}
+}
+
+func (s *MemoryStore) Add(ctx context.Context, message Message) (Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	message.ID = s.lastID
+	s.messages[message.GameID] = append(s.messages[message.GameID], message)
+	return message, nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, gameID, channel string) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []Message
+	for _, message := range s.messages[gameID] {
+		if message.Channel == channel {
+			list = append(list, message)
+		}
+	}
+	return list, nil
+}
+
+func (s *MemoryStore) Count(ctx context.Context, gameID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.messages[gameID]), nil
+}
+
+func (s *MemoryStore) DeleteGame(ctx context.Context, gameID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.messages, gameID)
+	return nil
+}
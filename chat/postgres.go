@@ -0,0 +1,95 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+
+	_ "github.com/lib/pq" // Postgres driver for database/sql
+)
+
+// schema creates the tables the store needs
+const schema = `
+CREATE TABLE IF NOT EXISTS chat_messages (
+	id          BIGSERIAL PRIMARY KEY,
+	game_id     TEXT NOT NULL,
+	channel     TEXT NOT NULL,
+	user_id     TEXT NOT NULL,
+	author      TEXT NOT NULL,
+	player      INTEGER NOT NULL,
+	text        TEXT NOT NULL,
+	move_number INTEGER NOT NULL,
+	sent_at     TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS chat_messages_game ON chat_messages (game_id, channel, id);
+`
+
+// columns are the message columns, in the order List reads them
+const columns = `id, game_id, channel, user_id, author, player, text, move_number, sent_at`
+
+// PostgresStore keeps chat messages in Postgres
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to the database and creates the schema
+func NewPostgresStore(url string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close releases the database connections
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks the database can be reached
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *PostgresStore) Add(ctx context.Context, message Message) (Message, error) {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO chat_messages (game_id, channel, user_id, author, player, text, move_number, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		message.GameID, message.Channel, message.UserID, message.Author, message.Player, message.Text, message.MoveNumber, message.Time,
+	).Scan(&message.ID)
+	return message, err
+}
+
+func (s *PostgresStore) List(ctx context.Context, gameID, channel string) ([]Message, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+columns+` FROM chat_messages WHERE game_id = $1 AND channel = $2 ORDER BY id`, gameID, channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Message
+	for rows.Next() {
+		var message Message
+		if err := rows.Scan(&message.ID, &message.GameID, &message.Channel, &message.UserID, &message.Author,
+			&message.Player, &message.Text, &message.MoveNumber, &message.Time); err != nil {
+			return nil, err
+		}
+		list = append(list, message)
+	}
+	return list, rows.Err()
+}
+
+func (s *PostgresStore) Count(ctx context.Context, gameID string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM chat_messages WHERE game_id = $1`, gameID).Scan(&count)
+	return count, err
+}
+
+func (s *PostgresStore) DeleteGame(ctx context.Context, gameID string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM chat_messages WHERE game_id = $1`, gameID)
+	return err
+}
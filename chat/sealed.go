@@ -0,0 +1,55 @@
+package chat
+
+import "context"
+
+// Sealer encrypts what was said in a game for storage, and decrypts it again
+type Sealer interface {
+	Seal(gameID, plaintext string) (string, error)
+	Open(gameID, stored string) (string, error)
+}
+
+// SealedStore keeps the text of messages in another store encrypted, so the database
+// never holds what was said; everything else about a message stays readable
+type SealedStore struct {
+	Store
+	sealer Sealer
+}
+
+// NewSealedStore encrypts the messages kept in a store with a sealer
+func NewSealedStore(store Store, sealer Sealer) *SealedStore {
+	return &SealedStore{Store: store, sealer: sealer}
+}
+
+func (s *SealedStore) Add(ctx context.Context, message Message) (Message, error) {
+	plaintext := message.Text
+	sealed, err := s.sealer.Seal(message.GameID, plaintext)
+	if err != nil {
+		return message, err
+	}
+
+	message.Text = sealed
+	message, err = s.Store.Add(ctx, message)
+	message.Text = plaintext
+	return message, err
+}
+
+func (s *SealedStore) List(ctx context.Context, gameID, channel string) ([]Message, error) {
+	list, err := s.Store.List(ctx, gameID, channel)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].Text, err = s.sealer.Open(gameID, list[i].Text); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// Ping checks the store underneath can be reached, if it depends on a server
+func (s *SealedStore) Ping(ctx context.Context) error {
+	if pinger, ok := s.Store.(interface{ Ping(context.Context) error }); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
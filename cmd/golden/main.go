@@ -0,0 +1,137 @@
+// Command golden replays the golden games against the rules engine
+// Run it after every rules change; it exits with status 1 if any game came out differently
+//
+//	go run ./cmd/golden                          replay every golden game
+//	go run ./cmd/golden -record game.json -name  record a game saved from GET /game/:id
+//	go run ./cmd/golden -update                  re-record every golden game after an intended change
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go-game/game"
+	"go-game/golden"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	dir := flag.String("dir", "golden/testdata", "directory holding the golden games")
+	record := flag.String("record", "", "board JSON (as returned by GET /game/:id) to record as a new golden game")
+	name := flag.String("name", "", "name of the golden game to record")
+	update := flag.Bool("update", false, "re-record every golden game with the current engine")
+	flag.Parse()
+
+	switch {
+	case *record != "":
+		if err := recordGame(*dir, *record, *name); err != nil {
+			log.Fatal(err)
+		}
+	case *update:
+		if err := updateGames(*dir); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		failed, err := replayGames(*dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+// recordGame saves a game played on the server as a golden game
+func recordGame(dir, source, name string) error {
+	if name == "" {
+		return fmt.Errorf("-name is required with -record")
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	board := &game.Board{}
+	if err := json.Unmarshal(data, board); err != nil {
+		return err
+	}
+
+	g, err := golden.RecordBoard(name, board)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, name+".json")
+	if err := golden.Save(path, g); err != nil {
+		return err
+	}
+	fmt.Printf("recorded %s (%d moves)\n", path, len(g.Steps))
+	return nil
+}
+
+// updateGames re-records every golden game from its moves
+func updateGames(dir string) error {
+	paths, err := golden.Files(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		old, err := golden.Load(path)
+		if err != nil {
+			return err
+		}
+
+		positions := make([]int, len(old.Steps))
+		for i, step := range old.Steps {
+			positions[i] = step.Position
+		}
+
+		g, err := golden.Record(old.Name, old.Size, old.Variant, old.Komi, positions)
+		if err != nil {
+			return err
+		}
+		if err := golden.Save(path, g); err != nil {
+			return err
+		}
+		fmt.Printf("updated %s\n", path)
+	}
+	return nil
+}
+
+// replayGames replays every golden game and reports the ones that changed
+func replayGames(dir string) (int, error) {
+	paths, err := golden.Files(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	failed := 0
+	for _, path := range paths {
+		g, err := golden.Load(path)
+		if err != nil {
+			return failed, err
+		}
+
+		diffs, err := golden.Replay(g)
+		if err != nil {
+			return failed, err
+		}
+		if len(diffs) == 0 {
+			fmt.Printf("ok    %s\n", g.Name)
+			continue
+		}
+
+		failed++
+		fmt.Printf("FAIL  %s\n", g.Name)
+		for _, diff := range diffs {
+			fmt.Printf("      %s\n", diff)
+		}
+	}
+
+	fmt.Printf("%d of %d golden games changed\n", failed, len(paths))
+	return failed, nil
+}
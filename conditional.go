@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-game/game"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Conditional moves: while waiting for the opponent, typically in correspondence games, a player
+// registers the replies they'd make to the moves they expect ("if they play D4, I play C3,
+// then if they play ..."). When the opponent plays one of them the server plays the reply at
+// once; any other move drops the whole tree, as the player has to think again
+// Trees are checked against the legal moves when registered, and kept beside the live game
+// rather than in it, as game snapshots are shown to everyone
+
+// maxConditionalMoves limits the replies in a tree, so checking it stays cheap
+const maxConditionalMoves = 64
+
+// ConditionalMoves maps the opponent moves a player expects, in standard notation ("D4",
+// "pass"), to their replies
+type ConditionalMoves map[string]ConditionalReply
+
+// ConditionalReply is a player's answer to an expected move, and what they expect after it
+type ConditionalReply struct {
+	Reply string           `json:"reply"`          // Move to play, in standard notation
+	Then  ConditionalMoves `json:"then,omitempty"` // Conditional moves from then on
+}
+
+// Conditional moves request and response structure
+type ConditionalRequest struct {
+	Player int              `json:"player"` // Player the moves are for (1 = black, 2 = white); the one waiting if omitted
+	Moves  ConditionalMoves `json:"moves"`
+}
+
+// conditionalTree is a player's registered tree, with the length of the move history it
+// was checked against; it only answers the move right after that
+type conditionalTree struct {
+	moves ConditionalMoves
+	at    int
+}
+
+// Conditional moves of the live games, by game and player (guarded by gamesMu)
+var conditionals = make(map[string]*[3]conditionalTree)
+
+// Register the conditional moves of a player, replacing the ones they had
+func setConditionalMoves(c echo.Context) error {
+	var req ConditionalRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	gameID := c.Param("id")
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	player := req.Player
+	if player == 0 {
+		player = 3 - board.CurrentPlayer
+	}
+	if status, message := checkConditionalPlayer(c, board, player); status != http.StatusOK {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+	if player == board.CurrentPlayer {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "It's this player's move; conditional moves answer the opponent's"})
+	}
+
+	moves, err := checkConditionalMoves(board, req.Moves)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	gamesMu.Lock()
+	trees := conditionals[gameID]
+	if trees == nil {
+		trees = new([3]conditionalTree)
+		conditionals[gameID] = trees
+	}
+	trees[player] = conditionalTree{moves: moves, at: len(board.MoveHistory)}
+	gamesMu.Unlock()
+
+	return c.JSON(http.StatusOK, ConditionalRequest{Player: player, Moves: moves})
+}
+
+// Get the conditional moves of a player (?player=, the one waiting if omitted)
+func getConditionalMoves(c echo.Context) error {
+	return conditionalMoves(c, false)
+}
+
+// Drop the conditional moves of a player (?player=, the one waiting if omitted)
+func deleteConditionalMoves(c echo.Context) error {
+	return conditionalMoves(c, true)
+}
+
+// conditionalMoves answers with a player's conditional moves, after dropping them if asked
+func conditionalMoves(c echo.Context, drop bool) error {
+	gameID := c.Param("id")
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	player := 3 - board.CurrentPlayer
+	if param := c.QueryParam("player"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid player"})
+		}
+		player = parsed
+	}
+	if status, message := checkConditionalPlayer(c, board, player); status != http.StatusOK {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	gamesMu.Lock()
+	var tree conditionalTree
+	if trees := conditionals[gameID]; trees != nil {
+		tree = trees[player]
+		if drop {
+			trees[player] = conditionalTree{}
+		}
+	}
+	gamesMu.Unlock()
+
+	if drop {
+		return c.NoContent(http.StatusNoContent)
+	}
+	if tree.at != len(board.MoveHistory) {
+		tree.moves = nil // Left behind by a move it didn't answer
+	}
+	return c.JSON(http.StatusOK, ConditionalRequest{Player: player, Moves: tree.moves})
+}
+
+// checkConditionalPlayer makes sure the request may act for a player of a game that takes
+// conditional moves; the status and message say why not otherwise
+func checkConditionalPlayer(c echo.Context, board *game.Board, player int) (int, string) {
+	if player != 1 && player != 2 {
+		return http.StatusBadRequest, "Invalid player"
+	}
+	if err := checkRequestSeat(c, board, player); err != nil {
+		return seatStatus(err), err.Error()
+	}
+	switch {
+	case board.Concealed():
+		return http.StatusBadRequest, "Hidden-information variants don't take conditional moves"
+	case board.IsTeamGame():
+		return http.StatusBadRequest, "Team games don't take conditional moves"
+	}
+	return http.StatusOK, ""
+}
+
+// checkConditionalMoves plays every line of a tree on copies of the board, so only legal
+// moves are kept, and returns the tree in canonical notation
+// The lines start with the move of the player to move
+func checkConditionalMoves(board *game.Board, moves ConditionalMoves) (ConditionalMoves, error) {
+	if board.Phase != game.PhasePlaying {
+		return nil, errors.New("the game isn't being played")
+	}
+
+	// The copies have no clock, so checking a line doesn't use anyone's time
+	position := board.Clone()
+	position.Clock = nil
+
+	count := 0
+	return checkConditionalLine(position, moves, &count)
+}
+
+// checkConditionalLine checks the conditional moves from a position and counts their replies
+func checkConditionalLine(position *game.Board, moves ConditionalMoves, count *int) (ConditionalMoves, error) {
+	checked := make(ConditionalMoves, len(moves))
+	for expected, reply := range moves {
+		if *count++; *count > maxConditionalMoves {
+			return nil, fmt.Errorf("at most %d conditional moves", maxConditionalMoves)
+		}
+
+		line := position.Clone()
+		if err := playMoveRequest(line, MoveRequest{Coordinate: expected}); err != nil {
+			return nil, fmt.Errorf("expected move %s: %w", expected, err)
+		}
+		if line.Phase != game.PhasePlaying {
+			return nil, fmt.Errorf("expected move %s ends play, there's nothing to reply", expected)
+		}
+		if err := playMoveRequest(line, MoveRequest{Coordinate: reply.Reply}); err != nil {
+			return nil, fmt.Errorf("reply %s to %s: %w", reply.Reply, expected, err)
+		}
+
+		played := line.MoveHistory[len(line.MoveHistory)-2:]
+		key := game.FormatCoordinate(played[0].Position, line.Size)
+		answer := ConditionalReply{Reply: game.FormatCoordinate(played[1].Position, line.Size)}
+		if _, duplicate := checked[key]; duplicate {
+			return nil, fmt.Errorf("expected move %s is listed twice", key)
+		}
+		if len(reply.Then) > 0 {
+			if line.Phase != game.PhasePlaying {
+				return nil, fmt.Errorf("reply %s to %s ends play, nothing can follow", answer.Reply, key)
+			}
+			then, err := checkConditionalLine(line, reply.Then, count)
+			if err != nil {
+				return nil, err
+			}
+			answer.Then = then
+		}
+		checked[key] = answer
+	}
+	return checked, nil
+}
+
+// playConditional plays the reply the player to move registered for the move just made, if
+// any, or drops their conditional moves if the move wasn't one they expected
+// Must be called with the game locked, right after a move
+func playConditional(ctx context.Context, gameID string, board *game.Board) {
+	if board.Phase != game.PhasePlaying || len(board.MoveHistory) == 0 {
+		return
+	}
+	player := board.CurrentPlayer
+	last := board.MoveHistory[len(board.MoveHistory)-1]
+
+	gamesMu.Lock()
+	trees := conditionals[gameID]
+	if trees == nil {
+		gamesMu.Unlock()
+		return
+	}
+	tree := trees[player]
+	trees[player] = conditionalTree{}
+	reply, found := tree.moves[game.FormatCoordinate(last.Position, board.Size)]
+	found = found && tree.at == len(board.MoveHistory)-1
+	if found && len(reply.Then) > 0 {
+		trees[player] = conditionalTree{moves: reply.Then, at: len(board.MoveHistory) + 1}
+	}
+	gamesMu.Unlock()
+
+	if !found {
+		return
+	}
+	if err := playMove(ctx, gameID, board, MoveRequest{Coordinate: reply.Reply}); err != nil {
+		log.Printf("conditional move %s in game %s: %v", reply.Reply, gameID, err)
+		gamesMu.Lock()
+		trees[player] = conditionalTree{}
+		gamesMu.Unlock()
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"go-game/game"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// adminKey is the bearer token of the admin endpoints; they are disabled when it is empty
+var adminKey = os.Getenv("ADMIN_API_KEY")
+
+// requireAdmin rejects requests without the admin key
+func requireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !isAdmin(c) {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid admin key"})
+		}
+		return next(c)
+	}
+}
+
+// isAdmin checks if a request was sent with the admin key
+func isAdmin(c echo.Context) bool {
+	key := strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+	return adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1
+}
+
+// Background consistency sampling
+const (
+	consistencySampleInterval = 10 * time.Minute
+	consistencySampleSize     = 20 // Live games checked per round
+)
+
+// ConsistencyCheck is the outcome of replaying a game's move log and comparing it with the live board
+type ConsistencyCheck struct {
+	GameID      string    `json:"gameId"`
+	Moves       int       `json:"moves"`           // Length of the move log that was replayed
+	Flagged     bool      `json:"flagged"`         // True if the board and its moves disagree
+	Differences []string  `json:"differences"`     // What disagrees
+	Error       string    `json:"error,omitempty"` // Why the move log could not be replayed
+	CheckedAt   time.Time `json:"checkedAt"`
+}
+
+// consistencyChecks holds the latest check of each game
+var (
+	consistencyChecks   = make(map[string]ConsistencyCheck)
+	consistencyChecksMu sync.Mutex
+)
+
+// checkConsistency replays a game and records how it compares with the board
+// The board must not be shared, callers pass a copy of live games
+func checkConsistency(gameID string, board *game.Board) ConsistencyCheck {
+	check := ConsistencyCheck{GameID: gameID, Moves: len(board.MoveHistory), Differences: make([]string, 0), CheckedAt: time.Now()}
+
+	differences, err := board.Divergences()
+	if err != nil {
+		// A move log that can't be replayed is a divergence too
+		check.Error = err.Error()
+		check.Flagged = true
+	} else {
+		check.Differences = differences
+		check.Flagged = len(differences) > 0
+	}
+
+	consistencyChecksMu.Lock()
+	consistencyChecks[gameID] = check
+	consistencyChecksMu.Unlock()
+
+	if check.Flagged {
+		log.Printf("game %s diverges from its move log: %v %s", gameID, check.Differences, check.Error)
+	}
+	return check
+}
+
+// runConsistencySampler periodically replays a random sample of the live games
+// It stops when ctx is cancelled (server shutdown)
+func runConsistencySampler(ctx context.Context, interval time.Duration, sampleSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for gameID, board := range sampleGames(sampleSize) {
+				checkConsistency(gameID, board)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sampleGames copies up to n live games picked at random, so they can be checked without the locks
+func sampleGames(n int) map[string]*game.Board {
+	gamesMu.Lock()
+	ids := make([]string, 0, len(games))
+	for gameID := range games {
+		ids = append(ids, gameID)
+	}
+	gamesMu.Unlock()
+	rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+
+	sample := make(map[string]*game.Board, min(n, len(ids)))
+	for _, gameID := range ids[:min(n, len(ids))] {
+		if board, unlock, exists := lockGame(gameID); exists {
+			sample[gameID] = board.Clone()
+			unlock()
+		}
+	}
+	return sample
+}
+
+// Replay a game's move log now and compare it with the live board
+func checkGameConsistency(c echo.Context) error {
+	gameID := c.Param("id")
+
+	board, unlock, exists := lockGame(gameID)
+	if exists {
+		board = board.Clone()
+		unlock()
+	}
+
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	return c.JSON(http.StatusOK, checkConsistency(gameID, board))
+}
+
+// List consistency checks, most recent first; ?flagged=true only returns the divergent games
+func listConsistencyChecks(c echo.Context) error {
+	flaggedOnly := c.QueryParam("flagged") == "true"
+
+	consistencyChecksMu.Lock()
+	checks := make([]ConsistencyCheck, 0, len(consistencyChecks))
+	for _, check := range consistencyChecks {
+		if !flaggedOnly || check.Flagged {
+			checks = append(checks, check)
+		}
+	}
+	consistencyChecksMu.Unlock()
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].CheckedAt.After(checks[j].CheckedAt)
+	})
+	return c.JSON(http.StatusOK, checks)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go-game/game"
+	"go-game/store"
+	"go-game/webhooks"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Correspondence games are played over days: a move may take days (see isCorrespondence),
+// players can go on vacation to stop their clocks, the player to move is told it's their
+// turn over WebSocket and webhooks, and unfinished games are put back live after a restart
+// so their clocks keep being enforced
+
+// Correspondence settings
+const (
+	day             = 24 * time.Hour
+	maxDaysPerMove  = 30
+	maxVacationDays = 60
+	restorePageSize = 100 // Stored games looked at per page when restoring
+)
+
+// correspondenceError checks the correspondence settings of a game request ("" if fine)
+func (r NewGameRequest) correspondenceError() string {
+	switch {
+	case r.DaysPerMove < 0 || r.DaysPerMove > maxDaysPerMove:
+		return fmt.Sprintf("Days per move must be between 1 and %d", maxDaysPerMove)
+	case r.VacationDays < 0 || r.VacationDays > maxVacationDays:
+		return fmt.Sprintf("Vacation days must be between 1 and %d", maxVacationDays)
+	case r.DaysPerMove > 0 && (r.MainTime > 0 || r.ByoYomiTime > 0 || r.ByoYomiPeriods > 0):
+		return "Choose days per move or main time, not both"
+	case r.VacationDays > 0 && r.DaysPerMove == 0 && time.Duration(r.MainTime)*time.Second < correspondenceMainTime:
+		return "Vacation is for correspondence games"
+	}
+	return ""
+}
+
+// TurnNotice tells a correspondence player it's their move
+type TurnNotice struct {
+	Player   int        `json:"player"`             // 1 = black, 2 = white
+	Deadline *time.Time `json:"deadline,omitempty"` // When they lose on time (not set while on vacation)
+}
+
+// turnDeadline returns when the player to move loses on time, if their clock is counting
+func turnDeadline(board *game.Board, now time.Time) *time.Time {
+	clock := board.Clock
+	if clock == nil || clock.Running != board.CurrentPlayer || clock.OnVacation(clock.Running, now) {
+		return nil
+	}
+	deadline := now.Add(clock.TimeToExpiry(clock.Running, now))
+	return &deadline
+}
+
+// announceTurn tells the account to move in a correspondence game that it's their turn
+// Must be called with the game locked
+func announceTurn(gameID string, board *game.Board) {
+	if !isCorrespondence(board) || board.Phase != game.PhasePlaying {
+		return
+	}
+	userID := board.Players[board.CurrentPlayer]
+	if userID == "" {
+		return
+	}
+
+	now := time.Now()
+	notifyUser(userID, Event{Time: now, Type: SocketYourTurn, GameID: gameID, Data: TurnNotice{
+		Player:   board.CurrentPlayer,
+		Deadline: turnDeadline(board, now),
+	}})
+	publishWebhook(webhooks.EventTurnStarted, gameID, board)
+}
+
+// VacationGame is a correspondence game as its player's vacation sees it
+type VacationGame struct {
+	GameID       string        `json:"gameId"`
+	Color        int           `json:"color"`        // 1 = black, 2 = white
+	VacationLeft time.Duration `json:"vacationLeft"` // Vacation time left in this game
+	OnVacation   bool          `json:"onVacation"`
+}
+
+// Vacation response structure
+type VacationStatus struct {
+	Games []VacationGame `json:"games"` // The user's correspondence games in progress
+}
+
+// Stop the user's clocks in all their correspondence games, while vacation time lasts
+func startVacation(c echo.Context) error {
+	return c.JSON(http.StatusOK, updateVacation(c, func(clock *game.Clock, player int, now time.Time) bool {
+		return clock.StartVacation(player, now) == nil && clock.OnVacation(player, now)
+	}))
+}
+
+// Come back from vacation: the user's clocks count again
+func endVacation(c echo.Context) error {
+	return c.JSON(http.StatusOK, updateVacation(c, func(clock *game.Clock, player int, now time.Time) bool {
+		changed := clock.OnVacation(player, now)
+		clock.EndVacation(player, now)
+		return changed
+	}))
+}
+
+// The user's correspondence games and their vacation time
+func getVacation(c echo.Context) error {
+	return c.JSON(http.StatusOK, updateVacation(c, nil))
+}
+
+// updateVacation applies a change to the user's clocks in every correspondence game they play,
+// saving the games it changed, and returns where their vacation stands
+func updateVacation(c echo.Context, change func(clock *game.Clock, player int, now time.Time) bool) VacationStatus {
+	user, _ := currentUser(c)
+	status := VacationStatus{Games: make([]VacationGame, 0)}
+	now := time.Now()
+
+	forEachGame(func(gameID string, board *game.Board) {
+		if !isCorrespondence(board) || board.Phase == game.PhaseFinished {
+			return
+		}
+		changed := false
+		for player := 1; player <= 2; player++ {
+			if board.Players[player] != user.ID {
+				continue
+			}
+			if change != nil && change(board.Clock, player, now) {
+				changed = true
+			}
+			status.Games = append(status.Games, VacationGame{
+				GameID:       gameID,
+				Color:        player,
+				VacationLeft: board.Clock.VacationLeft(player, now),
+				OnVacation:   board.Clock.OnVacation(player, now),
+			})
+		}
+		if changed {
+			saveGame(c.Request().Context(), gameID, board)
+		}
+	})
+	return status
+}
+
+// restoreCorrespondenceGames puts the unfinished correspondence games of the store back live,
+// so a restart doesn't stop their clocks or leave them unreachable to their players
+// Games idle for longer than the janitor allows aren't restored
+func restoreCorrespondenceGames(ctx context.Context, policy ExpiryPolicy) {
+	now := time.Now()
+	restored := 0
+	for offset := 0; ; offset += restorePageSize {
+		records, err := gameStore.ListGames(ctx, restorePageSize, offset)
+		if err != nil {
+			log.Printf("restoring correspondence games: %v", err)
+			return
+		}
+		for _, record := range records {
+			if now.Sub(record.UpdatedAt) > policy.CorrespondenceTTL {
+				records = nil // Older games are only older still
+				break
+			}
+			if restoreGame(record) {
+				touchGame(record.ID, record.UpdatedAt)
+				restored++
+			}
+		}
+		if len(records) < restorePageSize {
+			break
+		}
+	}
+	if restored > 0 {
+		log.Printf("restored %d correspondence games", restored)
+	}
+}
+
+// restoreGame makes a stored game live again if it is an unfinished correspondence game
+func restoreGame(record store.GameRecord) bool {
+	board := record.Board
+	if record.Corrupted || board == nil || board.Phase == game.PhaseFinished || !isCorrespondence(board) {
+		return false
+	}
+
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+	if _, live := games[record.ID]; live {
+		return false
+	}
+	addGame(record.ID, board)()
+	return true
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// Cross-origin requests: frontends and clients hosted elsewhere may call the API from the
+// origins listed in CORS_ALLOWED_ORIGINS, e.g. "https://play.example.com,http://localhost:3000",
+// or from anywhere with "*"
+// Listed origins are trusted like this site's own pages, so they may also use cookie sessions;
+// "*" only opens the API to clients signing in with tokens
+
+// Default methods cross-origin requests may use (CORS_ALLOWED_METHODS)
+var defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// corsMaxAge is how long browsers may cache a preflight response, in seconds
+const corsMaxAge = 600
+
+// CORSPolicy says who may call the API from other origins
+type CORSPolicy struct {
+	Origins []string // Allowed origins, or "*" for any (none = same origin only)
+	Methods []string // Allowed methods
+}
+
+// corsPolicy is the cross-origin policy (set in main)
+var corsPolicy CORSPolicy
+
+// corsPolicyFromEnv reads the policy from CORS_ALLOWED_ORIGINS and CORS_ALLOWED_METHODS,
+// both comma separated
+func corsPolicyFromEnv() (CORSPolicy, error) {
+	policy := CORSPolicy{Methods: defaultCORSMethods}
+
+	for _, origin := range splitList(os.Getenv("CORS_ALLOWED_ORIGINS")) {
+		if origin != "*" {
+			u, err := url.Parse(origin)
+			if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+				return policy, fmt.Errorf("CORS_ALLOWED_ORIGINS: invalid origin %q, expected e.g. \"https://play.example.com\"", origin)
+			}
+			origin = u.Scheme + "://" + u.Host
+		}
+		policy.Origins = append(policy.Origins, origin)
+	}
+
+	if methods := splitList(os.Getenv("CORS_ALLOWED_METHODS")); len(methods) > 0 {
+		policy.Methods = nil
+		for _, method := range methods {
+			policy.Methods = append(policy.Methods, strings.ToUpper(method))
+		}
+	}
+	return policy, nil
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Enabled reports whether any other origin may call the API
+func (p CORSPolicy) Enabled() bool {
+	return len(p.Origins) > 0
+}
+
+// Trusts reports whether an origin is listed by name, so its pages may use cookie sessions
+func (p CORSPolicy) Trusts(origin string) bool {
+	return origin != "*" && slices.Contains(p.Origins, origin)
+}
+
+// Middleware answers preflight requests and adds the CORS headers to responses
+// The headers clients need (seat tokens, game IDs, rate limits) are exposed to scripts
+func (p CORSPolicy) Middleware() echo.MiddlewareFunc {
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: p.Origins,
+		AllowMethods: p.Methods,
+		AllowHeaders: []string{
+			echo.HeaderAuthorization, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAcceptEncoding,
+			"Accept-Language", headerSeatToken,
+		},
+		ExposeHeaders: []string{
+			echo.HeaderLocation, echo.HeaderRetryAfter, headerGameID, headerBlackToken, headerWhiteToken, headerTeamTokens, headerSpectators,
+		},
+		AllowCredentials: !slices.Contains(p.Origins, "*"), // Browsers refuse credentials with "*" anyway
+		MaxAge:           corsMaxAge,
+	})
+}
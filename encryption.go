@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// sealedPrefix marks stored text that was encrypted by a Sealer
+// Text without it is treated as plaintext, so existing data keeps working after encryption is enabled
+const sealedPrefix = "enc:v1:"
+
+// KeyProvider supplies the master key used to encrypt private game data at rest
+// The environment provider is the default; a KMS client can be plugged in by implementing this
+type KeyProvider interface {
+	MasterKey() ([]byte, error)
+}
+
+// envKeyProvider reads a base64 encoded 32-byte master key from an environment variable
+type envKeyProvider struct {
+	variable string
+}
+
+// MasterKey decodes the key from the environment (nil if the variable is unset)
+func (p envKeyProvider) MasterKey() ([]byte, error) {
+	value := os.Getenv(p.variable)
+	if value == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s must be a base64 encoded 32-byte key", p.variable)
+	}
+	return key, nil
+}
+
+// Sealer encrypts chat messages and spectator comments before they are stored
+// Every game gets its own key derived from the master key, so leaking one game's key exposes nothing else
+// A nil Sealer stores text as is, which is the default when no key is configured
+type Sealer struct {
+	master []byte
+}
+
+// NewSealer creates a Sealer from a key provider (nil if the provider has no key configured)
+func NewSealer(provider KeyProvider) (*Sealer, error) {
+	master, err := provider.MasterKey()
+	if err != nil || master == nil {
+		return nil, err
+	}
+	return &Sealer{master: master}, nil
+}
+
+// gameCipher derives the AES-GCM cipher for one game
+func (s *Sealer) gameCipher(gameID string) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, s.master)
+	mac.Write([]byte("game:" + gameID))
+
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts text belonging to a game for storage
+func (s *Sealer) Seal(gameID, plaintext string) (string, error) {
+	if s == nil {
+		return plaintext, nil
+	}
+
+	aead, err := s.gameCipher(gameID)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	// The game ID is authenticated too, so sealed text can't be moved to another game
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(gameID))
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts stored text belonging to a game; plaintext is returned unchanged
+func (s *Sealer) Open(gameID, stored string) (string, error) {
+	if !strings.HasPrefix(stored, sealedPrefix) {
+		return stored, nil
+	}
+
+	if s == nil {
+		return "", errors.New("encrypted data found but no encryption key is configured")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, sealedPrefix))
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := s.gameCipher(gameID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted data is truncated")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(gameID))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
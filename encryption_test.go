@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"go-game/chat"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSealer makes a sealer with a fixed master key and uses it as the server's sealer
+func testSealer(t *testing.T) *Sealer {
+	t.Helper()
+	t.Setenv("GAME_DATA_KEY", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	s, err := NewSealer(envKeyProvider{variable: "GAME_DATA_KEY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := sealer
+	sealer = s
+	t.Cleanup(func() { sealer = previous })
+	return s
+}
+
+// isSealed checks stored text is ciphertext, with nothing of the plaintext in it
+func isSealed(stored, plaintext string) bool {
+	return strings.HasPrefix(stored, sealedPrefix) && !strings.Contains(stored, plaintext)
+}
+
+func TestChatIsStoredSealed(t *testing.T) {
+	s := testSealer(t)
+	ctx := context.Background()
+	const said = "meet me at tengen"
+
+	raw := chat.NewMemoryStore()
+	store := chat.NewSealedStore(raw, s)
+	added, err := store.Add(ctx, chat.Message{GameID: "sealed", Channel: chat.ChannelPlayers, Author: "black", Text: said, Time: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.Text != said {
+		t.Errorf("added text = %q, want %q", added.Text, said)
+	}
+
+	stored, err := raw.List(ctx, "sealed", chat.ChannelPlayers)
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("stored %v, %v", stored, err)
+	}
+	if !isSealed(stored[0].Text, said) {
+		t.Errorf("stored text = %q, want it sealed", stored[0].Text)
+	}
+	if stored[0].Author != "black" {
+		t.Errorf("stored author = %q, only the text is sealed", stored[0].Author)
+	}
+
+	listed, err := store.List(ctx, "sealed", chat.ChannelPlayers)
+	if err != nil || len(listed) != 1 {
+		t.Fatalf("listed %v, %v", listed, err)
+	}
+	if listed[0].Text != said {
+		t.Errorf("listed text = %q, want %q", listed[0].Text, said)
+	}
+
+	// The text was sealed for its game, so it can't be moved to another one
+	if _, err := s.Open("another", stored[0].Text); err == nil {
+		t.Error("another game opened the text")
+	}
+}
+
+func TestKibitzIsKeptSealed(t *testing.T) {
+	testSealer(t)
+	const said = "white should tenuki"
+	t.Cleanup(func() { forgetGame("sealed-kibitz") })
+
+	if added, err := addKibitz("sealed-kibitz", KibitzMessage{Author: "spectator", Text: said}); !added || err != nil {
+		t.Fatalf("added = %v, %v", added, err)
+	}
+
+	gamesMu.Lock()
+	stored := kibitz["sealed-kibitz"][0].Text
+	gamesMu.Unlock()
+	if !isSealed(stored, said) {
+		t.Errorf("kept text = %q, want it sealed", stored)
+	}
+
+	comments, err := kibitzOf("sealed-kibitz")
+	if err != nil || len(comments) != 1 {
+		t.Fatalf("comments %v, %v", comments, err)
+	}
+	if comments[0].Text != said {
+		t.Errorf("comment text = %q, want %q", comments[0].Text, said)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"go-game/analysis"
+	"go-game/bot"
+	"go-game/game"
+	"go-game/gtp"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// gtpEngines maps engine names to the command lines that start them
+// Loaded from GTP_ENGINES, formatted as "gnugo=gnugo --mode gtp;katago=katago gtp -model kata.bin.gz"
+var gtpEngines = loadGTPEngines(os.Getenv("GTP_ENGINES"))
+
+// loadGTPEngines parses the engine configuration
+func loadGTPEngines(config string) map[string]string {
+	engines := make(map[string]string)
+
+	for _, entry := range strings.Split(config, ";") {
+		name, commandLine, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || name == "" || strings.TrimSpace(commandLine) == "" {
+			continue
+		}
+		engines[strings.TrimSpace(name)] = strings.TrimSpace(commandLine)
+	}
+
+	return engines
+}
+
+// engineAnalysisTimeout bounds an analysis request, engine startup included
+const engineAnalysisTimeout = 30 * time.Second
+
+// Engine analysis response structure
+type EngineAnalysis struct {
+	Engine   string `json:"engine"`
+	Player   int    `json:"player"`   // Player the suggestion is for
+	Position int    `json:"position"` // Suggested move (-1 = pass)
+	Vertex   string `json:"vertex"`   // Suggested move in GTP notation
+	Resign   bool   `json:"resign"`   // The engine would resign
+	Score    string `json:"score"`    // The engine's count of the current position, e.g. "B+3.5"
+
+	Estimator string `json:"estimator"` // What answered (see the Estimator* constants)
+	Degraded  bool   `json:"degraded"`  // The engine budget ran low, so a cheaper estimator answered
+}
+
+// Ask an external engine (?engine=name) for its move and count of the current position
+// Without enough engine budget left to start the engine, the built-in estimators answer instead
+func analyzeWithEngine(c echo.Context) error {
+	gameID := c.Param("id")
+
+	name := c.QueryParam("engine")
+	commandLine, configured := gtpEngines[name]
+	if !configured {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown engine"})
+	}
+
+	// Copy the position so the game isn't locked while the engine thinks
+	board, unlock, exists := lockGame(gameID)
+	if exists {
+		board = board.Clone()
+		unlock()
+	}
+
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	if board.Concealed() {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not available while stones are hidden"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), engineAnalysisTimeout)
+	defer cancel()
+
+	user := budgetUser(c)
+	grant := engineBudgets.Reserve(user, engineReservation, time.Now())
+	if grant.Time < engineReservation {
+		engineBudgets.Settle(grant, 0, time.Now())
+		return analyzeWithPlayouts(c, ctx, user, name, board)
+	}
+	started := time.Now()
+	defer func() { engineBudgets.Settle(grant, time.Since(started), time.Now()) }()
+
+	engine, err := gtp.StartCommandLine(ctx, commandLine)
+	if err != nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Engine could not be started"})
+	}
+	defer engine.Close()
+
+	if err := engine.LoadBoard(ctx, board); err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+	}
+
+	analysis := EngineAnalysis{Engine: name, Player: board.CurrentPlayer, Estimator: EstimatorEngine}
+	analysis.Position, analysis.Resign, err = engine.SuggestMove(ctx, board.CurrentPlayer, board.Size)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+	}
+	if !analysis.Resign {
+		analysis.Vertex = gtp.Vertex(analysis.Position, board.Size)
+	}
+	if analysis.Score, err = engine.FinalScore(ctx); err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, analysis)
+}
+
+// analyzeWithPlayouts stands in for an external engine once the engine budget is low:
+// the move is picked like the built-in bot does, and the score is the estimated lead
+func analyzeWithPlayouts(c echo.Context, ctx context.Context, user, name string, board *game.Board) error {
+	estimate, estimator, _, err := budgetedEstimate(ctx, user, board, defaultPlayouts, defaultEstimateBudget)
+	if errors.Is(err, analysis.ErrNoPlayouts) {
+		estimate, estimator = analysis.Count(board), EstimatorCount
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	result := EngineAnalysis{Engine: name, Player: board.CurrentPlayer, Estimator: estimator, Degraded: true}
+	var pass bool
+	result.Position, pass = bot.BestMove(board, estimate)
+	if pass {
+		result.Position = -1
+	}
+	result.Vertex = gtp.Vertex(result.Position, board.Size)
+	result.Score = estimateNotation(estimate.ScoreLead)
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"go-game/analysis"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// analysisEngine runs playouts for every analysis request
+// Half the cores at most, so estimates never starve the HTTP server
+var analysisEngine = analysis.NewEngine(runtime.NumCPU() / 2)
+
+// Limits for estimate requests
+const (
+	defaultPlayouts       = 200
+	maxPlayouts           = 2000
+	defaultEstimateBudget = 2 * time.Second
+	maxEstimateBudget     = 10 * time.Second
+)
+
+// Estimate response structure
+type EstimateResponse struct {
+	*analysis.Estimate
+	Estimator string // How the estimate was made (see the Estimator* constants)
+	Degraded  bool   // The engine budget ran low, so the estimate is cheaper than asked for
+}
+
+// Estimate the score, ownership and dead stones of a game by random playouts
+// Once the caller's engine budget runs low the playouts stop sooner, and once it is
+// exhausted the position is only counted as it stands
+func estimateGame(c echo.Context) error {
+	gameID := c.Param("id")
+
+	playouts := defaultPlayouts
+	if param := c.QueryParam("playouts"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed <= 0 || parsed > maxPlayouts {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid playouts"})
+		}
+		playouts = parsed
+	}
+
+	budget := defaultEstimateBudget
+	if param := c.QueryParam("budget"); param != "" {
+		parsed, err := time.ParseDuration(param)
+		if err != nil || parsed <= 0 || parsed > maxEstimateBudget {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid budget"})
+		}
+		budget = parsed
+	}
+
+	// Copy the position so the game isn't locked while the playouts run
+	board, unlock, exists := lockGame(gameID)
+	if exists {
+		board = board.Clone()
+		unlock()
+	}
+
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	if board.Concealed() {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not available while stones are hidden"})
+	}
+
+	// Client disconnects cancel the playouts through the request context
+	estimate, estimator, degraded, err := budgetedEstimate(c.Request().Context(), budgetUser(c), board, playouts, budget)
+	if errors.Is(err, analysis.ErrNoPlayouts) {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Analysis is busy, try again later"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, EstimateResponse{Estimate: estimate, Estimator: estimator, Degraded: degraded})
+}
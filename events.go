@@ -0,0 +1,257 @@
+package main
+
+import (
+	"go-game/game"
+	"go-game/plugin"
+	"go-game/webhooks"
+	"sync"
+	"time"
+)
+
+// Event is a message pushed to clients following the games on this server
+type Event struct {
+	Seq    int64       `json:"seq"`    // Position of the event in the server-wide event log
+	Time   time.Time   `json:"time"`   // When the event happened
+	Type   string      `json:"type"`   // Kind of event (see the Event* constants)
+	GameID string      `json:"gameId"` // Game the event belongs to
+	Data   interface{} `json:"data"`   // Event payload (board state, result, ...)
+}
+
+// Event types broadcast by the server
+const (
+	EventGameCreated   = "game_created"   // A new game was started
+	EventMove          = "move"           // A stone was played or a player passed
+	EventBoardDelta    = "board_delta"    // What the last move changed on the board
+	EventScoring       = "scoring"        // Dead stones or score acceptance changed
+	EventPlayResumed   = "play_resumed"   // Play continues after a scoring disagreement
+	EventGameOver      = "game_over"      // A game has finished
+	EventPaceWarning   = "pace_warning"   // A player is running short of time
+	EventKibitz        = "kibitz"         // A spectator commented on a game
+	EventRated         = "rated"          // The players' ratings changed after the game; the data has the changes
+	EventChat          = "chat"           // A player said something in the players' channel
+	EventSpectatorChat = "spectator_chat" // A spectator said something in the spectators' channel
+	EventPredictions   = "predictions"    // Heatmap of the spectators' guesses for the next move
+	EventFeatured      = "featured"       // The featured games changed (lobby event, no game ID)
+	EventConsult       = "consultation"   // A rengo team started or stopped consulting
+	EventViolation     = "violation"      // A rengo team member tried to move out of rotation
+	EventSpectators    = "spectators"     // The number of spectators following a game changed
+	EventGameDeleted   = "game_deleted"   // A game was deleted, it is gone for good
+)
+
+// spectatorOnlyEvents are never delivered to the players of the game, e.g. so the
+// audience's predictions and chat can't influence the game
+var spectatorOnlyEvents = map[string]bool{EventPredictions: true, EventSpectatorChat: true}
+
+// maxEventLog is how many recent events the hub keeps for clients catching up
+const maxEventLog = 1000
+
+// Retention of the event archive behind the firehose; older events are dropped,
+// and so are all of them when the server restarts
+const (
+	eventArchiveRetention = 24 * time.Hour
+	maxEventArchive       = 100000
+)
+
+// Hub fans out events to the listeners following the game they belong to;
+// lobby events (no game ID) go to every listener
+// It also keeps a log of recent events so clients can catch up from a cursor
+type Hub struct {
+	mu        sync.Mutex
+	listeners map[chan Event]map[string]bool // Games each listener follows
+	followers map[string]map[chan Event]bool // Listeners following each game
+	seq       int64                          // Sequence number of the last event
+	log       []Event                        // Most recent events, oldest first
+	archive   []Event                        // Events within the archive retention, oldest first
+}
+
+// NewHub creates an empty event hub
+func NewHub() *Hub {
+	return &Hub{
+		listeners: make(map[chan Event]map[string]bool),
+		followers: make(map[string]map[chan Event]bool),
+		log:       make([]Event, 0, maxEventLog),
+	}
+}
+
+// Subscribe registers a new listener and returns the channel events are delivered on
+// It receives lobby events until it follows games with Follow
+func (h *Hub) Subscribe() chan Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Event, 16) // Buffered so a slow listener doesn't block the game
+	h.listeners[ch] = make(map[string]bool)
+	return ch
+}
+
+// Unsubscribe removes a listener from the games it follows and closes its channel
+func (h *Hub) Unsubscribe(ch chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	games, ok := h.listeners[ch]
+	if !ok {
+		return
+	}
+	for gameID := range games {
+		h.unfollow(ch, gameID)
+	}
+	delete(h.listeners, ch)
+	close(ch)
+}
+
+// Follow makes a listener receive the events of a game
+func (h *Hub) Follow(ch chan Event, gameID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	games, ok := h.listeners[ch]
+	if !ok {
+		return // Already unsubscribed
+	}
+	games[gameID] = true
+	if h.followers[gameID] == nil {
+		h.followers[gameID] = make(map[chan Event]bool)
+	}
+	h.followers[gameID][ch] = true
+}
+
+// Unfollow stops the events of a game for a listener
+func (h *Hub) Unfollow(ch chan Event, gameID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.listeners[ch] != nil {
+		h.unfollow(ch, gameID)
+	}
+}
+
+// unfollow removes a listener from a game; must be called with mu held
+func (h *Hub) unfollow(ch chan Event, gameID string) {
+	delete(h.listeners[ch], gameID)
+	delete(h.followers[gameID], ch)
+	if len(h.followers[gameID]) == 0 {
+		delete(h.followers, gameID)
+	}
+}
+
+// Broadcast delivers an event to the listeners following its game, or to all of them for lobby events
+// Listeners that are not keeping up miss the event instead of blocking the sender
+func (h *Hub) Broadcast(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Number the event and remember it for catching up
+	h.seq++
+	event.Seq = h.seq
+	event.Time = time.Now()
+	if len(h.log) == maxEventLog {
+		h.log = append(h.log[:0], h.log[1:]...)
+	}
+	h.log = append(h.log, event)
+	h.archive = append(h.archive, event)
+	h.trimArchive(event.Time)
+
+	deliver := func(ch chan Event) {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	if event.GameID == "" {
+		for ch := range h.listeners {
+			deliver(ch)
+		}
+		return
+	}
+	for ch := range h.followers[event.GameID] {
+		deliver(ch)
+	}
+}
+
+// EventsSince returns the logged events after the given cursor and the cursor to use next time
+// complete is false if older events were already dropped from the log, in which case
+// the caller has missed events and should fetch the full state instead
+func (h *Hub) EventsSince(cursor int64) (events []Event, next int64, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events = make([]Event, 0)
+	for _, event := range h.log {
+		if event.Seq > cursor {
+			events = append(events, event)
+		}
+	}
+
+	// The log is complete if it still holds the event right after the cursor
+	complete = cursor >= h.seq || (len(h.log) > 0 && h.log[0].Seq <= cursor+1)
+
+	return events, h.seq, complete
+}
+
+// trimArchive drops the archived events past the retention; must be called with mu held
+// The events are sliced off the front, never moved, so pages being read keep their copy
+func (h *Hub) trimArchive(now time.Time) {
+	drop := max(len(h.archive)-maxEventArchive, 0)
+	for drop < len(h.archive) && now.Sub(h.archive[drop].Time) > eventArchiveRetention {
+		drop++
+	}
+	h.archive = h.archive[drop:]
+}
+
+// EventPage returns up to limit archived events after the cursor whose type passes the filter
+// next is the cursor for the following page and more is true if there are events left after it
+// expired is true if events after the cursor were already dropped from the archive, or the
+// cursor is from before the server restarted; next is then the cursor to start again from
+func (h *Hub) EventPage(cursor int64, limit int, include func(Event) bool) (events []Event, next int64, more, expired bool) {
+	// The archive is only appended to and sliced, so it is read without holding up Broadcast
+	h.mu.Lock()
+	archive, seq := h.archive, h.seq
+	h.mu.Unlock()
+
+	oldest := seq + 1 // Sequence number of the oldest archived event
+	if len(archive) > 0 {
+		oldest = archive[0].Seq
+	}
+	if cursor > seq || cursor < oldest-1 {
+		return nil, oldest - 1, false, true
+	}
+
+	events = make([]Event, 0, limit)
+	next = cursor
+	for i := int(cursor - oldest + 1); i < len(archive) && len(events) < limit; i++ {
+		event := archive[i]
+		next = event.Seq // Filtered events are skipped over, not revisited on the next page
+		if include(event) {
+			events = append(events, event)
+		}
+	}
+
+	return events, next, next < seq, false
+}
+
+// announceCreated broadcasts that a game was started and tells the plugins and webhooks
+func announceCreated(gameID string, board *game.Board) {
+	hub.Broadcast(Event{Type: EventGameCreated, GameID: gameID})
+	plugin.GameCreated(gameID, board)
+	publishWebhook(webhooks.EventGameStarted, gameID, board)
+	announceTurn(gameID, board)
+}
+
+// announceMove broadcasts the last move played in a game and what it changed on the board,
+// and the result if that move ended it
+// A player who keeps draining into their last byo-yomi period is warned as well
+func announceMove(gameID string, board *game.Board) {
+	// Events go to everyone, so hidden-information games only announce what a spectator may see
+	view := board.ViewFor(0)
+	if len(view.MoveHistory) > 0 {
+		move := view.MoveHistory[len(view.MoveHistory)-1]
+		hub.Broadcast(Event{Type: EventMove, GameID: gameID, Data: move})
+		hub.Broadcast(Event{Type: EventBoardDelta, GameID: gameID, Data: view.LastDelta()})
+		warnLastPeriod(gameID, board, move.Player)
+		plugin.Moved(gameID, board)
+		publishWebhook(webhooks.EventMovePlayed, gameID, board)
+		announceTurn(gameID, board)
+	}
+	announceResult(gameID, board, game.PhasePlaying) // Moves are only played during play
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go-game/game"
+	"log"
+	"os"
+	"time"
+)
+
+// Games nobody has touched for a while are taken out of memory by the janitor, so the
+// games map doesn't grow forever: finished games can still be read from the store, and
+// unfinished ones are kept there or discarded depending on the expiry policy
+
+// Expiry settings
+const (
+	defaultLiveGameTTL           = 6 * time.Hour
+	defaultCorrespondenceGameTTL = 30 * 24 * time.Hour
+	gameJanitorInterval          = 5 * time.Minute // How often stale games are looked for
+)
+
+// Expiry policies for unfinished games
+const (
+	ExpiryPersist = "persist" // Keep the last snapshot in the store
+	ExpiryDiscard = "discard" // Delete the game from the store as well
+)
+
+// ExpiryPolicy decides when a live game counts as stale and what happens to it then
+type ExpiryPolicy struct {
+	LiveTTL           time.Duration // Idle time after which a live game is stale
+	CorrespondenceTTL time.Duration // Idle time after which a correspondence game is stale
+	Unfinished        string        // What happens to stale unfinished games (see the Expiry* constants)
+}
+
+// expiryPolicy is the janitor's policy (set in main)
+var expiryPolicy = ExpiryPolicy{LiveTTL: defaultLiveGameTTL, CorrespondenceTTL: defaultCorrespondenceGameTTL, Unfinished: ExpiryPersist}
+
+// lastActivity records when each live game last changed (guarded by gamesMu)
+var lastActivity = make(map[string]time.Time)
+
+// expiryPolicyFromEnv reads the expiry policy: GAME_TTL and CORRESPONDENCE_GAME_TTL are the idle
+// times, e.g. "6h" and "720h", and STALE_GAME_POLICY is "persist" (the default) or "discard"
+func expiryPolicyFromEnv() (ExpiryPolicy, error) {
+	policy := ExpiryPolicy{Unfinished: ExpiryPersist}
+
+	var err error
+	if policy.LiveTTL, err = envDuration("GAME_TTL", defaultLiveGameTTL); err != nil {
+		return policy, err
+	}
+	if policy.CorrespondenceTTL, err = envDuration("CORRESPONDENCE_GAME_TTL", defaultCorrespondenceGameTTL); err != nil {
+		return policy, err
+	}
+
+	switch value := os.Getenv("STALE_GAME_POLICY"); value {
+	case "", ExpiryPersist:
+	case ExpiryDiscard:
+		policy.Unfinished = ExpiryDiscard
+	default:
+		return policy, fmt.Errorf("STALE_GAME_POLICY must be %q or %q, got %q", ExpiryPersist, ExpiryDiscard, value)
+	}
+	return policy, nil
+}
+
+// TTL returns how long a game may sit idle before it is stale
+func (p ExpiryPolicy) TTL(board *game.Board) time.Duration {
+	if isCorrespondence(board) {
+		return p.CorrespondenceTTL
+	}
+	return p.LiveTTL
+}
+
+// touchGame records activity in a game
+func touchGame(gameID string, now time.Time) {
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	lastActivity[gameID] = now
+}
+
+// idleSince returns when a game was last active, starting the count at now for games
+// that have no activity recorded
+func idleSince(gameID string, now time.Time) time.Time {
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	if _, known := lastActivity[gameID]; !known {
+		lastActivity[gameID] = now
+	}
+	return lastActivity[gameID]
+}
+
+// runGameJanitor expires stale games at every interval until the context is cancelled
+func runGameJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if expired := expireStaleGames(ctx, expiryPolicy, now); expired > 0 {
+				log.Printf("game janitor: expired %d stale games", expired)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// expireStaleGames takes the games idle for longer than the policy allows out of memory
+// and returns how many there were
+// Sandbox games are left to their own purger
+func expireStaleGames(ctx context.Context, policy ExpiryPolicy, now time.Time) int {
+	expired := 0
+	forEachGame(func(gameID string, board *game.Board) {
+		if isSandbox(gameID) || now.Sub(idleSince(gameID, now)) < policy.TTL(board) {
+			return
+		}
+
+		// Finished games were saved when they ended
+		if board.Phase != game.PhaseFinished {
+			if policy.Unfinished == ExpiryDiscard {
+				if err := gameStore.DeleteGame(ctx, gameID); err != nil {
+					log.Printf("discarding game %s: %v", gameID, err)
+					return // Try again next round rather than leave it behind in the store
+				}
+				hub.Broadcast(Event{Type: EventGameDeleted, GameID: gameID})
+			} else {
+				saveGame(ctx, gameID, board)
+			}
+		}
+		forgetGame(gameID)
+		expired++
+	})
+	return expired
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"go-game/game"
+	"go-game/rating"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Featured game selection
+const (
+	featuredInterval   = 30 * time.Second       // How often the featured games are picked again
+	maxFeaturedGames   = 5                      // Games on the featured list
+	maxFeaturedChecked = 50                     // Games evaluated by the engine per round, busiest first
+	featuredPlayouts   = 100                    // Playouts per evaluation
+	featuredBudget     = 500 * time.Millisecond // Time allowed per evaluation
+	audienceWindow     = 10 * time.Minute       // How long a spectator counts as watching after their last comment or prediction
+)
+
+// Weights of the featured score components, each of which goes from 0 to 1
+const (
+	featuredRatingWeight    = 0.4
+	featuredClosenessWeight = 0.4
+	featuredAudienceWeight  = 0.2
+)
+
+// Scale of the featured score components
+const (
+	featuredRatingFloor   = 1500 // Games between players rated this or lower get no rating bonus
+	featuredRatingCeiling = 2700 // ... and games between players rated this or higher the full bonus
+	featuredAudienceFull  = 100  // Spectators for the full audience bonus
+)
+
+// FeaturedGame is a live game worth watching, with why it was picked
+type FeaturedGame struct {
+	GameID     string  `json:"gameId"`
+	Score      float64 `json:"score"`      // Weighted sum of the components below (0 to 1)
+	Rating     float64 `json:"rating"`     // Average rating of the players, from their recorded ranks
+	Closeness  float64 `json:"closeness"`  // 1 when the engine sees an even game, 0 when it's decided
+	Spectators int     `json:"spectators"` // Spectators who commented or predicted recently
+	Size       int     `json:"size"`
+	MoveNumber int     `json:"moveNumber"`
+	BlackName  string  `json:"blackName,omitempty"`
+	WhiteName  string  `json:"whiteName,omitempty"`
+}
+
+// audience records when each spectator of a live game last took part (guarded by gamesMu)
+var audience = make(map[string]map[string]time.Time)
+
+// featured is the current featured list, best first
+var (
+	featured   = make([]FeaturedGame, 0)
+	featuredMu sync.Mutex
+)
+
+// noteSpectator records that a spectator is watching a game
+func noteSpectator(gameID, spectator string) {
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	if audience[gameID] == nil {
+		audience[gameID] = make(map[string]time.Time)
+	}
+	audience[gameID][spectator] = time.Now()
+}
+
+// spectatorCount counts the spectators seen within the audience window, forgetting the others
+func spectatorCount(gameID string, now time.Time) int {
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	for spectator, seen := range audience[gameID] {
+		if now.Sub(seen) > audienceWindow {
+			delete(audience[gameID], spectator)
+		}
+	}
+	return len(audience[gameID])
+}
+
+// gameRating is the average rating of the players, from the ranks in the game record
+// Players without a readable rank count as new players
+func gameRating(board *game.Board) float64 {
+	total := 0.0
+	for _, rank := range []string{board.Info.BlackRank, board.Info.WhiteRank} {
+		value, ok := rating.RankRating(rank)
+		if !ok {
+			value = rating.InitialRating
+		}
+		total += value
+	}
+	return total / 2
+}
+
+// runFeaturedSelector picks the featured games at every interval until the context is cancelled
+func runFeaturedSelector(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			selectFeaturedGames(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// selectFeaturedGames scores the live public games and updates the featured list,
+// announcing it to the lobby when it changed
+func selectFeaturedGames(ctx context.Context) {
+	type candidate struct {
+		entry FeaturedGame
+		board *game.Board // Copy of the board, for the engine
+	}
+
+	// Copy the candidates so the engine runs without the locks
+	// Hidden-information games can't be shown to an audience, so they are never featured, nor are sandbox games
+	now := time.Now()
+	candidates := make([]candidate, 0)
+	forEachGame(func(gameID string, board *game.Board) {
+		if board.Phase != game.PhasePlaying || board.Concealed() || isSandbox(gameID) {
+			return
+		}
+		candidates = append(candidates, candidate{
+			entry: FeaturedGame{
+				GameID:     gameID,
+				Rating:     gameRating(board),
+				Spectators: spectatorCount(gameID, now),
+				Size:       board.Size,
+				MoveNumber: board.Version(),
+				BlackName:  board.Info.BlackName,
+				WhiteName:  board.Info.WhiteName,
+			},
+		})
+	})
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i].entry, candidates[j].entry
+		return a.Spectators > b.Spectators || a.Spectators == b.Spectators && a.GameID < b.GameID
+	})
+	candidates = candidates[:min(len(candidates), maxFeaturedChecked)]
+	for i := range candidates {
+		if board, unlock, exists := lockGame(candidates[i].entry.GameID); exists {
+			candidates[i].board = board.Clone()
+			unlock()
+		}
+	}
+
+	list := make([]FeaturedGame, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.board == nil {
+			continue // The game ended up leaving the server
+		}
+		entry := candidate.entry
+
+		// An evaluation that can't run (analysis busy) counts the game as undecided rather than dropping it
+		entry.Closeness = 0.5
+		if estimate, err := analysisEngine.Estimate(ctx, candidate.board, featuredPlayouts, featuredBudget); err == nil {
+			entry.Closeness = 1 - math.Abs(2*estimate.BlackWinRate-1)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		ratingScore := math.Min(math.Max((entry.Rating-featuredRatingFloor)/(featuredRatingCeiling-featuredRatingFloor), 0), 1)
+		audienceScore := math.Min(math.Log1p(float64(entry.Spectators))/math.Log1p(featuredAudienceFull), 1)
+		entry.Score = featuredRatingWeight*ratingScore + featuredClosenessWeight*entry.Closeness + featuredAudienceWeight*audienceScore
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Score > list[j].Score || list[i].Score == list[j].Score && list[i].GameID < list[j].GameID
+	})
+	list = list[:min(len(list), maxFeaturedGames)]
+
+	// Only a different selection is announced; the scores move with every move
+	featuredMu.Lock()
+	changed := !slices.EqualFunc(featured, list, func(a, b FeaturedGame) bool { return a.GameID == b.GameID })
+	featured = list
+	featuredMu.Unlock()
+
+	if changed {
+		hub.Broadcast(Event{Type: EventFeatured, Data: list})
+	}
+}
+
+// List the featured games, best first
+func listFeaturedGames(c echo.Context) error {
+	featuredMu.Lock()
+	defer featuredMu.Unlock()
+
+	return c.JSON(http.StatusOK, featured)
+}
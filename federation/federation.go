@@ -0,0 +1,261 @@
+// Package federation writes tournament results in the formats the national
+// rating bodies accept: the European Go Federation results table (.h9) and
+// the American Go Association results file
+package federation
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Player is a tournament participant as known to the federations
+type Player struct {
+	Name    string `json:"name"`    // Name as used in the game records
+	ID      string `json:"id"`      // EGF PIN or AGA member ID
+	Rank    string `json:"rank"`    // e.g. "5k" or "2d"
+	Country string `json:"country"` // Two letter country code (EGF)
+	Club    string `json:"club"`    // Club abbreviation (EGF)
+}
+
+// Game is a finished tournament game
+type Game struct {
+	Round    int    // Round the game was played in, from 1
+	Black    string // Player names
+	White    string
+	Winner   int     // 1 = black, 2 = white, 0 = jigo
+	Handicap int     // Handicap stones (0 for an even game)
+	Komi     float64 // Komi given to white
+}
+
+// Tournament is everything needed to report a tournament
+type Tournament struct {
+	Name     string
+	Location string
+	Date     string // Dates the tournament was played, e.g. "2024-05-04,2024-05-05"
+	Rounds   int
+	Players  []Player
+	Games    []Game
+}
+
+// Validate checks that every game is between two registered players in an existing round
+func (t *Tournament) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return errors.New("the tournament needs a name")
+	}
+
+	registered := make(map[string]bool)
+	for _, player := range t.Players {
+		if player.Name == "" || registered[player.Name] {
+			return fmt.Errorf("player names must be set and unique (%q)", player.Name)
+		}
+		registered[player.Name] = true
+	}
+
+	played := make(map[string]bool)
+	for _, g := range t.Games {
+		switch {
+		case g.Round < 1 || g.Round > t.Rounds:
+			return fmt.Errorf("game %s - %s is in round %d, outside the %d rounds", g.Black, g.White, g.Round, t.Rounds)
+		case !registered[g.Black] || !registered[g.White]:
+			return fmt.Errorf("game %s - %s has an unregistered player", g.Black, g.White)
+		case g.Black == g.White:
+			return fmt.Errorf("%s can't play against themselves", g.Black)
+		}
+		for _, name := range []string{g.Black, g.White} {
+			key := fmt.Sprintf("%d/%s", g.Round, name)
+			if played[key] {
+				return fmt.Errorf("%s plays more than one game in round %d", name, g.Round)
+			}
+			played[key] = true
+		}
+	}
+	return nil
+}
+
+// standing is a player's line in the results table
+type standing struct {
+	Player
+	Wins    float64
+	Results []string // Per round, in EGF notation
+}
+
+// standings ranks the players by wins (jigo counts as half a win), then by name
+// and fills in each player's results round by round
+func (t *Tournament) standings() []*standing {
+	byName := make(map[string]*standing, len(t.Players))
+	table := make([]*standing, 0, len(t.Players))
+	for _, player := range t.Players {
+		s := &standing{Player: player, Results: make([]string, t.Rounds)}
+		byName[player.Name] = s
+		table = append(table, s)
+	}
+
+	for _, g := range t.Games {
+		switch g.Winner {
+		case 1:
+			byName[g.Black].Wins++
+		case 2:
+			byName[g.White].Wins++
+		default:
+			byName[g.Black].Wins += 0.5
+			byName[g.White].Wins += 0.5
+		}
+	}
+
+	sort.SliceStable(table, func(i, j int) bool {
+		if table[i].Wins != table[j].Wins {
+			return table[i].Wins > table[j].Wins
+		}
+		return table[i].Name < table[j].Name
+	})
+
+	place := make(map[string]int, len(table))
+	for i, s := range table {
+		place[s.Name] = i + 1
+	}
+
+	// Results read "<opponent place><+|-|=>/<color><handicap>", e.g. "3+/w" or "5-/b2"
+	for _, g := range t.Games {
+		outcome := map[int][2]string{1: {"+", "-"}, 2: {"-", "+"}, 0: {"=", "="}}[g.Winner]
+		handicap := ""
+		if g.Handicap > 0 {
+			handicap = fmt.Sprint(g.Handicap)
+		}
+		byName[g.Black].Results[g.Round-1] = fmt.Sprintf("%d%s/b%s", place[g.White], outcome[0], handicap)
+		byName[g.White].Results[g.Round-1] = fmt.Sprintf("%d%s/w%s", place[g.Black], outcome[1], handicap)
+	}
+
+	// Rounds a player didn't play are written as "0-"
+	for _, s := range table {
+		for round, result := range s.Results {
+			if result == "" {
+				s.Results[round] = "0-"
+			}
+		}
+	}
+	return table
+}
+
+// WriteEGF writes the tournament as an EGF results table (.h9)
+func (t *Tournament) WriteEGF(w io.Writer) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "; EV[%s]\n", t.Name)
+	fmt.Fprintf(&out, "; PC[%s]\n", t.Location)
+	fmt.Fprintf(&out, "; DT[%s]\n", t.Date)
+	fmt.Fprintf(&out, "; HA[%s]\n", t.handicapRule())
+	fmt.Fprintf(&out, "; KM[%g]\n", t.komi())
+	out.WriteString(";\n")
+
+	header := "; Pl Name                         Rk  Co Club  Pts "
+	for round := 1; round <= t.Rounds; round++ {
+		header += fmt.Sprintf(" %-7d", round)
+	}
+	out.WriteString(strings.TrimRight(header, " ") + "\n")
+
+	for place, s := range t.standings() {
+		fmt.Fprintf(&out, "%4d %-28s %-3s %-2s %-5s %4g ", place+1, egfName(s.Name), s.Rank, s.Country, s.Club, s.Wins)
+		for _, result := range s.Results {
+			fmt.Fprintf(&out, " %-7s", result)
+		}
+		if s.ID != "" {
+			fmt.Fprintf(&out, " |%s", s.ID)
+		}
+		out.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// WriteAGA writes the tournament as an AGA results file
+func (t *Tournament) WriteAGA(w io.Writer) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	ids := make(map[string]string, len(t.Players))
+	for _, player := range t.Players {
+		if player.ID == "" {
+			return fmt.Errorf("%s has no AGA member ID", player.Name)
+		}
+		ids[player.Name] = player.ID
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "TOURNEY %s, %s\n", t.Name, t.Location)
+	fmt.Fprintf(&out, "\tdate=%s\n", t.Date)
+	out.WriteString("\trules=AGA\n")
+
+	out.WriteString("PLAYERS\n")
+	for _, player := range t.Players {
+		fmt.Fprintf(&out, "%s %s %s\n", player.ID, agaName(player.Name), strings.ToUpper(player.Rank))
+	}
+
+	// Game lines: white ID, black ID, winner (W or B), handicap, komi
+	out.WriteString("GAMES\n")
+	for round := 1; round <= t.Rounds; round++ {
+		fmt.Fprintf(&out, "# Round %d\n", round)
+		for _, g := range t.Games {
+			if g.Round != round {
+				continue
+			}
+			if g.Winner == 0 {
+				return fmt.Errorf("the AGA format has no jigo (%s - %s)", g.Black, g.White)
+			}
+			fmt.Fprintf(&out, "%s %s %s %d %g\n", ids[g.White], ids[g.Black], map[int]string{1: "B", 2: "W"}[g.Winner], g.Handicap, g.Komi)
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// handicapRule describes the handicap system for the EGF header (h0 = even games only)
+func (t *Tournament) handicapRule() string {
+	highest := 0
+	for _, g := range t.Games {
+		highest = max(highest, g.Handicap)
+	}
+	return fmt.Sprintf("h%d", highest)
+}
+
+// komi returns the komi of the even games (the most common one)
+func (t *Tournament) komi() float64 {
+	counts := make(map[float64]int)
+	best, bestCount := 0.0, 0
+	for _, g := range t.Games {
+		if g.Handicap > 0 {
+			continue
+		}
+		counts[g.Komi]++
+		if counts[g.Komi] > bestCount {
+			best, bestCount = g.Komi, counts[g.Komi]
+		}
+	}
+	return best
+}
+
+// egfName writes a name as "Surname Firstname", the EGF convention, with underscores joining compound names
+func egfName(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) < 2 {
+		return name
+	}
+	return parts[len(parts)-1] + " " + strings.Join(parts[:len(parts)-1], "_")
+}
+
+// agaName writes a name as "Surname, Firstname", the AGA convention
+func agaName(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) < 2 {
+		return name
+	}
+	return parts[len(parts)-1] + ", " + strings.Join(parts[:len(parts)-1], " ")
+}
@@ -0,0 +1,165 @@
+package federation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-game/rating"
+	"io"
+	"net/mail"
+	"strings"
+)
+
+// Entrant is a player on a tournament roster, with the address their invitation goes to
+type Entrant struct {
+	Player
+	Email string `json:"email"`
+}
+
+// RosterLine is an entrant read from a roster file, with the line it came from
+// (the CSV line counting the header as 1, or the position in a JSON array from 1)
+type RosterLine struct {
+	Line int `json:"line"`
+	Entrant
+	Err error `json:"-"` // Why the entry can't be registered, nil if it can
+}
+
+// rosterColumns maps the CSV headers tournament software commonly writes to entrant fields
+var rosterColumns = map[string]string{
+	"name": "name", "player": "name", "full name": "name",
+	"rank": "rank", "grade": "rank", "strength": "rank",
+	"club":    "club",
+	"country": "country",
+	"id":      "id", "pin": "id", "egf pin": "id", "aga id": "id", "member id": "id",
+	"email": "email", "e-mail": "email", "mail": "email",
+}
+
+// ParseRosterCSV reads a CSV roster whose first line names the columns
+// Only the name column is required; unknown columns are ignored
+func ParseRosterCSV(r io.Reader) ([]RosterLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Spreadsheets often drop trailing empty cells
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("the roster has no header line: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, title := range header {
+		if field, ok := rosterColumns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(title, "\ufeff")))]; ok {
+			columns[field] = i
+		}
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("the roster has no name column")
+	}
+
+	lines := make([]RosterLine, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cell := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		entrant := Entrant{
+			Player: Player{Name: cell("name"), ID: cell("id"), Rank: cell("rank"), Country: cell("country"), Club: cell("club")},
+			Email:  cell("email"),
+		}
+		if entrant == (Entrant{}) {
+			continue // Blank line
+		}
+		lines = append(lines, RosterLine{Line: line, Entrant: entrant})
+	}
+	return lines, nil
+}
+
+// ParseRosterJSON reads a roster written as a JSON array of entrants
+func ParseRosterJSON(r io.Reader) ([]RosterLine, error) {
+	var entrants []Entrant
+	if err := json.NewDecoder(r).Decode(&entrants); err != nil {
+		return nil, fmt.Errorf("the roster is not a JSON array of entrants: %w", err)
+	}
+
+	lines := make([]RosterLine, len(entrants))
+	for i, entrant := range entrants {
+		entrant.Name, entrant.Rank, entrant.Email = strings.TrimSpace(entrant.Name), strings.TrimSpace(entrant.Rank), strings.TrimSpace(entrant.Email)
+		lines[i] = RosterLine{Line: i + 1, Entrant: entrant}
+	}
+	return lines, nil
+}
+
+// Validate checks an entrant can be registered: a name, a rank the rating system can read,
+// and, when given, a well-formed email address and a two letter country code
+func (e Entrant) Validate() error {
+	switch {
+	case e.Name == "":
+		return errors.New("the entrant has no name")
+	case e.Rank == "":
+		return fmt.Errorf("%s has no rank", e.Name)
+	}
+	if _, ok := rating.RankRating(e.Rank); !ok {
+		return fmt.Errorf("%s has an invalid rank %q (e.g. \"5k\" or \"2d\")", e.Name, e.Rank)
+	}
+	if e.Email != "" {
+		if address, err := mail.ParseAddress(e.Email); err != nil || address.Address != e.Email {
+			return fmt.Errorf("%s has an invalid email address %q", e.Name, e.Email)
+		}
+	}
+	if e.Country != "" && len(e.Country) != 2 {
+		return fmt.Errorf("%s has an invalid country %q (two letter code)", e.Name, e.Country)
+	}
+	return nil
+}
+
+// DuplicateError flags an entrant listed on an earlier line of the same roster
+type DuplicateError struct {
+	Name      string
+	FirstLine int  // Line of the listing that was kept
+	SameEmail bool // The email address is the same, not the name
+}
+
+func (e *DuplicateError) Error() string {
+	if e.SameEmail {
+		return fmt.Sprintf("%s has the same email address as line %d", e.Name, e.FirstLine)
+	}
+	return fmt.Sprintf("%s is already listed on line %d", e.Name, e.FirstLine)
+}
+
+// CheckRoster validates every line and flags the entrants listed more than once
+// (a DuplicateError), by name or by email address, both compared ignoring case;
+// the first listing is kept
+func CheckRoster(lines []RosterLine) {
+	names := make(map[string]int)
+	emails := make(map[string]int)
+	for i := range lines {
+		line := &lines[i]
+		if line.Err = line.Validate(); line.Err != nil {
+			continue
+		}
+
+		name, email := strings.ToLower(line.Name), strings.ToLower(line.Email)
+		if first, seen := names[name]; seen {
+			line.Err = &DuplicateError{Name: line.Name, FirstLine: first}
+			continue
+		}
+		if first, seen := emails[email]; seen && email != "" {
+			line.Err = &DuplicateError{Name: line.Name, FirstLine: first, SameEmail: true}
+			continue
+		}
+		names[name] = line.Line
+		if email != "" {
+			emails[email] = line.Line
+		}
+	}
+}
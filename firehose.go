@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Access scopes that can be granted to firehose API keys
+// Each scope unlocks one family of events
+const (
+	ScopeGames   = "games"   // Game creation and featured game events
+	ScopeMoves   = "moves"   // Every move and pass, and what happens between them
+	ScopeResults = "results" // Game results
+)
+
+// scopeEvents maps each scope to the event types it grants access to
+var scopeEvents = map[string][]string{
+	ScopeGames:   {EventGameCreated, EventFeatured},
+	ScopeMoves:   {EventMove, EventBoardDelta, EventPlayResumed, EventPaceWarning, EventConsult, EventViolation},
+	ScopeResults: {EventScoring, EventGameOver},
+}
+
+// Page size limits for the firehose
+const (
+	defaultEventPageSize = 100
+	maxEventPageSize     = 1000
+)
+
+// firehoseKeys maps each API key to the event types it may read
+// Loaded from EVENT_API_KEYS, formatted as "key1=games,moves;key2=results"
+var firehoseKeys = loadFirehoseKeys(os.Getenv("EVENT_API_KEYS"))
+
+// loadFirehoseKeys parses the API key configuration
+// Unknown scopes are ignored so a typo never grants more than intended
+func loadFirehoseKeys(config string) map[string]map[string]bool {
+	keys := make(map[string]map[string]bool)
+
+	for _, entry := range strings.Split(config, ";") {
+		key, scopes, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || key == "" {
+			continue
+		}
+
+		allowed := make(map[string]bool)
+		for _, scope := range strings.Split(scopes, ",") {
+			for _, eventType := range scopeEvents[strings.TrimSpace(scope)] {
+				allowed[eventType] = true
+			}
+		}
+		keys[key] = allowed
+	}
+
+	return keys
+}
+
+// Firehose page response structure
+type EventPageResponse struct {
+	Events  []Event `json:"events"`  // Events in server order
+	Cursor  int64   `json:"cursor"`  // Pass this back as ?cursor= to get the next page
+	HasMore bool    `json:"hasMore"` // True if more events are already available
+}
+
+// Ordered stream of the game events on the server, paginated by cursor
+// Meant for analytics and mirroring services, which only see the event types their key allows
+// Events are kept for a day; a cursor older than that, or from before a restart, gets 410 Gone
+func listEvents(c echo.Context) error {
+	// Authenticate the consumer
+	key := strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+	allowed, exists := firehoseKeys[key]
+	if key == "" || !exists {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid API key"})
+	}
+
+	// Parse pagination parameters
+	var cursor int64
+	if param := c.QueryParam("cursor"); param != "" {
+		parsed, err := strconv.ParseInt(param, 10, 64)
+		if err != nil || parsed < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid cursor"})
+		}
+		cursor = parsed
+	}
+
+	limit := defaultEventPageSize
+	if param := c.QueryParam("limit"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed <= 0 || parsed > maxEventPageSize {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+		}
+		limit = parsed
+	}
+
+	events, next, more, expired := hub.EventPage(cursor, limit, func(event Event) bool {
+		return allowed[event.Type] && !isSandbox(event.GameID) // Sandbox games would skew the analytics
+	})
+	if expired {
+		// The consumer has missed events for good and has to start again from the oldest kept
+		return c.JSON(http.StatusGone, map[string]string{"error": "Cursor expired, the events after it are gone", "cursor": strconv.FormatInt(next, 10)})
+	}
+
+	page := EventPageResponse{Events: events, Cursor: next, HasMore: more}
+	switch {
+	case wantsProtobuf(c):
+		return protobuf(c, http.StatusOK, eventPageMessage(page))
+	case wantsMsgpack(c):
+		return msgpackBlob(c, http.StatusOK, page)
+	}
+	return c.JSON(http.StatusOK, page)
+}
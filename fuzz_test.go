@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"go-game/game"
+	"go-game/pb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/protobuf/proto"
+)
+
+// FuzzMoveRequest decodes a move body the way POST /game/:id/move does, as JSON or
+// as a Protocol Buffers message, and plays it on a fresh game
+func FuzzMoveRequest(f *testing.F) {
+	for _, body := range []string{
+		`{"position":12}`, `{"pass":true}`, `{"coordinate":"C3"}`, `{"coordinate":"pass","position":12}`,
+		`{"coordinate":"Z9"}`, `{"coordinate":"c3 "}`, `{"position":-1}`, `{"position":25}`, `{}`, `not json`,
+	} {
+		f.Add([]byte(body), false)
+	}
+	for _, msg := range []*pb.MoveRequest{{Position: 12}, {Coordinate: "E5"}, {Pass: true}, {Position: -7}} {
+		body, err := proto.Marshal(msg)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(body, true)
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte, protobuf bool) {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if protobuf {
+			req.Header.Set(echo.HeaderContentType, mimeProtobuf)
+		}
+		var moveReq MoveRequest
+		if err := bindMove(echo.New().NewContext(req, httptest.NewRecorder()), &moveReq); err != nil {
+			return
+		}
+
+		board := game.NewBoard(5)
+		if err := board.Start(); err != nil {
+			t.Fatal(err)
+		}
+		if err := applyMove(board, moveReq); err != nil {
+			return
+		}
+
+		// Exactly one move was played: a black stone where it says, or a pass
+		if len(board.MoveHistory) != 1 || board.CurrentPlayer != 2 {
+			t.Fatalf("%+v left %d moves, player %d to move", moveReq, len(board.MoveHistory), board.CurrentPlayer)
+		}
+		stones, position := 0, board.MoveHistory[0].Position
+		for _, stone := range board.Grid {
+			if stone != 0 {
+				stones++
+			}
+		}
+		if (position < 0 && stones != 0) || (position >= 0 && (stones != 1 || board.Grid[position] != 1)) {
+			t.Fatalf("%+v played at %d and left %d stones", moveReq, position, stones)
+		}
+	})
+}
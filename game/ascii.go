@@ -0,0 +1,104 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// String draws the board as an ASCII diagram for logs and command line clients
+// X = black, O = white, + = star point; the last move is wrapped in parentheses
+// Rows are numbered from the bottom and columns lettered from the left, like on a real board
+//
+//	  A B C D E
+//	5 . . . . .  5
+//	4 . X(O). .  4
+//	...
+func (b *Board) String() string {
+	var out strings.Builder
+
+	header := columnHeader(b.Size)
+	out.WriteString(header)
+
+	lastMove := -1
+	if b.LastMove != nil {
+		lastMove = b.LastMove.Position
+	}
+	stars := b.StarPoints()
+
+	for row := 0; row < b.Size; row++ {
+		// Separators around each point; the last move replaces the ones on either side of it
+		separators := []byte(strings.Repeat(" ", b.Size+1))
+		if lastMove >= 0 && lastMove/b.Size == row {
+			separators[lastMove%b.Size] = '('
+			separators[lastMove%b.Size+1] = ')'
+		}
+
+		fmt.Fprintf(&out, "%2d", b.Size-row)
+		for col := 0; col < b.Size; col++ {
+			out.WriteByte(separators[col])
+
+			position := b.GetPosition(row, col)
+			switch {
+			case b.Grid[position] == 1:
+				out.WriteByte('X')
+			case b.Grid[position] == 2:
+				out.WriteByte('O')
+			case stars[position]:
+				out.WriteByte('+')
+			default:
+				out.WriteByte('.')
+			}
+		}
+		fmt.Fprintf(&out, "%c%2d\n", separators[b.Size], b.Size-row)
+	}
+
+	out.WriteString(header)
+	return out.String()
+}
+
+// columnHeader returns the line of column letters above and below a diagram
+func columnHeader(size int) string {
+	var header strings.Builder
+	header.WriteString("  ")
+	for col := 0; col < size && col < len(ColumnLetters); col++ {
+		header.WriteByte(' ')
+		header.WriteByte(ColumnLetters[col])
+	}
+	header.WriteByte('\n')
+	return header.String()
+}
+
+// StarPoints returns the marked points of the board (hoshi)
+// Corner points sit on the 3-3 point of small boards and the 4-4 point of larger ones;
+// odd boards get the center point, and boards of 15 and up the side points too
+func (b *Board) StarPoints() map[int]bool {
+	stars := make(map[int]bool)
+	if b.Size < 7 {
+		return stars
+	}
+
+	edge := 3
+	if b.Size < 13 {
+		edge = 2
+	}
+	far, middle := b.Size-1-edge, b.Size/2
+
+	lines := []int{edge, far}
+	for _, row := range lines {
+		for _, col := range lines {
+			stars[b.GetPosition(row, col)] = true
+		}
+	}
+
+	if b.Size%2 == 1 {
+		stars[b.GetPosition(middle, middle)] = true
+		if b.Size >= 15 {
+			for _, line := range lines {
+				stars[b.GetPosition(line, middle)] = true
+				stars[b.GetPosition(middle, line)] = true
+			}
+		}
+	}
+
+	return stars
+}
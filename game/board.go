@@ -1,6 +1,11 @@
 package game
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Board represents the game state of a Go board
 // Go is played on a 19x19 grid with complex rules for capturing and scoring
@@ -27,6 +32,179 @@ type Board struct {
 
 	// MoveHistory stores all moves made in the game for game review and undo functionality
 	MoveHistory []Move
+
+	// MoveNumbers stores, for each intersection, the number of the move that placed
+	// the stone currently there (0 = empty), for numbered review diagrams
+	MoveNumbers []int
+
+	// LastMove is where the most recent stone was played (nil before the first stone or after a pass)
+	LastMove *Coordinates
+
+	// Phase is the stage the game is in (setup, playing, scoring, finished)
+	Phase Phase
+
+	// Variant selects the rules variant (see the Variant* constants)
+	Variant string
+
+	// Clock tracks each player's thinking time (nil = untimed game)
+	Clock *Clock
+
+	// Komi is the number of points added to white's score for playing second
+	Komi float64
+
+	// DeadStones lists the stones marked as dead during the scoring phase
+	DeadStones []int
+
+	// ScoreAccepted tracks which players accepted the score (index 1 = black, 2 = white)
+	ScoreAccepted [3]bool
+
+	// PrecomputeLegalMoves computes the legal moves for the next player right after every move
+	// Useful for bot games and move hints; otherwise they are computed on first use
+	PrecomputeLegalMoves bool
+
+	// PlayResumedAt is the length of MoveHistory when play last resumed from scoring
+	// Passes made before that point no longer count towards ending the game
+	PlayResumedAt int
+
+	// Result is set once the game has ended (nil while the game is in progress)
+	Result *Result
+
+	// SetupStones are the stones placed before the first move (index 1 = black, 2 = white)
+	SetupStones [3][]int
+
+	// SGF is the game record the game was imported from (empty for games started here)
+	// Kept so variations, comments and markup survive when the game is exported again
+	SGF string
+
+	// Info describes the game record (players, event, date, ...)
+	Info GameInfo
+
+	// Hotseat marks a pass-and-play game where one device plays both colors
+	Hotseat bool
+
+	// Sandbox marks a throwaway game made to try out the API; it is never rated
+	Sandbox bool
+
+	// Seats stores the SHA-256 hashes (hex) of the tokens that hold each seat (index 1 = black, 2 = white)
+	// An empty hash leaves the seat to the server (e.g. a bot); games without any are open to anyone
+	Seats [3]string
+
+	// Players stores the IDs of the accounts playing each color (index 1 = black, 2 = white; "" = anonymous)
+	Players [3]string
+
+	// Teams lists the members of each team in playing order (index 1 = black, 2 = white)
+	// Empty unless this is a team game
+	Teams [3][]string
+
+	// MemberSeats stores the hashes of the tokens of each team member, in the order of Teams
+	// Empty unless the members of a team game were given seats
+	MemberSeats [3][]string
+
+	// Consultation is the time each team may spend discussing its moves (nil = no consultation time)
+	Consultation *Consultation
+
+	// Violations lists the attempts of team members to move out of rotation
+	Violations []Violation
+
+	// Pace keeps statistics about how fast each player moves
+	Pace Pace
+
+	// Revealed lists, per player, the opponent stones they ran into in phantom go
+	Revealed [3][]int
+
+	// seenPositions counts how often each position occurred, to detect long cycles
+	// It isn't saved with the game, and is rebuilt from the move log when missing
+	seenPositions map[string]int
+
+	// scratch holds reusable buffers for the rules engine
+	scratch *scratch
+}
+
+// Result describes how a finished game ended
+type Result struct {
+	// Winner of the game (1 = black, 2 = white, 0 = no winner)
+	Winner int
+
+	// Reason explains how the game ended (see the Reason* constants)
+	Reason string
+
+	// Margin is the number of points the winner won by (only for games ended by counting)
+	Margin float64
+}
+
+// String writes the result in the usual short notation: "B+3.5", "W+R" (resignation),
+// "B+T" (time), "0" (jigo) or "Void" (no result, annulled)
+func (r *Result) String() string {
+	winner := "B"
+	if r.Winner == 2 {
+		winner = "W"
+	}
+
+	switch {
+	case r.Reason == ReasonNoResult || r.Reason == ReasonAnnulled:
+		return "Void"
+	case r.Winner == 0:
+		return "0" // Jigo
+	case r.Reason == ReasonScore:
+		return winner + "+" + strconv.FormatFloat(r.Margin, 'f', -1, 64)
+	case r.Reason == ReasonTimeout:
+		return winner + "+T"
+	case r.Reason == ReasonResign:
+		return winner + "+R"
+	default:
+		return winner + "+"
+	}
+}
+
+// Reasons a game can end
+const (
+	ReasonTimeout  = "time"      // A player ran out of time
+	ReasonScore    = "score"     // Both players accepted the counted score
+	ReasonNoResult = "no_result" // A long cycle (triple ko, ...) repeated, nobody wins
+	ReasonCapture  = "capture"   // First capture in capture go
+	ReasonNoMoves  = "no_moves"  // The loser had no legal move left (NoGo)
+	ReasonResign   = "resign"    // The loser resigned
+	ReasonAnnulled = "annulled"  // Abandoned by a player or an admin, nobody wins
+)
+
+// ParseResult reads a result as game records write it (the SGF RE property): "B+3.5",
+// "W+R" or "W+Resign", "B+T" or "B+Time", "0", "Draw" or "Jigo", "Void"
+// Other ways of winning ("B+F", "W+") keep the winner without a reason; ok is false for
+// unknown results ("?") and anything unreadable
+func ParseResult(notation string) (result *Result, ok bool) {
+	notation = strings.TrimSpace(notation)
+	switch strings.ToLower(notation) {
+	case "0", "draw", "jigo":
+		return &Result{Reason: ReasonScore}, true
+	case "void":
+		return &Result{Reason: ReasonNoResult}, true
+	}
+
+	color, how, found := strings.Cut(notation, "+")
+	winner := map[string]int{"B": 1, "W": 2}[strings.ToUpper(color)]
+	if !found || winner == 0 {
+		return nil, false
+	}
+
+	result = &Result{Winner: winner}
+	switch strings.ToLower(how) {
+	case "r", "resign":
+		result.Reason = ReasonResign
+	case "t", "time":
+		result.Reason = ReasonTimeout
+	default:
+		if margin, err := strconv.ParseFloat(how, 64); err == nil {
+			result.Reason, result.Margin = ReasonScore, margin
+		}
+	}
+	return result, true
+}
+
+// Coordinates locates an intersection both as a 1D position and as row, col
+type Coordinates struct {
+	Position int
+	Row      int
+	Col      int
 }
 
 // Move represents a single move in the game
@@ -40,6 +218,14 @@ type Move struct {
 	// CapturedPositions stores which stones were captured by this move
 	// Needed for proper undo functionality and Ko rule enforcement
 	CapturedPositions []int
+
+	// Spent is the thinking time the move took (0 for moves recorded before it was kept)
+	Spent time.Duration `json:",omitempty"`
+
+	// TimeLeft and PeriodsLeft are the main time and byo-yomi periods the player had left
+	// after the move, for replays (timed games only)
+	TimeLeft    time.Duration `json:",omitempty"`
+	PeriodsLeft int           `json:",omitempty"`
 }
 
 // NewBoard creates a new Go board with the specified size
@@ -52,6 +238,10 @@ func NewBoard(size int) *Board {
 		CapturedStones: [3]int{0, 0, 0},        // No captured stones initially
 		Ko:             nil,                    // No Ko situation initially
 		MoveHistory:    make([]Move, 0),        // Empty move history
+		MoveNumbers:    make([]int, size*size), // No stones numbered yet
+		Phase:          PhaseSetup,             // Waiting for Start
+		Komi:           DefaultKomi,            // Standard compensation for white
+		Variant:        VariantStandard,        // Regular rules
 	}
 }
 
@@ -82,21 +272,9 @@ func (b *Board) GetStone(position int) int {
 
 // GetNeighbors returns all adjacent positions (up, down, left, right)
 // In Go, only orthogonally adjacent intersections matter for captures and groups
+// The returned slice is shared and must not be modified
 func (b *Board) GetNeighbors(position int) []int {
-	row, col := b.GetCoordinates(position)
-	neighbors := make([]int, 0, 4) // Maximum 4 neighbors
-
-	// Check all four directions: up, down, left, right
-	directions := [][]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
-
-	for _, dir := range directions {
-		newRow, newCol := row+dir[0], col+dir[1]
-		if b.IsValidPosition(newRow, newCol) {
-			neighbors = append(neighbors, b.GetPosition(newRow, newCol))
-		}
-	}
-
-	return neighbors
+	return b.scratchSpace().neighbors[position]
 }
 
 // GetGroup finds all stones connected to a given position
@@ -108,26 +286,28 @@ func (b *Board) GetGroup(position int) []int {
 		return nil // Empty position has no group
 	}
 
-	visited := make(map[int]bool)
-	group := make([]int, 0)
+	s := b.scratchSpace()
+	gen := s.newSearch()
+	group := make([]int, 0, 8)
 
 	// Use depth-first search to find all connected stones of the same color
-	var dfs func(int)
-	dfs = func(pos int) {
-		if visited[pos] || b.GetStone(pos) != color {
-			return
-		}
+	stack := append(s.stack[:0], position)
+	s.marks[position] = gen
 
-		visited[pos] = true
+	for len(stack) > 0 {
+		pos := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
 		group = append(group, pos)
 
-		// Recursively check all neighbors
-		for _, neighbor := range b.GetNeighbors(pos) {
-			dfs(neighbor)
+		for _, neighbor := range s.neighbors[pos] {
+			if s.marks[neighbor] != gen && b.Grid[neighbor] == color {
+				s.marks[neighbor] = gen
+				stack = append(stack, neighbor)
+			}
 		}
 	}
 
-	dfs(position)
+	s.stack = stack[:0]
 	return group
 }
 
@@ -135,36 +315,45 @@ func (b *Board) GetGroup(position int) []int {
 // "Liberties" are empty intersections adjacent to a group
 // A group with no liberties is captured and removed from the board
 func (b *Board) GetLiberties(group []int) int {
-	liberties := make(map[int]bool) // Use map to avoid counting same liberty twice
+	s := b.scratchSpace()
+	gen := s.newSearch() // Avoids counting the same liberty twice
 
+	liberties := 0
 	for _, pos := range group {
-		for _, neighbor := range b.GetNeighbors(pos) {
-			if b.IsEmpty(neighbor) {
-				liberties[neighbor] = true
+		for _, neighbor := range s.neighbors[pos] {
+			if b.Grid[neighbor] == 0 && s.marks[neighbor] != gen {
+				s.marks[neighbor] = gen
+				liberties++
 			}
 		}
 	}
 
-	return len(liberties)
+	return liberties
 }
 
 // WouldBeSuicide checks if placing a stone would be suicide
 // Suicide is placing a stone that would immediately have no liberties
 // This is illegal unless the move captures opponent stones
 func (b *Board) WouldBeSuicide(position int, player int) bool {
+	// Quick check: an empty neighbor is a liberty right away
+	for _, neighbor := range b.GetNeighbors(position) {
+		if b.IsEmpty(neighbor) {
+			return false
+		}
+	}
+
 	// Temporarily place the stone
 	originalStone := b.Grid[position]
 	b.Grid[position] = player
 
 	// Check if this creates a group with liberties
-	group := b.GetGroup(position)
-	liberties := b.GetLiberties(group)
+	hasLiberties := b.countLiberties(position, 1) > 0
 
 	// Restore original state
 	b.Grid[position] = originalStone
 
 	// If the group would have no liberties, it's potentially suicide
-	if liberties > 0 {
+	if hasLiberties {
 		return false
 	}
 
@@ -172,11 +361,8 @@ func (b *Board) WouldBeSuicide(position int, player int) bool {
 	// If it captures opponent stones, it's not suicide even with no liberties
 	opponent := 3 - player // Convert 1->2, 2->1
 	for _, neighbor := range b.GetNeighbors(position) {
-		if b.GetStone(neighbor) == opponent {
-			opponentGroup := b.GetGroup(neighbor)
-			if b.GetLiberties(opponentGroup) == 1 {
-				return false // This move would capture, so not suicide
-			}
+		if b.GetStone(neighbor) == opponent && b.countLiberties(neighbor, 2) == 1 {
+			return false // This move would capture, so not suicide
 		}
 	}
 
@@ -195,6 +381,11 @@ func (b *Board) IsValidMove(position int) bool {
 		return false
 	}
 
+	// NoGo forbids capturing as well
+	if b.Variant == VariantNoGo && b.wouldCapture(position, b.CurrentPlayer) {
+		return false
+	}
+
 	// Move cannot violate Ko rule (immediate recapture)
 	if b.Ko != nil && len(b.Ko) == len(b.Grid) {
 		// Temporarily make the move and check if it recreates the Ko position
@@ -209,11 +400,12 @@ func (b *Board) IsValidMove(position int) bool {
 			}
 		}
 
-		// Restore board state
+		// Restore board state, including the capture count the trial capture added to
 		b.Grid[position] = 0
 		for _, capturedPos := range captures {
 			b.Grid[capturedPos] = 3 - b.CurrentPlayer
 		}
+		b.CapturedStones[b.CurrentPlayer] -= len(captures)
 
 		if isKo {
 			return false // Ko rule violation
@@ -231,30 +423,89 @@ func (b *Board) processCaptures(position int) []int {
 
 	// Check all adjacent opponent groups
 	for _, neighbor := range b.GetNeighbors(position) {
-		if b.GetStone(neighbor) == opponent {
+		if b.GetStone(neighbor) == opponent && b.countLiberties(neighbor, 1) == 0 {
+			// This group has no liberties, capture it
 			group := b.GetGroup(neighbor)
-			if b.GetLiberties(group) == 0 {
-				// This group has no liberties, capture it
-				for _, pos := range group {
-					b.Grid[pos] = 0 // Remove stone
-					captured = append(captured, pos)
-				}
-				b.CapturedStones[b.CurrentPlayer] += len(group)
+			for _, pos := range group {
+				b.Grid[pos] = 0 // Remove stone
+				captured = append(captured, pos)
 			}
+			b.CapturedStones[b.CurrentPlayer] += len(group)
 		}
 	}
 
 	return captured
 }
 
+// SetClock attaches a clock to the game; it starts ticking when the game starts
+func (b *Board) SetClock(clock *Clock) {
+	b.Clock = clock
+}
+
+// CheckTimeout ends the game if the player to move has run out of time
+// Returns true if the game was ended by this call
+func (b *Board) CheckTimeout(now time.Time) bool {
+	if b.Phase != PhasePlaying || b.Clock == nil || !b.Clock.Expired(now.Add(-b.consulted(now))) {
+		return false
+	}
+
+	b.settleConsultation(now)
+	b.Clock.Press(now) // Charges the used time before the clock is stopped
+	b.finish(&Result{Winner: 3 - b.CurrentPlayer, Reason: ReasonTimeout})
+	return true
+}
+
+// pressClock charges the time used by the current player for their move and records the pace
+// and the clock on the move, which is appended to the history right after
+// Ends the game and returns an error if the player had already run out of time
+func (b *Board) pressClock(move *Move) error {
+	now := time.Now()
+	b.settleConsultation(now) // Moving ends the consultation
+	player := b.CurrentPlayer
+	thinking := b.Pace.Thinking[player]
+	if b.Clock == nil {
+		b.recordPace(player, now, false)
+		move.Spent = b.Pace.Thinking[player] - thinking
+		return nil
+	}
+
+	mainBefore, periodsBefore := b.Clock.Remaining[player], b.Clock.PeriodsLeft[player]
+	elapsed := b.Clock.elapsed(now)
+
+	if b.Clock.Press(now) {
+		b.finish(&Result{Winner: 3 - player, Reason: ReasonTimeout})
+		return fmt.Errorf("player %d ran out of time", player)
+	}
+
+	b.recordPace(player, now, b.Clock.drainedLastPeriod(player, elapsed, mainBefore, periodsBefore))
+	move.Spent = b.Pace.Thinking[player] - thinking
+	move.TimeLeft, move.PeriodsLeft = b.Clock.Remaining[player], b.Clock.PeriodsLeft[player]
+	return nil
+}
+
 // MakeMove places a stone on the board and handles all game logic
 func (b *Board) MakeMove(position int) error {
+	if err := b.requirePhase(PhasePlaying); err != nil {
+		return err
+	}
+
 	if !b.IsValidMove(position) {
+		b.revealStone(position)
 		return fmt.Errorf("invalid move at position %d", position)
 	}
 
-	// Save current board state for Ko rule
-	previousBoard := make([]int, len(b.Grid))
+	// Charge the thinking time before the stone is placed
+	move := Move{Player: b.CurrentPlayer, Position: position}
+	if err := b.pressClock(&move); err != nil {
+		return err
+	}
+
+	// Save current board state for Ko rule, reusing the buffer of the position replaced by it
+	s := b.scratchSpace()
+	previousBoard := s.spareKo
+	if len(previousBoard) != len(b.Grid) {
+		previousBoard = make([]int, len(b.Grid))
+	}
 	copy(previousBoard, b.Grid)
 
 	// Place the stone
@@ -262,39 +513,99 @@ func (b *Board) MakeMove(position int) error {
 
 	// Process captures
 	captured := b.processCaptures(position)
+	b.forgetRevealed(captured)
 
 	// Record the move
-	move := Move{
-		Player:            b.CurrentPlayer,
-		Position:          position,
-		CapturedPositions: captured,
-	}
+	move.CapturedPositions = captured
 	b.MoveHistory = append(b.MoveHistory, move)
 
+	// Number the new stone and clear the numbers of the captured ones
+	b.MoveNumbers[position] = len(b.MoveHistory)
+	for _, capturedPos := range captured {
+		b.MoveNumbers[capturedPos] = 0
+	}
+
+	// Mark the last move
+	row, col := b.GetCoordinates(position)
+	b.LastMove = &Coordinates{Position: position, Row: row, Col: col}
+
 	// Update Ko position
+	s.spareKo = b.Ko
 	b.Ko = previousBoard
 
 	// Switch players
 	b.CurrentPlayer = 3 - b.CurrentPlayer
 
+	// Some variants end on the first capture or when a player is stuck
+	b.checkCaptureWin(move.Player, captured)
+	if b.Phase == PhasePlaying {
+		b.checkNoMovesLeft()
+	}
+
+	// A position that keeps repeating ends the game without a result
+	if b.Phase == PhasePlaying {
+		b.recordPosition()
+	}
+
+	b.precomputeLegalMoves()
 	return nil
 }
 
 // Pass allows a player to skip their turn
-func (b *Board) Pass() {
+func (b *Board) Pass() error {
+	if err := b.requirePhase(PhasePlaying); err != nil {
+		return err
+	}
+
+	if err := b.checkPassAllowed(); err != nil {
+		return err
+	}
+
+	// Passing still uses up thinking time
 	move := Move{
 		Player:   b.CurrentPlayer,
 		Position: -1, // -1 indicates a pass
 	}
+	if err := b.pressClock(&move); err != nil {
+		return err
+	}
+
 	b.MoveHistory = append(b.MoveHistory, move)
+	b.LastMove = nil
 
 	// Switch players
 	b.CurrentPlayer = 3 - b.CurrentPlayer
+
+	// Two passes in a row end the play and move on to scoring
+	if b.IsGameOver() {
+		if b.Clock != nil {
+			b.Clock.Stop()
+		}
+		b.DeadStones = b.RuledDeadStones() // Start from what the rules already decide
+		b.ScoreAccepted = [3]bool{}
+		return b.setPhase(PhaseScoring)
+	}
+
+	b.precomputeLegalMoves()
+	return nil
+}
+
+// Resign ends the game in favor of the player's opponent
+// Allowed while playing and while counting, but not before the game started
+func (b *Board) Resign(player int) error {
+	if player != 1 && player != 2 {
+		return fmt.Errorf("invalid player %d", player)
+	}
+	if b.Phase != PhasePlaying && b.Phase != PhaseScoring {
+		return fmt.Errorf("resigning is not allowed during the %s phase", b.Phase)
+	}
+
+	return b.finish(&Result{Winner: 3 - player, Reason: ReasonResign})
 }
 
 // IsGameOver checks if the game has ended (both players passed consecutively)
 func (b *Board) IsGameOver() bool {
-	if len(b.MoveHistory) < 2 {
+	if len(b.MoveHistory)-b.PlayResumedAt < 2 {
 		return false
 	}
 
@@ -304,3 +615,9 @@ func (b *Board) IsGameOver() bool {
 
 	return lastMove.Position == -1 && secondLastMove.Position == -1
 }
+
+// Version identifies the state of the game; it changes every time a move or pass is recorded
+// Clients send back the version they last saw so stale moves can be detected
+func (b *Board) Version() int {
+	return len(b.MoveHistory)
+}
@@ -0,0 +1,170 @@
+package game
+
+import "time"
+
+// Clock tracks the thinking time of both players
+// It supports a main time followed by Japanese byo-yomi periods
+type Clock struct {
+	// MainTime is the initial amount of time each player receives
+	MainTime time.Duration
+
+	// ByoYomiTime is the length of a single byo-yomi period (0 = no byo-yomi)
+	ByoYomiTime time.Duration
+
+	// ByoYomiPeriods is how many byo-yomi periods each player receives
+	ByoYomiPeriods int
+
+	// Remaining stores the main time left for each player
+	// Index 0 is unused, index 1 = black, index 2 = white (same layout as CapturedStones)
+	Remaining [3]time.Duration
+
+	// PeriodsLeft stores the byo-yomi periods left for each player
+	PeriodsLeft [3]int
+
+	// Running is the player whose clock is currently ticking (0 = stopped)
+	Running int
+
+	// TurnStart is the moment the running player's clock was started
+	TurnStart time.Time
+
+	// PerMove is the time every move gets, for correspondence games played at days per move
+	// After each move the player's main time is reset to it instead of carrying over (0 = not used)
+	PerMove time.Duration
+
+	// Vacation stores the vacation time each player had left when their current vacation
+	// started, or has left if they are not on vacation (see vacation.go)
+	Vacation [3]time.Duration
+
+	// VacationStart is the moment each player's current vacation started (zero = not on vacation)
+	VacationStart [3]time.Time
+}
+
+// NewClock creates a stopped clock with the given time control
+func NewClock(mainTime, byoYomiTime time.Duration, byoYomiPeriods int) *Clock {
+	return &Clock{
+		MainTime:       mainTime,
+		ByoYomiTime:    byoYomiTime,
+		ByoYomiPeriods: byoYomiPeriods,
+		Remaining:      [3]time.Duration{0, mainTime, mainTime},
+		PeriodsLeft:    [3]int{0, byoYomiPeriods, byoYomiPeriods},
+	}
+}
+
+// NewCorrespondenceClock creates a stopped clock that gives every move the same time, e.g. three days
+func NewCorrespondenceClock(perMove time.Duration) *Clock {
+	c := NewClock(perMove, 0, 0)
+	c.PerMove = perMove
+	return c
+}
+
+// Start begins ticking the clock for the given player
+func (c *Clock) Start(player int, now time.Time) {
+	c.Running = player
+	c.TurnStart = now
+}
+
+// Stop halts the clock without charging any time (used when the game ends)
+func (c *Clock) Stop() {
+	c.Running = 0
+	c.TurnStart = time.Time{}
+}
+
+// spend works out how much main time and how many byo-yomi periods a player
+// would have left after thinking for elapsed
+// expired is true if the player ran out of both main time and byo-yomi
+func (c *Clock) spend(player int, elapsed time.Duration) (remaining time.Duration, periods int, expired bool) {
+	remaining = c.Remaining[player]
+	periods = c.PeriodsLeft[player]
+
+	// Main time is consumed first
+	if elapsed <= remaining {
+		return remaining - elapsed, periods, false
+	}
+	elapsed -= remaining
+	remaining = 0
+
+	// Without byo-yomi, running out of main time loses the game
+	if c.ByoYomiTime <= 0 {
+		return 0, periods, true
+	}
+
+	// Every full period used up is lost; a move made within a period keeps it
+	used := int(elapsed / c.ByoYomiTime)
+	if used >= periods {
+		return 0, 0, true
+	}
+
+	return 0, periods - used, false
+}
+
+// Press stops the running player's clock after a move and starts the opponent's
+// Returns true if the player had already run out of time before moving
+func (c *Clock) Press(now time.Time) bool {
+	player := c.Running
+	if player == 0 {
+		return false
+	}
+
+	remaining, periods, expired := c.spend(player, c.elapsed(now))
+	c.Remaining[player] = remaining
+	c.PeriodsLeft[player] = periods
+	if expired {
+		c.Stop()
+		return true
+	}
+	if c.PerMove > 0 {
+		c.Remaining[player] = c.PerMove
+	}
+
+	c.Start(3-player, now)
+	return false
+}
+
+// Expired checks whether the running player has run out of time
+func (c *Clock) Expired(now time.Time) bool {
+	if c.Running == 0 {
+		return false
+	}
+
+	_, _, expired := c.spend(c.Running, c.elapsed(now))
+	return expired
+}
+
+// TimeLeft returns how much main time and byo-yomi periods a player has left right now
+func (c *Clock) TimeLeft(player int, now time.Time) (time.Duration, int) {
+	if c.Running != player {
+		return c.Remaining[player], c.PeriodsLeft[player]
+	}
+
+	remaining, periods, _ := c.spend(player, c.elapsed(now))
+	return remaining, periods
+}
+
+// ClockState is a snapshot of both players' remaining time at a given moment
+// Clients use it to display the clocks without knowing the time control rules
+type ClockState struct {
+	// TimeLeft is the main time left for each player (index 1 = black, 2 = white)
+	TimeLeft [3]time.Duration
+
+	// PeriodsLeft is the byo-yomi periods left for each player
+	PeriodsLeft [3]int
+
+	// Running is the player whose clock is ticking (0 = stopped)
+	Running int
+
+	// VacationLeft is the vacation time left for each player
+	VacationLeft [3]time.Duration
+
+	// OnVacation tells which players are on vacation, their clock not counting
+	OnVacation [3]bool
+}
+
+// State takes a snapshot of the clock at the given moment
+func (c *Clock) State(now time.Time) ClockState {
+	state := ClockState{Running: c.Running}
+	for player := 1; player <= 2; player++ {
+		state.TimeLeft[player], state.PeriodsLeft[player] = c.TimeLeft(player, now)
+		state.VacationLeft[player], state.OnVacation[player] = c.VacationLeft(player, now), c.OnVacation(player, now)
+	}
+	return state
+}
@@ -0,0 +1,56 @@
+package game
+
+// Clone returns a deep copy of the board that can be changed without affecting the original
+// Used for playouts and analysis, which explore moves on copies of live games
+func (b *Board) Clone() *Board {
+	clone := *b
+	clone.scratch = nil // Buffers are per board
+
+	clone.Grid = append([]int(nil), b.Grid...)
+	clone.Ko = append([]int(nil), b.Ko...)
+	clone.MoveNumbers = append([]int(nil), b.MoveNumbers...)
+	clone.DeadStones = append([]int(nil), b.DeadStones...)
+	for player := range b.SetupStones {
+		clone.SetupStones[player] = append([]int(nil), b.SetupStones[player]...)
+	}
+	for player := range b.Teams {
+		clone.Teams[player] = append([]string(nil), b.Teams[player]...)
+		clone.MemberSeats[player] = append([]string(nil), b.MemberSeats[player]...)
+	}
+	for player := range b.Revealed {
+		clone.Revealed[player] = append([]int(nil), b.Revealed[player]...)
+	}
+
+	clone.MoveHistory = make([]Move, len(b.MoveHistory))
+	for i, move := range b.MoveHistory {
+		move.CapturedPositions = append(make([]int, 0, len(move.CapturedPositions)), move.CapturedPositions...)
+		clone.MoveHistory[i] = move
+	}
+
+	if b.LastMove != nil {
+		lastMove := *b.LastMove
+		clone.LastMove = &lastMove
+	}
+	if b.Clock != nil {
+		clock := *b.Clock
+		clone.Clock = &clock
+	}
+	if b.Consultation != nil {
+		consultation := *b.Consultation
+		clone.Consultation = &consultation
+	}
+	clone.Violations = append([]Violation(nil), b.Violations...)
+	if b.Result != nil {
+		result := *b.Result
+		clone.Result = &result
+	}
+
+	if b.seenPositions != nil {
+		clone.seenPositions = make(map[string]int, len(b.seenPositions))
+		for key, count := range b.seenPositions {
+			clone.seenPositions[key] = count
+		}
+	}
+
+	return &clone
+}
@@ -0,0 +1,141 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Consultation is the time each team of a rengo game may spend discussing its moves
+// While a team consults, its game clock is paused and the shared consultation time runs instead;
+// once that is used up the game clock takes over again
+type Consultation struct {
+	// Allowance is the consultation time each team receives
+	Allowance time.Duration
+
+	// Remaining stores the consultation time left for each team (index 1 = black, 2 = white)
+	Remaining [3]time.Duration
+
+	// Active is the team consulting right now (0 = none)
+	Active int
+
+	// Since is when the active consultation started
+	Since time.Time
+}
+
+// Violation is an attempt by a team member to move out of rotation
+// Kept in the game so the opponent can see it, and referees can rule on it
+type Violation struct {
+	MoveNumber int       // Move the attempt was made at (1 = first move)
+	Player     int       // Team the member plays for (1 = black, 2 = white)
+	Member     string    // Who tried to move
+	Expected   string    // Who had to move
+	At         time.Time // When it happened
+}
+
+// MoverError rejects a move made by someone other than the team member who has to move
+type MoverError struct {
+	Expected  string
+	Violation *Violation // The attempt as recorded, nil if the mover isn't on the team
+}
+
+func (e *MoverError) Error() string {
+	return fmt.Sprintf("it is %s's turn to move", e.Expected)
+}
+
+// SetConsultation gives each team of a team game the same consultation time; it can only be done during setup
+func (b *Board) SetConsultation(allowance time.Duration) error {
+	if err := b.requirePhase(PhaseSetup); err != nil {
+		return err
+	}
+	if !b.IsTeamGame() {
+		return fmt.Errorf("only team games have consultation time")
+	}
+	if allowance <= 0 {
+		return fmt.Errorf("consultation time must be positive")
+	}
+
+	b.Consultation = &Consultation{Allowance: allowance, Remaining: [3]time.Duration{0, allowance, allowance}}
+	return nil
+}
+
+// StartConsultation lets the team to move consult, at the request of one of its members
+func (b *Board) StartConsultation(member string, now time.Time) error {
+	if err := b.requirePhase(PhasePlaying); err != nil {
+		return err
+	}
+
+	c := b.Consultation
+	switch {
+	case c == nil:
+		return fmt.Errorf("this game has no consultation time")
+	case !slices.Contains(b.Teams[b.CurrentPlayer], member):
+		return fmt.Errorf("only the team to move can consult")
+	case c.Active != 0:
+		return fmt.Errorf("the team is already consulting")
+	case c.Remaining[b.CurrentPlayer] <= 0:
+		return fmt.Errorf("the team has no consultation time left")
+	}
+
+	c.Active, c.Since = b.CurrentPlayer, now
+	return nil
+}
+
+// EndConsultation ends the active consultation and restarts the team's game clock
+func (b *Board) EndConsultation(now time.Time) error {
+	if b.Consultation == nil || b.Consultation.Active == 0 {
+		return fmt.Errorf("no consultation is going on")
+	}
+
+	b.settleConsultation(now)
+	return nil
+}
+
+// consulted is how much of the active consultation is charged to the consultation time so far
+// Time beyond what the team had left runs on the game clock
+func (b *Board) consulted(now time.Time) time.Duration {
+	c := b.Consultation
+	if c == nil || c.Active == 0 {
+		return 0
+	}
+	return min(now.Sub(c.Since), c.Remaining[c.Active])
+}
+
+// settleConsultation charges the active consultation, if any, to the team's consultation time
+// and moves the start of the turn forward by as much, so the game clock isn't charged for it
+func (b *Board) settleConsultation(now time.Time) {
+	used := b.consulted(now)
+	c := b.Consultation
+	if c == nil || c.Active == 0 {
+		return
+	}
+
+	c.Remaining[c.Active] -= used
+	if b.Clock != nil && b.Clock.Running == c.Active {
+		b.Clock.TurnStart = b.Clock.TurnStart.Add(used)
+	}
+	c.Active, c.Since = 0, time.Time{}
+}
+
+// ClockState takes a snapshot of the clock at the given moment, not counting
+// the time of an active consultation (nil for untimed games)
+func (b *Board) ClockState(now time.Time) *ClockState {
+	if b.Clock == nil {
+		return nil
+	}
+
+	state := b.Clock.State(now.Add(-b.consulted(now)))
+	return &state
+}
+
+// ConsultationLeft returns how much consultation time a team has left right now
+func (b *Board) ConsultationLeft(player int, now time.Time) time.Duration {
+	c := b.Consultation
+	if c == nil {
+		return 0
+	}
+	if c.Active == player {
+		return c.Remaining[player] - b.consulted(now)
+	}
+	return c.Remaining[player]
+}
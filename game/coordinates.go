@@ -0,0 +1,40 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ColumnLetters label the columns of the board from the left; "I" is skipped to avoid confusion with "J"
+// They also limit the boards that can be written in coordinates to 25x25
+const ColumnLetters = "ABCDEFGHJKLMNOPQRSTUVWXYZ"
+
+// FormatCoordinate writes a position in standard notation, e.g. "D4" or "Q16"
+// Rows are numbered from the bottom, as on a real board; a negative position is "pass"
+func FormatCoordinate(position, size int) string {
+	if position < 0 {
+		return "pass"
+	}
+	row, col := position/size, position%size
+	return fmt.Sprintf("%c%d", ColumnLetters[col], size-row)
+}
+
+// ParseCoordinate reads a position in standard notation (case insensitive)
+// "pass" returns -1
+func ParseCoordinate(coordinate string, size int) (int, error) {
+	coordinate = strings.ToUpper(strings.TrimSpace(coordinate))
+	if coordinate == "PASS" {
+		return -1, nil
+	}
+	if len(coordinate) < 2 {
+		return 0, fmt.Errorf("invalid coordinate %q", coordinate)
+	}
+
+	col := strings.IndexByte(ColumnLetters, coordinate[0])
+	number, err := strconv.Atoi(coordinate[1:])
+	if col < 0 || col >= size || err != nil || number < 1 || number > size {
+		return 0, fmt.Errorf("invalid coordinate %q", coordinate)
+	}
+	return (size-number)*size + col, nil
+}
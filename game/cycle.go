@@ -0,0 +1,53 @@
+package game
+
+// longCycleRepetitions is how many times the same position may occur before a
+// long cycle (triple ko, eternal life, ...) ends the game without a result
+// The first repetition is allowed so a player can still choose to break the cycle
+const longCycleRepetitions = 3
+
+// positionKey identifies a whole-board position together with the player to move
+func (b *Board) positionKey() string {
+	return positionKey(b.Grid, b.CurrentPlayer)
+}
+
+// positionKey identifies the stones of a grid together with the player to move
+func positionKey(grid []int, player int) string {
+	key := make([]byte, len(grid)+1)
+	for i, stone := range grid {
+		key[i] = byte(stone)
+	}
+	key[len(grid)] = byte(player)
+	return string(key)
+}
+
+// countPositions counts the positions the stones of the move log led to, the current one
+// included; the counts aren't saved with the game, so they are rebuilt after loading it
+func (b *Board) countPositions() map[string]int {
+	seen := make(map[string]int)
+	for steps := NewSteps(b); !steps.Done(); {
+		step, _ := steps.Next()
+		if step.Move.Position >= 0 {
+			seen[positionKey(step.Grid, 3-step.Move.Player)]++
+		}
+	}
+	return seen
+}
+
+// recordPosition counts the current position and ends the game as "no result" once
+// it has come up too often
+// Simple ko is already prevented by the ko rule, so only longer cycles get here
+// Under Japanese rules such cycles void the game instead of being forbidden (as superko would)
+func (b *Board) recordPosition() bool {
+	key := b.positionKey()
+	if b.seenPositions == nil {
+		b.seenPositions = b.countPositions()
+	} else {
+		b.seenPositions[key]++
+	}
+	if b.seenPositions[key] < longCycleRepetitions {
+		return false
+	}
+
+	b.finish(&Result{Winner: 0, Reason: ReasonNoResult})
+	return true
+}
@@ -0,0 +1,35 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestPositionCountsSurviveLoading(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		b := NewBoard(9)
+		if err := b.Start(); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 200 && b.Phase == PhasePlaying; i++ {
+			if legal := b.LegalMoves(); len(legal) > 0 {
+				b.MakeMove(legal[rng.Intn(len(legal))])
+			}
+		}
+
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var loaded Board
+		if err := json.Unmarshal(data, &loaded); err != nil {
+			t.Fatal(err)
+		}
+		if got := loaded.countPositions(); !reflect.DeepEqual(got, b.seenPositions) {
+			t.Errorf("seed %d: %d positions counted after loading, want %d", seed, len(got), len(b.seenPositions))
+		}
+	}
+}
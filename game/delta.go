@@ -0,0 +1,64 @@
+package game
+
+// Delta is what changed with the last move, so clients following a game can update
+// their board without receiving or diffing the whole grid
+// It describes the board it was made from: deltas of a view only show what the view shows
+type Delta struct {
+	// Version is the game version after the move; a client at Version-1 can apply the delta
+	Version int
+
+	// Player who moved and where (-1 for a pass, HiddenPosition if it can't be seen)
+	Player   int
+	Position int
+
+	// Added lists the stones that appeared, Removed the points that were emptied by captures
+	Added   []Stone
+	Removed []int
+
+	// CapturedStones are the prisoner totals after the move (index 1 = black, 2 = white)
+	CapturedStones [3]int
+
+	// CurrentPlayer is who moves next, Phase the stage the game is in now
+	CurrentPlayer int
+	Phase         Phase
+
+	// Clock is the state of the clocks after the move (nil = untimed game)
+	Clock *Clock
+
+	// PositionHash is the hash of the new position (see PositionHash), to check the result
+	PositionHash string
+}
+
+// Stone is a stone on a point of the board
+type Stone struct {
+	Position int
+	Color    int // 1 = black, 2 = white
+}
+
+// LastDelta returns the changes made by the last move (nil before the first move)
+func (b *Board) LastDelta() *Delta {
+	if len(b.MoveHistory) == 0 {
+		return nil
+	}
+	move := b.MoveHistory[len(b.MoveHistory)-1]
+
+	delta := &Delta{
+		Version:        b.Version(),
+		Player:         move.Player,
+		Position:       move.Position,
+		Added:          make([]Stone, 0, 1),
+		Removed:        append(make([]int, 0, len(move.CapturedPositions)), move.CapturedPositions...),
+		CapturedStones: b.CapturedStones,
+		CurrentPlayer:  b.CurrentPlayer,
+		Phase:          b.Phase,
+		PositionHash:   b.PositionHashHex(),
+	}
+	if move.Position >= 0 && b.Grid[move.Position] != 0 {
+		delta.Added = append(delta.Added, Stone{Position: move.Position, Color: b.Grid[move.Position]})
+	}
+	if b.Clock != nil {
+		clock := *b.Clock
+		delta.Clock = &clock
+	}
+	return delta
+}
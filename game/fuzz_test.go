@@ -0,0 +1,82 @@
+package game
+
+import "testing"
+
+// Fuzz targets for the inputs that come straight from clients and feed the rules engine
+// go test runs them on their seeds; go test -fuzz explores further, e.g.
+//
+//	go test -fuzz=FuzzMoves ./game
+
+// fuzzBoardSize keeps boards small so the fuzzer reaches captures, ko and scoring quickly
+const fuzzBoardSize = 5
+
+// FuzzMoves plays every byte as a move on one board, so the fuzzer can explore
+// whole games: 0xff passes, anything else is a position
+func FuzzMoves(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{12, 0xff, 0xff})                 // A stone, then both pass
+	f.Add([]byte{1, 0, 5, 6, 0xff, 10})           // Black captures in the corner
+	f.Add([]byte{1, 2, 5, 8, 7, 12, 11, 6, 2, 7}) // Ko fights on a small board
+	f.Add([]byte{200, 25, 24, 24, 0})             // Out of bounds and occupied points
+	f.Fuzz(func(t *testing.T, data []byte) {
+		b := NewBoard(fuzzBoardSize)
+		if err := b.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, c := range data {
+			if b.Phase != PhasePlaying {
+				break
+			}
+			switch {
+			case c == 0xff:
+				b.Pass()
+			case int(c) < b.Size*b.Size: // The move endpoint checks the bounds before the engine
+				b.MakeMove(int(c))
+			}
+			checkInvariants(t, b)
+		}
+	})
+}
+
+// FuzzParseCoordinate reads coordinates in standard notation, and writes back those it accepts
+func FuzzParseCoordinate(f *testing.F) {
+	for _, coordinate := range []string{"D4", "q16", " T19 ", "pass", "I5", "J5", "A0", "A20", "Z", "", "D+4"} {
+		f.Add(coordinate, 19)
+	}
+	f.Add("E5", 9)
+	f.Add("Z25", 25)
+	f.Fuzz(func(t *testing.T, coordinate string, size int) {
+		if size < 1 || size > len(ColumnLetters) {
+			return
+		}
+
+		position, err := ParseCoordinate(coordinate, size)
+		switch {
+		case err != nil || position == -1:
+			return
+		case position < 0 || position >= size*size:
+			t.Fatalf("%q is position %d, outside a %dx%d board", coordinate, position, size, size)
+		}
+		written := FormatCoordinate(position, size)
+		if again, err := ParseCoordinate(written, size); err != nil || again != position {
+			t.Fatalf("%q is position %d, written %q, which reads as %d (%v)", coordinate, position, written, again, err)
+		}
+	})
+}
+
+// checkInvariants fails if the engine left the board in an impossible state
+func checkInvariants(t *testing.T, b *Board) {
+	t.Helper()
+	for pos, stone := range b.Grid {
+		if stone < 0 || stone > 2 {
+			t.Fatalf("unknown stone color %d at %d", stone, pos)
+		}
+		if stone != 0 && b.GetLiberties(b.GetGroup(pos)) == 0 {
+			t.Fatalf("group without liberties left at %d", pos)
+		}
+	}
+	if b.CurrentPlayer != 1 && b.CurrentPlayer != 2 {
+		t.Fatalf("invalid player to move %d", b.CurrentPlayer)
+	}
+}
@@ -0,0 +1,108 @@
+package game
+
+// Hidden-information variants
+// The board always holds the true position; players get a filtered view of it
+// while the game is being played, and see everything again once play has stopped
+
+// HiddenPosition replaces the position of a move the viewer is not allowed to see
+const HiddenPosition = -2
+
+// Concealed checks if the board currently hides information from the players
+func (b *Board) Concealed() bool {
+	hidden := b.Variant == VariantOneColor || b.Variant == VariantPhantom
+	return hidden && (b.Phase == PhaseSetup || b.Phase == PhasePlaying)
+}
+
+// revealStone tells the current player in phantom go that their attempted move
+// ran into an opponent stone, which they now know about
+func (b *Board) revealStone(position int) {
+	if b.Variant != VariantPhantom || b.Grid[position] != 3-b.CurrentPlayer {
+		return
+	}
+
+	for _, known := range b.Revealed[b.CurrentPlayer] {
+		if known == position {
+			return
+		}
+	}
+	b.Revealed[b.CurrentPlayer] = append(b.Revealed[b.CurrentPlayer], position)
+}
+
+// forgetRevealed drops captured stones from what the players know,
+// so a stone later played on the same point isn't shown by mistake
+func (b *Board) forgetRevealed(captured []int) {
+	if len(captured) == 0 {
+		return
+	}
+
+	for player := 1; player <= 2; player++ {
+		kept := b.Revealed[player][:0]
+		for _, known := range b.Revealed[player] {
+			if b.Grid[known] != 0 {
+				kept = append(kept, known)
+			}
+		}
+		b.Revealed[player] = kept
+	}
+}
+
+// ViewFor returns the board as a player is allowed to see it (1 = black, 2 = white,
+// anything else = spectator). Boards that don't hide anything are returned as they are;
+// otherwise the view is a filtered copy and the board itself is left untouched
+func (b *Board) ViewFor(player int) *Board {
+	if !b.Concealed() {
+		return b
+	}
+	if player != 1 && player != 2 {
+		player = 0
+	}
+
+	view := b.Clone()
+	view.Ko = nil // The previous position would give the hidden stones away
+
+	switch b.Variant {
+	case VariantOneColor:
+		// Every stone is shown as black; players have to remember whose is whose
+		for pos, stone := range view.Grid {
+			if stone != 0 {
+				view.Grid[pos] = 1
+			}
+			view.MoveNumbers[pos] = 0
+		}
+		for i := range view.MoveHistory {
+			if view.MoveHistory[i].Position >= 0 {
+				view.MoveHistory[i].Position = HiddenPosition
+				view.MoveHistory[i].CapturedPositions = []int{}
+			}
+		}
+
+	case VariantPhantom:
+		// Players only see their own stones and the opponent stones they ran into
+		known := make(map[int]bool)
+		if player != 0 {
+			for _, pos := range b.Revealed[player] {
+				known[pos] = true
+			}
+		}
+		for pos, stone := range view.Grid {
+			if stone != 0 && stone != player && !known[pos] {
+				view.Grid[pos] = 0
+				view.MoveNumbers[pos] = 0
+			}
+		}
+		for i := range view.MoveHistory {
+			// The referee announces passes and captures, but not where the opponent played
+			if view.MoveHistory[i].Player != player && view.MoveHistory[i].Position >= 0 {
+				view.MoveHistory[i].Position = HiddenPosition
+			}
+		}
+		if view.LastMove != nil && view.Grid[view.LastMove.Position] != player {
+			view.LastMove = nil
+		}
+		own := view.Revealed[player]
+		view.Revealed = [3][]int{}
+		view.Revealed[player] = own
+	}
+
+	return view
+}
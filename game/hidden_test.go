@@ -0,0 +1,131 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+// hiddenGame starts a 9x9 game of a hidden-information variant, with black on E5 (40)
+// and white on D6 (30)
+func hiddenGame(t *testing.T, variant string) *Board {
+	t.Helper()
+	b := NewBoard(9)
+	if err := b.SetVariant(variant); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for _, position := range []int{40, 30} {
+		if err := b.MakeMove(position); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b
+}
+
+// stonesOf returns the points of a grid holding a stone, with its color
+func stonesOf(grid []int) map[int]int {
+	stones := make(map[int]int)
+	for position, stone := range grid {
+		if stone != 0 {
+			stones[position] = stone
+		}
+	}
+	return stones
+}
+
+// positionsOf returns where the moves of a history were played, as a viewer sees them
+func positionsOf(moves []Move) []int {
+	positions := make([]int, len(moves))
+	for i, move := range moves {
+		positions[i] = move.Position
+	}
+	return positions
+}
+
+func TestViewForPhantom(t *testing.T) {
+	tests := []struct {
+		name    string
+		viewer  int
+		reveal  bool // Black runs into the white stone first
+		stones  map[int]int
+		history []int
+	}{
+		{name: "spectator", viewer: 0, stones: map[int]int{}, history: []int{HiddenPosition, HiddenPosition}},
+		{name: "unknown viewer is a spectator", viewer: 3, stones: map[int]int{}, history: []int{HiddenPosition, HiddenPosition}},
+		{name: "black", viewer: 1, stones: map[int]int{40: 1}, history: []int{40, HiddenPosition}},
+		{name: "white", viewer: 2, stones: map[int]int{30: 2}, history: []int{HiddenPosition, 30}},
+		{name: "black after running into white", viewer: 1, reveal: true, stones: map[int]int{40: 1, 30: 2}, history: []int{40, HiddenPosition}},
+		{name: "white doesn't learn what black ran into", viewer: 2, reveal: true, stones: map[int]int{30: 2}, history: []int{HiddenPosition, 30}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := hiddenGame(t, VariantPhantom)
+			if tt.reveal {
+				if err := b.MakeMove(30); err == nil {
+					t.Fatal("playing on the white stone succeeded")
+				}
+			}
+			grid := append([]int(nil), b.Grid...)
+
+			view := b.ViewFor(tt.viewer)
+			if got := stonesOf(view.Grid); !reflect.DeepEqual(got, tt.stones) {
+				t.Errorf("stones = %v, want %v", got, tt.stones)
+			}
+			if got := positionsOf(view.MoveHistory); !reflect.DeepEqual(got, tt.history) {
+				t.Errorf("history = %v, want %v", got, tt.history)
+			}
+			if view.Ko != nil {
+				t.Error("the view keeps the previous position")
+			}
+			if !reflect.DeepEqual(b.Grid, grid) {
+				t.Error("the view changed the board")
+			}
+		})
+	}
+}
+
+func TestViewForOneColor(t *testing.T) {
+	for _, viewer := range []int{0, 1, 2} {
+		b := hiddenGame(t, VariantOneColor)
+
+		view := b.ViewFor(viewer)
+		if got, want := stonesOf(view.Grid), map[int]int{40: 1, 30: 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("viewer %d: stones = %v, want %v", viewer, got, want)
+		}
+		if got, want := positionsOf(view.MoveHistory), []int{HiddenPosition, HiddenPosition}; !reflect.DeepEqual(got, want) {
+			t.Errorf("viewer %d: history = %v, want %v", viewer, got, want)
+		}
+	}
+}
+
+func TestViewForShowsEverythingOncePlayEnds(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant string
+	}{
+		{name: "standard game", variant: VariantStandard},
+		{name: "phantom game being scored", variant: VariantPhantom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := hiddenGame(t, tt.variant)
+			if tt.variant != VariantStandard {
+				// Both pass, so the stones are shown for scoring
+				for i := 0; i < 2; i++ {
+					if err := b.Pass(); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			for _, viewer := range []int{0, 1, 2} {
+				if view := b.ViewFor(viewer); view != b {
+					t.Errorf("viewer %d got a filtered copy", viewer)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package game
+
+import "encoding/json"
+
+// MarshalJSON serializes the board together with computed blocks clients would
+// otherwise have to work out themselves, and the position hash (see PositionHash)
+func (b *Board) MarshalJSON() ([]byte, error) {
+	// boardFields has the same fields as Board but not this method, avoiding infinite recursion
+	type boardFields Board
+
+	return json.Marshal(struct {
+		*boardFields
+		Scoring      ScoringSummary
+		PositionHash string
+	}{
+		boardFields:  (*boardFields)(b),
+		Scoring:      b.ScoringSummary(),
+		PositionHash: b.PositionHashHex(),
+	})
+}
+
+// MarshalPackedJSON serializes the board like MarshalJSON, but with the grid packed
+// in base64 (GridPacked, see PackedGrid) instead of the Grid array
+func (b *Board) MarshalPackedJSON() ([]byte, error) {
+	type boardFields Board
+
+	return json.Marshal(struct {
+		*boardFields
+		Grid         []int `json:",omitempty"` // Hides the array of the embedded board
+		GridPacked   string
+		Scoring      ScoringSummary
+		PositionHash string
+	}{
+		boardFields:  (*boardFields)(b),
+		GridPacked:   b.PackedGrid(),
+		Scoring:      b.ScoringSummary(),
+		PositionHash: b.PositionHashHex(),
+	})
+}
@@ -0,0 +1,75 @@
+package game
+
+// legalCache remembers which moves are legal for the player to move
+// It is keyed on the game version, player and phase, so any move, pass or end of play invalidates it
+type legalCache struct {
+	version int
+	player  int
+	phase   Phase
+	bits    []uint64 // Bit i is set if a stone at position i is legal
+}
+
+// valid checks if the cache still describes the current position
+func (c *legalCache) valid(b *Board) bool {
+	return c.bits != nil && c.version == b.Version() && c.player == b.CurrentPlayer && c.phase == b.Phase
+}
+
+// legalMoves returns the legal-move bitmap for the player to move, computing it if needed
+func (b *Board) legalMoves() []uint64 {
+	s := b.scratchSpace()
+	if s.legal.valid(b) {
+		return s.legal.bits
+	}
+
+	words := (len(b.Grid) + 63) / 64
+	if len(s.legal.bits) != words {
+		s.legal.bits = make([]uint64, words)
+	}
+	bits := s.legal.bits
+	for i := range bits {
+		bits[i] = 0
+	}
+
+	// Nothing is legal outside of the playing phase
+	if b.Phase == PhasePlaying {
+		for position := range b.Grid {
+			if b.IsValidMove(position) {
+				bits[position/64] |= 1 << (position % 64)
+			}
+		}
+	}
+
+	s.legal.version = b.Version()
+	s.legal.player = b.CurrentPlayer
+	s.legal.phase = b.Phase
+	return bits
+}
+
+// IsLegal checks if the player to move may place a stone at position
+// After the first call for a position it is a constant-time lookup until the next move
+func (b *Board) IsLegal(position int) bool {
+	if position < 0 || position >= len(b.Grid) {
+		return false
+	}
+	return b.legalMoves()[position/64]&(1<<(position%64)) != 0
+}
+
+// LegalMoves lists every position where the player to move may place a stone
+func (b *Board) LegalMoves() []int {
+	bits := b.legalMoves()
+	moves := make([]int, 0, len(b.Grid))
+	for position := range b.Grid {
+		if bits[position/64]&(1<<(position%64)) != 0 {
+			moves = append(moves, position)
+		}
+	}
+	return moves
+}
+
+// precomputeLegalMoves fills the legal-move cache right after a move when enabled,
+// so the next lookups (bots, hints) don't pay for it
+func (b *Board) precomputeLegalMoves() {
+	if b.PrecomputeLegalMoves {
+		b.legalMoves()
+	}
+}
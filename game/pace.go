@@ -0,0 +1,71 @@
+package game
+
+import "time"
+
+// Pace keeps statistics about how fast each player moves
+// Index 0 is unused, index 1 = black, index 2 = white
+type Pace struct {
+	// Moves counts the moves (passes included) each player made
+	Moves [3]int
+
+	// Thinking is the total time each player spent on their moves
+	Thinking [3]time.Duration
+
+	// LastMoveAt is when the previous move was made (or when the game started)
+	LastMoveAt time.Time
+
+	// LastPeriodMoves counts each player's consecutive moves that drained into
+	// (or nearly through) their last byo-yomi period
+	LastPeriodMoves [3]int
+}
+
+// Average returns a player's average time per move (0 before their first move)
+func (p Pace) Average(player int) time.Duration {
+	if p.Moves[player] == 0 {
+		return 0
+	}
+	return p.Thinking[player] / time.Duration(p.Moves[player])
+}
+
+// lastPeriodMargin is how much of the last byo-yomi period a move must use to count as draining it
+const lastPeriodMargin = 0.8
+
+// recordPace adds a move made at now to the statistics
+func (b *Board) recordPace(player int, now time.Time, drainedLastPeriod bool) {
+	if !b.Pace.LastMoveAt.IsZero() {
+		b.Pace.Thinking[player] += now.Sub(b.Pace.LastMoveAt)
+	}
+	b.Pace.Moves[player]++
+	b.Pace.LastMoveAt = now
+
+	if drainedLastPeriod {
+		b.Pace.LastPeriodMoves[player]++
+	} else {
+		b.Pace.LastPeriodMoves[player] = 0
+	}
+}
+
+// drainedLastPeriod checks if a move that took elapsed left the player in their last
+// byo-yomi period, either by using up the periods before it or by nearly using it up
+// mainBefore and periodsBefore are what the player had when the move started
+func (c *Clock) drainedLastPeriod(player int, elapsed, mainBefore time.Duration, periodsBefore int) bool {
+	if c.ByoYomiTime <= 0 || c.Remaining[player] > 0 || c.PeriodsLeft[player] != 1 {
+		return false
+	}
+	if periodsBefore > 1 {
+		return true
+	}
+
+	inPeriod := (elapsed - mainBefore) % c.ByoYomiTime
+	return inPeriod >= time.Duration(lastPeriodMargin*float64(c.ByoYomiTime))
+}
+
+// TimeToExpiry returns how long a player can still think before losing on time,
+// main time and every byo-yomi period included
+func (c *Clock) TimeToExpiry(player int, now time.Time) time.Duration {
+	total := c.Remaining[player] + time.Duration(c.PeriodsLeft[player])*c.ByoYomiTime
+	if c.Running != player {
+		return total
+	}
+	return max(0, total-c.elapsed(now))
+}
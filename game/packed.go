@@ -0,0 +1,54 @@
+package game
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// PackGrid encodes a grid in 2 bits per intersection, four intersections per byte
+// starting with the low bits (0 = empty, 1 = black, 2 = white)
+// A 19x19 grid takes 91 bytes instead of the ~700 of its JSON array
+func PackGrid(grid []int) []byte {
+	packed := make([]byte, (len(grid)+3)/4)
+	for pos, stone := range grid {
+		packed[pos/4] |= byte(stone&3) << (2 * (pos % 4))
+	}
+	return packed
+}
+
+// UnpackGrid decodes a grid of size x size intersections packed by PackGrid
+func UnpackGrid(packed []byte, size int) ([]int, error) {
+	points := size * size
+	if size <= 0 || len(packed) != (points+3)/4 {
+		return nil, fmt.Errorf("packed grid has %d bytes, expected %d for a %dx%d board", len(packed), (points+3)/4, size, size)
+	}
+
+	grid := make([]int, points)
+	for pos := range grid {
+		stone := int(packed[pos/4]>>(2*(pos%4))) & 3
+		if stone == 3 {
+			return nil, fmt.Errorf("invalid stone at position %d", pos)
+		}
+		grid[pos] = stone
+	}
+
+	// Padding bits after the last intersection must be zero
+	if extra := points % 4; extra != 0 && packed[len(packed)-1]>>(2*extra) != 0 {
+		return nil, fmt.Errorf("packed grid has trailing data")
+	}
+	return grid, nil
+}
+
+// PackedGrid returns the grid packed by PackGrid in base64, the form used in the API
+func (b *Board) PackedGrid() string {
+	return base64.StdEncoding.EncodeToString(PackGrid(b.Grid))
+}
+
+// ParsePackedGrid decodes the base64 form returned by PackedGrid
+func ParsePackedGrid(encoded string, size int) ([]int, error) {
+	packed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("packed grid is not valid base64: %w", err)
+	}
+	return UnpackGrid(packed, size)
+}
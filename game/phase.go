@@ -0,0 +1,120 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// Phase is the stage a game is in
+// Each phase only allows its own actions, so the server can reject e.g. moves during scoring
+type Phase string
+
+// Game phases in the order a game normally goes through them
+const (
+	PhaseSetup    Phase = "setup"    // Game created but not started yet
+	PhasePlaying  Phase = "playing"  // Players are placing stones and passing
+	PhaseScoring  Phase = "scoring"  // Both players passed, the score is being settled
+	PhaseFinished Phase = "finished" // The game has a result, nothing can change anymore
+)
+
+// phaseTransitions lists the phases that can be entered from each phase
+var phaseTransitions = map[Phase][]Phase{
+	PhaseSetup:    {PhasePlaying, PhaseFinished},
+	PhasePlaying:  {PhaseScoring, PhaseFinished},
+	PhaseScoring:  {PhasePlaying, PhaseFinished}, // Play resumes if the players disagree on the score
+	PhaseFinished: {},
+}
+
+// CanTransition checks if the game is allowed to move from its current phase to next
+func (b *Board) CanTransition(next Phase) bool {
+	for _, allowed := range phaseTransitions[b.Phase] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// setPhase moves the game to the next phase if the transition is allowed
+func (b *Board) setPhase(next Phase) error {
+	if !b.CanTransition(next) {
+		return fmt.Errorf("cannot go from %s to %s phase", b.Phase, next)
+	}
+
+	b.Phase = next
+	return nil
+}
+
+// requirePhase returns an error unless the game is in the given phase
+func (b *Board) requirePhase(phase Phase) error {
+	if b.Phase != phase {
+		return fmt.Errorf("not allowed during the %s phase", b.Phase)
+	}
+	return nil
+}
+
+// Start moves the game from setup into play and starts the clock, if any
+func (b *Board) Start() error {
+	if err := b.setPhase(PhasePlaying); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	b.Pace.LastMoveAt = now
+	if b.Clock != nil {
+		b.Clock.Start(b.CurrentPlayer, now)
+	}
+	return nil
+}
+
+// Conclude ends a game rebuilt from a record with the result the record gives,
+// so imported history reads as finished games
+func (b *Board) Conclude(result *Result) error {
+	if b.Phase != PhasePlaying && b.Phase != PhaseScoring {
+		return fmt.Errorf("concluding is not allowed during the %s phase", b.Phase)
+	}
+	return b.finish(result)
+}
+
+// Annul ends a game nobody wants to finish without a winner; it is never rated
+// Allowed at any point before the game has a result
+func (b *Board) Annul() error {
+	if b.Phase == PhaseFinished {
+		return fmt.Errorf("the game is over")
+	}
+	return b.finish(&Result{Reason: ReasonAnnulled})
+}
+
+// AnnulResult voids the result of a finished game, e.g. when cheating comes to light
+// afterwards; the game stays finished, with no winner, and is never rated
+func (b *Board) AnnulResult() error {
+	if b.Phase != PhaseFinished {
+		return fmt.Errorf("the game isn't over")
+	}
+	if b.Annulled() {
+		return fmt.Errorf("the result is already annulled")
+	}
+	b.Result = &Result{Reason: ReasonAnnulled}
+	return nil
+}
+
+// Annulled checks if the game was annulled instead of played out
+func (b *Board) Annulled() bool {
+	return b.Result != nil && b.Result.Reason == ReasonAnnulled
+}
+
+// finish ends the game with the given result and stops the clock
+func (b *Board) finish(result *Result) error {
+	if err := b.setPhase(PhaseFinished); err != nil {
+		return err
+	}
+
+	if b.Clock != nil {
+		b.Clock.Stop()
+	}
+	if b.Consultation != nil {
+		b.Consultation.Active, b.Consultation.Since = 0, time.Time{}
+	}
+	b.Result = result
+	return nil
+}
@@ -0,0 +1,87 @@
+package game
+
+import "fmt"
+
+// Replay rebuilds the game from scratch by playing its move log again on an empty board
+// with the same settings and setup stones; clocks and scoring marks are not replayed
+func (b *Board) Replay() (*Board, error) {
+	replay := NewBoard(b.Size)
+	replay.Komi = b.Komi
+	if err := replay.SetVariant(b.Variant); err != nil {
+		return nil, err
+	}
+
+	for color := 1; color <= 2; color++ {
+		for _, position := range b.SetupStones[color] {
+			if err := replay.AddSetupStone(position, color); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if b.Phase == PhaseSetup {
+		return replay, nil
+	}
+
+	if len(b.MoveHistory) > 0 {
+		replay.CurrentPlayer = b.MoveHistory[0].Player
+	}
+	if err := replay.Start(); err != nil {
+		return nil, err
+	}
+
+	for i, move := range b.MoveHistory {
+		// Play went on after a scoring phase
+		if replay.Phase == PhaseScoring {
+			if err := replay.ResumePlay(); err != nil {
+				return nil, fmt.Errorf("move %d: %w", i+1, err)
+			}
+		}
+		if move.Player != replay.CurrentPlayer {
+			return nil, fmt.Errorf("move %d is played out of turn", i+1)
+		}
+
+		var err error
+		if move.Position < 0 {
+			err = replay.Pass()
+		} else {
+			err = replay.MakeMove(move.Position)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+	}
+	return replay, nil
+}
+
+// Divergences lists where the board differs from a replay of its move log
+// An empty list means the stored position is what the moves lead to
+func (b *Board) Divergences() ([]string, error) {
+	replay, err := b.Replay()
+	if err != nil {
+		return nil, err
+	}
+
+	differences := make([]string, 0)
+	for position := range b.Grid {
+		if b.Grid[position] != replay.Grid[position] {
+			differences = append(differences, fmt.Sprintf("%s holds %d, the moves leave %d",
+				FormatCoordinate(position, b.Size), b.Grid[position], replay.Grid[position]))
+		}
+	}
+	for i, move := range b.MoveHistory {
+		if len(move.CapturedPositions) != len(replay.MoveHistory[i].CapturedPositions) {
+			differences = append(differences, fmt.Sprintf("move %d records %d captures, replaying it captures %d",
+				i+1, len(move.CapturedPositions), len(replay.MoveHistory[i].CapturedPositions)))
+		}
+	}
+	for player := 1; player <= 2; player++ {
+		if b.CapturedStones[player] != replay.CapturedStones[player] {
+			differences = append(differences, fmt.Sprintf("player %d has %d prisoners, the moves give %d",
+				player, b.CapturedStones[player], replay.CapturedStones[player]))
+		}
+	}
+	if b.Phase == PhasePlaying && b.CurrentPlayer != replay.CurrentPlayer {
+		differences = append(differences, fmt.Sprintf("player %d is to move, the moves give player %d", b.CurrentPlayer, replay.CurrentPlayer))
+	}
+	return differences, nil
+}
@@ -0,0 +1,139 @@
+package game
+
+// Special status rulings of the Japanese rules
+// Some well-known positions are decided by precedent instead of by playing them out;
+// the scoring engine applies them so players don't have to agree on them
+
+// bentFourShapes returns the eye spaces that form a bent four in each corner of the board
+// The bend sits on the corner point, with one arm of three points and one of two
+func (b *Board) bentFourShapes() [][]int {
+	if b.Size < 3 {
+		return nil
+	}
+
+	last := b.Size - 1
+	shapes := make([][]int, 0, 8)
+
+	for _, corner := range [][2]int{{0, 0}, {0, last}, {last, 0}, {last, last}} {
+		row, col := corner[0], corner[1]
+
+		// Step away from the corner towards the middle of the board
+		dr, dc := 1, 1
+		if row == last {
+			dr = -1
+		}
+		if col == last {
+			dc = -1
+		}
+
+		// Long arm along the row, short arm along the column, and the mirrored shape
+		shapes = append(shapes, []int{
+			b.GetPosition(row, col), b.GetPosition(row, col+dc), b.GetPosition(row, col+2*dc), b.GetPosition(row+dr, col),
+		})
+		shapes = append(shapes, []int{
+			b.GetPosition(row, col), b.GetPosition(row+dr, col), b.GetPosition(row+2*dr, col), b.GetPosition(row, col+dc),
+		})
+	}
+
+	return shapes
+}
+
+// isBentFourInCorner checks if a group's only eye space is a bent four in the corner
+// Under Japanese rules such a group is dead: the attacker can remove all ko threats
+// before starting the ko, so the defender can never win it
+func (b *Board) isBentFourInCorner(group []int) bool {
+	color := b.GetStone(group[0])
+
+	inGroup := make(map[int]bool)
+	for _, pos := range group {
+		inGroup[pos] = true
+	}
+
+	// The eye space is everything reachable from the group's surroundings without
+	// crossing a stone of the group's color
+	space := make(map[int]bool)
+	stack := make([]int, 0)
+	for _, pos := range group {
+		for _, neighbor := range b.GetNeighbors(pos) {
+			if b.IsEmpty(neighbor) && !space[neighbor] {
+				space[neighbor] = true
+				stack = append(stack, neighbor)
+			}
+		}
+	}
+
+	for len(stack) > 0 {
+		pos := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, neighbor := range b.GetNeighbors(pos) {
+			stone := b.GetStone(neighbor)
+			if stone == color {
+				// The space must be closed off by this group alone
+				if !inGroup[neighbor] {
+					return false
+				}
+				continue
+			}
+
+			if !space[neighbor] {
+				space[neighbor] = true
+				stack = append(stack, neighbor)
+			}
+		}
+
+		if len(space) > 4 {
+			return false
+		}
+	}
+
+	// Compare the eye space against every bent four shape
+	for _, shape := range b.bentFourShapes() {
+		matches := len(space) == len(shape)
+		for _, pos := range shape {
+			if !space[pos] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RuledDeadStones returns the stones that are dead by special ruling
+// Currently this covers bent four in the corner
+func (b *Board) RuledDeadStones() []int {
+	dead := make([]int, 0)
+	visited := make(map[int]bool)
+
+	for pos := range b.Grid {
+		if b.IsEmpty(pos) || visited[pos] {
+			continue
+		}
+
+		group := b.GetGroup(pos)
+		for _, stone := range group {
+			visited[stone] = true
+		}
+
+		if b.isBentFourInCorner(group) {
+			dead = append(dead, group...)
+		}
+	}
+
+	return dead
+}
+
+// isRuledDead checks if the stone at a position is dead by special ruling
+func (b *Board) isRuledDead(position int) bool {
+	for _, dead := range b.RuledDeadStones() {
+		if dead == position {
+			return true
+		}
+	}
+	return false
+}
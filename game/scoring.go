@@ -0,0 +1,263 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultKomi is the compensation white receives for playing second
+const DefaultKomi = 6.5
+
+// Score is the result of counting a position with Japanese territory scoring
+type Score struct {
+	// Territory is the number of points surrounded by each player (index 1 = black, 2 = white)
+	// Points where dead stones were removed count as territory too
+	Territory [3]int
+
+	// Prisoners are the stones each player captured during play plus the dead stones removed at the end
+	Prisoners [3]int
+
+	// Dame is the number of neutral points that belong to nobody
+	Dame int
+
+	// Black and White are the final point totals (komi included for white)
+	Black float64
+	White float64
+}
+
+// Winner returns the player with more points (0 for a tie, which can only happen with integer komi)
+func (s Score) Winner() int {
+	switch {
+	case s.Black > s.White:
+		return 1
+	case s.White > s.Black:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Margin returns how many points the winner won by
+func (s Score) Margin() float64 {
+	if s.Black > s.White {
+		return s.Black - s.White
+	}
+	return s.White - s.Black
+}
+
+// isDead checks if a position has been marked as a dead stone
+func (b *Board) isDead(position int) bool {
+	for _, dead := range b.DeadStones {
+		if dead == position {
+			return true
+		}
+	}
+	return false
+}
+
+// ToggleDead marks the group at a position as dead, or alive again if it was already marked
+// Changing the marking withdraws any score acceptance, since the score changes too
+func (b *Board) ToggleDead(position int) error {
+	if err := b.requirePhase(PhaseScoring); err != nil {
+		return err
+	}
+
+	if position < 0 || position >= len(b.Grid) || b.IsEmpty(position) {
+		return fmt.Errorf("no stone at position %d", position)
+	}
+
+	// Special rulings can't be overturned by the players
+	if b.isRuledDead(position) {
+		return fmt.Errorf("stone at position %d is dead by special ruling (bent four in the corner)", position)
+	}
+
+	group := b.GetGroup(position)
+	if b.isDead(position) {
+		// Bring the whole group back to life
+		inGroup := make(map[int]bool)
+		for _, pos := range group {
+			inGroup[pos] = true
+		}
+
+		alive := make([]int, 0, len(b.DeadStones))
+		for _, dead := range b.DeadStones {
+			if !inGroup[dead] {
+				alive = append(alive, dead)
+			}
+		}
+		b.DeadStones = alive
+	} else {
+		b.DeadStones = append(b.DeadStones, group...)
+	}
+
+	b.ScoreAccepted = [3]bool{}
+	return nil
+}
+
+// Score counts the current position, treating the marked dead stones as captured
+func (b *Board) Score() Score {
+	score := Score{Prisoners: b.CapturedStones}
+
+	// Dead stones are removed and handed to the opponent as prisoners
+	for _, dead := range b.DeadStones {
+		score.Prisoners[3-b.GetStone(dead)]++
+	}
+
+	for pos, owner := range b.Territory() {
+		if !b.IsEmpty(pos) && !b.isDead(pos) {
+			continue
+		}
+		switch owner {
+		case 1, 2:
+			score.Territory[owner]++
+		default:
+			score.Dame++
+		}
+	}
+
+	score.Black = float64(score.Territory[1] + score.Prisoners[1])
+	score.White = float64(score.Territory[2]+score.Prisoners[2]) + b.Komi
+	return score
+}
+
+// Territory returns, for each intersection, the player whose territory it is (0 = nobody)
+// Empty points and dead stones belong to the color that alone surrounds their region;
+// living stones and neutral points are 0
+func (b *Board) Territory() []int {
+	owners := make([]int, len(b.Grid))
+
+	// Flood fill every region of empty (or dead) points and see who surrounds it
+	visited := make(map[int]bool)
+	for start := range b.Grid {
+		if visited[start] || (!b.IsEmpty(start) && !b.isDead(start)) {
+			continue
+		}
+
+		region := []int{}
+		borders := [3]bool{} // Which colors of living stones touch the region
+		stack := []int{start}
+		visited[start] = true
+
+		for len(stack) > 0 {
+			pos := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			region = append(region, pos)
+
+			for _, neighbor := range b.GetNeighbors(pos) {
+				if !b.IsEmpty(neighbor) && !b.isDead(neighbor) {
+					borders[b.GetStone(neighbor)] = true
+				} else if !visited[neighbor] {
+					visited[neighbor] = true
+					stack = append(stack, neighbor)
+				}
+			}
+		}
+
+		// Regions touching both colors (or none) are neutral
+		owner := 0
+		if borders[1] && !borders[2] {
+			owner = 1
+		} else if borders[2] && !borders[1] {
+			owner = 2
+		}
+		for _, pos := range region {
+			owners[pos] = owner
+		}
+	}
+
+	return owners
+}
+
+// AcceptScore records that a player agrees with the current dead stone marking
+// The game only finishes once both players have accepted the same score
+func (b *Board) AcceptScore(player int) error {
+	if err := b.requirePhase(PhaseScoring); err != nil {
+		return err
+	}
+
+	if player != 1 && player != 2 {
+		return fmt.Errorf("invalid player %d", player)
+	}
+
+	b.ScoreAccepted[player] = true
+	if !b.ScoreAccepted[1] || !b.ScoreAccepted[2] {
+		return nil
+	}
+
+	score := b.Score()
+	return b.finish(&Result{Winner: score.Winner(), Reason: ReasonScore, Margin: score.Margin()})
+}
+
+// ForceScore ends a game with the count of the current position and dead stone marking,
+// without waiting for the players to accept it, for games stuck in play or scoring
+func (b *Board) ForceScore() error {
+	if b.Phase != PhasePlaying && b.Phase != PhaseScoring {
+		return fmt.Errorf("scoring is not allowed during the %s phase", b.Phase)
+	}
+
+	score := b.Score()
+	return b.finish(&Result{Winner: score.Winner(), Reason: ReasonScore, Margin: score.Margin()})
+}
+
+// ResumePlay returns a game in scoring to the playing phase when the players can't agree on dead stones
+// The opponent of the player who passed last moves first, as on other Go servers
+func (b *Board) ResumePlay() error {
+	if err := b.requirePhase(PhaseScoring); err != nil {
+		return err
+	}
+	b.Phase = PhasePlaying
+
+	lastPasser := b.MoveHistory[len(b.MoveHistory)-1].Player
+	b.CurrentPlayer = 3 - lastPasser
+	b.PlayResumedAt = len(b.MoveHistory)
+
+	// The marking is thrown away, it will be redone at the next scoring phase
+	b.DeadStones = make([]int, 0)
+	b.ScoreAccepted = [3]bool{}
+
+	if b.Clock != nil {
+		b.Clock.Start(b.CurrentPlayer, time.Now())
+	}
+	return nil
+}
+
+// PlayerScoring is one player's side of the scoring summary
+type PlayerScoring struct {
+	Prisoners     int     // Stones captured from the opponent, dead stones included
+	StonesOnBoard int     // Living stones of this color still on the board
+	Territory     int     // Points surrounded by this player
+	Score         float64 // Total points (komi included for white)
+}
+
+// ScoringSummary is the readable scoring block included in the game state
+// During play it is only a snapshot of the current position, as dead stones aren't marked yet
+type ScoringSummary struct {
+	Black PlayerScoring
+	White PlayerScoring
+	Dame  int
+	Komi  float64
+}
+
+// ScoringSummary counts the position and lays it out per player
+func (b *Board) ScoringSummary() ScoringSummary {
+	score := b.Score()
+	summary := ScoringSummary{
+		Black: PlayerScoring{Prisoners: score.Prisoners[1], Territory: score.Territory[1], Score: score.Black},
+		White: PlayerScoring{Prisoners: score.Prisoners[2], Territory: score.Territory[2], Score: score.White},
+		Dame:  score.Dame,
+		Komi:  b.Komi,
+	}
+
+	for pos, stone := range b.Grid {
+		if stone == 0 || b.isDead(pos) {
+			continue
+		}
+		if stone == 1 {
+			summary.Black.StonesOnBoard++
+		} else {
+			summary.White.StonesOnBoard++
+		}
+	}
+
+	return summary
+}
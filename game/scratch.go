@@ -0,0 +1,104 @@
+package game
+
+// scratch holds buffers reused across rules-engine calls, so the hot paths
+// (move validation, group and liberty searches) don't allocate on every call
+// Like the rest of Board it is not safe for concurrent use
+type scratch struct {
+	// neighbors is the precomputed neighbor table, neighbors[position] lists the adjacent positions
+	neighbors [][]int
+
+	// marks is a visited set that never needs clearing: marks[pos] == gen means visited
+	// during the current search, and starting a new search just increments gen
+	marks []uint32
+	gen   uint32
+
+	// stack is the work list for flood fills
+	stack []int
+
+	// spareKo is the previous Ko snapshot, recycled for the next one
+	spareKo []int
+
+	// legal caches the legal moves of the player to move
+	legal legalCache
+}
+
+// scratchSpace returns the board's scratch buffers, building them on first use
+// (boards decoded from JSON start without them)
+func (b *Board) scratchSpace() *scratch {
+	if b.scratch != nil && len(b.scratch.marks) == len(b.Grid) {
+		return b.scratch
+	}
+
+	s := &scratch{
+		neighbors: make([][]int, len(b.Grid)),
+		marks:     make([]uint32, len(b.Grid)),
+		stack:     make([]int, 0, len(b.Grid)),
+	}
+
+	// All neighbor lists share one backing array (at most 4 neighbors each)
+	backing := make([]int, 0, 4*len(b.Grid))
+	directions := [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	for position := range b.Grid {
+		row, col := b.GetCoordinates(position)
+		start := len(backing)
+		for _, dir := range directions {
+			newRow, newCol := row+dir[0], col+dir[1]
+			if b.IsValidPosition(newRow, newCol) {
+				backing = append(backing, b.GetPosition(newRow, newCol))
+			}
+		}
+		s.neighbors[position] = backing[start:len(backing):len(backing)]
+	}
+
+	b.scratch = s
+	return s
+}
+
+// newSearch starts a new visited set
+func (s *scratch) newSearch() uint32 {
+	s.gen++
+	if s.gen == 0 {
+		// The counter wrapped around, old stamps could collide with new ones
+		for i := range s.marks {
+			s.marks[i] = 0
+		}
+		s.gen = 1
+	}
+	return s.gen
+}
+
+// countLiberties counts the liberties of the group at position, stopping early once limit is reached
+// Used where only "none", "one" or "some" matters, without building the group
+func (b *Board) countLiberties(position int, limit int) int {
+	color := b.Grid[position]
+	s := b.scratchSpace()
+	gen := s.newSearch()
+
+	liberties := 0
+	stack := append(s.stack[:0], position)
+	s.marks[position] = gen
+
+	for len(stack) > 0 && liberties < limit {
+		pos := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, neighbor := range s.neighbors[pos] {
+			if s.marks[neighbor] == gen {
+				continue
+			}
+
+			// Stones and liberties are different points, so one visited set covers both
+			switch b.Grid[neighbor] {
+			case 0:
+				s.marks[neighbor] = gen
+				liberties++
+			case color:
+				s.marks[neighbor] = gen
+				stack = append(stack, neighbor)
+			}
+		}
+	}
+
+	s.stack = stack[:0]
+	return liberties
+}
@@ -0,0 +1,131 @@
+package game
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"slices"
+)
+
+// Seats
+// A player holds a seat with a secret token handed out when the game is created;
+// the board only keeps a hash of it, so stored games and responses give no token away
+// In team games each member may hold a token too, which also holds the seat of their team
+
+// SetSeat gives a seat to whoever holds the token; it can only be done during setup
+func (b *Board) SetSeat(player int, token string) error {
+	if err := b.requirePhase(PhaseSetup); err != nil {
+		return err
+	}
+	if player != 1 && player != 2 {
+		return fmt.Errorf("invalid seat %d", player)
+	}
+	if token == "" {
+		return fmt.Errorf("seat tokens can't be empty")
+	}
+
+	b.Seats[player] = seatHash(token)
+	return nil
+}
+
+// SetMemberSeat gives the turns of a team member to whoever holds the token; it can only be done during setup
+func (b *Board) SetMemberSeat(member, token string) error {
+	if err := b.requirePhase(PhaseSetup); err != nil {
+		return err
+	}
+	if token == "" {
+		return fmt.Errorf("seat tokens can't be empty")
+	}
+
+	for player := 1; player <= 2; player++ {
+		if i := slices.Index(b.Teams[player], member); i >= 0 {
+			if len(b.MemberSeats[player]) == 0 {
+				b.MemberSeats[player] = make([]string, len(b.Teams[player]))
+			}
+			b.MemberSeats[player][i] = seatHash(token)
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not on a team", member)
+}
+
+// Seated checks if the game's seats are held by tokens; otherwise anyone may play either color
+func (b *Board) Seated() bool {
+	return b.Seats[1] != "" || b.Seats[2] != "" || b.MembersSeated()
+}
+
+// MembersSeated checks if the members of a team game hold seats, so they move with their own tokens
+func (b *Board) MembersSeated() bool {
+	return len(b.MemberSeats[1]) > 0 || len(b.MemberSeats[2]) > 0
+}
+
+// MemberOf returns the team member a token holds the seat of, and their team ("", 0 = none)
+func (b *Board) MemberOf(token string) (string, int) {
+	if token == "" {
+		return "", 0
+	}
+
+	hash := seatHash(token)
+	for player := 1; player <= 2; player++ {
+		for i, seat := range b.MemberSeats[player] {
+			if seat != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(seat)) == 1 {
+				return b.Teams[player][i], player
+			}
+		}
+	}
+	return "", 0
+}
+
+// SeatOf returns the seat a token holds, the team's for a member token (0 = none)
+func (b *Board) SeatOf(token string) int {
+	if token == "" {
+		return 0
+	}
+
+	hash := seatHash(token)
+	for player := 1; player <= 2; player++ {
+		if b.Seats[player] != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(b.Seats[player])) == 1 {
+			return player
+		}
+	}
+	_, team := b.MemberOf(token)
+	return team
+}
+
+// SitDown records the account playing a color; a seat taken by another account can't be changed
+func (b *Board) SitDown(player int, userID string) error {
+	if b.Phase == PhaseFinished {
+		return fmt.Errorf("the game is over")
+	}
+	if player != 1 && player != 2 {
+		return fmt.Errorf("invalid seat %d", player)
+	}
+	if b.Players[player] != "" && b.Players[player] != userID {
+		return fmt.Errorf("the seat is taken")
+	}
+
+	b.Players[player] = userID
+	return nil
+}
+
+// SeatOfUser returns the seat an account plays, preferring the given one if it plays both (0 = none)
+func (b *Board) SeatOfUser(userID string, prefer int) int {
+	switch {
+	case userID == "":
+		return 0
+	case (prefer == 1 || prefer == 2) && b.Players[prefer] == userID:
+		return prefer
+	case b.Players[1] == userID:
+		return 1
+	case b.Players[2] == userID:
+		return 2
+	}
+	return 0
+}
+
+// seatHash is what the board keeps of a seat token
+func seatHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,75 @@
+package game
+
+import "fmt"
+
+// GameInfo holds information about a game record, as found in the header of a game file
+type GameInfo struct {
+	BlackName string
+	WhiteName string
+	BlackRank string
+	WhiteRank string
+	Event     string
+	Round     string
+	Date      string
+	Place     string
+	Rules     string
+	Result    string // Result as written in the record, e.g. "B+3.5" or "W+R"
+	Handicap  int
+}
+
+// AddSetupStone places a stone before play starts, for handicap stones or
+// positions loaded from a game record; it can only be done during setup
+func (b *Board) AddSetupStone(position, color int) error {
+	if err := b.requirePhase(PhaseSetup); err != nil {
+		return err
+	}
+
+	if position < 0 || position >= len(b.Grid) {
+		return fmt.Errorf("setup stone out of bounds")
+	}
+	if color != 1 && color != 2 {
+		return fmt.Errorf("invalid setup stone color %d", color)
+	}
+	if !b.IsEmpty(position) {
+		return fmt.Errorf("position %d is already occupied", position)
+	}
+
+	b.Grid[position] = color
+	b.SetupStones[color] = append(b.SetupStones[color], position)
+	return nil
+}
+
+// SetHotseat makes the game a pass-and-play game, where one device plays both colors
+// Such games need no seats and are never rated; it can only be set during setup
+func (b *Board) SetHotseat() error {
+	if err := b.requirePhase(PhaseSetup); err != nil {
+		return err
+	}
+
+	switch {
+	case b.Variant == VariantPhantom:
+		return fmt.Errorf("phantom go can't be played on a shared device")
+	case b.IsTeamGame():
+		return fmt.Errorf("team games can't be played on a shared device")
+	}
+
+	b.Hotseat = true
+	return nil
+}
+
+// HandicapStones returns the number of handicap stones of the game: the recorded handicap,
+// or else the black stones placed before the game when white has none
+func (b *Board) HandicapStones() int {
+	if b.Info.Handicap > 0 {
+		return b.Info.Handicap
+	}
+	if len(b.SetupStones[2]) == 0 && len(b.SetupStones[1]) > 1 {
+		return len(b.SetupStones[1])
+	}
+	return 0
+}
+
+// IsRated checks if the result of the game counts for the players' ratings
+func (b *Board) IsRated() bool {
+	return !b.Hotseat && !b.Sandbox && !b.Annulled()
+}
@@ -0,0 +1,91 @@
+package game
+
+import "time"
+
+// Step is the game right after one move of its move log, for replays
+type Step struct {
+	Number         int // Of the move, from 1
+	Move           Move
+	Grid           []int      // Stones after the move
+	CapturedStones [3]int     // Prisoners of each player after the move (index 1 = black, 2 = white)
+	Clock          *StepClock // Time left after the move (nil for untimed games and moves recorded without it)
+}
+
+// StepClock is the time both players had left at a step of a replay
+type StepClock struct {
+	TimeLeft    [3]time.Duration
+	PeriodsLeft [3]int
+}
+
+// Steps walks the move log of a game one move at a time, from the setup stones on
+// It works on its own copy of the moves, so the board may change or go away meanwhile
+type Steps struct {
+	moves    []Move
+	grid     []int
+	captured [3]int
+	clock    *StepClock
+	next     int
+	last     Step // The game after the moves taken so far
+}
+
+// NewSteps starts walking the move log of a game
+func NewSteps(b *Board) *Steps {
+	s := &Steps{moves: b.Clone().MoveHistory, grid: make([]int, b.Size*b.Size)}
+	for color := 1; color <= 2; color++ {
+		for _, position := range b.SetupStones[color] {
+			s.grid[position] = color
+		}
+	}
+	if b.Clock != nil {
+		s.clock = &StepClock{
+			TimeLeft:    [3]time.Duration{0, b.Clock.MainTime, b.Clock.MainTime},
+			PeriodsLeft: [3]int{0, b.Clock.ByoYomiPeriods, b.Clock.ByoYomiPeriods},
+		}
+		clock := *s.clock
+		s.last.Clock = &clock
+	}
+	return s
+}
+
+// Position returns the game after the moves taken so far, without a move before the first one
+func (s *Steps) Position() Step {
+	step := s.last
+	step.Grid = append([]int(nil), s.grid...)
+	return step
+}
+
+// Len returns how many moves the game has
+func (s *Steps) Len() int {
+	return len(s.moves)
+}
+
+// Done checks if every move was taken
+func (s *Steps) Done() bool {
+	return s.next >= len(s.moves)
+}
+
+// Next plays the next move and returns the game after it; ok is false once every move was taken
+func (s *Steps) Next() (step Step, ok bool) {
+	if s.Done() {
+		return Step{}, false
+	}
+	move := s.moves[s.next]
+	s.next++
+
+	if move.Position >= 0 {
+		s.grid[move.Position] = move.Player
+	}
+	for _, position := range move.CapturedPositions {
+		s.grid[position] = 0
+	}
+	s.captured[move.Player] += len(move.CapturedPositions)
+
+	step = Step{Number: s.next, Move: move, Grid: append([]int(nil), s.grid...), CapturedStones: s.captured}
+	if s.clock != nil && (move.TimeLeft > 0 || move.PeriodsLeft > 0) {
+		s.clock.TimeLeft[move.Player], s.clock.PeriodsLeft[move.Player] = move.TimeLeft, move.PeriodsLeft
+		clock := *s.clock
+		step.Clock = &clock
+	}
+	s.last = step
+	return step, true
+}
@@ -0,0 +1,85 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Team games (rengo / pair go)
+// Each color is played by a team whose members take turns in a fixed order:
+// the first black player, the first white player, the second black player, and so on
+
+// SetTeams assigns the members of each team in the order they play; it can only be done during setup
+func (b *Board) SetTeams(black, white []string) error {
+	if err := b.requirePhase(PhaseSetup); err != nil {
+		return err
+	}
+
+	if len(black) == 0 || len(white) == 0 {
+		return fmt.Errorf("both teams need at least one player")
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string(nil), black...), white...) {
+		if name == "" {
+			return fmt.Errorf("team members need a name")
+		}
+		if seen[name] {
+			return fmt.Errorf("%q is seated more than once", name)
+		}
+		seen[name] = true
+	}
+
+	b.Teams = [3][]string{nil, append([]string(nil), black...), append([]string(nil), white...)}
+	return nil
+}
+
+// IsTeamGame checks if the colors are played by teams
+func (b *Board) IsTeamGame() bool {
+	return len(b.Teams[1]) > 0
+}
+
+// NextMover returns the team member who has to make the next move ("" outside team games)
+// Passes count as turns, so the rotation never depends on how the previous move was made
+func (b *Board) NextMover() string {
+	team := b.Teams[b.CurrentPlayer]
+	if len(team) == 0 {
+		return ""
+	}
+
+	turns := 0
+	for _, move := range b.MoveHistory {
+		if move.Player == b.CurrentPlayer {
+			turns++
+		}
+	}
+	return team[turns%len(team)]
+}
+
+// CheckMover rejects a move made out of rotation in a team game (a *MoverError)
+// An attempt by another member of the team to move is recorded in Violations
+func (b *Board) CheckMover(name string) error {
+	if !b.IsTeamGame() {
+		return nil
+	}
+
+	next := b.NextMover()
+	if name == next {
+		return nil
+	}
+
+	err := &MoverError{Expected: next}
+	if slices.Contains(b.Teams[b.CurrentPlayer], name) && b.Phase == PhasePlaying {
+		violation := Violation{
+			MoveNumber: len(b.MoveHistory) + 1,
+			Player:     b.CurrentPlayer,
+			Member:     name,
+			Expected:   next,
+			At:         time.Now(),
+		}
+		b.Violations = append(b.Violations, violation)
+		err.Violation = &violation
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"errors"
+	"time"
+)
+
+// Vacation lets correspondence players stop their clock for a while: until they come back,
+// or their vacation time runs out, the time of their turns is charged to the vacation instead
+// A vacation is the window from VacationStart to VacationStart + Vacation; the time used is
+// only taken off Vacation when it ends, so the window doesn't move while it lasts
+
+// ErrNoVacation is returned when a player with no vacation time left goes on vacation
+var ErrNoVacation = errors.New("no vacation time left")
+
+// SetVacation gives both players the same vacation time
+func (c *Clock) SetVacation(vacation time.Duration) {
+	c.Vacation = [3]time.Duration{0, vacation, vacation}
+	c.VacationStart = [3]time.Time{}
+}
+
+// StartVacation stops counting a player's time from now on
+// Going on vacation again while on vacation changes nothing
+func (c *Clock) StartVacation(player int, now time.Time) error {
+	c.settleVacation(player, now)
+	if !c.VacationStart[player].IsZero() {
+		return nil
+	}
+	if c.Vacation[player] <= 0 {
+		return ErrNoVacation
+	}
+	c.VacationStart[player] = now
+	return nil
+}
+
+// EndVacation counts a player's time again, keeping the vacation time they didn't use
+func (c *Clock) EndVacation(player int, now time.Time) {
+	if c.VacationStart[player].IsZero() {
+		return
+	}
+
+	// The running turn keeps the part of the vacation it contained
+	if c.Running == player {
+		c.TurnStart = c.TurnStart.Add(c.vacationBetween(player, c.TurnStart, now))
+	}
+	c.Vacation[player] -= min(max(0, now.Sub(c.VacationStart[player])), c.Vacation[player])
+	c.VacationStart[player] = time.Time{}
+}
+
+// OnVacation checks whether a player's time is not counting right now
+func (c *Clock) OnVacation(player int, now time.Time) bool {
+	start := c.VacationStart[player]
+	return !start.IsZero() && !now.Before(start) && now.Before(c.vacationEnd(player))
+}
+
+// VacationLeft returns how much vacation time a player has left right now
+func (c *Clock) VacationLeft(player int, now time.Time) time.Duration {
+	if c.VacationStart[player].IsZero() {
+		return c.Vacation[player]
+	}
+	return min(c.Vacation[player], max(0, c.vacationEnd(player).Sub(now)))
+}
+
+// settleVacation ends a vacation whose time has run out
+func (c *Clock) settleVacation(player int, now time.Time) {
+	if !c.VacationStart[player].IsZero() && !now.Before(c.vacationEnd(player)) {
+		c.EndVacation(player, now)
+	}
+}
+
+// vacationEnd returns when a player's current vacation runs out
+func (c *Clock) vacationEnd(player int) time.Time {
+	return c.VacationStart[player].Add(c.Vacation[player])
+}
+
+// vacationBetween returns how much of the time from one moment to another a player spent on vacation
+func (c *Clock) vacationBetween(player int, from, to time.Time) time.Duration {
+	if player == 0 || c.VacationStart[player].IsZero() {
+		return 0
+	}
+	if start := c.VacationStart[player]; start.After(from) {
+		from = start
+	}
+	if end := c.vacationEnd(player); end.Before(to) {
+		to = end
+	}
+	return max(0, to.Sub(from))
+}
+
+// elapsed returns how much of the running player's time their turn has used so far,
+// leaving out the time they were on vacation
+func (c *Clock) elapsed(now time.Time) time.Duration {
+	return now.Sub(c.TurnStart) - c.vacationBetween(c.Running, c.TurnStart, now)
+}
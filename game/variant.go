@@ -0,0 +1,74 @@
+package game
+
+import "fmt"
+
+// Game variants that change the rules or the way a game ends
+const (
+	VariantStandard = "standard"  // Regular Go
+	VariantCapture  = "capture"   // Capture Go (Atari Go): the first capture wins
+	VariantNoGo     = "nogo"      // NoGo: capturing is illegal, the first player without a legal move loses
+	VariantOneColor = "one_color" // One-color Go: all stones look the same to the players
+	VariantPhantom  = "phantom"   // Phantom Go: players don't see the opponent's stones
+)
+
+// variants lists every supported variant
+var variants = map[string]bool{
+	VariantStandard: true,
+	VariantCapture:  true,
+	VariantNoGo:     true,
+	VariantOneColor: true,
+	VariantPhantom:  true,
+}
+
+// SetVariant selects the rules variant of a game; it can only be changed during setup
+func (b *Board) SetVariant(variant string) error {
+	if err := b.requirePhase(PhaseSetup); err != nil {
+		return err
+	}
+
+	if !variants[variant] {
+		return fmt.Errorf("unknown variant %q", variant)
+	}
+
+	b.Variant = variant
+	return nil
+}
+
+// checkCaptureWin ends a capture go game as soon as the player who just moved captured something
+func (b *Board) checkCaptureWin(player int, captured []int) {
+	if b.Variant == VariantCapture && len(captured) > 0 {
+		b.finish(&Result{Winner: player, Reason: ReasonCapture})
+	}
+}
+
+// checkPassAllowed rejects passes where the variant forbids them
+// In capture go passing is only allowed when there is no legal move left
+// In NoGo running out of moves loses the game, so there is never a reason to pass
+func (b *Board) checkPassAllowed() error {
+	switch {
+	case b.Variant == VariantCapture && len(b.LegalMoves()) > 0:
+		return fmt.Errorf("passing is not allowed in capture go")
+	case b.Variant == VariantNoGo:
+		return fmt.Errorf("passing is not allowed in nogo")
+	}
+	return nil
+}
+
+// wouldCapture checks if placing a stone would capture any opponent stones
+func (b *Board) wouldCapture(position int, player int) bool {
+	opponent := 3 - player
+	for _, neighbor := range b.GetNeighbors(position) {
+		// The group's last liberty can only be the point being played
+		if b.GetStone(neighbor) == opponent && b.countLiberties(neighbor, 2) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNoMovesLeft ends a NoGo game when the player to move has no legal move: they lose
+func (b *Board) checkNoMovesLeft() {
+	if b.Variant == VariantNoGo && len(b.LegalMoves()) == 0 {
+		b.finish(&Result{Winner: 3 - b.CurrentPlayer, Reason: ReasonNoMoves})
+	}
+}
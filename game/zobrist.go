@@ -0,0 +1,59 @@
+package game
+
+import "fmt"
+
+// maxHashedPoints is the number of intersections on the largest board the hash keys cover
+const maxHashedPoints = 25 * 25
+
+// zobristKeys holds a random key per intersection and color, one for each board size
+// and one for white to move
+// They come from a fixed seed, so every server and client computes the same hashes
+var zobristKeys = func() (keys struct {
+	stones [maxHashedPoints][3]uint64
+	size   [26]uint64
+	white  uint64
+}) {
+	state := uint64(0x676f2d67616d65) // "go-game"
+	next := func() uint64 {
+		// splitmix64
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+
+	for i := range keys.stones {
+		keys.stones[i][1], keys.stones[i][2] = next(), next()
+	}
+	for i := range keys.size {
+		keys.size[i] = next()
+	}
+	keys.white = next()
+	return keys
+}()
+
+// PositionHash returns the Zobrist hash of the stones on the board, the board size
+// and the player to move
+// Equal positions always get the same hash, so it can key caches of analysis by position
+func (b *Board) PositionHash() uint64 {
+	if b.Size >= len(zobristKeys.size) || len(b.Grid) > maxHashedPoints {
+		return 0
+	}
+
+	hash := zobristKeys.size[b.Size]
+	for position, stone := range b.Grid {
+		if stone == 1 || stone == 2 {
+			hash ^= zobristKeys.stones[position][stone]
+		}
+	}
+	if b.CurrentPlayer == 2 {
+		hash ^= zobristKeys.white
+	}
+	return hash
+}
+
+// PositionHashHex returns the position hash as 16 hex digits, for clients without 64-bit integers
+func (b *Board) PositionHashHex() string {
+	return fmt.Sprintf("%016x", b.PositionHash())
+}
@@ -2,7 +2,17 @@ module go-game
 
 go 1.25.0
 
-require github.com/labstack/echo/v4 v4.15.0
+require (
+	github.com/graphql-go/graphql v0.8.1
+	github.com/labstack/echo/v4 v4.15.0
+	github.com/lib/pq v1.10.9
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	golang.org/x/crypto v0.46.0
+	golang.org/x/image v0.24.0
+	golang.org/x/net v0.48.0
+	google.golang.org/grpc v1.76.0
+	google.golang.org/protobuf v1.36.9
+)
 
 require (
 	github.com/labstack/gommon v0.4.2 // indirect
@@ -10,9 +20,9 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasttemplate v1.2.2 // indirect
-	golang.org/x/crypto v0.46.0 // indirect
-	golang.org/x/net v0.48.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
 	golang.org/x/text v0.32.0 // indirect
 	golang.org/x/time v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250804133106-a7a43d27e69b // indirect
 )
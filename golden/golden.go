@@ -0,0 +1,199 @@
+// Package golden records complete games together with the board states they went
+// through, and replays them against the rules engine to catch changes in outcome
+// Rules refactors (ko, scoring, capture handling) must keep every golden game identical
+package golden
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-game/game"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Game is a golden file: the game settings, every move with the state it led to,
+// and how the game ended
+type Game struct {
+	Name    string  `json:"name"`
+	Size    int     `json:"size"`
+	Variant string  `json:"variant,omitempty"`
+	Komi    float64 `json:"komi"`
+	Steps   []Step  `json:"steps"`
+	Final   Final   `json:"final"`
+}
+
+// Step is one move attempt and what the engine must make of it
+type Step struct {
+	Position int    `json:"position"`        // Board position, -1 for a pass
+	Error    string `json:"error,omitempty"` // Expected error for an illegal move (the board must not change)
+
+	Board    []string `json:"board"`    // Position after the move, one row per line (. = empty, X = black, O = white)
+	Captured [3]int   `json:"captured"` // Stones captured by each player so far
+}
+
+// Final is the state the game ended in
+type Final struct {
+	Phase  game.Phase   `json:"phase"`
+	Score  *game.Score  `json:"score,omitempty"`  // Count of the position once play stopped
+	Result *game.Result `json:"result,omitempty"` // Set if the game finished
+}
+
+// Record plays the moves on a new board and captures every state the game went through
+// Moves the engine rejects are recorded with their error, so illegal moves can be golden too
+func Record(name string, size int, variant string, komi float64, positions []int) (*Game, error) {
+	board, err := newBoard(size, variant, komi)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Game{Name: name, Size: size, Variant: variant, Komi: komi, Steps: make([]Step, 0, len(positions))}
+	for _, position := range positions {
+		step := Step{Position: position}
+		if err := play(board, position); err != nil {
+			step.Error = err.Error()
+		}
+		step.Board = Diagram(board)
+		step.Captured = board.CapturedStones
+		g.Steps = append(g.Steps, step)
+	}
+
+	g.Final = finalState(board)
+	return g, nil
+}
+
+// RecordBoard turns a game already played on the server (as returned by GET /game/:id)
+// into a golden game by replaying its move history
+func RecordBoard(name string, board *game.Board) (*Game, error) {
+	positions := make([]int, len(board.MoveHistory))
+	for i, move := range board.MoveHistory {
+		positions[i] = move.Position
+	}
+	return Record(name, board.Size, board.Variant, board.Komi, positions)
+}
+
+// Replay plays a golden game and returns every difference with what was recorded
+// An empty list means the engine still behaves exactly as it did
+func Replay(g *Game) ([]string, error) {
+	board, err := newBoard(g.Size, g.Variant, g.Komi)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]string, 0)
+	for i, step := range g.Steps {
+		errText := ""
+		if err := play(board, step.Position); err != nil {
+			errText = err.Error()
+		}
+
+		if errText != step.Error {
+			diffs = append(diffs, fmt.Sprintf("move %d (position %d): expected error %q, got %q", i+1, step.Position, step.Error, errText))
+		}
+		if got := Diagram(board); strings.Join(got, "\n") != strings.Join(step.Board, "\n") {
+			diffs = append(diffs, fmt.Sprintf("move %d (position %d): board differs\nexpected:\n%s\ngot:\n%s",
+				i+1, step.Position, strings.Join(step.Board, "\n"), strings.Join(got, "\n")))
+		}
+		if board.CapturedStones != step.Captured {
+			diffs = append(diffs, fmt.Sprintf("move %d (position %d): expected captures %v, got %v", i+1, step.Position, step.Captured, board.CapturedStones))
+		}
+	}
+
+	expected, _ := json.Marshal(g.Final)
+	got, _ := json.Marshal(finalState(board))
+	if string(expected) != string(got) {
+		diffs = append(diffs, fmt.Sprintf("final state differs\nexpected: %s\ngot:      %s", expected, got))
+	}
+
+	return diffs, nil
+}
+
+// Diagram draws the stones on the board, one row per line
+func Diagram(board *game.Board) []string {
+	rows := make([]string, board.Size)
+	for row := 0; row < board.Size; row++ {
+		var line strings.Builder
+		for col := 0; col < board.Size; col++ {
+			switch board.GetStone(board.GetPosition(row, col)) {
+			case 1:
+				line.WriteByte('X')
+			case 2:
+				line.WriteByte('O')
+			default:
+				line.WriteByte('.')
+			}
+		}
+		rows[row] = line.String()
+	}
+	return rows
+}
+
+// Load reads a golden file
+func Load(path string) (*Game, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Game{}
+	if err := json.Unmarshal(data, g); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return g, nil
+}
+
+// Save writes a golden file
+func Save(path string, g *Game) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Files lists the golden files in a directory, in name order
+func Files(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// newBoard creates a board with the golden game's settings, ready to play
+func newBoard(size int, variant string, komi float64) (*game.Board, error) {
+	board := game.NewBoard(size)
+	board.Komi = komi
+	if variant != "" {
+		if err := board.SetVariant(variant); err != nil {
+			return nil, err
+		}
+	}
+	if err := board.Start(); err != nil {
+		return nil, err
+	}
+	return board, nil
+}
+
+// play applies a recorded move (-1 = pass)
+func play(board *game.Board, position int) error {
+	if position == -1 {
+		return board.Pass()
+	}
+	if position < 0 || position >= board.Size*board.Size {
+		return fmt.Errorf("position out of bounds")
+	}
+	return board.MakeMove(position)
+}
+
+// finalState captures how the game ended
+func finalState(board *game.Board) Final {
+	final := Final{Phase: board.Phase, Result: board.Result}
+	if board.Phase == game.PhaseScoring || board.Phase == game.PhaseFinished {
+		score := board.Score()
+		final.Score = &score
+	}
+	return final
+}
@@ -0,0 +1,660 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-game/auth"
+	"go-game/game"
+	"go-game/store"
+	"go-game/users"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+// GraphQL: frontends ask for exactly the fields they need in one round trip
+// Queries and mutations are POSTed to /graphql (or sent as ?query= in a GET); subscriptions
+// run over a WebSocket on the same path, speaking the graphql-transport-ws protocol
+// Players act with the seatToken argument, or the account they are signed in with
+
+// GraphQL limits
+const (
+	maxGraphQLGames = 100 // Most games a games query returns
+)
+
+// gqlGame is a game as resolved by GraphQL: a copy of the board, as the asking player may see it
+type gqlGame struct {
+	ID    string
+	Board *game.Board
+}
+
+// gqlUpdate is an event of a subscribed game
+type gqlUpdate struct {
+	Event Event
+	Game  *gqlGame // After the event, if it changed the board (nil otherwise)
+}
+
+// gqlCaller is who sent a GraphQL request, for resolvers that need it
+type gqlCaller struct {
+	User     auth.User // Zero if anonymous
+	IP       string
+	Admin    bool
+	SeatFrom string // Seat token of the request headers, if any
+}
+
+// gqlCallerKey is the context key of the caller
+type gqlCallerKey struct{}
+
+// callerOf returns the caller of a GraphQL request
+func callerOf(ctx context.Context) gqlCaller {
+	caller, _ := ctx.Value(gqlCallerKey{}).(gqlCaller)
+	return caller
+}
+
+// withCaller adds the caller of an HTTP request to a context
+func withCaller(ctx context.Context, c echo.Context) context.Context {
+	user, _ := currentUser(c)
+	return context.WithValue(ctx, gqlCallerKey{}, gqlCaller{User: user, IP: c.RealIP(), Admin: isAdmin(c), SeatFrom: seatToken(c)})
+}
+
+// Shared types
+var (
+	gqlColor = graphql.NewEnum(graphql.EnumConfig{
+		Name:        "Color",
+		Description: "Color of a stone or player",
+		Values: graphql.EnumValueConfigMap{
+			"NONE":  {Value: 0},
+			"BLACK": {Value: 1},
+			"WHITE": {Value: 2},
+		},
+	})
+
+	gqlMove = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Move",
+		Description: "A stone played or a pass",
+		Fields: graphql.Fields{
+			"player":   {Type: graphql.NewNonNull(gqlColor)},
+			"position": {Type: graphql.NewNonNull(graphql.Int), Description: "-1 for a pass"},
+			"captured": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Int))), Resolve: func(p graphql.ResolveParams) (any, error) {
+				return nonNilInts(p.Source.(game.Move).CapturedPositions), nil
+			}},
+		},
+	})
+
+	gqlResult = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Result",
+		Description: "How a finished game ended",
+		Fields: graphql.Fields{
+			"winner": {Type: graphql.NewNonNull(gqlColor), Description: "NONE for a draw or void game"},
+			"reason": {Type: graphql.NewNonNull(graphql.String)},
+			"margin": {Type: graphql.NewNonNull(graphql.Float)},
+			"text": {Type: graphql.NewNonNull(graphql.String), Description: "Short form, e.g. \"B+3.5\"", Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(*game.Result).String(), nil
+			}},
+		},
+	})
+
+	gqlPlayer = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Player",
+		Description: "Public profile of an account",
+		Fields: graphql.Fields{
+			"id":          {Type: graphql.NewNonNull(graphql.ID)},
+			"username":    {Type: graphql.NewNonNull(graphql.String)},
+			"displayName": {Type: graphql.NewNonNull(graphql.String)},
+			"rank":        {Type: graphql.String},
+			"createdAt":   {Type: graphql.NewNonNull(graphql.DateTime)},
+		},
+	})
+)
+
+// gqlGameType describes a game; the board fields resolve on the copy in gqlGame
+var gqlGameType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Game",
+	Description: "A game as the asking player may see it",
+	Fields: graphql.Fields{
+		"id":              {Type: graphql.NewNonNull(graphql.ID), Resolve: gameField(func(g *gqlGame) any { return g.ID })},
+		"size":            {Type: graphql.NewNonNull(graphql.Int), Resolve: boardField(func(b *game.Board) any { return b.Size })},
+		"phase":           {Type: graphql.NewNonNull(graphql.String), Resolve: boardField(func(b *game.Board) any { return string(b.Phase) })},
+		"variant":         {Type: graphql.NewNonNull(graphql.String), Resolve: boardField(func(b *game.Board) any { return b.Variant })},
+		"komi":            {Type: graphql.NewNonNull(graphql.Float), Resolve: boardField(func(b *game.Board) any { return b.Komi })},
+		"currentPlayer":   {Type: graphql.NewNonNull(gqlColor), Resolve: boardField(func(b *game.Board) any { return b.CurrentPlayer })},
+		"grid":            {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(gqlColor))), Description: "One entry per intersection, row*size + col", Resolve: boardField(func(b *game.Board) any { return b.Grid })},
+		"moves":           {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(gqlMove))), Resolve: boardField(func(b *game.Board) any { return b.MoveHistory })},
+		"moveCount":       {Type: graphql.NewNonNull(graphql.Int), Resolve: boardField(func(b *game.Board) any { return len(b.MoveHistory) })},
+		"capturedByBlack": {Type: graphql.NewNonNull(graphql.Int), Resolve: boardField(func(b *game.Board) any { return b.CapturedStones[1] })},
+		"capturedByWhite": {Type: graphql.NewNonNull(graphql.Int), Resolve: boardField(func(b *game.Board) any { return b.CapturedStones[2] })},
+		"result": {Type: gqlResult, Description: "Null while the game is in progress", Resolve: boardField(func(b *game.Board) any {
+			if b.Result == nil {
+				return nil
+			}
+			return b.Result
+		})},
+		"blackName": {Type: graphql.NewNonNull(graphql.String), Resolve: boardField(func(b *game.Board) any { return b.Info.BlackName })},
+		"whiteName": {Type: graphql.NewNonNull(graphql.String), Resolve: boardField(func(b *game.Board) any { return b.Info.WhiteName })},
+		"blackRank": {Type: graphql.NewNonNull(graphql.String), Resolve: boardField(func(b *game.Board) any { return b.Info.BlackRank })},
+		"whiteRank": {Type: graphql.NewNonNull(graphql.String), Resolve: boardField(func(b *game.Board) any { return b.Info.WhiteRank })},
+		"black":     {Type: gqlPlayer, Description: "Account playing black, if any", Resolve: seatPlayer(1)},
+		"white":     {Type: gqlPlayer, Description: "Account playing white, if any", Resolve: seatPlayer(2)},
+		"spectators": {Type: graphql.NewNonNull(graphql.Int), Description: "Spectators following live", Resolve: gameField(func(g *gqlGame) any {
+			return liveSpectators(g.ID)
+		})},
+	},
+})
+
+// gqlUpdateType describes an event of a subscribed game
+var gqlUpdateType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "GameUpdate",
+	Description: "An event of a game, with the game after it if it changed the board",
+	Fields: graphql.Fields{
+		"type": {Type: graphql.NewNonNull(graphql.String), Description: "Event type, as in the event log", Resolve: func(p graphql.ResolveParams) (any, error) {
+			return p.Source.(*gqlUpdate).Event.Type, nil
+		}},
+		"seq": {Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return int(p.Source.(*gqlUpdate).Event.Seq), nil
+		}},
+		"time": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return p.Source.(*gqlUpdate).Event.Time, nil
+		}},
+		"game": {Type: gqlGameType, Resolve: func(p graphql.ResolveParams) (any, error) {
+			if update := p.Source.(*gqlUpdate); update.Game != nil {
+				return update.Game, nil
+			}
+			return nil, nil
+		}},
+	},
+})
+
+// gameField resolves a field of a game
+func gameField(get func(*gqlGame) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source.(*gqlGame)), nil
+	}
+}
+
+// boardField resolves a field of a game's board
+func boardField(get func(*game.Board) any) graphql.FieldResolveFn {
+	return gameField(func(g *gqlGame) any { return get(g.Board) })
+}
+
+// seatPlayer resolves the account playing a color
+func seatPlayer(player int) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		userID := p.Source.(*gqlGame).Board.Players[player]
+		if userID == "" {
+			return nil, nil
+		}
+		user, err := userStore.Get(p.Context, userID)
+		if errors.Is(err, users.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return user.Profile(), nil
+	}
+}
+
+// nonNilInts returns an empty list instead of nil, for non-null list fields
+func nonNilInts(values []int) []int {
+	if values == nil {
+		return []int{}
+	}
+	return values
+}
+
+// viewGame copies a live game as a player may see it, falling back to the store
+// Hidden stones are only shown to the holder of the player's seat token, or their account
+func viewGame(ctx context.Context, gameID string, player int, token, userID string) (*gqlGame, error) {
+	board, unlock, exists := lockGame(gameID)
+	if exists {
+		view := board.ViewFor(viewerFor(board, token, userID, player)).Clone()
+		unlock()
+		return &gqlGame{ID: gameID, Board: view}, nil
+	}
+
+	stored, err := gameStore.LoadGame(ctx, gameID)
+	if errors.Is(err, store.ErrCorrupted) {
+		log.Printf("loading game %s: %v", gameID, err)
+		return nil, errors.New("the stored game failed its integrity check")
+	}
+	if err != nil {
+		return nil, nil // Null, as GraphQL answers missing objects
+	}
+	return &gqlGame{ID: gameID, Board: stored.ViewFor(viewerFor(stored, token, userID, player))}, nil
+}
+
+// Root types
+var (
+	gqlQuery = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"game": {
+				Type:        gqlGameType,
+				Description: "A game, hiding what the player may not see",
+				Args: graphql.FieldConfigArgument{
+					"id":        {Type: graphql.NewNonNull(graphql.ID)},
+					"player":    {Type: gqlColor, DefaultValue: 0, Description: "Player looking at the game"},
+					"seatToken": {Type: graphql.String, Description: "Token of the player's seat, unless sent in X-Seat-Token or looked at with the account"},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					view, err := viewGame(p.Context, p.Args["id"].(string), p.Args["player"].(int), seatTokenArg(p), callerOf(p.Context).User.ID)
+					if view == nil {
+						return nil, err
+					}
+					return view, nil
+				},
+			},
+			"games": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(gqlGameType))),
+				Description: "Games, most recently updated first, as spectators see them",
+				Args: graphql.FieldConfigArgument{
+					"limit":  {Type: graphql.Int, DefaultValue: defaultGamePageSize},
+					"offset": {Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					limit, offset := p.Args["limit"].(int), p.Args["offset"].(int)
+					if limit <= 0 || limit > maxGraphQLGames || offset < 0 {
+						return nil, errors.New("invalid limit or offset")
+					}
+					records, err := gameStore.ListGames(p.Context, limit, offset)
+					if err != nil {
+						return nil, errors.New("failed to list games")
+					}
+					list := make([]*gqlGame, 0, len(records))
+					for _, record := range records {
+						if !record.Corrupted {
+							list = append(list, &gqlGame{ID: record.ID, Board: record.Board.ViewFor(0)})
+						}
+					}
+					return list, nil
+				},
+			},
+			"player": {
+				Type:        gqlPlayer,
+				Description: "Public profile of an account",
+				Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					user, err := userStore.Get(p.Context, p.Args["id"].(string))
+					if errors.Is(err, users.ErrNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					return user.Profile(), nil
+				},
+			},
+		},
+	})
+
+	gqlMutation = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"makeMove": {
+				Type:        graphql.NewNonNull(gqlGameType),
+				Description: "Play for the player to move; returns the game as they see it",
+				Args: graphql.FieldConfigArgument{
+					"gameId":     {Type: graphql.NewNonNull(graphql.ID)},
+					"coordinate": {Type: graphql.String, Description: "Standard notation (\"D4\", \"pass\"); used instead of position if set"},
+					"position":   {Type: graphql.Int},
+					"pass":       {Type: graphql.Boolean, DefaultValue: false},
+					"member":     {Type: graphql.String, Description: "Team member making the move (team games whose members hold no seats)"},
+					"seatToken":  {Type: graphql.String, Description: "Token of the seat, unless sent in X-Seat-Token or played with the account"},
+				},
+				Resolve: resolveMakeMove,
+			},
+		},
+	})
+
+	gqlSubscription = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"gameUpdated": {
+				Type:        graphql.NewNonNull(gqlUpdateType),
+				Description: "The events of a game as they happen",
+				Args: graphql.FieldConfigArgument{
+					"id":        {Type: graphql.NewNonNull(graphql.ID)},
+					"player":    {Type: gqlColor, DefaultValue: 0, Description: "Seat to watch as (needs its token or account)"},
+					"seatToken": {Type: graphql.String},
+				},
+				Subscribe: subscribeGame,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source, nil
+				},
+			},
+		},
+	})
+)
+
+// graphQLSchema is the schema of /graphql
+var graphQLSchema = func() graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: gqlQuery, Mutation: gqlMutation, Subscription: gqlSubscription})
+	if err != nil {
+		panic(err) // The schema is fixed, so this is a programming error
+	}
+	return schema
+}()
+
+// seatTokenArg returns the seat token of an argument, or else of the request
+func seatTokenArg(p graphql.ResolveParams) string {
+	if token, ok := p.Args["seatToken"].(string); ok && token != "" {
+		return token
+	}
+	return callerOf(p.Context).SeatFrom
+}
+
+// resolveMakeMove plays a move, as POST /game/:id/move does
+func resolveMakeMove(p graphql.ResolveParams) (any, error) {
+	caller := callerOf(p.Context)
+	if !caller.Admin {
+		if allowed, _ := moveRateLimit.allow(caller.IP, caller.User.ID, time.Now()); !allowed {
+			return nil, errors.New("too many requests, slow down")
+		}
+	}
+
+	gameID := p.Args["gameId"].(string)
+	moveReq := MoveRequest{Pass: p.Args["pass"].(bool)}
+	moveReq.Coordinate, _ = p.Args["coordinate"].(string)
+	moveReq.Position, _ = p.Args["position"].(int)
+	moveReq.Player, _ = p.Args["member"].(string)
+
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return nil, errors.New("game not found")
+	}
+	defer unlock()
+
+	// Only the player to move may, with the token of their seat
+	mover := board.CurrentPlayer
+	if err := checkSeat(board, seatTokenArg(p), caller.User.ID, mover); err != nil {
+		return nil, err
+	}
+	moveReq.Player = teamMember(board, seatTokenArg(p), moveReq.Player)
+	if err := playMove(p.Context, gameID, board, moveReq); err != nil {
+		return nil, err
+	}
+	return &gqlGame{ID: gameID, Board: board.ViewFor(mover).Clone()}, nil
+}
+
+// subscribeGame follows a game for the gameUpdated subscription, until the context ends
+func subscribeGame(p graphql.ResolveParams) (any, error) {
+	gameID, player := p.Args["id"].(string), p.Args["player"].(int)
+
+	// Follow the game before checking the seat, so no event falls in between
+	events := hub.Subscribe()
+	hub.Follow(events, gameID)
+
+	board, unlock, exists := lockGame(gameID)
+	var err error
+	if exists {
+		if player != 0 {
+			err = checkSeat(board, seatTokenArg(p), callerOf(p.Context).User.ID, player)
+		}
+		unlock()
+	}
+	if !exists || err != nil {
+		hub.Unsubscribe(events)
+		if err == nil {
+			err = errors.New("game not found")
+		}
+		return nil, err
+	}
+
+	updates := make(chan any)
+	go func() {
+		defer close(updates)
+		defer hub.Unsubscribe(events)
+
+		for {
+			select {
+			case <-p.Context.Done():
+				return
+			case <-stopping:
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if event.GameID != gameID || (player != 0 && spectatorOnlyEvents[event.Type]) {
+					continue // Lobby events, or not for players
+				}
+
+				update := &gqlUpdate{Event: event}
+				if changesBoard(event.Type) {
+					if update.Game, err = viewGame(p.Context, gameID, player, seatTokenArg(p), callerOf(p.Context).User.ID); err != nil {
+						return
+					}
+				}
+				select {
+				case updates <- update:
+				case <-p.Context.Done():
+					return
+				}
+				if event.Type == EventGameDeleted {
+					return
+				}
+			}
+		}
+	}()
+	return updates, nil
+}
+
+// GraphQLRequest is a GraphQL operation
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// Run a GraphQL query or mutation, or open a WebSocket for subscriptions
+func serveGraphQL(c echo.Context) error {
+	if c.IsWebSocket() {
+		return serveGraphQLSocket(c)
+	}
+
+	var req GraphQLRequest
+	if c.Request().Method == http.MethodGet {
+		req.Query, req.OperationName = c.QueryParam("query"), c.QueryParam("operationName")
+	} else if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	if req.Query == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No query"})
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         graphQLSchema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        withCaller(c.Request().Context(), c),
+	})
+	return c.JSON(http.StatusOK, result)
+}
+
+// GraphQL over WebSocket (the graphql-transport-ws protocol of graphql-ws)
+const (
+	graphQLSocketProtocol = "graphql-transport-ws"
+	graphQLInitTimeout    = 10 * time.Second // How long a client has to send connection_init
+)
+
+// GraphQLSocketMessage is a message of the graphql-transport-ws protocol
+type GraphQLSocketMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// graphQLSocket is a GraphQL WebSocket connection and the operations running on it
+type graphQLSocket struct {
+	conn   *websocket.Conn
+	sendMu sync.Mutex
+
+	opsMu sync.Mutex
+	ops   map[string]context.CancelFunc // By operation ID
+}
+
+// serveGraphQLSocket runs the operations a client subscribes to over a WebSocket
+func serveGraphQLSocket(c echo.Context) error {
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			for _, protocol := range config.Protocol {
+				if protocol == graphQLSocketProtocol {
+					config.Protocol = []string{graphQLSocketProtocol}
+					return nil
+				}
+			}
+			return errors.New("unsupported WebSocket subprotocol")
+		},
+		Handler: func(conn *websocket.Conn) {
+			conn.MaxPayloadBytes = maxSocketMessage
+			socket := &graphQLSocket{conn: conn, ops: make(map[string]context.CancelFunc)}
+			socket.serve(withCaller(c.Request().Context(), c))
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// serve reads the client's messages until it goes away or the server stops
+func (s *graphQLSocket) serve(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer s.conn.Close()
+
+	webSocketConnections.Add(1)
+	defer webSocketConnections.Add(-1)
+
+	go func() {
+		select {
+		case <-stopping:
+			s.conn.Close() // Ends the read below
+		case <-ctx.Done():
+		}
+	}()
+
+	s.conn.SetReadDeadline(time.Now().Add(graphQLInitTimeout))
+	acknowledged := false
+	for {
+		var msg GraphQLSocketMessage
+		if err := websocket.JSON.Receive(s.conn, &msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			if acknowledged {
+				return // Only once per connection
+			}
+			acknowledged = true
+			s.conn.SetReadDeadline(time.Time{})
+			s.send(GraphQLSocketMessage{Type: "connection_ack"})
+		case "ping":
+			s.send(GraphQLSocketMessage{Type: "pong"})
+		case "pong":
+		case "subscribe":
+			if !acknowledged || msg.ID == "" {
+				return
+			}
+			var req GraphQLRequest
+			if err := json.Unmarshal(msg.Payload, &req); err != nil {
+				return
+			}
+			s.start(ctx, msg.ID, req)
+		case "complete":
+			s.stop(msg.ID)
+		default:
+			return
+		}
+	}
+}
+
+// start runs an operation, sending its results until it completes or the client stops it
+func (s *graphQLSocket) start(ctx context.Context, id string, req GraphQLRequest) {
+	s.opsMu.Lock()
+	if _, running := s.ops[id]; running {
+		s.opsMu.Unlock()
+		s.conn.Close() // The protocol forbids reusing the ID of a running operation
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.ops[id] = cancel
+	s.opsMu.Unlock()
+
+	params := graphql.Params{
+		Schema:         graphQLSchema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	}
+	go func() {
+		defer s.stop(id)
+
+		var results chan *graphql.Result
+		if isSubscription(req) {
+			results = graphql.Subscribe(params)
+		} else {
+			results = make(chan *graphql.Result, 1)
+			results <- graphql.Do(params)
+			close(results)
+		}
+
+		// Drain the results to the end, as the library sends them regardless of the context
+		completed := true
+		for result := range results {
+			if ctx.Err() != nil {
+				completed = false
+				continue
+			}
+			payload, _ := json.Marshal(result)
+			s.send(GraphQLSocketMessage{ID: id, Type: "next", Payload: payload})
+		}
+		if completed {
+			s.send(GraphQLSocketMessage{ID: id, Type: "complete"})
+		}
+	}()
+}
+
+// stop cancels an operation, if it is still running
+func (s *graphQLSocket) stop(id string) {
+	s.opsMu.Lock()
+	defer s.opsMu.Unlock()
+	if cancel, running := s.ops[id]; running {
+		cancel()
+		delete(s.ops, id)
+	}
+}
+
+// send writes a message to the client
+func (s *graphQLSocket) send(msg GraphQLSocketMessage) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	websocket.JSON.Send(s.conn, msg)
+}
+
+// isSubscription reports whether the operation of a request is a subscription
+func isSubscription(req GraphQLRequest) bool {
+	document, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return false // graphql.Do reports the syntax error
+	}
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if req.OperationName == "" || (operation.Name != nil && operation.Name.Value == req.OperationName) {
+			return operation.Operation == ast.OperationTypeSubscription
+		}
+	}
+	return false
+}
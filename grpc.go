@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"go-game/auth"
+	"go-game/pb"
+	"go-game/store"
+	"go-game/users"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC: GameService (proto/service.proto) gives programmatic clients and bots typed calls and
+// a stream of the game they watch, on GRPC_ADDR (e.g. ":9090"; no gRPC if unset)
+// It uses the certificates of the REST API, so it is served over TLS whenever the API is
+
+// grpcSeatToken is the metadata key of seat tokens, as the X-Seat-Token header
+const grpcSeatToken = "x-seat-token"
+
+// grpcServer serves GameService (nil without GRPC_ADDR)
+var grpcServer *grpc.Server
+
+// gameService implements GameService on the live games
+type gameService struct {
+	pb.UnimplementedGameServiceServer
+}
+
+// startGRPCServer serves GameService on an address, with the TLS settings of the API
+// Call it after setUpAutocert, as autocert certificates come from the Echo instance
+func startGRPCServer(e *echo.Echo, config TLSConfig, addr string) error {
+	var options []grpc.ServerOption
+	switch config.Mode {
+	case TLSFiles:
+		certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return err
+		}
+		options = append(options, grpc.Creds(credentials.NewServerTLSFromCert(&certificate)))
+	case TLSAuto:
+		options = append(options, grpc.Creds(credentials.NewTLS(&tls.Config{GetCertificate: e.AutoTLSManager.GetCertificate})))
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	grpcServer = grpc.NewServer(options...)
+	pb.RegisterGameServiceServer(grpcServer, gameService{})
+
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Printf("gRPC server on %s: %v", addr, err)
+		}
+	}()
+	return nil
+}
+
+// stopGRPCServer lets the calls in progress finish, cutting them off once ctx is done
+// Streams of watched games end as the server starts stopping
+func stopGRPCServer(ctx context.Context) {
+	if grpcServer == nil {
+		return
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
+}
+
+// grpcUser returns the user of a call's access token, if it has one
+func grpcUser(ctx context.Context) (auth.User, bool, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return auth.User{}, false, nil
+	}
+	token := strings.TrimPrefix(values[0], "Bearer ")
+	if !auth.LooksLikeToken(token) {
+		return auth.User{}, false, nil
+	}
+
+	claims, err := tokenIssuer.Verify(token, auth.KindAccess, time.Now())
+	if err != nil {
+		return auth.User{}, false, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return claims.User(), true, nil
+}
+
+// grpcSeat returns the seat token a call was made with
+func grpcSeat(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(grpcSeatToken); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// grpcClientIP returns the address a call came from, for the rate limits
+func grpcClientIP(ctx context.Context) string {
+	client, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(client.Addr.String())
+	if err != nil {
+		return client.Addr.String()
+	}
+	return host
+}
+
+// grpcLimit checks a call against a rate limit
+func grpcLimit(ctx context.Context, limit RateLimit, user auth.User) error {
+	if allowed, _ := limit.allow(grpcClientIP(ctx), user.ID, time.Now()); !allowed {
+		return status.Error(codes.ResourceExhausted, "Too many requests, slow down")
+	}
+	return nil
+}
+
+// grpcCode is the gRPC code of an HTTP status
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
+// CreateGame starts a game
+func (gameService) CreateGame(ctx context.Context, req *pb.CreateGameRequest) (*pb.CreateGameResponse, error) {
+	current, signedIn, err := grpcUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := grpcLimit(ctx, gameRateLimit, current); err != nil {
+		return nil, err
+	}
+
+	// A signed in creator plays with their account
+	var user users.User
+	if signedIn {
+		if user, signedIn, err = lookupAccount(ctx, current); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	gameID, board, tokens, unlock, err := createGame(ctx, NewGameRequest{
+		Size:                 int(req.Size),
+		MainTime:             int(req.MainTime),
+		ByoYomiTime:          int(req.ByoYomiTime),
+		ByoYomiPeriods:       int(req.ByoYomiPeriods),
+		PrecomputeLegalMoves: req.PrecomputeLegalMoves,
+		Variant:              req.Variant,
+		BlackName:            req.BlackName,
+		WhiteName:            req.WhiteName,
+		BlackRank:            req.BlackRank,
+		WhiteRank:            req.WhiteRank,
+		Hotseat:              req.Hotseat,
+		Sandbox:              req.Sandbox,
+		Color:                int(req.Color),
+	}, user, signedIn)
+	var refused *GameRequestError
+	if errors.As(err, &refused) {
+		return nil, status.Error(grpcCode(refused.Status), refused.Message)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	defer unlock()
+
+	return &pb.CreateGameResponse{
+		GameId:     gameID,
+		Board:      pb.FromBoard(board),
+		BlackToken: tokens.Colors[1],
+		WhiteToken: tokens.Colors[2],
+	}, nil
+}
+
+// GetGame returns a game, falling back to the store for games not live on this server
+func (gameService) GetGame(ctx context.Context, req *pb.GetGameRequest) (*pb.Board, error) {
+	board, unlock, exists := lockGame(req.GameId)
+	if exists {
+		defer unlock()
+	} else {
+		stored, err := gameStore.LoadGame(ctx, req.GameId)
+		if errors.Is(err, store.ErrCorrupted) {
+			log.Printf("loading game %s: %v", req.GameId, err)
+			return nil, status.Error(codes.DataLoss, "The stored game failed its integrity check")
+		}
+		if err != nil {
+			return nil, status.Error(codes.NotFound, "Game not found")
+		}
+		board = stored
+	}
+
+	// Hidden stones are only shown to the seat the call holds
+	user, _, err := grpcUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pb.FromBoard(board.ViewFor(viewerFor(board, grpcSeat(ctx), user.ID, int(req.Player)))), nil
+}
+
+// MakeMove plays for the player to move
+func (gameService) MakeMove(ctx context.Context, req *pb.MakeMoveRequest) (*pb.Board, error) {
+	user, _, err := grpcUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := grpcLimit(ctx, moveRateLimit, user); err != nil {
+		return nil, err
+	}
+	if req.Move == nil {
+		return nil, status.Error(codes.InvalidArgument, "No move given")
+	}
+
+	board, unlock, exists := lockGame(req.GameId)
+	if !exists {
+		return nil, status.Error(codes.NotFound, "Game not found")
+	}
+	defer unlock()
+
+	// Only the player to move may, with the token of their seat
+	mover := board.CurrentPlayer
+	if err := checkSeat(board, grpcSeat(ctx), user.ID, mover); err != nil {
+		return nil, status.Error(grpcCode(seatStatus(err)), err.Error())
+	}
+
+	moveReq := MoveRequest{
+		Position:   int(req.Move.Position),
+		Coordinate: req.Move.Coordinate,
+		Pass:       req.Move.Pass,
+		Player:     teamMember(board, grpcSeat(ctx), req.Move.Player),
+	}
+	if err := playMove(ctx, req.GameId, board, moveReq); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return pb.FromBoard(board.ViewFor(mover)), nil
+}
+
+// WatchGame streams the board and the events of a game
+func (gameService) WatchGame(req *pb.WatchGameRequest, stream grpc.ServerStreamingServer[pb.GameUpdate]) error {
+	ctx := stream.Context()
+	gameID, player := req.GameId, int(req.Player)
+	if player < 0 || player > 2 {
+		return status.Error(codes.InvalidArgument, "Invalid player")
+	}
+	user, _, err := grpcUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Follow the game before reading the board, so no change falls in between
+	events := hub.Subscribe()
+	defer hub.Unsubscribe(events)
+	hub.Follow(events, gameID)
+
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return status.Error(codes.NotFound, "Game not found")
+	}
+	if player != 0 {
+		err = checkSeat(board, grpcSeat(ctx), user.ID, player)
+	}
+	view := pb.FromBoard(board.ViewFor(player))
+	unlock()
+	if err != nil {
+		return status.Error(grpcCode(seatStatus(err)), err.Error())
+	}
+	if err := stream.Send(&pb.GameUpdate{Update: &pb.GameUpdate_Board{Board: view}}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-stopping:
+			return status.Error(codes.Unavailable, "The server is shutting down")
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if event.GameID != gameID || (player != 0 && spectatorOnlyEvents[event.Type]) {
+				continue // Lobby events, or not for players
+			}
+			if err := stream.Send(&pb.GameUpdate{Update: &pb.GameUpdate_Event{Event: eventMessage(event)}}); err != nil {
+				return err
+			}
+			if event.Type == EventGameDeleted {
+				return nil
+			}
+			if changesBoard(event.Type) {
+				if err := sendWatchedBoard(stream, gameID, player); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// sendWatchedBoard sends the board of a watched game as the watcher may see it
+func sendWatchedBoard(stream grpc.ServerStreamingServer[pb.GameUpdate], gameID string, player int) error {
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return nil // Taken out of memory in the meantime
+	}
+	view := pb.FromBoard(board.ViewFor(player))
+	unlock()
+	return stream.Send(&pb.GameUpdate{Update: &pb.GameUpdate_Board{Board: view}})
+}
+
+// changesBoard reports whether events of a type change the board, so followers need it again
+func changesBoard(eventType string) bool {
+	switch eventType {
+	case EventMove, EventScoring, EventPlayResumed, EventGameOver:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package gtp
+
+import (
+	"context"
+	"fmt"
+	"go-game/game"
+	"strings"
+)
+
+// Vertex converts a board position to GTP notation ("D4"; rows count from the bottom)
+// A negative position is a pass
+func Vertex(position, size int) string {
+	return game.FormatCoordinate(position, size)
+}
+
+// ParseVertex converts a GTP vertex back to a board position (-1 for a pass)
+func ParseVertex(vertex string, size int) (int, error) {
+	position, err := game.ParseCoordinate(vertex, size)
+	if err != nil {
+		return 0, fmt.Errorf("gtp: %w", err)
+	}
+	return position, nil
+}
+
+// color returns the GTP color of a player
+func color(player int) string {
+	if player == 1 {
+		return "B"
+	}
+	return "W"
+}
+
+// LoadBoard sets the engine up with the board size, komi, setup stones and every move of a game
+func (e *Engine) LoadBoard(ctx context.Context, board *game.Board) error {
+	commands := []string{
+		fmt.Sprintf("boardsize %d", board.Size),
+		"clear_board",
+		fmt.Sprintf("komi %g", board.Komi),
+	}
+	for player := 1; player <= 2; player++ {
+		for _, position := range board.SetupStones[player] {
+			commands = append(commands, fmt.Sprintf("play %s %s", color(player), Vertex(position, board.Size)))
+		}
+	}
+	for _, move := range board.MoveHistory {
+		commands = append(commands, fmt.Sprintf("play %s %s", color(move.Player), Vertex(move.Position, board.Size)))
+	}
+
+	for _, command := range commands {
+		if _, err := e.Command(ctx, command); err != nil {
+			return fmt.Errorf("%s: %w", command, err)
+		}
+	}
+	return nil
+}
+
+// FinalScore asks the engine to count the game, e.g. "B+3.5", "W+12" or "0" for a tie
+func (e *Engine) FinalScore(ctx context.Context) (string, error) {
+	return e.Command(ctx, "final_score")
+}
+
+// GenMove asks the engine to play for a player and returns the position it played
+// (-1 for a pass), or resigned = true if the engine gave up
+func (e *Engine) GenMove(ctx context.Context, player, size int) (position int, resigned bool, err error) {
+	return e.generate(ctx, "genmove", player, size)
+}
+
+// SuggestMove asks the engine what it would play without playing it (reg_genmove)
+func (e *Engine) SuggestMove(ctx context.Context, player, size int) (position int, resigned bool, err error) {
+	return e.generate(ctx, "reg_genmove", player, size)
+}
+
+// generate sends a move generation command and parses the vertex it returns
+func (e *Engine) generate(ctx context.Context, command string, player, size int) (int, bool, error) {
+	response, err := e.Command(ctx, command+" "+color(player))
+	if err != nil {
+		return 0, false, err
+	}
+	if strings.EqualFold(response, "resign") {
+		return 0, true, nil
+	}
+
+	position, err := ParseVertex(response, size)
+	return position, false, err
+}
@@ -0,0 +1,127 @@
+// Package gtp talks to external Go engines over the Go Text Protocol (version 2)
+// https://www.lysator.liu.se/~gunnar/gtp/
+package gtp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Engine is a running engine process
+// Commands are sent one at a time; an Engine is safe for concurrent use
+type Engine struct {
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// ErrEngine is wrapped by the failure responses ("? ...") of the engine
+var ErrEngine = errors.New("gtp: engine error")
+
+// Start spawns an engine, e.g. Start(ctx, "gnugo", "--mode", "gtp")
+// The process is killed when ctx is cancelled
+func Start(ctx context.Context, command string, args ...string) (*Engine, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &Engine{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// StartCommandLine spawns an engine from a single command line, e.g. "gnugo --mode gtp"
+func StartCommandLine(ctx context.Context, commandLine string) (*Engine, error) {
+	fields := strings.Fields(commandLine)
+	if len(fields) == 0 {
+		return nil, errors.New("gtp: empty engine command")
+	}
+	return Start(ctx, fields[0], fields[1:]...)
+}
+
+// Command sends a command and returns the engine's response without the "=" prefix
+// A failure response is returned as an error wrapping ErrEngine
+// If ctx ends before the engine answers, the engine is killed since its output can no
+// longer be matched to commands
+func (e *Engine) Command(ctx context.Context, command string) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, err := fmt.Fprintf(e.stdin, "%s\n", command); err != nil {
+		return "", err
+	}
+
+	type reply struct {
+		response string
+		err      error
+	}
+	done := make(chan reply, 1)
+	go func() {
+		response, err := e.readResponse()
+		done <- reply{response, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.response, r.err
+	case <-ctx.Done():
+		e.cmd.Process.Kill()
+		return "", ctx.Err()
+	}
+}
+
+// readResponse reads one response, which ends with an empty line
+func (e *Engine) readResponse() (string, error) {
+	lines := make([]string, 0, 1)
+	for {
+		line, err := e.stdout.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("gtp: reading response: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if len(lines) == 0 {
+				continue // Stray empty lines between responses
+			}
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	response := strings.Join(lines, "\n")
+	switch response[0] {
+	case '=':
+		return strings.TrimSpace(response[1:]), nil
+	case '?':
+		return "", fmt.Errorf("%w: %s", ErrEngine, strings.TrimSpace(response[1:]))
+	default:
+		return "", fmt.Errorf("gtp: malformed response %q", response)
+	}
+}
+
+// Close asks the engine to quit and waits for the process to exit
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	fmt.Fprintf(e.stdin, "quit\n")
+	e.stdin.Close()
+	return e.cmd.Wait()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"go-game/gtp"
+	"go-game/store"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Probes for orchestrators and load balancers
+// /healthz only says the process is up and serving; /readyz also checks what requests need
+// (the stores and the external engines) and turns unready while the server shuts down, so
+// traffic is drained before it goes away
+
+// Health check settings
+const (
+	readinessTimeout    = 2 * time.Second  // Bounds the store checks of a readiness probe
+	engineCheckInterval = time.Minute      // How often the external engines are started to check them
+	engineCheckTimeout  = 20 * time.Second // Startup and first answer of an engine being checked
+)
+
+// Check statuses
+const (
+	CheckOK     = "ok"
+	CheckFailed = "failed"
+)
+
+// HealthCheck is the outcome of checking one dependency
+type HealthCheck struct {
+	Status  string    `json:"status"` // See the Check* constants
+	Error   string    `json:"error,omitempty"`
+	Checked time.Time `json:"checked"`
+}
+
+// HealthResponse is the answer of a probe
+type HealthResponse struct {
+	Status string                 `json:"status"` // "ok", "unready" or "stopping"
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// Latest check of every external engine (by name), kept by the engine checker
+var (
+	engineChecksMu sync.Mutex
+	engineChecks   = make(map[string]HealthCheck)
+)
+
+// newHealthCheck turns the error of a check into its outcome
+func newHealthCheck(err error, now time.Time) HealthCheck {
+	if err != nil {
+		return HealthCheck{Status: CheckFailed, Error: err.Error(), Checked: now}
+	}
+	return HealthCheck{Status: CheckOK, Checked: now}
+}
+
+// Liveness probe
+func getHealth(c echo.Context) error {
+	return c.JSON(http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// Readiness probe: 503 while a store can't be reached, an engine doesn't start, or the server stops
+func getReadiness(c echo.Context) error {
+	select {
+	case <-stopping:
+		return c.JSON(http.StatusServiceUnavailable, HealthResponse{Status: "stopping"})
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), readinessTimeout)
+	defer cancel()
+
+	now := time.Now()
+	checks := map[string]HealthCheck{
+		"games":    newHealthCheck(store.Ping(ctx, gameStore), now),
+		"users":    newHealthCheck(pingStore(ctx, userStore), now),
+		"sessions": newHealthCheck(pingStore(ctx, sessionStore), now),
+		"chat":     newHealthCheck(pingStore(ctx, chatStore), now),
+		"ratings":  newHealthCheck(pingStore(ctx, ratingStore), now),
+		"archive":  newHealthCheck(pingStore(ctx, archiveStore), now),
+		"roster":   newHealthCheck(pingStore(ctx, rosterStore), now),
+	}
+	engineChecksMu.Lock()
+	for name, check := range engineChecks {
+		checks["engine:"+name] = check
+	}
+	engineChecksMu.Unlock()
+
+	response := HealthResponse{Status: "ok", Checks: checks}
+	for _, check := range checks {
+		if check.Status != CheckOK {
+			response.Status = "unready"
+			return c.JSON(http.StatusServiceUnavailable, response)
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// pingStore checks a store that depends on a server can be reached
+func pingStore(ctx context.Context, s any) error {
+	if pinger, ok := s.(store.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// runEngineChecker checks the external engines right away and then at every interval,
+// until the context is cancelled
+func runEngineChecker(ctx context.Context, interval time.Duration) {
+	if len(gtpEngines) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		checkEngines(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkEngines starts every external engine and asks for its protocol version
+func checkEngines(ctx context.Context) {
+	names := make([]string, 0, len(gtpEngines))
+	for name := range gtpEngines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err := checkEngine(ctx, gtpEngines[name])
+		if ctx.Err() != nil {
+			return // Shutting down, not a failure of the engine
+		}
+
+		engineChecksMu.Lock()
+		engineChecks[name] = newHealthCheck(err, time.Now())
+		engineChecksMu.Unlock()
+	}
+}
+
+// checkEngine starts an engine and waits for its first answer
+func checkEngine(ctx context.Context, commandLine string) error {
+	ctx, cancel := context.WithTimeout(ctx, engineCheckTimeout)
+	defer cancel()
+
+	engine, err := gtp.StartCommandLine(ctx, commandLine)
+	if err != nil {
+		return err
+	}
+	defer engine.Close()
+
+	_, err = engine.Command(ctx, "protocol_version")
+	return err
+}
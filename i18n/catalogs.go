@@ -0,0 +1,105 @@
+package i18n
+
+// Message keys
+const (
+	ColorBlack = "color.black"
+	ColorWhite = "color.white"
+
+	// Results; {winner} and {loser} are color names, {margin} the points won by
+	ResultScore   = "result.score"
+	ResultResign  = "result.resign"
+	ResultTime    = "result.time"
+	ResultCapture = "result.capture"
+	ResultNoMoves = "result.no_moves"
+	ResultJigo    = "result.jigo"
+	ResultVoid    = "result.void"
+	ResultAnnul   = "result.annulled"
+)
+
+// catalogs holds the messages of every supported language
+var catalogs = map[Language]map[string]string{
+	English: {
+		ColorBlack:    "Black",
+		ColorWhite:    "White",
+		ResultScore:   "{winner} wins by {margin} points",
+		ResultResign:  "{winner} wins by resignation",
+		ResultTime:    "{winner} wins on time",
+		ResultCapture: "{winner} wins by capture",
+		ResultNoMoves: "{winner} wins, {loser} has no legal move left",
+		ResultJigo:    "Draw (jigo)",
+		ResultVoid:    "No result",
+		ResultAnnul:   "Annulled",
+	},
+	Spanish: {
+		ColorBlack:    "Negro",
+		ColorWhite:    "Blanco",
+		ResultScore:   "{winner} gana por {margin} puntos",
+		ResultResign:  "{winner} gana por abandono",
+		ResultTime:    "{winner} gana por tiempo",
+		ResultCapture: "{winner} gana por captura",
+		ResultNoMoves: "{winner} gana, {loser} no tiene jugadas legales",
+		ResultJigo:    "Empate (jigo)",
+		ResultVoid:    "Sin resultado",
+		ResultAnnul:   "Anulada",
+	},
+	French: {
+		ColorBlack:    "Noir",
+		ColorWhite:    "Blanc",
+		ResultScore:   "{winner} gagne de {margin} points",
+		ResultResign:  "{winner} gagne par abandon",
+		ResultTime:    "{winner} gagne au temps",
+		ResultCapture: "{winner} gagne par capture",
+		ResultNoMoves: "{winner} gagne, {loser} n'a plus de coup légal",
+		ResultJigo:    "Égalité (jigo)",
+		ResultVoid:    "Sans résultat",
+		ResultAnnul:   "Annulée",
+	},
+	German: {
+		ColorBlack:    "Schwarz",
+		ColorWhite:    "Weiß",
+		ResultScore:   "{winner} gewinnt mit {margin} Punkten",
+		ResultResign:  "{winner} gewinnt durch Aufgabe",
+		ResultTime:    "{winner} gewinnt auf Zeit",
+		ResultCapture: "{winner} gewinnt durch Schlagen",
+		ResultNoMoves: "{winner} gewinnt, {loser} hat keinen legalen Zug mehr",
+		ResultJigo:    "Unentschieden (Jigo)",
+		ResultVoid:    "Kein Ergebnis",
+		ResultAnnul:   "Annulliert",
+	},
+	Japanese: {
+		ColorBlack:    "黒",
+		ColorWhite:    "白",
+		ResultScore:   "{winner}の{margin}目勝ち",
+		ResultResign:  "{winner}の中押し勝ち",
+		ResultTime:    "{winner}の時間切れ勝ち",
+		ResultCapture: "{winner}の取り勝ち",
+		ResultNoMoves: "{winner}の勝ち（{loser}は着手できる点がありません）",
+		ResultJigo:    "持碁",
+		ResultVoid:    "無勝負",
+		ResultAnnul:   "無効試合",
+	},
+	Korean: {
+		ColorBlack:    "흑",
+		ColorWhite:    "백",
+		ResultScore:   "{winner} {margin}집 승",
+		ResultResign:  "{winner} 불계승",
+		ResultTime:    "{winner} 시간승",
+		ResultCapture: "{winner} 따내기 승",
+		ResultNoMoves: "{winner} 승 ({loser} 둘 곳 없음)",
+		ResultJigo:    "무승부",
+		ResultVoid:    "무효",
+		ResultAnnul:   "무효 처리",
+	},
+	Chinese: {
+		ColorBlack:    "黑",
+		ColorWhite:    "白",
+		ResultScore:   "{winner}胜{margin}目",
+		ResultResign:  "{winner}中盘胜",
+		ResultTime:    "{winner}超时胜",
+		ResultCapture: "{winner}吃子胜",
+		ResultNoMoves: "{winner}胜（{loser}无子可下）",
+		ResultJigo:    "和棋",
+		ResultVoid:    "无胜负",
+		ResultAnnul:   "作废",
+	},
+}
@@ -0,0 +1,75 @@
+// Package i18n holds the translations of the messages the server writes for people,
+// and picks the language to use from what the client accepts
+package i18n
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Language is a language tag such as "en" or "ja"
+type Language string
+
+// Supported languages
+const (
+	English  Language = "en"
+	Spanish  Language = "es"
+	French   Language = "fr"
+	German   Language = "de"
+	Japanese Language = "ja"
+	Korean   Language = "ko"
+	Chinese  Language = "zh"
+)
+
+// Default is used when the client accepts none of the supported languages
+const Default = English
+
+// Supported lists every language with a catalog
+var Supported = []Language{English, Spanish, French, German, Japanese, Korean, Chinese}
+
+// Negotiate picks the best supported language from an Accept-Language header,
+// e.g. "es-CL,es;q=0.9,en;q=0.8"; regional variants fall back to their language
+func Negotiate(acceptLanguage string) Language {
+	best, bestQuality := Default, 0.0
+	for _, entry := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if lang := Language(primary); slices.Contains(Supported, lang) && quality > bestQuality {
+			best, bestQuality = lang, quality
+		}
+	}
+	return best
+}
+
+// Parse returns the supported language of a tag, and false if there is none
+func Parse(tag string) (Language, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	lang := Language(primary)
+	return lang, slices.Contains(Supported, lang)
+}
+
+// T translates a message, filling in its {placeholders} from args given as name, value pairs
+// Messages missing from a catalog are taken from the default language, unknown keys are returned as they are
+func T(lang Language, key string, args ...string) string {
+	text, ok := catalogs[lang][key]
+	if !ok {
+		if text, ok = catalogs[Default][key]; !ok {
+			return key
+		}
+	}
+
+	for i := 0; i+1 < len(args); i += 2 {
+		text = strings.ReplaceAll(text, "{"+args[i]+"}", args[i+1])
+	}
+	return text
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newGameID makes a random version 4 UUID for a new game, so games never overwrite each other
+// Must be called with gamesMu held
+func newGameID() string {
+	for {
+		var id [16]byte
+		rand.Read(id[:])
+		id[6] = id[6]&0x0f | 0x40 // Version 4
+		id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+		gameID := fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+		if _, taken := games[gameID]; !taken {
+			return gameID
+		}
+	}
+}
+
+// setGameLocation tells the client where a game it just created lives, in the Location and
+// X-Game-ID headers; the legacy responses are the bare board, which doesn't know its ID
+func setGameLocation(c echo.Context, gameID string) {
+	c.Response().Header().Set(echo.HeaderLocation, "/game/"+gameID)
+	c.Response().Header().Set(headerGameID, gameID)
+}
+
+// headerGameID carries the ID of a created game
+const headerGameID = "X-Game-ID"
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"go-game/analysis"
+	"go-game/render"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Render the current position as a PNG image (?cell= sets the line spacing in pixels)
+func getGameImage(c echo.Context) error {
+	gameID := c.Param("id")
+
+	cellSize := 0
+	if value := c.QueryParam("cell"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < render.MinCellSize || parsed > render.MaxCellSize {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid cell size"})
+		}
+		cellSize = parsed
+	}
+	style, err := imageStyle(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	// Copy the position so the game isn't locked while drawing
+	board, unlock, exists := lockGame(gameID)
+	if exists {
+		board = board.ViewFor(viewerOf(c, board)).Clone()
+		unlock()
+	}
+
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+
+	var image bytes.Buffer
+	if err := render.PNG(&image, board, cellSize, style); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.Blob(http.StatusOK, "image/png", image.Bytes())
+}
+
+// Render the current position as an SVG image for review pages
+// ?overlay= adds marks, comma separated: "numbers" (move numbers), "territory" (counted
+// territory and dead stones) and "analysis" (playout ownership estimate)
+func getGameSVG(c echo.Context) error {
+	gameID := c.Param("id")
+
+	requested := make(map[string]bool)
+	for _, overlay := range strings.Split(c.QueryParam("overlay"), ",") {
+		switch overlay = strings.TrimSpace(overlay); overlay {
+		case "":
+		case "numbers", "territory", "analysis":
+			requested[overlay] = true
+		default:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown overlay " + overlay})
+		}
+	}
+	style, err := imageStyle(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	// Copy the position so the game isn't locked while drawing
+	board, unlock, exists := lockGame(gameID)
+	if exists {
+		board = board.ViewFor(viewerOf(c, board)).Clone()
+		unlock()
+	}
+
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	if board.Concealed() && (requested["territory"] || requested["analysis"]) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not available while stones are hidden"})
+	}
+
+	overlays := render.Overlays{MoveNumbers: requested["numbers"]}
+	if requested["territory"] {
+		overlays.Territory = board.Territory()
+		overlays.DeadStones = board.DeadStones
+	}
+	if requested["analysis"] {
+		estimate, _, _, err := budgetedEstimate(c.Request().Context(), budgetUser(c), board, defaultPlayouts, defaultEstimateBudget)
+		if errors.Is(err, analysis.ErrNoPlayouts) {
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Analysis is busy, try again later"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		overlays.Ownership = estimate.Ownership
+		if !requested["territory"] {
+			overlays.DeadStones = estimate.DeadStones
+		}
+	}
+
+	var image bytes.Buffer
+	if err := render.SVG(&image, board, overlays, style); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.Blob(http.StatusOK, "image/svg+xml", image.Bytes())
+}
+
+// imageStyle reads the look of a board image from the query: ?theme= (see render.Themes),
+// ?lastMove= (the shape marking the last move) and ?markers=true (shapes on the stones,
+// so black and white differ by more than color)
+func imageStyle(c echo.Context) (render.Style, error) {
+	return render.NewStyle(c.QueryParam("theme"), c.QueryParam("lastMove"), c.QueryParam("markers") == "true")
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go-game/game"
+	"go-game/remote"
+	"go-game/sgf"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// kgsArchives is where KGS user archives are fetched from (KGS_ARCHIVES_URL, for mirrors and tests)
+var kgsArchives = cmp.Or(os.Getenv("KGS_ARCHIVES_URL"), remote.KGSArchives)
+
+// KGS import request structure
+// Either a user and month, or the address of an archive or game record on KGS
+type KGSImportRequest struct {
+	User  string `json:"user"`
+	Year  int    `json:"year"`
+	Month int    `json:"month"` // 1 to 12
+	URL   string `json:"url"`
+}
+
+// ImportedGame is a game added to the store by an archive import
+type ImportedGame struct {
+	GameID    string `json:"gameId"`
+	File      string `json:"file"` // Record in the archive it came from
+	BlackName string `json:"blackName"`
+	WhiteName string `json:"whiteName"`
+	BlackRank string `json:"blackRank,omitempty"`
+	WhiteRank string `json:"whiteRank,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Result    string `json:"result,omitempty"` // As written in the record
+}
+
+// ImportFailure is a record of an archive that couldn't be imported
+type ImportFailure struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// Archive import response structure
+type ArchiveImportReport struct {
+	Imported   []ImportedGame  `json:"imported"`
+	Duplicates int             `json:"duplicates"` // Records imported before, left as they were
+	Failed     []ImportFailure `json:"failed"`
+}
+
+// Import a KGS archive into the game store, as finished games with the players, ranks, date
+// and result of their records: a zip uploaded as the request body (application/zip), or a
+// JSON request naming a user and month, or the address of an archive or record on KGS
+// Games keep an ID derived from their record, so importing an archive again adds nothing
+func importKGSArchive(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var records []remote.Record
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if mediaType == "application/zip" {
+		data, err := io.ReadAll(io.LimitReader(c.Request().Body, remote.MaxArchiveSize+1))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Could not read the archive"})
+		}
+		if len(data) > remote.MaxArchiveSize {
+			return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "Archive is too large"})
+		}
+		if records, err = remote.ReadArchive(data); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	} else {
+		var importReq KGSImportRequest
+		if err := c.Bind(&importReq); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+		}
+
+		address := importReq.URL
+		if address == "" {
+			var err error
+			if address, err = remote.KGSArchiveURL(kgsArchives, importReq.User, importReq.Year, time.Month(importReq.Month)); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+		} else if !remote.IsKGSURL(address) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Only archives on gokgs.com can be imported"})
+		}
+
+		var err error
+		if records, err = remote.FetchKGS(ctx, address); err != nil {
+			return remoteError(c, "KGS", err)
+		}
+	}
+
+	report := ArchiveImportReport{Imported: make([]ImportedGame, 0, len(records)), Failed: make([]ImportFailure, 0)}
+	for _, record := range records {
+		roots, err := sgf.Parse(record.SGF)
+		if err != nil {
+			report.Failed = append(report.Failed, ImportFailure{File: record.Name, Error: err.Error()})
+			continue
+		}
+		for _, root := range roots {
+			imported, duplicate, err := storeArchivedGame(ctx, root)
+			switch {
+			case err != nil:
+				report.Failed = append(report.Failed, ImportFailure{File: record.Name, Error: err.Error()})
+			case duplicate:
+				report.Duplicates++
+			default:
+				imported.File = record.Name
+				report.Imported = append(report.Imported, imported)
+			}
+		}
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// storeArchivedGame saves the game of a record to the store, finished with the recorded result
+// The game is only stored, not made live: it's history to review and export, not a game to play
+func storeArchivedGame(ctx context.Context, root *sgf.Node) (imported ImportedGame, duplicate bool, err error) {
+	board, err := sgf.ToBoard(root)
+	if err != nil {
+		return imported, false, err
+	}
+	if result, ok := game.ParseResult(board.Info.Result); ok {
+		if err := board.Conclude(result); err != nil {
+			return imported, false, err
+		}
+	}
+
+	sum := sha256.Sum256([]byte(board.SGF))
+	gameID := "kgs-" + hex.EncodeToString(sum[:8])
+	if _, err := gameStore.LoadGame(ctx, gameID); err == nil {
+		return imported, true, nil
+	}
+	if err := gameStore.SaveGame(ctx, gameID, board); err != nil {
+		log.Printf("saving imported game %s: %v", gameID, err)
+		return imported, false, errors.New("failed to save the game")
+	}
+
+	return ImportedGame{
+		GameID:    gameID,
+		BlackName: board.Info.BlackName,
+		WhiteName: board.Info.WhiteName,
+		BlackRank: board.Info.BlackRank,
+		WhiteRank: board.Info.WhiteRank,
+		Date:      board.Info.Date,
+		Result:    board.Info.Result,
+	}, false, nil
+}
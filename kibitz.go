@@ -0,0 +1,205 @@
+package main
+
+import (
+	"go-game/chat"
+	"go-game/game"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Kibitz limits
+const (
+	maxKibitzLength   = 500  // Characters per message
+	maxKibitzPerGame  = 1000 // Messages kept per game
+	maxKibitzNameSize = 40   // Characters in an author name
+)
+
+// KibitzMessage is a spectator comment on a game
+type KibitzMessage struct {
+	Author     string    `json:"author"`
+	Text       string    `json:"text"`
+	MoveNumber int       `json:"moveNumber"` // Move the comment is about (0 = the start), -1 if not anchored
+	Time       time.Time `json:"time"`
+}
+
+// kibitz holds the spectator comments of each live game (guarded by gamesMu; only ever appended to)
+// Like bots, comments live with the game on this server and aren't persisted; their text is
+// still kept sealed like the chat's, in case the server's memory ends up on disk
+var kibitz = make(map[string][]KibitzMessage)
+
+// Kibitz request structure
+type KibitzRequest struct {
+	Author string `json:"author"`
+	Text   string `json:"text"`
+	Anchor bool   `json:"anchor"` // Attach the comment to the current move
+}
+
+// Post a spectator comment, optionally anchored to the current move
+func postKibitz(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Parse the request
+	var kibitzReq KibitzRequest
+	if err := c.Bind(&kibitzReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	author, text := strings.TrimSpace(kibitzReq.Author), strings.TrimSpace(kibitzReq.Text)
+	if author == "" || utf8.RuneCountInString(author) > maxKibitzNameSize {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid author"})
+	}
+	if text == "" || utf8.RuneCountInString(text) > maxKibitzLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid message"})
+	}
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	message := KibitzMessage{Author: author, Text: text, MoveNumber: -1, Time: time.Now()}
+	if kibitzReq.Anchor {
+		message.MoveNumber = board.Version()
+	}
+	added, err := addKibitz(gameID, message)
+	if err != nil {
+		log.Printf("sealing a comment on game %s: %v", gameID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save the comment"})
+	}
+	if !added {
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "Too many comments on this game"})
+	}
+	noteSpectator(gameID, author)
+
+	hub.Broadcast(Event{Type: EventKibitz, GameID: gameID, Data: message})
+	return c.JSON(http.StatusOK, message)
+}
+
+// addKibitz records a comment on a game, unless it has too many already
+func addKibitz(gameID string, message KibitzMessage) (bool, error) {
+	var err error
+	if message.Text, err = sealer.Seal(gameID, message.Text); err != nil {
+		return false, err
+	}
+
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	if len(kibitz[gameID]) >= maxKibitzPerGame {
+		return false, nil
+	}
+	kibitz[gameID] = append(kibitz[gameID], message)
+	return true, nil
+}
+
+// kibitzOf returns the comments on a game so far, opened
+func kibitzOf(gameID string) ([]KibitzMessage, error) {
+	gamesMu.Lock()
+	messages := append([]KibitzMessage(nil), kibitz[gameID]...)
+	gamesMu.Unlock()
+
+	for i := range messages {
+		var err error
+		if messages[i].Text, err = sealer.Open(gameID, messages[i].Text); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+// List the spectator comments of a game
+func listKibitz(c echo.Context) error {
+	gameID := c.Param("id")
+
+	gamesMu.Lock()
+	_, exists := games[gameID]
+	gamesMu.Unlock()
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+
+	messages, err := kibitzOf(gameID)
+	if err != nil {
+		log.Printf("opening the comments on game %s: %v", gameID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load the comments"})
+	}
+	if messages == nil {
+		messages = []KibitzMessage{}
+	}
+	return c.JSON(http.StatusOK, messages)
+}
+
+// ReviewEntry is one step of a game review: a move and the comments made about it
+type ReviewEntry struct {
+	MoveNumber int             `json:"moveNumber"` // 0 = the starting position
+	Move       *game.Move      `json:"move"`       // nil for the starting position
+	Kibitz     []KibitzMessage `json:"kibitz"`
+	Chat       []chat.Message  `json:"chat"` // Both channels, said while this was the last move
+}
+
+// Review response structure
+type ReviewResponse struct {
+	Timeline []ReviewEntry   `json:"timeline"` // One entry per move, starting with the starting position
+	General  []KibitzMessage `json:"general"`  // Comments that weren't anchored to a move
+}
+
+// Get the review of a finished game, with the anchored comments placed on their moves
+func getReview(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+	if board.Phase != game.PhaseFinished {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "The review is available once the game has finished"})
+	}
+
+	review := ReviewResponse{
+		Timeline: make([]ReviewEntry, len(board.MoveHistory)+1),
+		General:  make([]KibitzMessage, 0),
+	}
+	for i := range review.Timeline {
+		review.Timeline[i] = ReviewEntry{MoveNumber: i, Kibitz: make([]KibitzMessage, 0), Chat: make([]chat.Message, 0)}
+		if i > 0 {
+			review.Timeline[i].Move = &board.MoveHistory[i-1]
+		}
+	}
+
+	comments, err := kibitzOf(gameID)
+	if err != nil {
+		log.Printf("opening the comments on game %s: %v", gameID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load the comments"})
+	}
+	for _, message := range comments {
+		if message.MoveNumber < 0 || message.MoveNumber >= len(review.Timeline) {
+			review.General = append(review.General, message)
+			continue
+		}
+		review.Timeline[message.MoveNumber].Kibitz = append(review.Timeline[message.MoveNumber].Kibitz, message)
+	}
+
+	// The chat of both channels, now that the players may read the spectators'
+	for _, channel := range []string{chat.ChannelPlayers, chat.ChannelSpectators} {
+		messages, err := chatStore.List(c.Request().Context(), gameID, channel)
+		if err != nil {
+			log.Printf("loading the chat of game %s: %v", gameID, err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load the chat"})
+		}
+		for _, message := range messages {
+			step := min(max(message.MoveNumber, 0), len(review.Timeline)-1)
+			review.Timeline[step].Chat = append(review.Timeline[step].Chat, message)
+		}
+	}
+
+	return c.JSON(http.StatusOK, review)
+}
@@ -0,0 +1,135 @@
+// Package kifu lays out a game record as printable figures: diagrams with numbered
+// moves, split every so many moves, with notes for the moves that can't be shown
+// on the diagram because they were played where another stone is already drawn
+package kifu
+
+import (
+	"fmt"
+	"go-game/game"
+)
+
+// DefaultMovesPerFigure is how many moves one figure shows, as in printed game records
+const DefaultMovesPerFigure = 100
+
+// Kifu is a printable game record
+type Kifu struct {
+	Size    int      `json:"size"`
+	Black   string   `json:"black,omitempty"`
+	White   string   `json:"white,omitempty"`
+	Komi    float64  `json:"komi"`
+	Result  string   `json:"result,omitempty"`
+	Figures []Figure `json:"figures"`
+}
+
+// Figure is one diagram of the record
+type Figure struct {
+	FirstMove int     `json:"firstMove"` // Number of the first move in the figure
+	LastMove  int     `json:"lastMove"`  // Number of the last move in the figure
+	Stones    []Stone `json:"stones"`    // What is drawn on the diagram
+	Notes     []Note  `json:"notes"`     // Moves that couldn't be drawn
+}
+
+// Stone is a point drawn on a figure
+type Stone struct {
+	Position   int    `json:"position"`
+	Coordinate string `json:"coordinate"`
+	Color      int    `json:"color"`            // 1 = black, 2 = white
+	Number     int    `json:"number,omitempty"` // Move number; 0 for stones already on the board when the figure starts
+}
+
+// Note describes a move that isn't drawn on its figure, e.g. "25 at 19", "31 at D4" or "40 pass"
+type Note struct {
+	Move  int    `json:"move"`
+	Color int    `json:"color"`
+	At    int    `json:"at,omitempty"` // Number of the drawn move on the same point, if it has one
+	Text  string `json:"text"`
+}
+
+// FromBoard lays out the moves of a game as figures of movesPerFigure moves
+// Each figure starts from the position left by the previous one, with those stones unnumbered
+func FromBoard(board *game.Board, movesPerFigure int) *Kifu {
+	if movesPerFigure <= 0 {
+		movesPerFigure = DefaultMovesPerFigure
+	}
+
+	k := &Kifu{
+		Size:    board.Size,
+		Black:   board.Info.BlackName,
+		White:   board.Info.WhiteName,
+		Komi:    board.Komi,
+		Result:  board.Info.Result,
+		Figures: make([]Figure, 0),
+	}
+	if board.Result != nil {
+		k.Result = board.Result.String()
+	}
+
+	// Replay the record on a plain grid; captures come from the move history
+	grid := make([]int, board.Size*board.Size)
+	for color := 1; color <= 2; color++ {
+		for _, position := range board.SetupStones[color] {
+			grid[position] = color
+		}
+	}
+
+	for first := 0; ; first += movesPerFigure {
+		last := min(first+movesPerFigure, len(board.MoveHistory))
+		k.Figures = append(k.Figures, figure(board.Size, grid, board.MoveHistory[first:last], first+1))
+		if last == len(board.MoveHistory) {
+			break
+		}
+	}
+	return k
+}
+
+// figure draws the moves starting at number firstMove on top of the grid, and leaves the grid
+// as it is after the last of them
+func figure(size int, grid []int, moves []game.Move, firstMove int) Figure {
+	f := Figure{FirstMove: firstMove, LastMove: firstMove + len(moves) - 1, Stones: make([]Stone, 0), Notes: make([]Note, 0)}
+
+	// drawn maps each point to the index of the stone drawn there
+	drawn := make(map[int]int)
+	for position, color := range grid {
+		if color != 0 {
+			drawn[position] = len(f.Stones)
+			f.Stones = append(f.Stones, Stone{Position: position, Coordinate: game.FormatCoordinate(position, size), Color: color})
+		}
+	}
+
+	for i, move := range moves {
+		number := firstMove + i
+
+		switch index, taken := drawn[move.Position]; {
+		case move.Position < 0:
+			f.Notes = append(f.Notes, Note{Move: number, Color: move.Player, Text: fmt.Sprintf("%d pass", number)})
+
+		case taken:
+			// The point already shows a stone: refer to it
+			note := Note{Move: number, Color: move.Player, At: f.Stones[index].Number}
+			if note.At > 0 {
+				note.Text = fmt.Sprintf("%d at %d", number, note.At)
+			} else {
+				note.Text = fmt.Sprintf("%d at %s", number, f.Stones[index].Coordinate)
+			}
+			f.Notes = append(f.Notes, note)
+
+		default:
+			drawn[move.Position] = len(f.Stones)
+			f.Stones = append(f.Stones, Stone{
+				Position:   move.Position,
+				Coordinate: game.FormatCoordinate(move.Position, size),
+				Color:      move.Player,
+				Number:     number,
+			})
+		}
+
+		if move.Position >= 0 {
+			grid[move.Position] = move.Player
+			for _, captured := range move.CapturedPositions {
+				grid[captured] = 0
+			}
+		}
+	}
+
+	return f
+}
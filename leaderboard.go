@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"go-game/rating"
+	"go-game/users"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Leaderboard settings
+const (
+	leaderboardInterval    = 5 * time.Minute // How often the leaderboards are ranked again
+	leaderboardMinGames    = 10              // Rated games a player needs to appear on a leaderboard
+	defaultLeaderboardPage = 50
+	maxLeaderboardPage     = 200
+)
+
+// leaderboardOverall names the leaderboard of every board size together
+const leaderboardOverall = "overall"
+
+// LeaderboardEntry is a player's place on a leaderboard
+type LeaderboardEntry struct {
+	Position    int           `json:"position"` // 1 for the best player
+	UserID      string        `json:"userId"`
+	Username    string        `json:"username"`
+	DisplayName string        `json:"displayName"`
+	Rating      rating.Rating `json:"rating"` // Overall rating, or the rating on the board size
+	Games       int           `json:"games"`  // Rated games counted for this leaderboard
+}
+
+// Leaderboard is a page of a leaderboard
+type Leaderboard struct {
+	Board     string             `json:"board"`           // "overall", or a board size ("9x9", "13x13" or "19x19")
+	Speed     rating.Speed       `json:"speed,omitempty"` // Only the games played at this speed
+	MinGames  int                `json:"minGames"`        // Rated games needed to appear
+	Total     int                `json:"total"`           // Players on the leaderboard
+	Entries   []LeaderboardEntry `json:"entries"`
+	UpdatedAt time.Time          `json:"updatedAt"` // When the leaderboard was last ranked
+}
+
+// leaderboardKey identifies a leaderboard: a track ("" overall) and a speed ("" every speed)
+type leaderboardKey struct {
+	track rating.Track
+	speed rating.Speed
+}
+
+// leaderboards are ranked in the background, so requests only page through them
+var (
+	leaderboards   = make(map[leaderboardKey][]LeaderboardEntry)
+	leaderboardsAt time.Time
+	leaderboardsMu sync.Mutex
+)
+
+// runLeaderboards ranks the leaderboards now and at every interval until the context is cancelled
+func runLeaderboards(ctx context.Context, interval time.Duration) {
+	rankLeaderboards(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rankLeaderboards(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// standingOn returns a player's rating on a leaderboard and the games counting for it;
+// ok is false if the player never played on its board size
+func standingOn(standing rating.Standing, key leaderboardKey) (player rating.Rating, games int, ok bool) {
+	if key.track == "" {
+		player = standing.Ratings.Overall()
+	} else if player, ok = standing.Ratings[key.track]; !ok {
+		return rating.Rating{}, 0, false
+	}
+	for track, speeds := range standing.Games {
+		if key.track != "" && track != key.track {
+			continue
+		}
+		for speed, played := range speeds {
+			if key.speed == "" || speed == key.speed {
+				games += played
+			}
+		}
+	}
+	return player, games, true
+}
+
+// rankLeaderboards ranks every leaderboard from the ratings of the players; the previous
+// leaderboards stay up if the ratings can't be read
+func rankLeaderboards(ctx context.Context) {
+	standings, err := ratingStore.Standings(ctx)
+	if err != nil {
+		log.Printf("ranking the leaderboards: %v", err)
+		return
+	}
+
+	var keys []leaderboardKey
+	for _, track := range append([]rating.Track{""}, rating.Tracks...) {
+		keys = append(keys, leaderboardKey{track: track})
+		for _, speed := range rating.Speeds {
+			keys = append(keys, leaderboardKey{track: track, speed: speed})
+		}
+	}
+
+	// Accounts are only looked up for the players on at least one leaderboard
+	ranked := make(map[leaderboardKey][]LeaderboardEntry, len(keys))
+	accounts := make(map[string]users.User)
+	for _, standing := range standings {
+		for _, key := range keys {
+			player, games, ok := standingOn(standing, key)
+			if !ok || games < leaderboardMinGames {
+				continue
+			}
+			account, known := accounts[standing.UserID]
+			if !known {
+				account, err = userStore.Get(ctx, standing.UserID)
+				if errors.Is(err, users.ErrNotFound) {
+					break // A deleted account
+				}
+				if err != nil {
+					log.Printf("ranking the leaderboards: %v", err)
+					return
+				}
+				accounts[standing.UserID] = account
+			}
+			ranked[key] = append(ranked[key], LeaderboardEntry{
+				UserID:      account.ID,
+				Username:    account.Username,
+				DisplayName: account.DisplayName,
+				Rating:      player,
+				Games:       games,
+			})
+		}
+	}
+	for _, entries := range ranked {
+		sort.Slice(entries, func(i, j int) bool {
+			a, b := entries[i], entries[j]
+			return a.Rating.Value > b.Rating.Value || a.Rating.Value == b.Rating.Value && a.UserID < b.UserID
+		})
+		for i := range entries {
+			entries[i].Position = i + 1
+		}
+	}
+
+	leaderboardsMu.Lock()
+	leaderboards, leaderboardsAt = ranked, time.Now()
+	leaderboardsMu.Unlock()
+}
+
+// Players by rating, overall or on one board size (?board=), optionally at one speed (?speed=)
+func getLeaderboard(c echo.Context) error {
+	board := leaderboardOverall
+	var key leaderboardKey
+	if param := c.QueryParam("board"); param != "" && param != leaderboardOverall {
+		track, ok := rating.ParseTrack(param)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid board"})
+		}
+		board, key.track = param, track
+	}
+	if param := c.QueryParam("speed"); param != "" {
+		for _, speed := range rating.Speeds {
+			if string(speed) == param {
+				key.speed = speed
+			}
+		}
+		if key.speed == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid speed"})
+		}
+	}
+
+	limit := defaultLeaderboardPage
+	if param := c.QueryParam("limit"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed <= 0 || parsed > maxLeaderboardPage {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+		}
+		limit = parsed
+	}
+	offset := 0
+	if param := c.QueryParam("offset"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid offset"})
+		}
+		offset = parsed
+	}
+
+	leaderboardsMu.Lock()
+	entries, updatedAt := leaderboards[key], leaderboardsAt
+	leaderboardsMu.Unlock()
+
+	start := min(offset, len(entries))
+	page := entries[start : start+min(limit, len(entries)-start)]
+	return c.JSON(http.StatusOK, Leaderboard{
+		Board:     board,
+		Speed:     key.speed,
+		MinGames:  leaderboardMinGames,
+		Total:     len(entries),
+		Entries:   append([]LeaderboardEntry{}, page...),
+		UpdatedAt: updatedAt,
+	})
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"go-game/artifacts"
+	"log"
+	"time"
+)
+
+// artifactLifecycleInterval is how often expired artifacts are cleaned up
+const artifactLifecycleInterval = time.Hour
+
+// runArtifactLifecycle periodically deletes artifacts that have outlived their retention rule
+// It stops when ctx is cancelled, interrupting a cleanup in progress
+func runArtifactLifecycle(ctx context.Context, store artifacts.Store, rules []artifacts.LifecycleRule, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			deleted, err := artifacts.ApplyLifecycle(ctx, store, rules, now)
+			if err != nil {
+				log.Printf("artifact lifecycle: %v", err)
+			}
+			if deleted > 0 {
+				log.Printf("artifact lifecycle: deleted %d expired artifacts", deleted)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
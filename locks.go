@@ -0,0 +1,70 @@
+package main
+
+import (
+	"go-game/game"
+	"sync"
+)
+
+// Locking
+// Every live game has its own lock, held by whoever reads or changes its board: requests
+// for the same game wait for each other, requests for different games run side by side
+// gamesMu only guards the games map and the per-game state kept beside it (bots, kibitz,
+// audience, ...) and is held just long enough to look them up or update them
+// Locks are always taken in that order: a game's lock first, then gamesMu; never wait for
+// a game's lock while holding gamesMu
+
+// gameLocks holds the lock of each live game (guarded by gamesMu)
+var gameLocks = make(map[string]*sync.Mutex)
+
+// addGame makes a game live on this server, locked until the caller calls unlock
+// Must be called with gamesMu held; nobody else knows the game yet, so its lock is free
+func addGame(gameID string, board *game.Board) (unlock func()) {
+	lock := &sync.Mutex{}
+	lock.Lock()
+	games[gameID] = board
+	gameLocks[gameID] = lock
+	return lock.Unlock
+}
+
+// lockGame finds a live game and locks it, returning the function that unlocks it
+// exists is false if the game isn't live on this server, or stopped being live while
+// waiting for the lock
+func lockGame(gameID string) (board *game.Board, unlock func(), exists bool) {
+	gamesMu.Lock()
+	board, exists = games[gameID]
+	lock := gameLocks[gameID]
+	gamesMu.Unlock()
+	if !exists {
+		return nil, nil, false
+	}
+
+	lock.Lock()
+	gamesMu.Lock()
+	current := games[gameID]
+	gamesMu.Unlock()
+	if current != board {
+		lock.Unlock() // Forgotten while we waited
+		return nil, nil, false
+	}
+	return board, lock.Unlock, true
+}
+
+// forEachGame calls fn on every live game in turn, with that game locked
+// Games created while it runs may or may not be visited
+func forEachGame(fn func(gameID string, board *game.Board)) {
+	gamesMu.Lock()
+	gameIDs := make([]string, 0, len(games))
+	for gameID := range games {
+		gameIDs = append(gameIDs, gameID)
+	}
+	gamesMu.Unlock()
+
+	for _, gameID := range gameIDs {
+		board, unlock, exists := lockGame(gameID)
+		if !exists {
+			continue
+		}
+		fn(gameID, board)
+		unlock()
+	}
+}
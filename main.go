@@ -1,8 +1,27 @@
 package main
 
 import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"go-game/artifacts"
+	"go-game/bot"
+	"go-game/budget"
 	"go-game/game"
+	"go-game/plugin"
+	"go-game/rating"
+	"go-game/store"
+	"go-game/users"
+	"go-game/webhooks"
+	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -11,13 +30,183 @@ import (
 // In-memory storage for games (use database in production)
 var games = make(map[string]*game.Board)
 
+// gamesMu guards the games map and the per-game state kept beside it
+// The boards themselves are guarded by their game's lock (see locks.go)
+var gamesMu sync.Mutex
+
+// hub broadcasts game events to connected clients
+var hub = NewHub()
+
+// sealer encrypts chat messages and spectator comments at rest (nil = stored as plaintext)
+var sealer *Sealer
+
+// gameStore persists game snapshots (Postgres if DATABASE_URL is set, memory otherwise)
+var gameStore store.Store
+
+// artifactStore keeps large files (SGF archives, reports, renders) out of the game store
+var artifactStore artifacts.Store
+
 func main() {
+	// Root context, cancelled on SIGINT/SIGTERM
+	// Background workers derive from it, so shutting down stops them right away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Request contexts are only cancelled once the shutdown grace period is over, so
+	// moves in progress get to finish (and be saved)
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
 	// Create Echo instance
 	e := echo.New()
+	e.Server.BaseContext = func(net.Listener) context.Context { return requestCtx }
+	e.TLSServer.BaseContext = e.Server.BaseContext
+
+	// Encryption key for private game data, read from GAME_DATA_KEY
+	var err error
+	if sealer, err = NewSealer(envKeyProvider{variable: "GAME_DATA_KEY"}); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Key for signing game passports, read from PASSPORT_KEY (a base64 encoded 32-byte seed)
+	if passportKey, err = newPassportKey(envKeyProvider{variable: "PASSPORT_KEY"}); err != nil {
+		e.Logger.Fatal(err)
+	}
+	trustedServers[passportIssuer] = passportKey.Public().(ed25519.PublicKey)
+
+	// Player accounts, kept next to the games
+	if userStore, err = newUserStoreFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+	accountDirectory = userDirectory{store: userStore}
+	userStore = sandboxUserStore{Store: userStore} // Throwaway accounts of the sandbox stay in memory
+
+	// Sessions of browsers signed in with a cookie
+	if sessionStore, err = newSessionStoreFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Webhooks users registered for their games; WEBHOOK_ALLOW_PRIVATE="true" lets them reach
+	// private addresses, e.g. to try them out against a local service
+	if webhookStore, err = newWebhookStoreFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+	webhookDispatcher = webhooks.NewDispatcher(webhookStore, os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true")
+
+	// Chat of the games, kept next to them
+	if chatStore, err = newChatStoreFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Ratings of the accounts, updated as their games end
+	if ratingStore, err = newRatingStoreFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Finished games indexed by the accounts that played them
+	if archiveStore, err = newArchiveStoreFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Entrants registered for tournaments, kept next to the games
+	if rosterStore, err = newRosterStoreFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Public address of the server, for the links in invitations, e.g. PUBLIC_BASE_URL="https://go.example.com"
+	if publicBaseURL, err = publicBaseURLFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Key for signing user tokens, read from JWT_KEY (a base64 encoded 32-byte key)
+	if tokenIssuer, err = newTokenIssuer(envKeyProvider{variable: "JWT_KEY"}); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// How games are rated, e.g. RATING_SYSTEM="elo", or Glicko-2 with RATING_PERIOD="168h" for weekly periods
+	if ratingSystem, ratingPeriod, err = ratingSystemFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// How handicap and komi count when rating games, e.g. RATING_HANDICAP_MODEL="pointsPerStone=100,komiPerStone=13"
+	if handicapModel, err = rating.ParseHandicapModel(os.Getenv("RATING_HANDICAP_MODEL")); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Board sizes for new games, e.g. BOARD_SIZES="9,13,19" and BOARD_ODD_SIZES="7-25" for odd sizes too
+	if boardSizes, err = parseBoardSizePolicy(os.Getenv("BOARD_SIZES"), os.Getenv("BOARD_ODD_SIZES")); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Lifetime of sandbox games, e.g. SANDBOX_TTL="30m"
+	if sandboxTTL, err = parseSandboxTTL(os.Getenv("SANDBOX_TTL")); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// When idle games are taken out of memory, and what happens to unfinished ones
+	// (GAME_TTL, CORRESPONDENCE_GAME_TTL and STALE_GAME_POLICY)
+	if expiryPolicy, err = expiryPolicyFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// HTTP or HTTPS, on LISTEN_ADDR; see tlsConfigFromEnv for the TLS settings
+	listenConfig, err := tlsConfigFromEnv()
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// How long requests in progress get to finish on shutdown (SHUTDOWN_TIMEOUT, e.g. "30s")
+	shutdownTimeout, err := envDuration("SHUTDOWN_TIMEOUT", defaultShutdownTimeout)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Rate limits per client address and per user, e.g. GAME_RATE_LIMIT_IP="30/m",
+	// GAME_RATE_LIMIT_USER, MOVE_RATE_LIMIT_IP and MOVE_RATE_LIMIT_USER ("off" for none)
+	if gameRateLimit, err = rateLimitFromEnv("GAME_RATE_LIMIT", defaultGameRateIP, defaultGameRateUser); err != nil {
+		e.Logger.Fatal(err)
+	}
+	if moveRateLimit, err = rateLimitFromEnv("MOVE_RATE_LIMIT", defaultMoveRateIP, defaultMoveRateUser); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Origins other than this site allowed to call the API (CORS_ALLOWED_ORIGINS and CORS_ALLOWED_METHODS)
+	if corsPolicy, err = corsPolicyFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Engine time budgets per hour, from a tier (ENGINE_TIER="small", "medium" or "large")
+	// and/or explicit limits, e.g. ENGINE_BUDGET_GLOBAL="2h" and ENGINE_BUDGET_USER="5m"
+	engineTier = os.Getenv("ENGINE_TIER")
+	engineLimits, err := budget.ParseLimits(engineTier, os.Getenv("ENGINE_BUDGET_GLOBAL"), os.Getenv("ENGINE_BUDGET_USER"))
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	engineBudgets = budget.NewLedger(engineLimits, engineBudgetWindow)
+
+	// Persistent game storage
+	if gameStore, err = newStoreFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Storage for large artifacts, selected by ARTIFACT_STORE (disk or s3)
+	if artifactStore, err = artifacts.NewStoreFromEnv(); err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	// Retention rules for artifacts, e.g. ARTIFACT_RETENTION="renders/=168h,reports/=720h"
+	retention, err := artifacts.ParseLifecycleRules(os.Getenv("ARTIFACT_RETENTION"))
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
 
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
+	if corsPolicy.Enabled() {
+		e.Use(corsPolicy.Middleware()) // Before authentication, so preflight requests need no credentials
+	}
+	e.Use(authenticate) // Makes the user of an access token known to the handlers
 
 	// Serve static files (HTML, CSS, JS for game board)
 	e.Static("/", "static")
@@ -25,50 +214,505 @@ func main() {
 	// WebSocket endpoint for real-time game moves
 	e.GET("/ws", handleWebSocket)
 
+	// GraphQL: queries and mutations, and subscriptions over a WebSocket on the GET route
+	e.POST("/graphql", serveGraphQL)
+	e.GET("/graphql", serveGraphQL)
+
+	// Metrics for Prometheus
+	e.GET("/metrics", getMetrics)
+
+	// Probes for orchestrators and load balancers
+	e.GET("/healthz", getHealth)   // The process is up
+	e.GET("/readyz", getReadiness) // The stores and engines work too
+
+	// Description of the REST API for client generators and API explorers
+	e.GET("/openapi.json", getOpenAPI)
+
+	// Authentication: tokens go in "Authorization: Bearer <access token>"
+	e.POST("/auth/guest", issueGuestTokens)        // Tokens for a new guest user
+	e.POST("/auth/refresh", refreshTokens)         // Trade a refresh token for new tokens
+	e.GET("/auth/me", getCurrentUser, requireUser) // The authenticated user
+	e.POST("/auth/register", register)             // Create an account and sign in
+	e.POST("/auth/login", login)                   // Sign in with a username and password
+
+	// Browser sessions: a cookie instead of tokens, for the frontend
+	e.POST("/auth/session", createSession)                       // Sign in with a username and password
+	e.DELETE("/auth/session", deleteSession)                     // Sign out of the cookie's session
+	e.GET("/auth/sessions", listSessions, requireUser)           // Browsers the user is signed in on
+	e.DELETE("/auth/sessions", revokeOtherSessions, requireUser) // Sign out everywhere else
+	e.DELETE("/auth/sessions/:sid", revokeSession, requireUser)  // Sign out a browser
+
+	// Accounts
+	e.GET("/users/:id", getUserProfile)                      // Public profile
+	e.GET("/users/:id/ratings", getRatingHistory)            // Rating changes, newest first
+	e.GET("/players/:id", getPlayer, staleReads)             // Profile with statistics over the player's games
+	e.GET("/players/:id/games", listPlayerGames)             // Finished games of the player, most recent first
+	e.PATCH("/users/me", updateProfile, requireUser)         // Change display name, rank, email or password
+	e.POST("/game/:id/seat", claimSeat, requireUser)         // Play a seat with the account, given its token
+	e.POST("/game/:id/rematch", requestRematch, requireUser) // Ask the opponent for another game, colors swapped
+
+	// Vacation from the user's correspondence games
+	e.GET("/vacation", getVacation, requireUser)    // The user's correspondence games and vacation time left
+	e.POST("/vacation", startVacation, requireUser) // Stop the user's clocks while vacation time lasts
+	e.DELETE("/vacation", endVacation, requireUser) // Back from vacation, the clocks count again
+
+	// Automatch: wait for an opponent with compatible preferences
+	e.POST("/match", joinMatchQueue, requireUser)    // Join the queue, or change preferences
+	e.GET("/match", getMatchStatus, requireUser)     // Waiting, or the game found
+	e.DELETE("/match", leaveMatchQueue, requireUser) // Stop waiting
+
+	// Challenges: a game sent to a player, or as a link, waiting for its opponent
+	e.POST("/challenges", createChallenge, requireUser)               // Challenge a player, or make a link
+	e.GET("/challenges", listChallenges, requireUser)                 // Open challenges sent and received
+	e.GET("/challenges/:cid", getChallenge)                           // A challenge, as its link shows it
+	e.POST("/challenges/:cid/accept", acceptChallenge, requireUser)   // Take the opponent's seat; the game starts
+	e.POST("/challenges/:cid/decline", declineChallenge, requireUser) // Turn down a challenge sent to the user
+	e.DELETE("/challenges/:cid", cancelChallenge, requireUser)        // Withdraw one of the user's challenges
+
+	// Webhooks of the user's games
+	e.POST("/webhooks", createWebhook, requireUser)        // Register a URL for signed game events
+	e.GET("/webhooks", listWebhooks, requireUser)          // The user's webhooks
+	e.DELETE("/webhooks/:wid", deleteWebhook, requireUser) // Remove a webhook
+
+	// Rate limits of game creation and moves
+	limitGames, limitMoves := limitRate(&gameRateLimit), limitRate(&moveRateLimit)
+
+	// Sandbox: throwaway accounts that only play sandbox games (see sandbox.go)
+	e.POST("/sandbox/accounts", createSandboxAccount, limitGames) // Create an account purged after SANDBOX_TTL, signed in
+
 	// REST API endpoints
-	e.POST("/game/new", newGame)       // Create new game
-	e.GET("/game/:id", getGame)        // Get game state
-	e.POST("/game/:id/move", makeMove) // Make a move
+	e.POST("/game/new", newGame, limitGames)                  // Create new game
+	e.POST("/game/import", importGame)                        // Create a game from an SGF record
+	e.POST("/game/import/ogs", importOGSGame)                 // Create a game from an online-go.com game
+	e.POST("/game/import/kgs", importKGSArchive)              // Store the games of a KGS archive
+	e.GET("/game/:id", getGame)                               // Get game state
+	e.POST("/game/:id/move", makeMove, limitMoves)            // Make a move
+	e.POST("/game/:id/moves", submitMoveBatch)                // Apply moves queued while offline
+	e.GET("/game/:id/kifu", exportKifu)                       // Printable record with numbered figures
+	e.GET("/game/:id/sgf", exportGame)                        // Download the game record
+	e.GET("/game/:id/image.png", getGameImage)                // Picture of the current position
+	e.GET("/game/:id/image.svg", getGameSVG)                  // Scalable picture with optional review overlays
+	e.GET("/game/:id/events", streamGameEvents)               // Server-Sent Events stream, a fallback for WebSockets
+	e.GET("/game/:id/legal-moves", getLegalMoves)             // List legal moves for the player to move
+	e.GET("/game/:id/estimate", estimateGame)                 // Playout-based score and ownership estimate
+	e.GET("/game/:id/engine", analyzeWithEngine)              // Ask an external GTP engine about the position
+	e.GET("/game/:id/score", getScore)                        // Count the position
+	e.GET("/game/:id/result", getResult)                      // Result as data and localized text (?lang= or Accept-Language)
+	e.GET("/game/:id/pace", getPace)                          // Move pace statistics
+	e.POST("/game/:id/kibitz", postKibitz)                    // Spectator comment
+	e.GET("/game/:id/kibitz", listKibitz)                     // Spectator comments
+	e.POST("/game/:id/chat", postChat)                        // Say something in the players' or the spectators' channel
+	e.GET("/game/:id/chat", listChat)                         // Messages of a chat channel
+	e.POST("/game/:id/predictions", postPrediction)           // Spectator guess of the next move
+	e.GET("/game/:id/predictions", getPredictions)            // Heatmap of the guesses (spectators only)
+	e.GET("/game/:id/review", getReview)                      // Moves with the comments made about them
+	e.GET("/game/:id/replay", getReplay)                      // Position at a review link anchor (?move=57&var=2)
+	e.GET("/game/:id/passport", exportPassport)               // Signed portable record of a finished game
+	e.POST("/game/:id/dead", markDeadStones)                  // Mark dead stones during scoring
+	e.POST("/game/:id/accept-score", acceptScore)             // Agree to the counted score
+	e.POST("/game/:id/resume", resumePlay)                    // Go back to playing from scoring
+	e.POST("/game/:id/resign", resignGame)                    // Give up the game
+	e.GET("/game/:id/conditional", getConditionalMoves)       // A player's conditional moves
+	e.PUT("/game/:id/conditional", setConditionalMoves)       // Replies to play if the opponent plays the moves expected
+	e.DELETE("/game/:id/conditional", deleteConditionalMoves) // Drop a player's conditional moves
+	e.POST("/game/:id/consultation", consultTeam)             // Pause the clock while a rengo team consults
+	e.POST("/game/:id/abandon", abandonGame)                  // Annul the game before its first move, resign it after (admin: annul)
+	e.DELETE("/game/:id", deleteGame)                         // Delete the game for good (players before its first move, or admin)
+	e.GET("/games", listGames, staleReads)                    // List games (may be served by a replica)
+	e.GET("/games/featured", listFeaturedGames)               // Live games worth watching, best first
+	e.GET("/sync", syncState, requireUser)                    // Batched catch-up for mobile clients
+	e.GET("/events", listEvents)                              // Server-wide event firehose for analytics
+	e.GET("/score-checks", listScoreChecks)                   // Final scores compared with the reference engine
+	e.GET("/engine/quota", getEngineQuota)                    // Engine time the caller has used and has left
+	e.POST("/reports/tournament", tournamentReport)           // EGF or AGA rating report for a tournament
+	e.GET("/tournaments/:name/roster", listRoster)            // Entrants registered for a tournament
+	e.GET("/ratings/handicap", suggestHandicap)               // Fair handicap and expected result for two ratings
+	e.GET("/leaderboard", getLeaderboard)                     // Players by rating, overall, per board size or per speed
+	e.GET("/passport/key", getPassportKey)                    // Key other servers use to recognize our passports
+	e.POST("/passport/verify", verifyPassport)                // Check a passport from any server
+
+	// Finished games of a player or tournament as a zip of SGF files (may be served by a replica)
+	e.GET("/games/archive.zip", exportArchive, staleReads)
+
+	// Versioned API, answering with dedicated responses instead of the whole board
+	api := e.Group("/api/v1", apiV1)
+	api.POST("/games", newGame, limitGames)            // Create new game
+	api.GET("/games/:id", getGame)                     // Get game state (?include=moves,legal,scoring,info)
+	api.POST("/games/:id/moves", makeMove, limitMoves) // Make a move
+	api.POST("/games/:id/resign", resignGame)          // Give up the game
+	api.POST("/games/:id/resume", resumePlay)          // Go back to playing from scoring
+	api.POST("/games/:id/accept-score", acceptScore)   // Agree to the counted score
+
+	// Admin endpoints, authenticated with ADMIN_API_KEY
+	e.POST("/admin/tournaments/:name/roster", importRoster, requireAdmin)    // Pre-register the entrants of a roster
+	e.GET("/admin/consistency", listConsistencyChecks, requireAdmin)         // Games replayed against their move log
+	e.POST("/admin/consistency/:id", checkGameConsistency, requireAdmin)     // Replay one game now
+	e.GET("/admin/engine/usage", getEngineUsage, requireAdmin)               // Engine time used, overall and by user
+	e.GET("/admin/games", listAdminGames, requireAdmin)                      // Live games, idle the longest first
+	e.GET("/admin/games/:id", getAdminGame, requireAdmin)                    // Whole board and connections of a live game
+	e.POST("/admin/games/:id/terminate", terminateGame, requireAdmin)        // Annul a game still going and unload it
+	e.POST("/admin/games/:id/annul", annulResult, requireAdmin)              // Void the result of a finished game
+	e.POST("/admin/games/:id/score", forceScore, requireAdmin)               // Score a stuck game as it is marked
+	e.GET("/admin/connections", listConnections, requireAdmin)               // Open WebSocket connections
+	e.DELETE("/admin/connections/:cid", kickConnection, requireAdmin)        // Close a connection
+	e.DELETE("/admin/users/:uid/connections", kickUser, requireAdmin)        // Close every connection of a user
+	e.POST("/admin/players/games/reindex", reindexPlayerGames, requireAdmin) // Index the stored finished games by player
+
+	// Routes of the plugins compiled in, under /plugins/<name>
+	plugin.Mount(e)
+
+	// Background worker that calls the plugins' game hooks
+	go plugin.Run(ctx)
 
-	// Start server on port 8080
-	e.Logger.Fatal(e.Start(":8080"))
+	// Background workers that deliver the events of games to webhooks
+	webhookDispatcher.Run(ctx, webhookWorkers)
+
+	// Background worker that takes players who waited too long out of the automatch queue
+	go runMatchExpiry(ctx, matchExpiryInterval)
+
+	// Background worker that rates the games of each Glicko-2 rating period
+	if ratingSystem == RatingGlicko2 {
+		go runRatingPeriods(ctx, ratingPeriod)
+	}
+
+	// Background worker that ranks the leaderboards
+	go runLeaderboards(ctx, leaderboardInterval)
+
+	// Correspondence games in progress are played over days, so they are put back live after a restart
+	restoreCorrespondenceGames(ctx, expiryPolicy)
+
+	// Background worker that ends games when a player's clock runs out
+	go runTimeoutAdjudicator(ctx, timeoutCheckInterval)
+
+	// Background worker that replays a sample of the live games to catch engine bugs
+	go runConsistencySampler(ctx, consistencySampleInterval, consistencySampleSize)
+
+	// Background worker that purges expired sandbox games
+	go runSandboxPurger(ctx, sandboxPurgeInterval)
+
+	// Background worker that takes stale games out of memory
+	go runGameJanitor(ctx, gameJanitorInterval)
+
+	// Background worker that checks the external engines still start
+	go runEngineChecker(ctx, engineCheckInterval)
+
+	// Background worker that picks the featured games
+	go runFeaturedSelector(ctx, featuredInterval)
+
+	// Background worker that expires old artifacts
+	if len(retention) > 0 {
+		go runArtifactLifecycle(ctx, artifactStore, retention, artifactLifecycleInterval)
+	}
+
+	// Start server, on port 8080 unless configured otherwise
+	setUpAutocert(e, listenConfig)
+
+	// gRPC service next to the REST API, on GRPC_ADDR if set (see grpc.go)
+	if addr := os.Getenv("GRPC_ADDR"); addr != "" {
+		if err := startGRPCServer(e, listenConfig, addr); err != nil {
+			e.Logger.Fatal(err)
+		}
+	}
+	go func() {
+		if err := startServer(e, listenConfig); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	// Shut down gracefully once the root context is cancelled
+	<-ctx.Done()
+	shutdown(e, cancelRequests, shutdownTimeout)
 }
 
-// WebSocket handler for real-time communication
-func handleWebSocket(c echo.Context) error {
-	return nil
+// New game request structure
+type NewGameRequest struct {
+	Size int `json:"size"` // Board size (19 if not set), checked against the allowed sizes
+
+	MainTime       int `json:"mainTime"`       // Main time per player in seconds (0 = untimed game)
+	ByoYomiTime    int `json:"byoYomiTime"`    // Length of each byo-yomi period in seconds
+	ByoYomiPeriods int `json:"byoYomiPeriods"` // Number of byo-yomi periods per player
+
+	// Correspondence games: days each move may take, instead of main time and byo-yomi,
+	// and vacation days each player may take off (see correspondence.go)
+	DaysPerMove  int `json:"daysPerMove"`
+	VacationDays int `json:"vacationDays"`
+
+	PrecomputeLegalMoves bool `json:"precomputeLegalMoves"` // Cache the legal moves after every move (bots, hints)
+
+	Variant string `json:"variant"` // Rules variant ("standard", "capture", "nogo", "one_color" or "phantom"), standard if empty
+
+	// Players, as they appear in game records and tournament reports
+	BlackName string `json:"blackName"`
+	WhiteName string `json:"whiteName"`
+	BlackRank string `json:"blackRank"`
+	WhiteRank string `json:"whiteRank"`
+
+	// Team games (rengo): the members of each team in the order they play
+	BlackTeam []string `json:"blackTeam"`
+	WhiteTeam []string `json:"whiteTeam"`
+
+	ConsultationTime int `json:"consultationTime"` // Consultation time per team in seconds (team games only, 0 = none)
+
+	Hotseat bool `json:"hotseat"` // One device plays both colors (no seats, never rated)
+
+	Sandbox bool `json:"sandbox"` // Throwaway game for trying out the API (see sandbox.go)
+
+	Bot *BotRequest `json:"bot"` // Let the built-in bot play one color
+
+	// Color the signed in creator plays (1 = black, 2 = white, 0 = none); in bot and hotseat
+	// games they play every color the bot doesn't
+	Color int `json:"color"`
+
+	opponent *users.User // Account playing the other color, for games the server sets up (matchmaking, challenges)
 }
 
 // Create new Go game
 func newGame(c echo.Context) error {
-	// Create a new 19x19 Go board
-	board := game.NewBoard(19)
+	// Parse the optional game settings
+	var gameReq NewGameRequest
+	if err := c.Bind(&gameReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	// A signed in creator plays with their account
+	user, signedIn, err := account(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	gameID, board, tokens, unlock, err := createGame(c.Request().Context(), gameReq, user, signedIn)
+	var refused *GameRequestError
+	if errors.As(err, &refused) {
+		return c.JSON(refused.Status, refused.Body)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	defer unlock()
+
+	// Return the board state, with the game ID and seat tokens in the headers
+	setGameLocation(c, gameID)
+	setSeatTokens(c, tokens)
+	return respondBoard(c, http.StatusOK, gameID, board)
+}
+
+// GameRequestError is a game request the server refuses, with the status and body to answer with
+type GameRequestError struct {
+	Status  int
+	Message string
+	Body    any // The JSON body; {"error": Message} unless the error has details
+}
+
+func (e *GameRequestError) Error() string {
+	return e.Message
+}
+
+// refuseGame refuses a game request with a status and message
+func refuseGame(status int, message string) *GameRequestError {
+	return &GameRequestError{Status: status, Message: message, Body: map[string]string{"error": message}}
+}
+
+// createGame sets up a game as asked, makes it live, saves it and tells everyone
+// The game is returned locked; the caller must call unlock once done with it
+// Requests the server refuses fail with a *GameRequestError
+func createGame(ctx context.Context, gameReq NewGameRequest, user users.User, signedIn bool) (gameID string, board *game.Board, tokens SeatTokens, unlock func(), err error) {
+	if gameReq.MainTime < 0 || gameReq.ByoYomiTime < 0 || gameReq.ByoYomiPeriods < 0 {
+		return "", nil, tokens, nil, refuseGame(http.StatusBadRequest, "Invalid time control")
+	}
+	if message := gameReq.correspondenceError(); message != "" {
+		return "", nil, tokens, nil, refuseGame(http.StatusBadRequest, message)
+	}
+
+	// Sandbox accounts only ever play sandbox games, which keeps them out of the ratings
+	if (signedIn && isSandbox(user.ID)) || (gameReq.opponent != nil && isSandbox(gameReq.opponent.ID)) {
+		gameReq.Sandbox = true
+	}
+
+	// Only sizes the server allows; huge sizes would allocate huge grids
+	if gameReq.Size == 0 {
+		gameReq.Size = defaultBoardSize
+	}
+	if !boardSizes.Allows(gameReq.Size) {
+		sizeErr := boardSizes.sizeError(gameReq.Size)
+		return "", nil, tokens, nil, &GameRequestError{Status: http.StatusBadRequest, Message: sizeErr.Error, Body: sizeErr}
+	}
+
+	// Create a new Go board
+	board = game.NewBoard(gameReq.Size)
+	board.PrecomputeLegalMoves = gameReq.PrecomputeLegalMoves
+	board.Info.BlackName, board.Info.WhiteName = gameReq.BlackName, gameReq.WhiteName
+	board.Info.BlackRank, board.Info.WhiteRank = gameReq.BlackRank, gameReq.WhiteRank
+
+	// Select the rules variant
+	if gameReq.Variant != "" {
+		if err := board.SetVariant(gameReq.Variant); err != nil {
+			return "", nil, tokens, nil, refuseGame(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	// Seat the teams of a rengo game
+	if len(gameReq.BlackTeam) > 0 || len(gameReq.WhiteTeam) > 0 {
+		if err := board.SetTeams(gameReq.BlackTeam, gameReq.WhiteTeam); err != nil {
+			return "", nil, tokens, nil, refuseGame(http.StatusBadRequest, err.Error())
+		}
+	}
+	if gameReq.ConsultationTime != 0 {
+		if err := board.SetConsultation(time.Duration(gameReq.ConsultationTime) * time.Second); err != nil {
+			return "", nil, tokens, nil, refuseGame(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	// Pass-and-play on a single device
+	if gameReq.Hotseat {
+		if err := board.SetHotseat(); err != nil {
+			return "", nil, tokens, nil, refuseGame(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	// Create the bot before anything is stored, so bad settings fail the request
+	var opponent bot.Player
+	if gameReq.Bot != nil {
+		if board.Concealed() {
+			return "", nil, tokens, nil, refuseGame(http.StatusBadRequest, "The bot can't play hidden-information variants")
+		}
+		if opponent, err = newBotPlayer(gameReq.Bot); err != nil {
+			return "", nil, tokens, nil, refuseGame(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	// Attach a clock if the game is timed
+	if gameReq.DaysPerMove > 0 {
+		board.SetClock(game.NewCorrespondenceClock(time.Duration(gameReq.DaysPerMove) * day))
+	} else if gameReq.MainTime > 0 || (gameReq.ByoYomiTime > 0 && gameReq.ByoYomiPeriods > 0) {
+		board.SetClock(game.NewClock(
+			time.Duration(gameReq.MainTime)*time.Second,
+			time.Duration(gameReq.ByoYomiTime)*time.Second,
+			gameReq.ByoYomiPeriods,
+		))
+	}
+	if gameReq.VacationDays > 0 {
+		board.Clock.SetVacation(time.Duration(gameReq.VacationDays) * day)
+	}
+
+	// Seats go to whoever holds their token, except the bot's
+	botColor := 0
+	if gameReq.Bot != nil {
+		botColor = gameReq.Bot.Color
+	}
+	if tokens.Colors, err = assignSeats(board, botColor); err != nil {
+		return "", nil, tokens, nil, err
+	}
+	if tokens.Members, err = assignMemberSeats(board); err != nil {
+		return "", nil, tokens, nil, err
+	}
+
+	// A signed in creator plays with their account
+	if gameReq.Color < 0 || gameReq.Color > 2 {
+		return "", nil, tokens, nil, refuseGame(http.StatusBadRequest, "Invalid color")
+	}
+	for player := 1; player <= 2 && signedIn; player++ {
+		if player != botColor && (player == gameReq.Color || gameReq.Bot != nil || gameReq.Hotseat) {
+			if err := seatAccount(board, player, user); err != nil {
+				return "", nil, tokens, nil, err
+			}
+		}
+	}
 
-	// Store it with a fixed ID for now (use UUID in production)
-	gameID := "local"
-	games[gameID] = board
+	// Matched games seat the opponent's account on the other color
+	if gameReq.opponent != nil && (gameReq.Color == 1 || gameReq.Color == 2) {
+		if err := seatAccount(board, 3-gameReq.Color, *gameReq.opponent); err != nil {
+			return "", nil, tokens, nil, err
+		}
+	}
+
+	// Nothing to set up yet, so play starts right away
+	if err := board.Start(); err != nil {
+		return "", nil, tokens, nil, err
+	}
+
+	gamesMu.Lock()
+
+	// Every game gets its own ID; sandbox ones are marked so they can be told apart
+	gameID = newGameID()
+	if gameReq.Sandbox {
+		if len(sandboxGames) >= maxSandboxGames {
+			gamesMu.Unlock()
+			return "", nil, tokens, nil, refuseGame(http.StatusServiceUnavailable, "Too many sandbox games, try again later")
+		}
+		gameID = sandboxPrefix + gameID
+		board.Sandbox = true
+		sandboxGames[gameID] = time.Now()
+	}
+	unlock = addGame(gameID, board)
+	if opponent != nil {
+		bots[gameID] = opponent
+	}
+	gamesMu.Unlock()
 
-	// Return the board state
-	return c.JSON(http.StatusOK, board)
+	saveGame(ctx, gameID, board)
+
+	announceCreated(gameID, board)
+	scheduleBotMove(ctx, gameID, board) // The bot may have black
+	return gameID, board, tokens, unlock, nil
 }
 
 // Get current game state
 func getGame(c echo.Context) error {
 	gameID := c.Param("id")
 
-	// Find the game
-	board, exists := games[gameID]
-	if !exists {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	// Find the game, falling back to the store for games not live on this server
+	board, unlock, exists := lockGame(gameID)
+	if exists {
+		defer unlock()
+	} else {
+		stored, err := gameStore.LoadGame(c.Request().Context(), gameID)
+		if errors.Is(err, store.ErrCorrupted) {
+			log.Printf("loading game %s: %v", gameID, err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "The stored game failed its integrity check"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+		}
+		board = stored
 	}
 
-	return c.JSON(http.StatusOK, board)
+	// Hidden-information variants only show what the asking player may see
+	view := board.ViewFor(viewerOf(c, board))
+
+	// Command line clients can ask for a plain diagram
+	if c.QueryParam("format") == "text" {
+		return c.String(http.StatusOK, view.String())
+	}
+
+	// Clients that poll a lot can ask for the compact grid encoding (the v1 response is compact already)
+	if c.QueryParam("grid") == "packed" && apiVersion(c) == 0 {
+		packed, err := view.MarshalPackedJSON()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return c.JSONBlob(http.StatusOK, packed)
+	}
+	return respondBoard(c, http.StatusOK, gameID, view)
+}
+
+// viewerOf works out which player is looking at a game from the seat token or account of
+// the request; the "player" query parameter (1 = black, 2 = white) picks a seat it may act
+// for, e.g. in hotseat games; anyone else is a spectator
+func viewerOf(c echo.Context, board *game.Board) int {
+	player, _ := strconv.Atoi(c.QueryParam("player"))
+	if player == 0 {
+		player = requestSeat(c, board)
+	}
+	user, _ := currentUser(c)
+	return viewerFor(board, seatToken(c), user.ID, player)
 }
 
 // Move request structure
 type MoveRequest struct {
-	Position int  `json:"position"` // Board position (0-360 for 19x19)
-	Pass     bool `json:"pass"`     // True if player wants to pass
+	Position   int    `json:"position"`   // Board position (0-360 for 19x19)
+	Coordinate string `json:"coordinate"` // Board position in standard notation ("D4", "pass"); used instead of position if set
+	Pass       bool   `json:"pass"`       // True if player wants to pass
+	Player     string `json:"player"`     // Team member making the move (team games whose members hold no seats)
 }
 
 // Process player move
@@ -76,33 +720,111 @@ func makeMove(c echo.Context) error {
 	gameID := c.Param("id")
 
 	// Find the game
-	board, exists := games[gameID]
+	board, unlock, exists := lockGame(gameID)
 	if !exists {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
 	}
+	defer unlock()
 
 	// Parse the move request
 	var moveReq MoveRequest
-	if err := c.Bind(&moveReq); err != nil {
+	if err := bindMove(c, &moveReq); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
 	}
 
+	// Only the player to move may, with the token of their seat
+	mover := board.CurrentPlayer
+	if err := checkRequestSeat(c, board, mover); err != nil {
+		return c.JSON(seatStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	// Attempt to make the move, as the team member holding the seat token
+	moveReq.Player = teamMember(board, seatToken(c), moveReq.Player)
+	if err := playMove(c.Request().Context(), gameID, board, moveReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	// Return updated board state, as the player who moved may see it
+	return respondBoard(c, http.StatusOK, gameID, board.ViewFor(mover))
+}
+
+// playMove applies a move request to a live game, saves it and tells everyone following it
+// Must be called with the game locked
+func playMove(ctx context.Context, gameID string, board *game.Board, moveReq MoveRequest) error {
+	phase := board.Phase
+	if err := applyMove(board, moveReq); err != nil {
+		announceViolation(gameID, err)
+		saveGame(ctx, gameID, board) // The move may have lost the game on time
+		announceResult(gameID, board, phase)
+		return err
+	}
+	saveGame(ctx, gameID, board)
+	announceMove(gameID, board)
+	playConditional(ctx, gameID, board)
+	scheduleBotMove(ctx, gameID, board)
+	return nil
+}
+
+// applyMove plays the pass or stone described by a move request
+func applyMove(board *game.Board, moveReq MoveRequest) error {
+	defer moveValidationTime.ObserveSince(time.Now())
+	if err := playMoveRequest(board, moveReq); err != nil {
+		return err
+	}
+	movesPlayed.Inc()
+	return nil
+}
+
+// playMoveRequest checks a move request and plays it on the board
+func playMoveRequest(board *game.Board, moveReq MoveRequest) error {
+	// In team games only the next member in the rotation may move
+	if err := board.CheckMover(moveReq.Player); err != nil {
+		return err
+	}
+
+	// Standard notation takes precedence over the raw position
+	if moveReq.Coordinate != "" {
+		position, err := game.ParseCoordinate(moveReq.Coordinate, board.Size)
+		if err != nil {
+			return err
+		}
+		moveReq.Position, moveReq.Pass = position, moveReq.Pass || position < 0
+	}
+
 	// Handle pass move
 	if moveReq.Pass {
-		board.Pass()
-		return c.JSON(http.StatusOK, board)
+		return board.Pass()
 	}
 
 	// Validate position range
 	if moveReq.Position < 0 || moveReq.Position >= board.Size*board.Size {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Position out of bounds"})
+		return errors.New("position out of bounds")
 	}
 
-	// Attempt to make the move
-	if err := board.MakeMove(moveReq.Position); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	return board.MakeMove(moveReq.Position)
+}
+
+// Legal moves response structure
+type LegalMovesResponse struct {
+	Player int   `json:"player"` // Player the moves are legal for
+	Moves  []int `json:"moves"`  // Positions where that player may play
+}
+
+// List the legal moves of the player to move
+func getLegalMoves(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	// Which points are occupied would give hidden stones away
+	if board.Concealed() {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not available while stones are hidden"})
 	}
 
-	// Return updated board state
-	return c.JSON(http.StatusOK, board)
+	return c.JSON(http.StatusOK, LegalMovesResponse{Player: board.CurrentPlayer, Moves: board.LegalMoves()})
 }
@@ -0,0 +1,181 @@
+// Package matchmaking pairs players waiting for a game with others whose preferences
+// (board size, time control, rating range) are compatible
+package matchmaking
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// TimeControl is the clock of the game a player wants; both players must want the same one
+type TimeControl struct {
+	MainTime       int `json:"mainTime"`       // Seconds per player (0 = untimed)
+	ByoYomiTime    int `json:"byoYomiTime"`    // Seconds per period
+	ByoYomiPeriods int `json:"byoYomiPeriods"` // Periods per player
+}
+
+// Entry is a player waiting in the queue
+type Entry struct {
+	UserID      string      `json:"userId"`
+	Rating      float64     `json:"rating"`
+	Sizes       []int       `json:"sizes"` // Board sizes the player accepts, preferred first
+	TimeControl TimeControl `json:"timeControl"`
+	RatingRange float64     `json:"ratingRange"` // Largest rating gap to the opponent (0 = any)
+	Joined      time.Time   `json:"joined"`
+}
+
+// accepts reports whether the entry's player takes an opponent of a rating
+func (e Entry) accepts(rating float64) bool {
+	return e.RatingRange <= 0 || math.Abs(e.Rating-rating) <= e.RatingRange
+}
+
+// Pairing is two players matched for a game; black is the lower rated one
+type Pairing struct {
+	Black, White Entry
+	Size         int
+}
+
+// Queue holds the players waiting for a game, in the order they joined
+type Queue struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewQueue creates an empty queue
+func NewQueue() *Queue {
+	return &Queue{}
+}
+
+// Join adds a player to the queue, replacing their earlier entry if they were waiting already
+func (q *Queue) Join(entry Entry) error {
+	if entry.UserID == "" {
+		return errors.New("only signed in players can wait for a game")
+	}
+	if len(entry.Sizes) == 0 {
+		return errors.New("accept at least one board size")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.remove(entry.UserID)
+	q.entries = append(q.entries, entry)
+	return nil
+}
+
+// Leave takes a player out of the queue; false if they weren't waiting
+func (q *Queue) Leave(userID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.remove(userID)
+}
+
+// Get returns the entry of a waiting player
+func (q *Queue) Get(userID string) (Entry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, entry := range q.entries {
+		if entry.UserID == userID {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Len returns how many players are waiting
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.entries)
+}
+
+// Match pairs the waiting players who can play each other and takes them out of the queue
+// Players who waited longest are served first, with the first compatible player after them
+func (q *Queue) Match() []Pairing {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var pairings []Pairing
+	matched := make([]bool, len(q.entries))
+	for i, first := range q.entries {
+		if matched[i] {
+			continue
+		}
+		for j := i + 1; j < len(q.entries); j++ {
+			if matched[j] {
+				continue
+			}
+			if pairing, ok := pair(first, q.entries[j]); ok {
+				pairings = append(pairings, pairing)
+				matched[i], matched[j] = true, true
+				break
+			}
+		}
+	}
+
+	waiting := q.entries[:0]
+	for i, entry := range q.entries {
+		if !matched[i] {
+			waiting = append(waiting, entry)
+		}
+	}
+	clear(q.entries[len(waiting):])
+	q.entries = waiting
+	return pairings
+}
+
+// Expire takes out the players who have waited longer than maxWait and returns them
+func (q *Queue) Expire(now time.Time, maxWait time.Duration) []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var expired []Entry
+	waiting := q.entries[:0]
+	for _, entry := range q.entries {
+		if now.Sub(entry.Joined) > maxWait {
+			expired = append(expired, entry)
+		} else {
+			waiting = append(waiting, entry)
+		}
+	}
+	clear(q.entries[len(waiting):])
+	q.entries = waiting
+	return expired
+}
+
+// remove drops the entry of a player; must be called with mu held
+func (q *Queue) remove(userID string) bool {
+	for i, entry := range q.entries {
+		if entry.UserID == userID {
+			q.entries = slices.Delete(q.entries, i, i+1)
+			return true
+		}
+	}
+	return false
+}
+
+// pair matches two players if their preferences agree, on the first size the earlier one prefers
+func pair(first, second Entry) (Pairing, bool) {
+	if first.UserID == second.UserID || first.TimeControl != second.TimeControl {
+		return Pairing{}, false
+	}
+	if !first.accepts(second.Rating) || !second.accepts(first.Rating) {
+		return Pairing{}, false
+	}
+
+	for _, size := range first.Sizes {
+		if slices.Contains(second.Sizes, size) {
+			if second.Rating < first.Rating {
+				return Pairing{Black: second, White: first, Size: size}, true
+			}
+			return Pairing{Black: first, White: second, Size: size}, true
+		}
+	}
+	return Pairing{}, false
+}
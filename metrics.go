@@ -0,0 +1,54 @@
+package main
+
+import (
+	"go-game/metrics"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Operational metrics, scraped by Prometheus from /metrics
+// Set METRICS_TOKEN to make scrapers send it as a bearer token
+
+// registry holds every metric the server exposes
+var registry = &metrics.Registry{}
+
+// Metrics kept as things happen
+var (
+	movesPlayed          = &metrics.Counter{}
+	moveValidationTime   = metrics.NewHistogram(metrics.DurationBuckets...)
+	webSocketConnections = &metrics.Gauge{}
+)
+
+func init() {
+	registry.Register("go_game_active_games", "Games live in memory on this server", metrics.GaugeFunc(func() float64 {
+		gamesMu.Lock()
+		defer gamesMu.Unlock()
+		return float64(len(games))
+	}))
+	registry.Register("go_game_moves_total", "Moves and passes played", movesPlayed)
+	registry.Register("go_game_move_validation_seconds", "Time taken to check and apply a move", moveValidationTime)
+	registry.Register("go_game_websocket_connections", "Open WebSocket connections", webSocketConnections)
+	registry.Register("go_game_matchmaking_queue_depth", "Players waiting in the automatch queue", metrics.GaugeFunc(func() float64 {
+		return float64(matchQueue.Len())
+	}))
+	registry.Register("go_game_analysis_queue_depth", "Playouts waiting for an analysis worker", metrics.GaugeFunc(func() float64 {
+		return float64(analysisEngine.Queued())
+	}))
+}
+
+// metricsToken is the bearer token scrapers must send (METRICS_TOKEN, none if empty)
+var metricsToken = os.Getenv("METRICS_TOKEN")
+
+// Serve the metrics in the Prometheus text format
+func getMetrics(c echo.Context) error {
+	if metricsToken != "" && strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer ") != metricsToken {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid metrics token"})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, metrics.ContentType)
+	c.Response().WriteHeader(http.StatusOK)
+	return registry.WriteText(c.Response())
+}
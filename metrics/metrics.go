@@ -0,0 +1,183 @@
+// Package metrics keeps counters, gauges and histograms and writes them in the Prometheus
+// text format, so operators can scrape the server without a client library
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metric is anything a registry can write
+type Metric interface {
+	kind() string
+	write(w io.Writer, name string)
+}
+
+// Counter only goes up
+type Counter struct {
+	value atomic.Uint64
+}
+
+// Inc adds one
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Value returns the count so far
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+func (c *Counter) kind() string { return "counter" }
+
+func (c *Counter) write(w io.Writer, name string) {
+	fmt.Fprintf(w, "%s %d\n", name, c.Value())
+}
+
+// Gauge goes up and down
+type Gauge struct {
+	value atomic.Int64
+}
+
+// Add changes the gauge by delta
+func (g *Gauge) Add(delta int64) {
+	g.value.Add(delta)
+}
+
+// Value returns the current value
+func (g *Gauge) Value() int64 {
+	return g.value.Load()
+}
+
+func (g *Gauge) kind() string { return "gauge" }
+
+func (g *Gauge) write(w io.Writer, name string) {
+	fmt.Fprintf(w, "%s %d\n", name, g.Value())
+}
+
+// GaugeFunc is a gauge read when the metrics are written, for values kept elsewhere
+type GaugeFunc func() float64
+
+func (f GaugeFunc) kind() string { return "gauge" }
+
+func (f GaugeFunc) write(w io.Writer, name string) {
+	fmt.Fprintf(w, "%s %s\n", name, formatFloat(f()))
+}
+
+// Histogram counts observations in buckets by their upper bounds
+type Histogram struct {
+	mu      sync.Mutex
+	bounds  []float64
+	buckets []uint64 // Not cumulative; the last one is +Inf
+	count   uint64
+	sum     float64
+}
+
+// NewHistogram creates a histogram with the given bucket upper bounds
+func NewHistogram(bounds ...float64) *Histogram {
+	bounds = append([]float64(nil), bounds...)
+	sort.Float64s(bounds)
+	return &Histogram{bounds: bounds, buckets: make([]uint64, len(bounds)+1)}
+}
+
+// DurationBuckets are bucket bounds in seconds for work taking from microseconds to a second
+var DurationBuckets = []float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// Observe records a value
+func (h *Histogram) Observe(value float64) {
+	i := sort.SearchFloat64s(h.bounds, value) // First bound >= value
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buckets[i]++
+	h.count++
+	h.sum += value
+}
+
+// ObserveSince records the seconds since start
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+func (h *Histogram) kind() string { return "histogram" }
+
+func (h *Histogram) write(w io.Writer, name string) {
+	h.mu.Lock()
+	buckets := append([]uint64(nil), h.buckets...)
+	count, sum := h.count, h.sum
+	h.mu.Unlock()
+
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += buckets[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, count)
+	fmt.Fprintf(w, "%s_sum %s\n", name, formatFloat(sum))
+	fmt.Fprintf(w, "%s_count %d\n", name, count)
+}
+
+// formatFloat writes a value the way Prometheus reads it
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// entry is a registered metric
+type entry struct {
+	name   string
+	help   string
+	metric Metric
+}
+
+// Registry is a set of named metrics
+type Registry struct {
+	mu      sync.Mutex
+	entries []entry
+}
+
+// Register adds a metric under a name, e.g. "go_game_moves_total"
+// Names must be unique; registering one twice panics, as it is a programming error
+func (r *Registry) Register(name, help string, metric Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.entries {
+		if e.name == name {
+			panic("metrics: " + name + " registered twice")
+		}
+	}
+	r.entries = append(r.entries, entry{name: name, help: help, metric: metric})
+}
+
+// WriteText writes every metric in the Prometheus text format, sorted by name
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	entries := append([]entry(nil), r.entries...)
+	r.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	buffered := bufio.NewWriter(w)
+	for _, e := range entries {
+		fmt.Fprintf(buffered, "# HELP %s %s\n", e.name, e.help)
+		fmt.Fprintf(buffered, "# TYPE %s %s\n", e.name, e.metric.kind())
+		e.metric.write(buffered, e.name)
+	}
+	return buffered.Flush()
+}
+
+// ContentType is the media type of the text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
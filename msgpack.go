@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// mimeMsgpack is the content type of MessagePack responses
+const mimeMsgpack = "application/msgpack"
+
+// WebSocket subprotocols a client can ask for at connect time, in Sec-WebSocket-Protocol
+// Game updates are sent as text frames of JSON, or binary frames of MessagePack
+const (
+	socketProtocolJSON    = "go-game.json"
+	socketProtocolMsgpack = "go-game.msgpack"
+)
+
+// wantsMsgpack reports whether the client asked for MessagePack responses
+func wantsMsgpack(c echo.Context) bool {
+	return accepts(c, mimeMsgpack, "application/x-msgpack")
+}
+
+// marshalMsgpack encodes a value as MessagePack with the same structure and field names as its JSON,
+// so clients can switch encodings without changing how they read the messages
+func marshalMsgpack(v any) ([]byte, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var tree any
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	encoder := msgpack.NewEncoder(&out)
+	encoder.UseCompactInts(true)
+	encoder.UseCompactFloats(true)
+	if err := encoder.Encode(compactNumbers(tree)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// compactNumbers turns the JSON numbers of a decoded tree into integers where they are whole,
+// which MessagePack stores in as little as one byte
+func compactNumbers(node any) any {
+	switch value := node.(type) {
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		f, _ := value.Float64()
+		return f
+	case []any:
+		for i := range value {
+			value[i] = compactNumbers(value[i])
+		}
+	case map[string]any:
+		for key := range value {
+			value[key] = compactNumbers(value[key])
+		}
+	}
+	return node
+}
+
+// msgpackBlob sends a value as a MessagePack response
+func msgpackBlob(c echo.Context, status int, v any) error {
+	data, err := marshalMsgpack(v)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.Blob(status, mimeMsgpack, data)
+}
+
+// socketProtocol picks the subprotocol of a WebSocket connection from the ones the client offered
+// MessagePack wins when offered; clients offering nothing get JSON
+func socketProtocol(r *http.Request) string {
+	for _, offered := range strings.Split(r.Header.Get("Sec-WebSocket-Protocol"), ",") {
+		if strings.TrimSpace(offered) == socketProtocolMsgpack {
+			return socketProtocolMsgpack
+		}
+	}
+	return socketProtocolJSON
+}
+
+// encodeSocketMessage encodes a game update for a WebSocket connection using its subprotocol
+// and reports whether it goes in a binary frame
+func encodeSocketMessage(protocol string, v any) ([]byte, bool, error) {
+	if protocol == socketProtocolMsgpack {
+		data, err := marshalMsgpack(v)
+		return data, true, err
+	}
+	data, err := json.Marshal(v)
+	return data, false, err
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	v1 "go-game/api/v1"
+	"go-game/archive"
+	"go-game/auth"
+	"go-game/chat"
+	"go-game/federation"
+	"go-game/game"
+	"go-game/kifu"
+	"go-game/openapi"
+	"go-game/passport"
+	"go-game/rating"
+	"go-game/users"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Query parameters shared by several routes
+var (
+	playerQuery      = openapi.Query{Name: "player", Type: "integer", Description: "Player looking at the game (1 = black, 2 = white), shown what they may see only with their seat token or account; the seat of the request if omitted"}
+	limitQuery       = openapi.Query{Name: "limit", Type: "integer", Description: "Page size"}
+	cursorQuery      = openapi.Query{Name: "cursor", Type: "integer", Description: "Cursor returned by the previous page"}
+	conditionalQuery = openapi.Query{Name: "player", Type: "integer", Description: "Player the conditional moves are for (1 = black, 2 = white); the one waiting if omitted"}
+	includeQuery     = openapi.Query{Name: "include", Type: "string", Description: "Comma-separated expansions of the v1 response: moves, legal, scoring, info"}
+
+	// Board image styles
+	themeQuery    = openapi.Query{Name: "theme", Type: "string", Description: "Palette: classic, colorblind or high-contrast"}
+	lastMoveQuery = openapi.Query{Name: "lastMove", Type: "string", Description: "Last move marker: circle, square, triangle, cross or none"}
+	markersQuery  = openapi.Query{Name: "markers", Type: "boolean", Description: "Mark black stones with a square and white stones with a diamond"}
+)
+
+// apiRoutes describes the REST API for the OpenAPI document
+// Keep it in step with the routes registered in main
+var apiRoutes = []openapi.Route{
+	{Method: http.MethodPost, Path: "/sandbox/accounts", Summary: "Create a throwaway account, signed in; it only plays sandbox games and is purged with them", Request: SandboxAccountRequest{}, Response: SandboxAccountResponse{}},
+	{Method: http.MethodPost, Path: "/game/new", Summary: "Create new game (seat tokens in X-Black-Token and X-White-Token, those of team members in X-Team-Tokens)", Request: NewGameRequest{}, Response: game.Board{}},
+	{Method: http.MethodPost, Path: "/game/import", Summary: "Create a game from an SGF record", RequestType: "application/x-go-sgf", Response: game.Board{}},
+	{Method: http.MethodPost, Path: "/game/import/ogs", Summary: "Create a game from an online-go.com game", Request: OGSImportRequest{}, Response: game.Board{}},
+	{Method: http.MethodPost, Path: "/game/import/kgs", Summary: "Store the games of a KGS archive (zip body, or a JSON request)", Request: KGSImportRequest{}, Response: ArchiveImportReport{}},
+	{Method: http.MethodGet, Path: "/game/:id", Summary: "Get game state", Response: game.Board{}, Query: []openapi.Query{
+		playerQuery,
+		{Name: "format", Type: "string", Description: "\"text\" for a plain diagram"},
+		{Name: "grid", Type: "string", Description: "\"packed\" for the base64 grid encoding"},
+	}},
+	{Method: http.MethodPost, Path: "/game/:id/move", Summary: "Make a move (seat token in X-Seat-Token)", Request: MoveRequest{}, Response: game.Board{}},
+	{Method: http.MethodPost, Path: "/game/:id/moves", Summary: "Apply up to 50 moves queued while offline, each counted as a move by the rate limit (seat token in X-Seat-Token)", Request: MoveBatchRequest{}, Response: ReconciliationReport{}},
+	{Method: http.MethodGet, Path: "/game/:id/kifu", Summary: "Printable record with numbered figures", Response: kifu.Kifu{}, Query: []openapi.Query{
+		{Name: "movesPerFigure", Type: "integer", Description: "Moves per figure (default 100)"},
+	}},
+	{Method: http.MethodGet, Path: "/game/:id/sgf", Summary: "Download the game record", ContentType: "application/x-go-sgf", Query: []openapi.Query{
+		{Name: "anchors", Type: "boolean", Description: "Label every move with its review link anchor"},
+	}},
+	{Method: http.MethodGet, Path: "/game/:id/image.png", Summary: "Picture of the current position", ContentType: "image/png", Query: []openapi.Query{
+		playerQuery,
+		{Name: "cell", Type: "integer", Description: "Pixels between two lines"},
+		themeQuery, lastMoveQuery, markersQuery,
+	}},
+	{Method: http.MethodGet, Path: "/game/:id/image.svg", Summary: "Scalable picture with optional review overlays", ContentType: "image/svg+xml", Query: []openapi.Query{
+		playerQuery,
+		{Name: "overlay", Type: "string", Description: "Comma-separated overlays: numbers, territory, analysis"},
+		themeQuery, lastMoveQuery, markersQuery,
+	}},
+	{Method: http.MethodGet, Path: "/game/:id/events", Summary: "Server-Sent Events stream of the game, a fallback for WebSockets", ContentType: "text/event-stream", Query: []openapi.Query{
+		playerQuery,
+		{Name: "seat_token", Type: "string", Description: "Token of the seat, for clients that can't send X-Seat-Token"},
+		{Name: "lastEventId", Type: "integer", Description: "Resume after this event, for clients that can't send Last-Event-ID"},
+	}},
+	{Method: http.MethodGet, Path: "/game/:id/legal-moves", Summary: "List legal moves for the player to move", Response: LegalMovesResponse{}},
+	{Method: http.MethodGet, Path: "/game/:id/estimate", Summary: "Playout-based score and ownership estimate", Response: EstimateResponse{}, Query: []openapi.Query{
+		{Name: "playouts", Type: "integer", Description: "Number of playouts"},
+		{Name: "budget", Type: "string", Description: "Time budget, e.g. \"2s\""},
+	}},
+	{Method: http.MethodGet, Path: "/game/:id/engine", Summary: "Ask an external GTP engine about the position", Response: EngineAnalysis{}, Query: []openapi.Query{
+		{Name: "engine", Type: "string", Description: "Name of a configured engine"},
+	}},
+	{Method: http.MethodGet, Path: "/game/:id/score", Summary: "Count the position", Response: ScoreResponse{}},
+	{Method: http.MethodGet, Path: "/game/:id/result", Summary: "Result as data and localized text", Response: ResultDescription{}, Query: []openapi.Query{
+		{Name: "lang", Type: "string", Description: "Language of the text; the Accept-Language header is used otherwise"},
+	}},
+	{Method: http.MethodGet, Path: "/game/:id/pace", Summary: "Move pace statistics", Response: PaceResponse{}},
+	{Method: http.MethodPost, Path: "/game/:id/kibitz", Summary: "Spectator comment", Request: KibitzRequest{}, Response: KibitzMessage{}},
+	{Method: http.MethodGet, Path: "/game/:id/kibitz", Summary: "Spectator comments", Response: []KibitzMessage{}},
+	{Method: http.MethodPost, Path: "/game/:id/chat", Summary: "Say something in the players' channel (seat token in X-Seat-Token) or the spectators' channel", Request: ChatRequest{}, Response: chat.Message{}},
+	{Method: http.MethodGet, Path: "/game/:id/chat", Summary: "Messages of a chat channel; the spectators' is hidden from the players until the game ends", Response: []chat.Message{}, Query: []openapi.Query{
+		{Name: "channel", Type: "string", Description: "\"players\" (the default) or \"spectators\""},
+	}},
+	{Method: http.MethodPost, Path: "/game/:id/predictions", Summary: "Spectator guess of the next move", Request: PredictionRequest{}, Response: PredictionHeatmap{}, Query: []openapi.Query{playerQuery}},
+	{Method: http.MethodGet, Path: "/game/:id/predictions", Summary: "Heatmap of the guesses (spectators only)", Response: PredictionHeatmap{}, Query: []openapi.Query{playerQuery}},
+	{Method: http.MethodGet, Path: "/game/:id/review", Summary: "Moves with the comments made about them", Response: ReviewResponse{}},
+	{Method: http.MethodGet, Path: "/game/:id/replay", Summary: "Position at a review link anchor", Response: ReplayResponse{}, Query: []openapi.Query{
+		{Name: "move", Type: "integer", Description: "Move number (0 = start position)"},
+		{Name: "var", Type: "integer", Description: "Variation (0 = main line)"},
+	}},
+	{Method: http.MethodGet, Path: "/game/:id/passport", Summary: "Signed portable record of a finished game", Response: passport.Passport{}},
+	{Method: http.MethodPost, Path: "/game/:id/dead", Summary: "Mark dead stones during scoring", Request: DeadStoneRequest{}, Response: ScoreResponse{}},
+	{Method: http.MethodPost, Path: "/game/:id/accept-score", Summary: "Agree to the counted score", Request: AcceptScoreRequest{}, Response: game.Board{}},
+	{Method: http.MethodPost, Path: "/game/:id/resume", Summary: "Go back to playing from scoring", Response: game.Board{}, Query: []openapi.Query{playerQuery}},
+	{Method: http.MethodPost, Path: "/game/:id/resign", Summary: "Give up the game (seat token in X-Seat-Token)", Request: ResignRequest{}, Response: game.Board{}},
+	{Method: http.MethodGet, Path: "/game/:id/conditional", Summary: "A player's conditional moves (seat token in X-Seat-Token)", Response: ConditionalRequest{}, Query: []openapi.Query{
+		conditionalQuery,
+	}},
+	{Method: http.MethodPut, Path: "/game/:id/conditional", Summary: "Register replies to play when the opponent plays the moves expected (seat token in X-Seat-Token)", Request: ConditionalRequest{}, Response: ConditionalRequest{}},
+	{Method: http.MethodDelete, Path: "/game/:id/conditional", Summary: "Drop a player's conditional moves (seat token in X-Seat-Token)", Query: []openapi.Query{
+		conditionalQuery,
+	}},
+	{Method: http.MethodPost, Path: "/game/:id/abandon", Summary: "Annul the game before its first move, or resign it after (seat token in X-Seat-Token); admins annul it any time (admin key)", Response: game.Board{}},
+	{Method: http.MethodDelete, Path: "/game/:id", Summary: "Delete the game for good, before its first move (seat token in X-Seat-Token); finished and started games only with the admin key"},
+	{Method: http.MethodPost, Path: "/game/:id/consultation", Summary: "Start or end a consultation of the rengo team to move", Request: ConsultationRequest{}, Response: ConsultationState{}},
+	{Method: http.MethodGet, Path: "/games", Summary: "List games", Response: []GameSync{}, Query: []openapi.Query{
+		limitQuery,
+		{Name: "offset", Type: "integer", Description: "Games to skip"},
+	}},
+	{Method: http.MethodGet, Path: "/games/archive.zip", Summary: "Finished games of a player or tournament as SGF files", ContentType: "application/zip", Query: []openapi.Query{
+		{Name: "player", Type: "string", Description: "Account of the player, whose games are found through the index"},
+		{Name: "name", Type: "string", Description: "Player name, as either color or a team member"},
+		{Name: "event", Type: "string", Description: "Tournament name, as recorded in the games"},
+	}},
+	{Method: http.MethodGet, Path: "/games/featured", Summary: "Live games worth watching, best first", Response: []FeaturedGame{}},
+	{Method: http.MethodGet, Path: "/sync", Summary: "Batched catch-up on the user's games for mobile clients", Response: SyncResponse{}, Auth: true, Query: []openapi.Query{cursorQuery}},
+	{Method: http.MethodGet, Path: "/events", Summary: "Server-wide event firehose for analytics", Response: EventPageResponse{}, Auth: true, Query: []openapi.Query{cursorQuery, limitQuery}},
+	{Method: http.MethodGet, Path: "/score-checks", Summary: "Final scores compared with the reference engine", Response: []ScoreCheck{}, Query: []openapi.Query{
+		{Name: "flagged", Type: "boolean", Description: "Only the checks needing review"},
+	}},
+	{Method: http.MethodGet, Path: "/healthz", Summary: "Liveness probe", Response: HealthResponse{}},
+	{Method: http.MethodGet, Path: "/readyz", Summary: "Readiness probe: stores and engines work (503 if not)", Response: HealthResponse{}},
+	{Method: http.MethodPost, Path: "/auth/guest", Summary: "Tokens for a new guest user", Request: GuestRequest{}, Response: auth.Tokens{}},
+	{Method: http.MethodPost, Path: "/auth/refresh", Summary: "Trade a refresh token for new tokens", Request: RefreshRequest{}, Response: auth.Tokens{}},
+	{Method: http.MethodGet, Path: "/auth/me", Summary: "The authenticated user", Response: users.User{}, Auth: true},
+	{Method: http.MethodPost, Path: "/auth/register", Summary: "Create an account and sign in", Request: RegisterRequest{}, Response: SignInResponse{}},
+	{Method: http.MethodPost, Path: "/auth/login", Summary: "Sign in with a username and password", Request: LoginRequest{}, Response: SignInResponse{}},
+	{Method: http.MethodPost, Path: "/auth/session", Summary: "Sign in with a session cookie (browsers)", Request: LoginRequest{}, Response: users.User{}},
+	{Method: http.MethodDelete, Path: "/auth/session", Summary: "Sign out of the cookie's session"},
+	{Method: http.MethodGet, Path: "/auth/sessions", Summary: "Browsers the user is signed in on", Response: []SessionResponse{}, Auth: true},
+	{Method: http.MethodDelete, Path: "/auth/sessions", Summary: "Sign out everywhere else", Auth: true},
+	{Method: http.MethodDelete, Path: "/auth/sessions/:sid", Summary: "Sign out a browser", Auth: true},
+	{Method: http.MethodGet, Path: "/users/:id", Summary: "Public profile", Response: users.Profile{}},
+	{Method: http.MethodGet, Path: "/users/:id/ratings", Summary: "Rating changes of an account, newest first", Response: []rating.Change{}, Query: []openapi.Query{
+		{Name: "track", Type: "string", Description: "Only one board size: \"9x9\", \"13x13\" or \"19x19\""},
+		limitQuery,
+	}},
+	{Method: http.MethodGet, Path: "/players/:id", Summary: "Profile with rating history, record by color, board sizes, game length and recent games", Response: PlayerStats{}},
+	{Method: http.MethodGet, Path: "/players/:id/games", Summary: "Finished games of a player, most recent first", Response: []archive.Entry{}, Query: []openapi.Query{
+		{Name: "outcome", Type: "string", Description: "Only the games the player had this outcome in: \"win\", \"loss\" or \"draw\""},
+		{Name: "opponent", Type: "string", Description: "Only the games against this account (ID or username)"},
+		limitQuery,
+		{Name: "offset", Type: "integer", Description: "Games to skip"},
+	}},
+	{Method: http.MethodPatch, Path: "/users/me", Summary: "Change display name, rank, email or password (with currentPassword)", Request: ProfileRequest{}, Response: users.User{}, Auth: true},
+	{Method: http.MethodPost, Path: "/game/:id/seat", Summary: "Play a seat with the account, given its token (X-Seat-Token)", Response: game.Board{}, Auth: true},
+	{Method: http.MethodPost, Path: "/game/:id/rematch", Summary: "Ask for a rematch, colors swapped; accepts the opponent's if they asked first", Response: Challenge{}, Auth: true},
+	{Method: http.MethodPost, Path: "/match", Summary: "Wait for an opponent with compatible preferences", Request: MatchRequest{}, Response: MatchStatus{}, Auth: true},
+	{Method: http.MethodGet, Path: "/match", Summary: "Waiting, or the game automatch found", Response: MatchStatus{}, Auth: true},
+	{Method: http.MethodDelete, Path: "/match", Summary: "Stop waiting for an opponent", Auth: true},
+	{Method: http.MethodGet, Path: "/vacation", Summary: "The user's correspondence games and their vacation time left", Response: VacationStatus{}, Auth: true},
+	{Method: http.MethodPost, Path: "/vacation", Summary: "Go on vacation: the user's correspondence clocks stop while vacation time lasts", Response: VacationStatus{}, Auth: true},
+	{Method: http.MethodDelete, Path: "/vacation", Summary: "Come back from vacation", Response: VacationStatus{}, Auth: true},
+	{Method: http.MethodPost, Path: "/challenges", Summary: "Challenge a player, or make a link anyone can accept", Request: ChallengeRequest{}, Response: Challenge{}, Auth: true},
+	{Method: http.MethodGet, Path: "/challenges", Summary: "Open challenges the user sent or received", Response: []Challenge{}, Auth: true},
+	{Method: http.MethodGet, Path: "/challenges/:cid", Summary: "Get a challenge", Response: Challenge{}},
+	{Method: http.MethodPost, Path: "/challenges/:cid/accept", Summary: "Accept a challenge and start its game", Response: Challenge{}, Auth: true},
+	{Method: http.MethodPost, Path: "/challenges/:cid/decline", Summary: "Decline a challenge sent to the user", Response: Challenge{}, Auth: true},
+	{Method: http.MethodDelete, Path: "/challenges/:cid", Summary: "Cancel one of the user's challenges", Response: Challenge{}, Auth: true},
+	{Method: http.MethodPost, Path: "/webhooks", Summary: "Register a URL for signed game events (the secret is only shown now)", Request: WebhookRequest{}, Response: WebhookResponse{}, Auth: true},
+	{Method: http.MethodGet, Path: "/webhooks", Summary: "The user's webhooks", Response: []WebhookResponse{}, Auth: true},
+	{Method: http.MethodDelete, Path: "/webhooks/:wid", Summary: "Remove a webhook", Auth: true},
+	{Method: http.MethodGet, Path: "/engine/quota", Summary: "Engine time the caller has used and has left", Response: EngineQuota{}},
+	{Method: http.MethodPost, Path: "/reports/tournament", Summary: "EGF or AGA rating report for a tournament", Request: TournamentReportRequest{}, ContentType: "text/plain"},
+	{Method: http.MethodGet, Path: "/tournaments/:name/roster", Summary: "Entrants registered for a tournament", Response: []federation.Player{}},
+	{Method: http.MethodGet, Path: "/ratings/handicap", Summary: "Fair handicap and expected result for two ratings", Response: HandicapSuggestion{}, Query: []openapi.Query{
+		{Name: "black", Type: "number", Description: "Rating of black"},
+		{Name: "white", Type: "number", Description: "Rating of white"},
+		{Name: "handicap", Type: "integer", Description: "Handicap stones of a planned game"},
+		{Name: "komi", Type: "number", Description: "Komi of a planned game"},
+	}},
+	{Method: http.MethodGet, Path: "/leaderboard", Summary: "Players by rating, overall, per board size or per speed", Response: Leaderboard{}, Query: []openapi.Query{
+		{Name: "board", Type: "string", Description: "\"overall\" (the default), \"9x9\", \"13x13\" or \"19x19\""},
+		{Name: "speed", Type: "string", Description: "Only the games played at one speed: \"blitz\", \"live\", \"correspondence\" or \"untimed\""},
+		limitQuery,
+		{Name: "offset", Type: "integer", Description: "Players to skip"},
+	}},
+	{Method: http.MethodGet, Path: "/passport/key", Summary: "Key other servers use to recognize our passports", Response: PassportKeyResponse{}},
+	{Method: http.MethodPost, Path: "/passport/verify", Summary: "Check a passport from any server", Request: passport.Passport{}, Response: passport.Verification{}},
+	{Method: http.MethodPost, Path: "/api/v1/games", Summary: "Create new game (seat tokens in X-Black-Token and X-White-Token, those of team members in X-Team-Tokens)", Request: NewGameRequest{}, Response: v1.Game{}, Query: []openapi.Query{includeQuery}},
+	{Method: http.MethodGet, Path: "/api/v1/games/:id", Summary: "Get game state", Response: v1.Game{}, Query: []openapi.Query{playerQuery, includeQuery}},
+	{Method: http.MethodPost, Path: "/api/v1/games/:id/moves", Summary: "Make a move (seat token in X-Seat-Token)", Request: MoveRequest{}, Response: v1.Game{}, Query: []openapi.Query{includeQuery}},
+	{Method: http.MethodPost, Path: "/api/v1/games/:id/resign", Summary: "Give up the game (seat token in X-Seat-Token)", Request: ResignRequest{}, Response: v1.Game{}, Query: []openapi.Query{includeQuery}},
+	{Method: http.MethodPost, Path: "/api/v1/games/:id/resume", Summary: "Go back to playing from scoring", Response: v1.Game{}, Query: []openapi.Query{playerQuery, includeQuery}},
+	{Method: http.MethodPost, Path: "/api/v1/games/:id/accept-score", Summary: "Agree to the counted score", Request: AcceptScoreRequest{}, Response: v1.Game{}, Query: []openapi.Query{includeQuery}},
+	{Method: http.MethodPost, Path: "/admin/tournaments/:name/roster", Summary: "Pre-register the entrants of a CSV or JSON roster", RequestType: "text/csv", Response: RosterImportReport{}, Auth: true, Query: []openapi.Query{
+		{Name: "dryRun", Type: "boolean", Description: "Only report what the import would do"},
+	}},
+	{Method: http.MethodGet, Path: "/admin/consistency", Summary: "Games replayed against their move log", Response: []ConsistencyCheck{}, Auth: true, Query: []openapi.Query{
+		{Name: "flagged", Type: "boolean", Description: "Only the divergent games"},
+	}},
+	{Method: http.MethodPost, Path: "/admin/consistency/:id", Summary: "Replay one game now", Response: ConsistencyCheck{}, Auth: true},
+	{Method: http.MethodGet, Path: "/admin/engine/usage", Summary: "Engine time used, overall and by user", Response: EngineUsageReport{}, Auth: true, Query: []openapi.Query{limitQuery}},
+	{Method: http.MethodGet, Path: "/admin/games", Summary: "Live games, idle the longest first", Response: []AdminGame{}, Auth: true, Query: []openapi.Query{
+		{Name: "phase", Type: "string", Description: "Only the games in this phase"},
+	}},
+	{Method: http.MethodGet, Path: "/admin/games/:id", Summary: "A live game with its whole board and the connections following it", Response: AdminGameDetail{}, Auth: true},
+	{Method: http.MethodPost, Path: "/admin/games/:id/terminate", Summary: "Annul a game still going and take it off the server", Request: AdminActionRequest{}, Response: ResultDescription{}, Auth: true},
+	{Method: http.MethodPost, Path: "/admin/games/:id/annul", Summary: "Annul the result of a finished game", Request: AdminActionRequest{}, Response: ResultDescription{}, Auth: true},
+	{Method: http.MethodPost, Path: "/admin/games/:id/score", Summary: "Score a stuck game with the current dead stones", Request: AdminActionRequest{}, Response: ResultDescription{}, Auth: true},
+	{Method: http.MethodGet, Path: "/admin/connections", Summary: "Open WebSocket connections, longest open first", Response: []AdminConnection{}, Auth: true, Query: []openapi.Query{
+		{Name: "user", Type: "string", Description: "Only the connections of this user"},
+		{Name: "game", Type: "string", Description: "Only the connections following this game"},
+	}},
+	{Method: http.MethodDelete, Path: "/admin/connections/:cid", Summary: "Close a WebSocket connection", Request: AdminActionRequest{}, Auth: true},
+	{Method: http.MethodDelete, Path: "/admin/users/:uid/connections", Summary: "Close every WebSocket connection of a user", Request: AdminActionRequest{}, Auth: true},
+	{Method: http.MethodPost, Path: "/admin/players/games/reindex", Summary: "Index the stored finished games by player, e.g. games from before the index", Response: map[string]int{}, Auth: true},
+}
+
+// openapiDocument is built on first use, as the routes don't change while the server runs
+var openapiDocument = sync.OnceValue(func() *openapi.Document {
+	generator := openapi.Generator{Extra: map[reflect.Type]map[string]any{
+		// Computed blocks added by Board.MarshalJSON
+		reflect.TypeOf(game.Board{}): {"Scoring": game.ScoringSummary{}, "PositionHash": ""},
+	}}
+	return generator.Build(openapi.Info{
+		Title:       "Go-on-Go",
+		Version:     "1",
+		Description: "Play, review and analyze games of Go",
+	}, apiRoutes)
+})
+
+// Serve the OpenAPI document of the REST API
+func getOpenAPI(c echo.Context) error {
+	return c.JSON(http.StatusOK, openapiDocument())
+}
@@ -0,0 +1,308 @@
+// Package openapi builds an OpenAPI 3 document from route metadata and the Go types
+// the routes read and write, so the document can't drift from the code
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI version of the documents
+const Version = "3.0.3"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]Operation `json:"paths"` // Path, then lower case method
+	Components Components                      `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds the schemas shared by the operations
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is a way of authenticating
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// Operation is one method on one path
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *Body                 `json:"requestBody,omitempty"`
+	Responses   map[string]Body       `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Body is a request or response body
+type Body struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body in one content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema describes a JSON value
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Query is a query parameter of a route
+type Query struct {
+	Name        string
+	Type        string // "string", "integer", "number" or "boolean"
+	Description string
+}
+
+// Route is the metadata of one route
+type Route struct {
+	Method      string
+	Path        string // Echo path, e.g. "/game/:id"
+	Summary     string
+	Query       []Query
+	Request     any    // Value of the JSON request body type (nil = no JSON body)
+	RequestType string // Content type of non-JSON request bodies, e.g. "application/x-go-sgf"
+	Response    any    // Value of the JSON response type (nil = no JSON response)
+	ContentType string // Content type of non-JSON responses, e.g. "image/png"
+	Auth        bool   // Needs a bearer token
+}
+
+// Generator turns Go types into schemas, collecting the named ones as components
+type Generator struct {
+	// Extra lists properties that types add to their JSON through a MarshalJSON method
+	Extra map[reflect.Type]map[string]any
+
+	schemas map[string]*Schema
+}
+
+// Build writes the document of a list of routes
+func (g *Generator) Build(info Info, routes []Route) *Document {
+	g.schemas = make(map[string]*Schema)
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]map[string]Operation),
+		Components: Components{Schemas: g.schemas},
+	}
+
+	for _, route := range routes {
+		path, params := convertPath(route.Path)
+		op := Operation{
+			Summary:     route.Summary,
+			OperationID: operationID(route.Method, route.Path),
+			Parameters:  params,
+			Responses:   map[string]Body{},
+		}
+		for _, query := range route.Query {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name: query.Name, In: "query", Description: query.Description, Schema: &Schema{Type: query.Type},
+			})
+		}
+
+		switch {
+		case route.RequestType != "":
+			op.RequestBody = &Body{Required: true, Content: map[string]MediaType{
+				route.RequestType: {Schema: &Schema{Type: "string"}},
+			}}
+		case route.Request != nil:
+			op.RequestBody = &Body{Required: true, Content: map[string]MediaType{
+				"application/json": {Schema: g.SchemaOf(reflect.TypeOf(route.Request))},
+			}}
+		}
+
+		success := Body{Description: "OK"}
+		switch {
+		case route.ContentType != "":
+			success.Content = map[string]MediaType{route.ContentType: {Schema: &Schema{Type: "string", Format: "binary"}}}
+		case route.Response != nil:
+			success.Content = map[string]MediaType{"application/json": {Schema: g.SchemaOf(reflect.TypeOf(route.Response))}}
+		}
+		op.Responses["200"] = success
+		op.Responses["default"] = Body{Description: "Error", Content: map[string]MediaType{
+			"application/json": {Schema: &Schema{Type: "object", Properties: map[string]*Schema{"error": {Type: "string"}}}},
+		}}
+
+		if route.Auth {
+			op.Security = []map[string][]string{{"bearer": {}}}
+			doc.Components.SecuritySchemes = map[string]SecurityScheme{"bearer": {Type: "http", Scheme: "bearer"}}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]Operation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+	return doc
+}
+
+// convertPath turns Echo path parameters (":id") into OpenAPI ones ("{id}")
+func convertPath(path string) (string, []Parameter) {
+	params := make([]Parameter, 0)
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID names an operation after its method and path, e.g. "get_game_id_kibitz"
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, word := range strings.FieldsFunc(path, func(r rune) bool { return !('a' <= r && r <= 'z' || '0' <= r && r <= '9') }) {
+		id += "_" + word
+	}
+	return id
+}
+
+// Types with a fixed JSON representation
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	rawType      = reflect.TypeOf(json.RawMessage{})
+)
+
+// SchemaOf returns the schema of a type, as a reference for named structs
+func (g *Generator) SchemaOf(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64"} // Nanoseconds
+	case rawType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := g.SchemaOf(t.Elem())
+		if schema.Ref != "" {
+			return schema // References can't be nullable in OpenAPI 3.0
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"} // Base64
+		}
+		return &Schema{Type: "array", Items: g.SchemaOf(t.Elem()), Nullable: true}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: g.SchemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.SchemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, exists := g.schemas[name]; !exists {
+			g.schemas[name] = &Schema{} // Placeholder, for types that refer to themselves
+			g.schemas[name] = g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &Schema{} // Any value
+	}
+}
+
+// schemaName names the component of a type; types outside the main package get their package prefix
+func schemaName(t reflect.Type) string {
+	path := t.PkgPath()
+	if path == "main" || path == "" {
+		return t.Name()
+	}
+	return path[strings.LastIndex(path, "/")+1:] + "." + t.Name()
+}
+
+// structSchema lists the JSON properties of a struct, including those of embedded structs
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(schema, t)
+
+	extras := make([]string, 0)
+	for name := range g.Extra[t] {
+		extras = append(extras, name)
+	}
+	sort.Strings(extras)
+	for _, name := range extras {
+		schema.Properties[name] = g.SchemaOf(reflect.TypeOf(g.Extra[t][name]))
+	}
+	return schema
+}
+
+// addFields adds the properties of the fields of t, as encoding/json would write them
+func (g *Generator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = g.SchemaOf(field.Type)
+	}
+}
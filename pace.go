@@ -0,0 +1,104 @@
+package main
+
+import (
+	"go-game/game"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Pace warning settings
+const (
+	correspondenceMainTime  = 24 * time.Hour // Games with at least this much main time are correspondence games
+	deadlineWarningFraction = 0.1            // Warn correspondence players with this fraction of their main time left
+	lastPeriodWarningMoves  = 3              // Warn live players after this many moves drained into their last period
+)
+
+// Kinds of pace warnings
+const (
+	PaceDeadline = "deadline" // A correspondence player is about to run out of time
+	PaceByoYomi  = "byo_yomi" // A live player keeps draining into their last byo-yomi period
+)
+
+// PaceWarning is sent to clients so they can nudge a player to speed up
+type PaceWarning struct {
+	Player   int           `json:"player"`   // Player being warned (1 = black, 2 = white)
+	Kind     string        `json:"kind"`     // deadline or byo_yomi
+	TimeLeft time.Duration `json:"timeLeft"` // Time the player has before losing on time
+}
+
+// deadlineWarned remembers, per game, the version at which the player to move was
+// last warned about their deadline, so each turn is warned about once (guarded by gamesMu)
+var deadlineWarned = make(map[string]int)
+
+// isCorrespondence checks if a game is played at correspondence pace, with days per move
+func isCorrespondence(board *game.Board) bool {
+	return board.Clock != nil && board.Clock.MainTime >= correspondenceMainTime
+}
+
+// warnDeadlines warns correspondence players who are close to running out of time
+// Must be called with the game locked
+func warnDeadlines(gameID string, board *game.Board, now time.Time) {
+	clock := board.Clock
+	if !isCorrespondence(board) || clock.Running == 0 {
+		return
+	}
+
+	left := clock.TimeToExpiry(clock.Running, now)
+	if left > time.Duration(deadlineWarningFraction*float64(clock.MainTime)) {
+		return
+	}
+
+	gamesMu.Lock()
+	warned, exists := deadlineWarned[gameID]
+	deadlineWarned[gameID] = board.Version()
+	gamesMu.Unlock()
+	if exists && warned == board.Version() {
+		return
+	}
+
+	hub.Broadcast(Event{Type: EventPaceWarning, GameID: gameID, Data: PaceWarning{
+		Player:   clock.Running,
+		Kind:     PaceDeadline,
+		TimeLeft: left,
+	}})
+}
+
+// warnLastPeriod warns a live player who keeps draining into their last byo-yomi period
+func warnLastPeriod(gameID string, board *game.Board, player int) {
+	if board.Clock == nil || board.Pace.LastPeriodMoves[player] != lastPeriodWarningMoves {
+		return
+	}
+
+	hub.Broadcast(Event{Type: EventPaceWarning, GameID: gameID, Data: PaceWarning{
+		Player:   player,
+		Kind:     PaceByoYomi,
+		TimeLeft: board.Clock.TimeToExpiry(player, time.Now()),
+	}})
+}
+
+// Pace response structure
+type PaceResponse struct {
+	Moves           [3]int           `json:"moves"`           // Moves made by each player (index 1 = black, 2 = white)
+	AverageMoveTime [3]time.Duration `json:"averageMoveTime"` // Average time per move of each player
+	LastPeriodMoves [3]int           `json:"lastPeriodMoves"` // Consecutive moves each player drained into their last period
+}
+
+// Get the move pace statistics of a game
+func getPace(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	response := PaceResponse{Moves: board.Pace.Moves, LastPeriodMoves: board.Pace.LastPeriodMoves}
+	for player := 1; player <= 2; player++ {
+		response.AverageMoveTime[player] = board.Pace.Average(player)
+	}
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"go-game/passport"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// passportIssuer is the name this server signs game passports with (PASSPORT_ISSUER)
+var passportIssuer = serverName(os.Getenv("PASSPORT_ISSUER"))
+
+// passportKey signs game passports; set up at startup from PASSPORT_KEY
+var passportKey ed25519.PrivateKey
+
+// trustedServers maps the names of servers whose passports are recognized to their public keys
+// Loaded from PASSPORT_TRUSTED_KEYS, formatted as "go.example.org=<base64 key>;other=<base64 key>"
+// This server's own key is always trusted
+var trustedServers = loadTrustedServers(os.Getenv("PASSPORT_TRUSTED_KEYS"))
+
+// serverName returns the configured issuer name, or "go-game" if none is set
+func serverName(name string) string {
+	if name = strings.TrimSpace(name); name != "" {
+		return name
+	}
+	return "go-game"
+}
+
+// loadTrustedServers parses the trusted key configuration; malformed keys are skipped
+func loadTrustedServers(config string) map[string]ed25519.PublicKey {
+	servers := make(map[string]ed25519.PublicKey)
+
+	for _, entry := range strings.Split(config, ";") {
+		name, encoded, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || name == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			continue
+		}
+		servers[strings.TrimSpace(name)] = ed25519.PublicKey(key)
+	}
+
+	return servers
+}
+
+// newPassportKey derives the signing key from the 32-byte seed in the key provider
+// Without one a key is generated, so passports signed before a restart can no longer be traced to this server
+func newPassportKey(provider KeyProvider) (ed25519.PrivateKey, error) {
+	seed, err := provider.MasterKey()
+	if err != nil {
+		return nil, err
+	}
+	if seed == nil {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// Passport key response structure
+type PassportKeyResponse struct {
+	Issuer    string `json:"issuer"`
+	PublicKey []byte `json:"publicKey"` // Base64 encoded ed25519 public key
+}
+
+// Get the key other servers need to recognize this server's passports
+func getPassportKey(c echo.Context) error {
+	return c.JSON(http.StatusOK, PassportKeyResponse{
+		Issuer:    passportIssuer,
+		PublicKey: passportKey.Public().(ed25519.PublicKey),
+	})
+}
+
+// Export a finished game as a signed passport
+func exportPassport(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	p, err := passport.FromBoard(passportIssuer, gameID, board, time.Now())
+	if errors.Is(err, passport.ErrNotFinished) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Only finished games have a passport"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := p.Sign(passportIssuer, passportKey); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, p)
+}
+
+// Check the signatures of a passport and replay its game
+func verifyPassport(c echo.Context) error {
+	var p passport.Passport
+	if err := c.Bind(&p); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid passport format"})
+	}
+
+	return c.JSON(http.StatusOK, p.Verify(trustedServers))
+}
@@ -0,0 +1,315 @@
+// Package passport defines the game passport: a signed, portable record of a finished game
+// (players, settings, moves and result) that other servers and rating bodies can check
+// without trusting the transport it came through
+// Moves use GTP coordinates ("D4", "pass") so the format doesn't depend on this server's board layout
+package passport
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-game/game"
+	"go-game/gtp"
+	"math"
+	"time"
+)
+
+// Format identifies the version of the passport format
+const Format = "go-game-passport/1"
+
+// Passport is the portable record of a finished game
+type Passport struct {
+	Format   string    `json:"format"`
+	Issuer   string    `json:"issuer"` // Server the game was played on
+	GameID   string    `json:"gameId"` // ID of the game on the issuing server
+	IssuedAt time.Time `json:"issuedAt"`
+
+	Black    Player   `json:"black"`
+	White    Player   `json:"white"`
+	Settings Settings `json:"settings"`
+
+	Setup      Stones       `json:"setup"`      // Stones placed before the first move
+	Moves      []Move       `json:"moves"`      // Every move and pass, in order
+	DeadStones []string     `json:"deadStones"` // Stones counted as dead at the end
+	Result     *game.Result `json:"result"`
+
+	Signatures []Signature `json:"signatures"`
+}
+
+// Player is one side of the game
+type Player struct {
+	Name  string   `json:"name,omitempty"`
+	Rank  string   `json:"rank,omitempty"`
+	Team  []string `json:"team,omitempty"` // Members in playing order, for team games
+	Rated bool     `json:"rated"`          // The result counts for this player's rating
+}
+
+// Settings are the rules the game was played under
+type Settings struct {
+	Size     int     `json:"size"`
+	Komi     float64 `json:"komi"`
+	Variant  string  `json:"variant"`
+	Handicap int     `json:"handicap,omitempty"`
+	Rules    string  `json:"rules,omitempty"`
+}
+
+// Stones lists stones by color
+type Stones struct {
+	Black []string `json:"black"`
+	White []string `json:"white"`
+}
+
+// Move is one move of the game
+type Move struct {
+	Color  string `json:"color"`  // "B" or "W"
+	Vertex string `json:"vertex"` // GTP vertex, or "pass"
+}
+
+// Signature is a server's signature over the passport
+// Any server that recognizes the game may add its own, e.g. when a result is migrated
+type Signature struct {
+	Signer    string            `json:"signer"`
+	PublicKey ed25519.PublicKey `json:"publicKey"`
+	Value     []byte            `json:"value"`
+}
+
+// ErrNotFinished is returned when a passport is requested for a game that is still going
+var ErrNotFinished = errors.New("passport: the game hasn't finished")
+
+// FromBoard builds an unsigned passport for a finished game
+func FromBoard(issuer, gameID string, board *game.Board, now time.Time) (*Passport, error) {
+	if board.Result == nil {
+		return nil, ErrNotFinished
+	}
+
+	rated := board.IsRated()
+	p := &Passport{
+		Format:   Format,
+		Issuer:   issuer,
+		GameID:   gameID,
+		IssuedAt: now.UTC(),
+		Black:    Player{Name: board.Info.BlackName, Rank: board.Info.BlackRank, Team: board.Teams[1], Rated: rated},
+		White:    Player{Name: board.Info.WhiteName, Rank: board.Info.WhiteRank, Team: board.Teams[2], Rated: rated},
+		Settings: Settings{
+			Size:     board.Size,
+			Komi:     board.Komi,
+			Variant:  board.Variant,
+			Handicap: board.Info.Handicap,
+			Rules:    board.Info.Rules,
+		},
+		Setup: Stones{
+			Black: vertices(board.SetupStones[1], board.Size),
+			White: vertices(board.SetupStones[2], board.Size),
+		},
+		Moves:      make([]Move, 0, len(board.MoveHistory)),
+		DeadStones: vertices(board.DeadStones, board.Size),
+		Result:     board.Result,
+	}
+	for _, move := range board.MoveHistory {
+		p.Moves = append(p.Moves, Move{Color: colors[move.Player], Vertex: gtp.Vertex(move.Position, board.Size)})
+	}
+	return p, nil
+}
+
+// colors maps players to move colors (index 1 = black, 2 = white)
+var colors = [3]string{1: "B", 2: "W"}
+
+// vertices converts board positions to GTP vertices
+func vertices(positions []int, size int) []string {
+	out := make([]string, 0, len(positions))
+	for _, position := range positions {
+		out = append(out, gtp.Vertex(position, size))
+	}
+	return out
+}
+
+// payload is the signed content: the JSON encoding of the passport without its signatures
+func (p *Passport) payload() ([]byte, error) {
+	unsigned := *p
+	unsigned.Signatures = nil
+	return json.Marshal(unsigned)
+}
+
+// Sign adds a signature by the given server
+func (p *Passport) Sign(signer string, key ed25519.PrivateKey) error {
+	payload, err := p.payload()
+	if err != nil {
+		return err
+	}
+	p.Signatures = append(p.Signatures, Signature{
+		Signer:    signer,
+		PublicKey: key.Public().(ed25519.PublicKey),
+		Value:     ed25519.Sign(key, payload),
+	})
+	return nil
+}
+
+// SignatureCheck is the outcome of checking one signature
+type SignatureCheck struct {
+	Signer  string `json:"signer"`
+	Valid   bool   `json:"valid"`   // The signature matches the passport and its public key
+	Trusted bool   `json:"trusted"` // The public key belongs to a server the checker trusts
+}
+
+// Verification is the outcome of checking a passport
+type Verification struct {
+	Valid      bool             `json:"valid"` // At least one trusted, valid signature and a consistent game
+	Signatures []SignatureCheck `json:"signatures"`
+	Error      string           `json:"error,omitempty"` // Why the game itself doesn't check out
+}
+
+// Verify checks the signatures against the trusted keys (signer name => public key)
+// and replays the game to make sure the moves are legal and lead to the recorded result
+func (p *Passport) Verify(trusted map[string]ed25519.PublicKey) Verification {
+	verification := Verification{Signatures: make([]SignatureCheck, 0, len(p.Signatures))}
+
+	payload, err := p.payload()
+	if err != nil {
+		verification.Error = err.Error()
+		return verification
+	}
+
+	signed := false
+	for _, signature := range p.Signatures {
+		check := SignatureCheck{Signer: signature.Signer}
+		check.Valid = len(signature.PublicKey) == ed25519.PublicKeySize &&
+			ed25519.Verify(signature.PublicKey, payload, signature.Value)
+		if key, ok := trusted[signature.Signer]; ok {
+			check.Trusted = key.Equal(signature.PublicKey)
+		}
+		signed = signed || (check.Valid && check.Trusted)
+		verification.Signatures = append(verification.Signatures, check)
+	}
+
+	if err := p.check(); err != nil {
+		verification.Error = err.Error()
+		return verification
+	}
+	verification.Valid = signed
+	return verification
+}
+
+// check replays the game and compares the outcome with the recorded result
+func (p *Passport) check() error {
+	if p.Format != Format {
+		return fmt.Errorf("unsupported format %q", p.Format)
+	}
+	if p.Result == nil {
+		return errors.New("the passport has no result")
+	}
+
+	board, err := p.Replay()
+	if err != nil {
+		return err
+	}
+
+	switch p.Result.Reason {
+	case game.ReasonScore:
+		if board.Phase != game.PhaseScoring {
+			return errors.New("the game was scored but play didn't end with two passes")
+		}
+		score := board.Score()
+		if score.Winner() != p.Result.Winner || math.Abs(score.Margin()-p.Result.Margin) > 1e-9 {
+			return fmt.Errorf("the recorded score doesn't match the position (counted %d by %g)", score.Winner(), score.Margin())
+		}
+	case game.ReasonCapture:
+		if board.Result == nil || *board.Result != *p.Result {
+			return errors.New("the recorded capture win doesn't match the moves")
+		}
+	}
+	return nil
+}
+
+// Replay rebuilds the game from the passport, with the dead stones marked if play ended
+func (p *Passport) Replay() (*game.Board, error) {
+	size := p.Settings.Size
+	if size < 2 || size > len(game.ColumnLetters) {
+		return nil, fmt.Errorf("unsupported board size %d", size)
+	}
+
+	board := game.NewBoard(size)
+	board.Komi = p.Settings.Komi
+	if p.Settings.Variant != "" {
+		if err := board.SetVariant(p.Settings.Variant); err != nil {
+			return nil, err
+		}
+	}
+
+	for color, stones := range [3][]string{1: p.Setup.Black, 2: p.Setup.White} {
+		for _, vertex := range stones {
+			position, err := parseStone(vertex, size)
+			if err != nil {
+				return nil, err
+			}
+			if err := board.AddSetupStone(position, color); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(p.Moves) > 0 && p.Moves[0].Color == "W" {
+		board.CurrentPlayer = 2
+	}
+	if err := board.Start(); err != nil {
+		return nil, err
+	}
+
+	for i, move := range p.Moves {
+		if board.Phase == game.PhaseScoring {
+			if err := board.ResumePlay(); err != nil {
+				return nil, fmt.Errorf("move %d: %w", i+1, err)
+			}
+		}
+		if move.Color != colors[board.CurrentPlayer] {
+			return nil, fmt.Errorf("move %d is played out of turn", i+1)
+		}
+
+		position, err := gtp.ParseVertex(move.Vertex, size)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		if position < 0 {
+			err = board.Pass()
+		} else {
+			err = board.MakeMove(position)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+	}
+
+	if board.Phase == game.PhaseScoring {
+		for _, vertex := range p.DeadStones {
+			position, err := parseStone(vertex, size)
+			if err != nil {
+				return nil, err
+			}
+			if dead(board, position) {
+				continue // Already marked with the rest of its group
+			}
+			if err := board.ToggleDead(position); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return board, nil
+}
+
+// parseStone converts the vertex of a stone (passes aren't stones)
+func parseStone(vertex string, size int) (int, error) {
+	position, err := gtp.ParseVertex(vertex, size)
+	if err == nil && position < 0 {
+		err = fmt.Errorf("%q is not a point on the board", vertex)
+	}
+	return position, err
+}
+
+// dead checks if a stone is already marked dead
+func dead(board *game.Board, position int) bool {
+	for _, stone := range board.DeadStones {
+		if stone == position {
+			return true
+		}
+	}
+	return false
+}
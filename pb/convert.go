@@ -0,0 +1,109 @@
+// Package pb holds the Protocol Buffers messages and gRPC service of the game API (see proto/)
+// and conversions from the game types
+package pb
+
+//go:generate protoc -I ../proto --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative game.proto service.proto
+
+import (
+	"go-game/game"
+	"strconv"
+)
+
+// FromBoard converts a board to its message
+func FromBoard(board *game.Board) *Board {
+	msg := &Board{
+		Size:            int32(board.Size),
+		Grid:            make([]Color, len(board.Grid)),
+		CurrentPlayer:   Color(board.CurrentPlayer),
+		CapturedByBlack: int32(board.CapturedStones[1]),
+		CapturedByWhite: int32(board.CapturedStones[2]),
+		MoveHistory:     make([]*Move, len(board.MoveHistory)),
+		MoveNumbers:     ints(board.MoveNumbers),
+		LastMove:        -1,
+		Phase:           string(board.Phase),
+		Variant:         board.Variant,
+		Komi:            board.Komi,
+		DeadStones:      ints(board.DeadStones),
+		Result:          FromResult(board.Result),
+		BlackSetup:      ints(board.SetupStones[1]),
+		WhiteSetup:      ints(board.SetupStones[2]),
+		Info: &GameInfo{
+			BlackName: board.Info.BlackName,
+			WhiteName: board.Info.WhiteName,
+			BlackRank: board.Info.BlackRank,
+			WhiteRank: board.Info.WhiteRank,
+			Handicap:  int32(board.HandicapStones()),
+		},
+		PositionHash: board.PositionHash(),
+	}
+	for i, stone := range board.Grid {
+		msg.Grid[i] = Color(stone)
+	}
+	for i, move := range board.MoveHistory {
+		msg.MoveHistory[i] = FromMove(move)
+	}
+	if board.LastMove != nil {
+		msg.LastMove = int32(board.LastMove.Position)
+	}
+	return msg
+}
+
+// FromMove converts a move to its message
+func FromMove(move game.Move) *Move {
+	return &Move{
+		Player:            Color(move.Player),
+		Position:          int32(move.Position),
+		CapturedPositions: ints(move.CapturedPositions),
+	}
+}
+
+// FromResult converts a result to its message (nil for games still in progress)
+func FromResult(result *game.Result) *Result {
+	if result == nil {
+		return nil
+	}
+	return &Result{
+		Winner: Color(result.Winner),
+		Reason: result.Reason,
+		Margin: result.Margin,
+		Text:   result.String(),
+	}
+}
+
+// FromDelta converts a board delta to its message
+func FromDelta(delta *game.Delta) *BoardDelta {
+	msg := &BoardDelta{
+		Version:         int32(delta.Version),
+		Player:          Color(delta.Player),
+		Position:        int32(delta.Position),
+		Added:           make([]*Stone, len(delta.Added)),
+		Removed:         ints(delta.Removed),
+		CapturedByBlack: int32(delta.CapturedStones[1]),
+		CapturedByWhite: int32(delta.CapturedStones[2]),
+		CurrentPlayer:   Color(delta.CurrentPlayer),
+		Phase:           string(delta.Phase),
+	}
+	msg.PositionHash, _ = strconv.ParseUint(delta.PositionHash, 16, 64)
+	for i, stone := range delta.Added {
+		msg.Added[i] = &Stone{Position: int32(stone.Position), Color: Color(stone.Color)}
+	}
+	if delta.Clock != nil {
+		msg.Clock = &Clock{
+			BlackRemainingMs: delta.Clock.Remaining[1].Milliseconds(),
+			WhiteRemainingMs: delta.Clock.Remaining[2].Milliseconds(),
+			BlackPeriodsLeft: int32(delta.Clock.PeriodsLeft[1]),
+			WhitePeriodsLeft: int32(delta.Clock.PeriodsLeft[2]),
+			Running:          Color(delta.Clock.Running),
+		}
+	}
+	return msg
+}
+
+// ints converts positions to their message form
+func ints(values []int) []int32 {
+	converted := make([]int32, len(values))
+	for i, v := range values {
+		converted[i] = int32(v)
+	}
+	return converted
+}
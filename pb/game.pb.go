@@ -0,0 +1,1157 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: game.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Color int32
+
+const (
+	Color_COLOR_NONE  Color = 0
+	Color_COLOR_BLACK Color = 1
+	Color_COLOR_WHITE Color = 2
+)
+
+// Enum value maps for Color.
+var (
+	Color_name = map[int32]string{
+		0: "COLOR_NONE",
+		1: "COLOR_BLACK",
+		2: "COLOR_WHITE",
+	}
+	Color_value = map[string]int32{
+		"COLOR_NONE":  0,
+		"COLOR_BLACK": 1,
+		"COLOR_WHITE": 2,
+	}
+)
+
+func (x Color) Enum() *Color {
+	p := new(Color)
+	*p = x
+	return p
+}
+
+func (x Color) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Color) Descriptor() protoreflect.EnumDescriptor {
+	return file_game_proto_enumTypes[0].Descriptor()
+}
+
+func (Color) Type() protoreflect.EnumType {
+	return &file_game_proto_enumTypes[0]
+}
+
+func (x Color) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Color.Descriptor instead.
+func (Color) EnumDescriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{0}
+}
+
+type Board struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Size            int32                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Grid            []Color                `protobuf:"varint,2,rep,packed,name=grid,proto3,enum=gogame.v1.Color" json:"grid,omitempty"`
+	CurrentPlayer   Color                  `protobuf:"varint,3,opt,name=current_player,json=currentPlayer,proto3,enum=gogame.v1.Color" json:"current_player,omitempty"`
+	CapturedByBlack int32                  `protobuf:"varint,4,opt,name=captured_by_black,json=capturedByBlack,proto3" json:"captured_by_black,omitempty"`
+	CapturedByWhite int32                  `protobuf:"varint,5,opt,name=captured_by_white,json=capturedByWhite,proto3" json:"captured_by_white,omitempty"`
+	MoveHistory     []*Move                `protobuf:"bytes,6,rep,name=move_history,json=moveHistory,proto3" json:"move_history,omitempty"`
+	MoveNumbers     []int32                `protobuf:"varint,7,rep,packed,name=move_numbers,json=moveNumbers,proto3" json:"move_numbers,omitempty"`
+	LastMove        int32                  `protobuf:"varint,8,opt,name=last_move,json=lastMove,proto3" json:"last_move,omitempty"`
+	Phase           string                 `protobuf:"bytes,9,opt,name=phase,proto3" json:"phase,omitempty"`
+	Variant         string                 `protobuf:"bytes,10,opt,name=variant,proto3" json:"variant,omitempty"`
+	Komi            float64                `protobuf:"fixed64,11,opt,name=komi,proto3" json:"komi,omitempty"`
+	DeadStones      []int32                `protobuf:"varint,12,rep,packed,name=dead_stones,json=deadStones,proto3" json:"dead_stones,omitempty"`
+	Result          *Result                `protobuf:"bytes,13,opt,name=result,proto3" json:"result,omitempty"`
+	BlackSetup      []int32                `protobuf:"varint,14,rep,packed,name=black_setup,json=blackSetup,proto3" json:"black_setup,omitempty"`
+	WhiteSetup      []int32                `protobuf:"varint,15,rep,packed,name=white_setup,json=whiteSetup,proto3" json:"white_setup,omitempty"`
+	Info            *GameInfo              `protobuf:"bytes,16,opt,name=info,proto3" json:"info,omitempty"`
+	PositionHash    uint64                 `protobuf:"fixed64,17,opt,name=position_hash,json=positionHash,proto3" json:"position_hash,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Board) Reset() {
+	*x = Board{}
+	mi := &file_game_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Board) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Board) ProtoMessage() {}
+
+func (x *Board) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Board.ProtoReflect.Descriptor instead.
+func (*Board) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Board) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Board) GetGrid() []Color {
+	if x != nil {
+		return x.Grid
+	}
+	return nil
+}
+
+func (x *Board) GetCurrentPlayer() Color {
+	if x != nil {
+		return x.CurrentPlayer
+	}
+	return Color_COLOR_NONE
+}
+
+func (x *Board) GetCapturedByBlack() int32 {
+	if x != nil {
+		return x.CapturedByBlack
+	}
+	return 0
+}
+
+func (x *Board) GetCapturedByWhite() int32 {
+	if x != nil {
+		return x.CapturedByWhite
+	}
+	return 0
+}
+
+func (x *Board) GetMoveHistory() []*Move {
+	if x != nil {
+		return x.MoveHistory
+	}
+	return nil
+}
+
+func (x *Board) GetMoveNumbers() []int32 {
+	if x != nil {
+		return x.MoveNumbers
+	}
+	return nil
+}
+
+func (x *Board) GetLastMove() int32 {
+	if x != nil {
+		return x.LastMove
+	}
+	return 0
+}
+
+func (x *Board) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *Board) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+func (x *Board) GetKomi() float64 {
+	if x != nil {
+		return x.Komi
+	}
+	return 0
+}
+
+func (x *Board) GetDeadStones() []int32 {
+	if x != nil {
+		return x.DeadStones
+	}
+	return nil
+}
+
+func (x *Board) GetResult() *Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *Board) GetBlackSetup() []int32 {
+	if x != nil {
+		return x.BlackSetup
+	}
+	return nil
+}
+
+func (x *Board) GetWhiteSetup() []int32 {
+	if x != nil {
+		return x.WhiteSetup
+	}
+	return nil
+}
+
+func (x *Board) GetInfo() *GameInfo {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *Board) GetPositionHash() uint64 {
+	if x != nil {
+		return x.PositionHash
+	}
+	return 0
+}
+
+type Move struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Player            Color                  `protobuf:"varint,1,opt,name=player,proto3,enum=gogame.v1.Color" json:"player,omitempty"`
+	Position          int32                  `protobuf:"varint,2,opt,name=position,proto3" json:"position,omitempty"`
+	CapturedPositions []int32                `protobuf:"varint,3,rep,packed,name=captured_positions,json=capturedPositions,proto3" json:"captured_positions,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Move) Reset() {
+	*x = Move{}
+	mi := &file_game_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Move) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Move) ProtoMessage() {}
+
+func (x *Move) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Move.ProtoReflect.Descriptor instead.
+func (*Move) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Move) GetPlayer() Color {
+	if x != nil {
+		return x.Player
+	}
+	return Color_COLOR_NONE
+}
+
+func (x *Move) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *Move) GetCapturedPositions() []int32 {
+	if x != nil {
+		return x.CapturedPositions
+	}
+	return nil
+}
+
+type Stone struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Position      int32                  `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`
+	Color         Color                  `protobuf:"varint,2,opt,name=color,proto3,enum=gogame.v1.Color" json:"color,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Stone) Reset() {
+	*x = Stone{}
+	mi := &file_game_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stone) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stone) ProtoMessage() {}
+
+func (x *Stone) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stone.ProtoReflect.Descriptor instead.
+func (*Stone) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Stone) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *Stone) GetColor() Color {
+	if x != nil {
+		return x.Color
+	}
+	return Color_COLOR_NONE
+}
+
+type Clock struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	BlackRemainingMs int64                  `protobuf:"varint,1,opt,name=black_remaining_ms,json=blackRemainingMs,proto3" json:"black_remaining_ms,omitempty"`
+	WhiteRemainingMs int64                  `protobuf:"varint,2,opt,name=white_remaining_ms,json=whiteRemainingMs,proto3" json:"white_remaining_ms,omitempty"`
+	BlackPeriodsLeft int32                  `protobuf:"varint,3,opt,name=black_periods_left,json=blackPeriodsLeft,proto3" json:"black_periods_left,omitempty"`
+	WhitePeriodsLeft int32                  `protobuf:"varint,4,opt,name=white_periods_left,json=whitePeriodsLeft,proto3" json:"white_periods_left,omitempty"`
+	Running          Color                  `protobuf:"varint,5,opt,name=running,proto3,enum=gogame.v1.Color" json:"running,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Clock) Reset() {
+	*x = Clock{}
+	mi := &file_game_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Clock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Clock) ProtoMessage() {}
+
+func (x *Clock) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Clock.ProtoReflect.Descriptor instead.
+func (*Clock) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Clock) GetBlackRemainingMs() int64 {
+	if x != nil {
+		return x.BlackRemainingMs
+	}
+	return 0
+}
+
+func (x *Clock) GetWhiteRemainingMs() int64 {
+	if x != nil {
+		return x.WhiteRemainingMs
+	}
+	return 0
+}
+
+func (x *Clock) GetBlackPeriodsLeft() int32 {
+	if x != nil {
+		return x.BlackPeriodsLeft
+	}
+	return 0
+}
+
+func (x *Clock) GetWhitePeriodsLeft() int32 {
+	if x != nil {
+		return x.WhitePeriodsLeft
+	}
+	return 0
+}
+
+func (x *Clock) GetRunning() Color {
+	if x != nil {
+		return x.Running
+	}
+	return Color_COLOR_NONE
+}
+
+type BoardDelta struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Version         int32                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Player          Color                  `protobuf:"varint,2,opt,name=player,proto3,enum=gogame.v1.Color" json:"player,omitempty"`
+	Position        int32                  `protobuf:"varint,3,opt,name=position,proto3" json:"position,omitempty"`
+	Added           []*Stone               `protobuf:"bytes,4,rep,name=added,proto3" json:"added,omitempty"`
+	Removed         []int32                `protobuf:"varint,5,rep,packed,name=removed,proto3" json:"removed,omitempty"`
+	CapturedByBlack int32                  `protobuf:"varint,6,opt,name=captured_by_black,json=capturedByBlack,proto3" json:"captured_by_black,omitempty"`
+	CapturedByWhite int32                  `protobuf:"varint,7,opt,name=captured_by_white,json=capturedByWhite,proto3" json:"captured_by_white,omitempty"`
+	CurrentPlayer   Color                  `protobuf:"varint,8,opt,name=current_player,json=currentPlayer,proto3,enum=gogame.v1.Color" json:"current_player,omitempty"`
+	Phase           string                 `protobuf:"bytes,9,opt,name=phase,proto3" json:"phase,omitempty"`
+	Clock           *Clock                 `protobuf:"bytes,10,opt,name=clock,proto3" json:"clock,omitempty"`
+	PositionHash    uint64                 `protobuf:"fixed64,11,opt,name=position_hash,json=positionHash,proto3" json:"position_hash,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *BoardDelta) Reset() {
+	*x = BoardDelta{}
+	mi := &file_game_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BoardDelta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BoardDelta) ProtoMessage() {}
+
+func (x *BoardDelta) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BoardDelta.ProtoReflect.Descriptor instead.
+func (*BoardDelta) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BoardDelta) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *BoardDelta) GetPlayer() Color {
+	if x != nil {
+		return x.Player
+	}
+	return Color_COLOR_NONE
+}
+
+func (x *BoardDelta) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *BoardDelta) GetAdded() []*Stone {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *BoardDelta) GetRemoved() []int32 {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+func (x *BoardDelta) GetCapturedByBlack() int32 {
+	if x != nil {
+		return x.CapturedByBlack
+	}
+	return 0
+}
+
+func (x *BoardDelta) GetCapturedByWhite() int32 {
+	if x != nil {
+		return x.CapturedByWhite
+	}
+	return 0
+}
+
+func (x *BoardDelta) GetCurrentPlayer() Color {
+	if x != nil {
+		return x.CurrentPlayer
+	}
+	return Color_COLOR_NONE
+}
+
+func (x *BoardDelta) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *BoardDelta) GetClock() *Clock {
+	if x != nil {
+		return x.Clock
+	}
+	return nil
+}
+
+func (x *BoardDelta) GetPositionHash() uint64 {
+	if x != nil {
+		return x.PositionHash
+	}
+	return 0
+}
+
+type MoveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Position      int32                  `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`
+	Coordinate    string                 `protobuf:"bytes,2,opt,name=coordinate,proto3" json:"coordinate,omitempty"`
+	Pass          bool                   `protobuf:"varint,3,opt,name=pass,proto3" json:"pass,omitempty"`
+	Player        string                 `protobuf:"bytes,4,opt,name=player,proto3" json:"player,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveRequest) Reset() {
+	*x = MoveRequest{}
+	mi := &file_game_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveRequest) ProtoMessage() {}
+
+func (x *MoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveRequest.ProtoReflect.Descriptor instead.
+func (*MoveRequest) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MoveRequest) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *MoveRequest) GetCoordinate() string {
+	if x != nil {
+		return x.Coordinate
+	}
+	return ""
+}
+
+func (x *MoveRequest) GetPass() bool {
+	if x != nil {
+		return x.Pass
+	}
+	return false
+}
+
+func (x *MoveRequest) GetPlayer() string {
+	if x != nil {
+		return x.Player
+	}
+	return ""
+}
+
+type Result struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Winner        Color                  `protobuf:"varint,1,opt,name=winner,proto3,enum=gogame.v1.Color" json:"winner,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Margin        float64                `protobuf:"fixed64,3,opt,name=margin,proto3" json:"margin,omitempty"`
+	Text          string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_game_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Result) GetWinner() Color {
+	if x != nil {
+		return x.Winner
+	}
+	return Color_COLOR_NONE
+}
+
+func (x *Result) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Result) GetMargin() float64 {
+	if x != nil {
+		return x.Margin
+	}
+	return 0
+}
+
+func (x *Result) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type GameInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BlackName     string                 `protobuf:"bytes,1,opt,name=black_name,json=blackName,proto3" json:"black_name,omitempty"`
+	WhiteName     string                 `protobuf:"bytes,2,opt,name=white_name,json=whiteName,proto3" json:"white_name,omitempty"`
+	BlackRank     string                 `protobuf:"bytes,3,opt,name=black_rank,json=blackRank,proto3" json:"black_rank,omitempty"`
+	WhiteRank     string                 `protobuf:"bytes,4,opt,name=white_rank,json=whiteRank,proto3" json:"white_rank,omitempty"`
+	Handicap      int32                  `protobuf:"varint,5,opt,name=handicap,proto3" json:"handicap,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GameInfo) Reset() {
+	*x = GameInfo{}
+	mi := &file_game_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GameInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GameInfo) ProtoMessage() {}
+
+func (x *GameInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GameInfo.ProtoReflect.Descriptor instead.
+func (*GameInfo) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GameInfo) GetBlackName() string {
+	if x != nil {
+		return x.BlackName
+	}
+	return ""
+}
+
+func (x *GameInfo) GetWhiteName() string {
+	if x != nil {
+		return x.WhiteName
+	}
+	return ""
+}
+
+func (x *GameInfo) GetBlackRank() string {
+	if x != nil {
+		return x.BlackRank
+	}
+	return ""
+}
+
+func (x *GameInfo) GetWhiteRank() string {
+	if x != nil {
+		return x.WhiteRank
+	}
+	return ""
+}
+
+func (x *GameInfo) GetHandicap() int32 {
+	if x != nil {
+		return x.Handicap
+	}
+	return 0
+}
+
+type Event struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Seq    int64                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Time   *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Type   string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	GameId string                 `protobuf:"bytes,4,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	// Types that are valid to be assigned to Data:
+	//
+	//	*Event_Move
+	//	*Event_Result
+	//	*Event_Json
+	//	*Event_Delta
+	Data          isEvent_Data `protobuf_oneof:"data"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_game_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Event) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetGameId() string {
+	if x != nil {
+		return x.GameId
+	}
+	return ""
+}
+
+func (x *Event) GetData() isEvent_Data {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Event) GetMove() *Move {
+	if x != nil {
+		if x, ok := x.Data.(*Event_Move); ok {
+			return x.Move
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetResult() *Result {
+	if x != nil {
+		if x, ok := x.Data.(*Event_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetJson() []byte {
+	if x != nil {
+		if x, ok := x.Data.(*Event_Json); ok {
+			return x.Json
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetDelta() *BoardDelta {
+	if x != nil {
+		if x, ok := x.Data.(*Event_Delta); ok {
+			return x.Delta
+		}
+	}
+	return nil
+}
+
+type isEvent_Data interface {
+	isEvent_Data()
+}
+
+type Event_Move struct {
+	Move *Move `protobuf:"bytes,5,opt,name=move,proto3,oneof"`
+}
+
+type Event_Result struct {
+	Result *Result `protobuf:"bytes,6,opt,name=result,proto3,oneof"`
+}
+
+type Event_Json struct {
+	Json []byte `protobuf:"bytes,7,opt,name=json,proto3,oneof"`
+}
+
+type Event_Delta struct {
+	Delta *BoardDelta `protobuf:"bytes,8,opt,name=delta,proto3,oneof"`
+}
+
+func (*Event_Move) isEvent_Data() {}
+
+func (*Event_Result) isEvent_Data() {}
+
+func (*Event_Json) isEvent_Data() {}
+
+func (*Event_Delta) isEvent_Data() {}
+
+type EventPage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	Cursor        int64                  `protobuf:"varint,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	HasMore       bool                   `protobuf:"varint,3,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventPage) Reset() {
+	*x = EventPage{}
+	mi := &file_game_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventPage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventPage) ProtoMessage() {}
+
+func (x *EventPage) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventPage.ProtoReflect.Descriptor instead.
+func (*EventPage) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *EventPage) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *EventPage) GetCursor() int64 {
+	if x != nil {
+		return x.Cursor
+	}
+	return 0
+}
+
+func (x *EventPage) GetHasMore() bool {
+	if x != nil {
+		return x.HasMore
+	}
+	return false
+}
+
+var File_game_proto protoreflect.FileDescriptor
+
+const file_game_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"game.proto\x12\tgogame.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe6\x04\n" +
+	"\x05Board\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x05R\x04size\x12$\n" +
+	"\x04grid\x18\x02 \x03(\x0e2\x10.gogame.v1.ColorR\x04grid\x127\n" +
+	"\x0ecurrent_player\x18\x03 \x01(\x0e2\x10.gogame.v1.ColorR\rcurrentPlayer\x12*\n" +
+	"\x11captured_by_black\x18\x04 \x01(\x05R\x0fcapturedByBlack\x12*\n" +
+	"\x11captured_by_white\x18\x05 \x01(\x05R\x0fcapturedByWhite\x122\n" +
+	"\fmove_history\x18\x06 \x03(\v2\x0f.gogame.v1.MoveR\vmoveHistory\x12!\n" +
+	"\fmove_numbers\x18\a \x03(\x05R\vmoveNumbers\x12\x1b\n" +
+	"\tlast_move\x18\b \x01(\x05R\blastMove\x12\x14\n" +
+	"\x05phase\x18\t \x01(\tR\x05phase\x12\x18\n" +
+	"\avariant\x18\n" +
+	" \x01(\tR\avariant\x12\x12\n" +
+	"\x04komi\x18\v \x01(\x01R\x04komi\x12\x1f\n" +
+	"\vdead_stones\x18\f \x03(\x05R\n" +
+	"deadStones\x12)\n" +
+	"\x06result\x18\r \x01(\v2\x11.gogame.v1.ResultR\x06result\x12\x1f\n" +
+	"\vblack_setup\x18\x0e \x03(\x05R\n" +
+	"blackSetup\x12\x1f\n" +
+	"\vwhite_setup\x18\x0f \x03(\x05R\n" +
+	"whiteSetup\x12'\n" +
+	"\x04info\x18\x10 \x01(\v2\x13.gogame.v1.GameInfoR\x04info\x12#\n" +
+	"\rposition_hash\x18\x11 \x01(\x06R\fpositionHash\"{\n" +
+	"\x04Move\x12(\n" +
+	"\x06player\x18\x01 \x01(\x0e2\x10.gogame.v1.ColorR\x06player\x12\x1a\n" +
+	"\bposition\x18\x02 \x01(\x05R\bposition\x12-\n" +
+	"\x12captured_positions\x18\x03 \x03(\x05R\x11capturedPositions\"K\n" +
+	"\x05Stone\x12\x1a\n" +
+	"\bposition\x18\x01 \x01(\x05R\bposition\x12&\n" +
+	"\x05color\x18\x02 \x01(\x0e2\x10.gogame.v1.ColorR\x05color\"\xeb\x01\n" +
+	"\x05Clock\x12,\n" +
+	"\x12black_remaining_ms\x18\x01 \x01(\x03R\x10blackRemainingMs\x12,\n" +
+	"\x12white_remaining_ms\x18\x02 \x01(\x03R\x10whiteRemainingMs\x12,\n" +
+	"\x12black_periods_left\x18\x03 \x01(\x05R\x10blackPeriodsLeft\x12,\n" +
+	"\x12white_periods_left\x18\x04 \x01(\x05R\x10whitePeriodsLeft\x12*\n" +
+	"\arunning\x18\x05 \x01(\x0e2\x10.gogame.v1.ColorR\arunning\"\xa2\x03\n" +
+	"\n" +
+	"BoardDelta\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x05R\aversion\x12(\n" +
+	"\x06player\x18\x02 \x01(\x0e2\x10.gogame.v1.ColorR\x06player\x12\x1a\n" +
+	"\bposition\x18\x03 \x01(\x05R\bposition\x12&\n" +
+	"\x05added\x18\x04 \x03(\v2\x10.gogame.v1.StoneR\x05added\x12\x18\n" +
+	"\aremoved\x18\x05 \x03(\x05R\aremoved\x12*\n" +
+	"\x11captured_by_black\x18\x06 \x01(\x05R\x0fcapturedByBlack\x12*\n" +
+	"\x11captured_by_white\x18\a \x01(\x05R\x0fcapturedByWhite\x127\n" +
+	"\x0ecurrent_player\x18\b \x01(\x0e2\x10.gogame.v1.ColorR\rcurrentPlayer\x12\x14\n" +
+	"\x05phase\x18\t \x01(\tR\x05phase\x12&\n" +
+	"\x05clock\x18\n" +
+	" \x01(\v2\x10.gogame.v1.ClockR\x05clock\x12#\n" +
+	"\rposition_hash\x18\v \x01(\x06R\fpositionHash\"u\n" +
+	"\vMoveRequest\x12\x1a\n" +
+	"\bposition\x18\x01 \x01(\x05R\bposition\x12\x1e\n" +
+	"\n" +
+	"coordinate\x18\x02 \x01(\tR\n" +
+	"coordinate\x12\x12\n" +
+	"\x04pass\x18\x03 \x01(\bR\x04pass\x12\x16\n" +
+	"\x06player\x18\x04 \x01(\tR\x06player\"v\n" +
+	"\x06Result\x12(\n" +
+	"\x06winner\x18\x01 \x01(\x0e2\x10.gogame.v1.ColorR\x06winner\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x16\n" +
+	"\x06margin\x18\x03 \x01(\x01R\x06margin\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\"\xa2\x01\n" +
+	"\bGameInfo\x12\x1d\n" +
+	"\n" +
+	"black_name\x18\x01 \x01(\tR\tblackName\x12\x1d\n" +
+	"\n" +
+	"white_name\x18\x02 \x01(\tR\twhiteName\x12\x1d\n" +
+	"\n" +
+	"black_rank\x18\x03 \x01(\tR\tblackRank\x12\x1d\n" +
+	"\n" +
+	"white_rank\x18\x04 \x01(\tR\twhiteRank\x12\x1a\n" +
+	"\bhandicap\x18\x05 \x01(\x05R\bhandicap\"\x97\x02\n" +
+	"\x05Event\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x03R\x03seq\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x17\n" +
+	"\agame_id\x18\x04 \x01(\tR\x06gameId\x12%\n" +
+	"\x04move\x18\x05 \x01(\v2\x0f.gogame.v1.MoveH\x00R\x04move\x12+\n" +
+	"\x06result\x18\x06 \x01(\v2\x11.gogame.v1.ResultH\x00R\x06result\x12\x14\n" +
+	"\x04json\x18\a \x01(\fH\x00R\x04json\x12-\n" +
+	"\x05delta\x18\b \x01(\v2\x15.gogame.v1.BoardDeltaH\x00R\x05deltaB\x06\n" +
+	"\x04data\"h\n" +
+	"\tEventPage\x12(\n" +
+	"\x06events\x18\x01 \x03(\v2\x10.gogame.v1.EventR\x06events\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\x03R\x06cursor\x12\x19\n" +
+	"\bhas_more\x18\x03 \x01(\bR\ahasMore*9\n" +
+	"\x05Color\x12\x0e\n" +
+	"\n" +
+	"COLOR_NONE\x10\x00\x12\x0f\n" +
+	"\vCOLOR_BLACK\x10\x01\x12\x0f\n" +
+	"\vCOLOR_WHITE\x10\x02B\fZ\n" +
+	"go-game/pbb\x06proto3"
+
+var (
+	file_game_proto_rawDescOnce sync.Once
+	file_game_proto_rawDescData []byte
+)
+
+func file_game_proto_rawDescGZIP() []byte {
+	file_game_proto_rawDescOnce.Do(func() {
+		file_game_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_game_proto_rawDesc), len(file_game_proto_rawDesc)))
+	})
+	return file_game_proto_rawDescData
+}
+
+var file_game_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_game_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_game_proto_goTypes = []any{
+	(Color)(0),                    // 0: gogame.v1.Color
+	(*Board)(nil),                 // 1: gogame.v1.Board
+	(*Move)(nil),                  // 2: gogame.v1.Move
+	(*Stone)(nil),                 // 3: gogame.v1.Stone
+	(*Clock)(nil),                 // 4: gogame.v1.Clock
+	(*BoardDelta)(nil),            // 5: gogame.v1.BoardDelta
+	(*MoveRequest)(nil),           // 6: gogame.v1.MoveRequest
+	(*Result)(nil),                // 7: gogame.v1.Result
+	(*GameInfo)(nil),              // 8: gogame.v1.GameInfo
+	(*Event)(nil),                 // 9: gogame.v1.Event
+	(*EventPage)(nil),             // 10: gogame.v1.EventPage
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_game_proto_depIdxs = []int32{
+	0,  // 0: gogame.v1.Board.grid:type_name -> gogame.v1.Color
+	0,  // 1: gogame.v1.Board.current_player:type_name -> gogame.v1.Color
+	2,  // 2: gogame.v1.Board.move_history:type_name -> gogame.v1.Move
+	7,  // 3: gogame.v1.Board.result:type_name -> gogame.v1.Result
+	8,  // 4: gogame.v1.Board.info:type_name -> gogame.v1.GameInfo
+	0,  // 5: gogame.v1.Move.player:type_name -> gogame.v1.Color
+	0,  // 6: gogame.v1.Stone.color:type_name -> gogame.v1.Color
+	0,  // 7: gogame.v1.Clock.running:type_name -> gogame.v1.Color
+	0,  // 8: gogame.v1.BoardDelta.player:type_name -> gogame.v1.Color
+	3,  // 9: gogame.v1.BoardDelta.added:type_name -> gogame.v1.Stone
+	0,  // 10: gogame.v1.BoardDelta.current_player:type_name -> gogame.v1.Color
+	4,  // 11: gogame.v1.BoardDelta.clock:type_name -> gogame.v1.Clock
+	0,  // 12: gogame.v1.Result.winner:type_name -> gogame.v1.Color
+	11, // 13: gogame.v1.Event.time:type_name -> google.protobuf.Timestamp
+	2,  // 14: gogame.v1.Event.move:type_name -> gogame.v1.Move
+	7,  // 15: gogame.v1.Event.result:type_name -> gogame.v1.Result
+	5,  // 16: gogame.v1.Event.delta:type_name -> gogame.v1.BoardDelta
+	9,  // 17: gogame.v1.EventPage.events:type_name -> gogame.v1.Event
+	18, // [18:18] is the sub-list for method output_type
+	18, // [18:18] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_game_proto_init() }
+func file_game_proto_init() {
+	if File_game_proto != nil {
+		return
+	}
+	file_game_proto_msgTypes[8].OneofWrappers = []any{
+		(*Event_Move)(nil),
+		(*Event_Result)(nil),
+		(*Event_Json)(nil),
+		(*Event_Delta)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_game_proto_rawDesc), len(file_game_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_game_proto_goTypes,
+		DependencyIndexes: file_game_proto_depIdxs,
+		EnumInfos:         file_game_proto_enumTypes,
+		MessageInfos:      file_game_proto_msgTypes,
+	}.Build()
+	File_game_proto = out.File
+	file_game_proto_goTypes = nil
+	file_game_proto_depIdxs = nil
+}
@@ -0,0 +1,599 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: service.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateGameRequest struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Size                 int32                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	MainTime             int32                  `protobuf:"varint,2,opt,name=main_time,json=mainTime,proto3" json:"main_time,omitempty"`
+	ByoYomiTime          int32                  `protobuf:"varint,3,opt,name=byo_yomi_time,json=byoYomiTime,proto3" json:"byo_yomi_time,omitempty"`
+	ByoYomiPeriods       int32                  `protobuf:"varint,4,opt,name=byo_yomi_periods,json=byoYomiPeriods,proto3" json:"byo_yomi_periods,omitempty"`
+	Variant              string                 `protobuf:"bytes,5,opt,name=variant,proto3" json:"variant,omitempty"`
+	BlackName            string                 `protobuf:"bytes,6,opt,name=black_name,json=blackName,proto3" json:"black_name,omitempty"`
+	WhiteName            string                 `protobuf:"bytes,7,opt,name=white_name,json=whiteName,proto3" json:"white_name,omitempty"`
+	BlackRank            string                 `protobuf:"bytes,8,opt,name=black_rank,json=blackRank,proto3" json:"black_rank,omitempty"`
+	WhiteRank            string                 `protobuf:"bytes,9,opt,name=white_rank,json=whiteRank,proto3" json:"white_rank,omitempty"`
+	Hotseat              bool                   `protobuf:"varint,10,opt,name=hotseat,proto3" json:"hotseat,omitempty"`
+	Sandbox              bool                   `protobuf:"varint,11,opt,name=sandbox,proto3" json:"sandbox,omitempty"`
+	PrecomputeLegalMoves bool                   `protobuf:"varint,12,opt,name=precompute_legal_moves,json=precomputeLegalMoves,proto3" json:"precompute_legal_moves,omitempty"`
+	Color                Color                  `protobuf:"varint,13,opt,name=color,proto3,enum=gogame.v1.Color" json:"color,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *CreateGameRequest) Reset() {
+	*x = CreateGameRequest{}
+	mi := &file_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateGameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateGameRequest) ProtoMessage() {}
+
+func (x *CreateGameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateGameRequest.ProtoReflect.Descriptor instead.
+func (*CreateGameRequest) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateGameRequest) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *CreateGameRequest) GetMainTime() int32 {
+	if x != nil {
+		return x.MainTime
+	}
+	return 0
+}
+
+func (x *CreateGameRequest) GetByoYomiTime() int32 {
+	if x != nil {
+		return x.ByoYomiTime
+	}
+	return 0
+}
+
+func (x *CreateGameRequest) GetByoYomiPeriods() int32 {
+	if x != nil {
+		return x.ByoYomiPeriods
+	}
+	return 0
+}
+
+func (x *CreateGameRequest) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+func (x *CreateGameRequest) GetBlackName() string {
+	if x != nil {
+		return x.BlackName
+	}
+	return ""
+}
+
+func (x *CreateGameRequest) GetWhiteName() string {
+	if x != nil {
+		return x.WhiteName
+	}
+	return ""
+}
+
+func (x *CreateGameRequest) GetBlackRank() string {
+	if x != nil {
+		return x.BlackRank
+	}
+	return ""
+}
+
+func (x *CreateGameRequest) GetWhiteRank() string {
+	if x != nil {
+		return x.WhiteRank
+	}
+	return ""
+}
+
+func (x *CreateGameRequest) GetHotseat() bool {
+	if x != nil {
+		return x.Hotseat
+	}
+	return false
+}
+
+func (x *CreateGameRequest) GetSandbox() bool {
+	if x != nil {
+		return x.Sandbox
+	}
+	return false
+}
+
+func (x *CreateGameRequest) GetPrecomputeLegalMoves() bool {
+	if x != nil {
+		return x.PrecomputeLegalMoves
+	}
+	return false
+}
+
+func (x *CreateGameRequest) GetColor() Color {
+	if x != nil {
+		return x.Color
+	}
+	return Color_COLOR_NONE
+}
+
+type CreateGameResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GameId        string                 `protobuf:"bytes,1,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	Board         *Board                 `protobuf:"bytes,2,opt,name=board,proto3" json:"board,omitempty"`
+	BlackToken    string                 `protobuf:"bytes,3,opt,name=black_token,json=blackToken,proto3" json:"black_token,omitempty"`
+	WhiteToken    string                 `protobuf:"bytes,4,opt,name=white_token,json=whiteToken,proto3" json:"white_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateGameResponse) Reset() {
+	*x = CreateGameResponse{}
+	mi := &file_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateGameResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateGameResponse) ProtoMessage() {}
+
+func (x *CreateGameResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateGameResponse.ProtoReflect.Descriptor instead.
+func (*CreateGameResponse) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateGameResponse) GetGameId() string {
+	if x != nil {
+		return x.GameId
+	}
+	return ""
+}
+
+func (x *CreateGameResponse) GetBoard() *Board {
+	if x != nil {
+		return x.Board
+	}
+	return nil
+}
+
+func (x *CreateGameResponse) GetBlackToken() string {
+	if x != nil {
+		return x.BlackToken
+	}
+	return ""
+}
+
+func (x *CreateGameResponse) GetWhiteToken() string {
+	if x != nil {
+		return x.WhiteToken
+	}
+	return ""
+}
+
+type GetGameRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GameId        string                 `protobuf:"bytes,1,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	Player        Color                  `protobuf:"varint,2,opt,name=player,proto3,enum=gogame.v1.Color" json:"player,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGameRequest) Reset() {
+	*x = GetGameRequest{}
+	mi := &file_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGameRequest) ProtoMessage() {}
+
+func (x *GetGameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGameRequest.ProtoReflect.Descriptor instead.
+func (*GetGameRequest) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetGameRequest) GetGameId() string {
+	if x != nil {
+		return x.GameId
+	}
+	return ""
+}
+
+func (x *GetGameRequest) GetPlayer() Color {
+	if x != nil {
+		return x.Player
+	}
+	return Color_COLOR_NONE
+}
+
+type MakeMoveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GameId        string                 `protobuf:"bytes,1,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	Move          *MoveRequest           `protobuf:"bytes,2,opt,name=move,proto3" json:"move,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MakeMoveRequest) Reset() {
+	*x = MakeMoveRequest{}
+	mi := &file_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MakeMoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MakeMoveRequest) ProtoMessage() {}
+
+func (x *MakeMoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MakeMoveRequest.ProtoReflect.Descriptor instead.
+func (*MakeMoveRequest) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MakeMoveRequest) GetGameId() string {
+	if x != nil {
+		return x.GameId
+	}
+	return ""
+}
+
+func (x *MakeMoveRequest) GetMove() *MoveRequest {
+	if x != nil {
+		return x.Move
+	}
+	return nil
+}
+
+type WatchGameRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GameId        string                 `protobuf:"bytes,1,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	Player        Color                  `protobuf:"varint,2,opt,name=player,proto3,enum=gogame.v1.Color" json:"player,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchGameRequest) Reset() {
+	*x = WatchGameRequest{}
+	mi := &file_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchGameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchGameRequest) ProtoMessage() {}
+
+func (x *WatchGameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchGameRequest.ProtoReflect.Descriptor instead.
+func (*WatchGameRequest) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WatchGameRequest) GetGameId() string {
+	if x != nil {
+		return x.GameId
+	}
+	return ""
+}
+
+func (x *WatchGameRequest) GetPlayer() Color {
+	if x != nil {
+		return x.Player
+	}
+	return Color_COLOR_NONE
+}
+
+type GameUpdate struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Update:
+	//
+	//	*GameUpdate_Event
+	//	*GameUpdate_Board
+	Update        isGameUpdate_Update `protobuf_oneof:"update"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GameUpdate) Reset() {
+	*x = GameUpdate{}
+	mi := &file_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GameUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GameUpdate) ProtoMessage() {}
+
+func (x *GameUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GameUpdate.ProtoReflect.Descriptor instead.
+func (*GameUpdate) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GameUpdate) GetUpdate() isGameUpdate_Update {
+	if x != nil {
+		return x.Update
+	}
+	return nil
+}
+
+func (x *GameUpdate) GetEvent() *Event {
+	if x != nil {
+		if x, ok := x.Update.(*GameUpdate_Event); ok {
+			return x.Event
+		}
+	}
+	return nil
+}
+
+func (x *GameUpdate) GetBoard() *Board {
+	if x != nil {
+		if x, ok := x.Update.(*GameUpdate_Board); ok {
+			return x.Board
+		}
+	}
+	return nil
+}
+
+type isGameUpdate_Update interface {
+	isGameUpdate_Update()
+}
+
+type GameUpdate_Event struct {
+	Event *Event `protobuf:"bytes,1,opt,name=event,proto3,oneof"`
+}
+
+type GameUpdate_Board struct {
+	Board *Board `protobuf:"bytes,2,opt,name=board,proto3,oneof"`
+}
+
+func (*GameUpdate_Event) isGameUpdate_Update() {}
+
+func (*GameUpdate_Board) isGameUpdate_Update() {}
+
+var File_service_proto protoreflect.FileDescriptor
+
+const file_service_proto_rawDesc = "" +
+	"\n" +
+	"\rservice.proto\x12\tgogame.v1\x1a\n" +
+	"game.proto\"\xba\x03\n" +
+	"\x11CreateGameRequest\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x05R\x04size\x12\x1b\n" +
+	"\tmain_time\x18\x02 \x01(\x05R\bmainTime\x12\"\n" +
+	"\rbyo_yomi_time\x18\x03 \x01(\x05R\vbyoYomiTime\x12(\n" +
+	"\x10byo_yomi_periods\x18\x04 \x01(\x05R\x0ebyoYomiPeriods\x12\x18\n" +
+	"\avariant\x18\x05 \x01(\tR\avariant\x12\x1d\n" +
+	"\n" +
+	"black_name\x18\x06 \x01(\tR\tblackName\x12\x1d\n" +
+	"\n" +
+	"white_name\x18\a \x01(\tR\twhiteName\x12\x1d\n" +
+	"\n" +
+	"black_rank\x18\b \x01(\tR\tblackRank\x12\x1d\n" +
+	"\n" +
+	"white_rank\x18\t \x01(\tR\twhiteRank\x12\x18\n" +
+	"\ahotseat\x18\n" +
+	" \x01(\bR\ahotseat\x12\x18\n" +
+	"\asandbox\x18\v \x01(\bR\asandbox\x124\n" +
+	"\x16precompute_legal_moves\x18\f \x01(\bR\x14precomputeLegalMoves\x12&\n" +
+	"\x05color\x18\r \x01(\x0e2\x10.gogame.v1.ColorR\x05color\"\x97\x01\n" +
+	"\x12CreateGameResponse\x12\x17\n" +
+	"\agame_id\x18\x01 \x01(\tR\x06gameId\x12&\n" +
+	"\x05board\x18\x02 \x01(\v2\x10.gogame.v1.BoardR\x05board\x12\x1f\n" +
+	"\vblack_token\x18\x03 \x01(\tR\n" +
+	"blackToken\x12\x1f\n" +
+	"\vwhite_token\x18\x04 \x01(\tR\n" +
+	"whiteToken\"S\n" +
+	"\x0eGetGameRequest\x12\x17\n" +
+	"\agame_id\x18\x01 \x01(\tR\x06gameId\x12(\n" +
+	"\x06player\x18\x02 \x01(\x0e2\x10.gogame.v1.ColorR\x06player\"V\n" +
+	"\x0fMakeMoveRequest\x12\x17\n" +
+	"\agame_id\x18\x01 \x01(\tR\x06gameId\x12*\n" +
+	"\x04move\x18\x02 \x01(\v2\x16.gogame.v1.MoveRequestR\x04move\"U\n" +
+	"\x10WatchGameRequest\x12\x17\n" +
+	"\agame_id\x18\x01 \x01(\tR\x06gameId\x12(\n" +
+	"\x06player\x18\x02 \x01(\x0e2\x10.gogame.v1.ColorR\x06player\"j\n" +
+	"\n" +
+	"GameUpdate\x12(\n" +
+	"\x05event\x18\x01 \x01(\v2\x10.gogame.v1.EventH\x00R\x05event\x12(\n" +
+	"\x05board\x18\x02 \x01(\v2\x10.gogame.v1.BoardH\x00R\x05boardB\b\n" +
+	"\x06update2\x8d\x02\n" +
+	"\vGameService\x12I\n" +
+	"\n" +
+	"CreateGame\x12\x1c.gogame.v1.CreateGameRequest\x1a\x1d.gogame.v1.CreateGameResponse\x126\n" +
+	"\aGetGame\x12\x19.gogame.v1.GetGameRequest\x1a\x10.gogame.v1.Board\x128\n" +
+	"\bMakeMove\x12\x1a.gogame.v1.MakeMoveRequest\x1a\x10.gogame.v1.Board\x12A\n" +
+	"\tWatchGame\x12\x1b.gogame.v1.WatchGameRequest\x1a\x15.gogame.v1.GameUpdate0\x01B\fZ\n" +
+	"go-game/pbb\x06proto3"
+
+var (
+	file_service_proto_rawDescOnce sync.Once
+	file_service_proto_rawDescData []byte
+)
+
+func file_service_proto_rawDescGZIP() []byte {
+	file_service_proto_rawDescOnce.Do(func() {
+		file_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_service_proto_rawDesc), len(file_service_proto_rawDesc)))
+	})
+	return file_service_proto_rawDescData
+}
+
+var file_service_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_service_proto_goTypes = []any{
+	(*CreateGameRequest)(nil),  // 0: gogame.v1.CreateGameRequest
+	(*CreateGameResponse)(nil), // 1: gogame.v1.CreateGameResponse
+	(*GetGameRequest)(nil),     // 2: gogame.v1.GetGameRequest
+	(*MakeMoveRequest)(nil),    // 3: gogame.v1.MakeMoveRequest
+	(*WatchGameRequest)(nil),   // 4: gogame.v1.WatchGameRequest
+	(*GameUpdate)(nil),         // 5: gogame.v1.GameUpdate
+	(Color)(0),                 // 6: gogame.v1.Color
+	(*Board)(nil),              // 7: gogame.v1.Board
+	(*MoveRequest)(nil),        // 8: gogame.v1.MoveRequest
+	(*Event)(nil),              // 9: gogame.v1.Event
+}
+var file_service_proto_depIdxs = []int32{
+	6,  // 0: gogame.v1.CreateGameRequest.color:type_name -> gogame.v1.Color
+	7,  // 1: gogame.v1.CreateGameResponse.board:type_name -> gogame.v1.Board
+	6,  // 2: gogame.v1.GetGameRequest.player:type_name -> gogame.v1.Color
+	8,  // 3: gogame.v1.MakeMoveRequest.move:type_name -> gogame.v1.MoveRequest
+	6,  // 4: gogame.v1.WatchGameRequest.player:type_name -> gogame.v1.Color
+	9,  // 5: gogame.v1.GameUpdate.event:type_name -> gogame.v1.Event
+	7,  // 6: gogame.v1.GameUpdate.board:type_name -> gogame.v1.Board
+	0,  // 7: gogame.v1.GameService.CreateGame:input_type -> gogame.v1.CreateGameRequest
+	2,  // 8: gogame.v1.GameService.GetGame:input_type -> gogame.v1.GetGameRequest
+	3,  // 9: gogame.v1.GameService.MakeMove:input_type -> gogame.v1.MakeMoveRequest
+	4,  // 10: gogame.v1.GameService.WatchGame:input_type -> gogame.v1.WatchGameRequest
+	1,  // 11: gogame.v1.GameService.CreateGame:output_type -> gogame.v1.CreateGameResponse
+	7,  // 12: gogame.v1.GameService.GetGame:output_type -> gogame.v1.Board
+	7,  // 13: gogame.v1.GameService.MakeMove:output_type -> gogame.v1.Board
+	5,  // 14: gogame.v1.GameService.WatchGame:output_type -> gogame.v1.GameUpdate
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_service_proto_init() }
+func file_service_proto_init() {
+	if File_service_proto != nil {
+		return
+	}
+	file_game_proto_init()
+	file_service_proto_msgTypes[5].OneofWrappers = []any{
+		(*GameUpdate_Event)(nil),
+		(*GameUpdate_Board)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_service_proto_rawDesc), len(file_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_service_proto_goTypes,
+		DependencyIndexes: file_service_proto_depIdxs,
+		MessageInfos:      file_service_proto_msgTypes,
+	}.Build()
+	File_service_proto = out.File
+	file_service_proto_goTypes = nil
+	file_service_proto_depIdxs = nil
+}
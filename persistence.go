@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go-game/game"
+	"go-game/rating"
+	"go-game/redis"
+	"go-game/store"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newStoreFromEnv creates the game store
+// DATABASE_URL selects Postgres, with optional read replicas in DATABASE_REPLICA_URLS (comma separated)
+// and a read-through cache in front: in Redis if REDIS_URL is set, shared by every server, in memory
+// otherwise. REDIS_URL alone keeps the games in Redis; without either games are only kept in memory
+func newStoreFromEnv() (store.Store, error) {
+	var cache *redis.Client
+	if url := os.Getenv("REDIS_URL"); url != "" {
+		var err error
+		if cache, err = redis.Open(url); err != nil {
+			return nil, err
+		}
+	}
+
+	primaryURL := os.Getenv("DATABASE_URL")
+	if primaryURL == "" {
+		if cache == nil {
+			return store.NewMemoryStore(), nil
+		}
+
+		// Unfinished games nobody touched for REDIS_GAME_TTL (default a week) are dropped as abandoned
+		ttl, err := envDuration("REDIS_GAME_TTL", defaultRedisGameTTL)
+		if err != nil {
+			return nil, err
+		}
+		return store.NewRedisStore(cache, ttl), nil
+	}
+
+	replicaURLs := make([]string, 0)
+	for _, url := range strings.Split(os.Getenv("DATABASE_REPLICA_URLS"), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			replicaURLs = append(replicaURLs, url)
+		}
+	}
+
+	// Move log checksums are keyed with GAME_INTEGRITY_KEY if set, so edits to stored games can't be covered up
+	integrityKey, err := envKeyProvider{variable: "GAME_INTEGRITY_KEY"}.MasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	postgres, err := store.NewPostgresStore(primaryURL, replicaURLs, integrityKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep hot games cached so polling doesn't hit the database (GAME_CACHE_TTL, default 30s)
+	ttl, err := envDuration("GAME_CACHE_TTL", defaultGameCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		return store.NewRedisCache(postgres, cache, ttl), nil
+	}
+	return store.NewCachedStore(postgres, ttl, gameCacheSize), nil
+}
+
+// envDuration reads a duration from an environment variable, e.g. "30s"
+func envDuration(variable string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(variable)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration, got %q", variable, value)
+	}
+	return d, nil
+}
+
+// Game cache settings
+const (
+	defaultGameCacheTTL = 30 * time.Second
+	defaultRedisGameTTL = 7 * 24 * time.Hour
+	gameCacheSize       = 1000
+)
+
+// saveGame persists the current state of a game
+// Failures are logged rather than failing the request; the game keeps running in memory
+// The save is detached from ctx's cancellation: a client hanging up right after its move
+// must not leave the stored game behind the live one
+// Sandbox games are never saved, which keeps them out of listings and archives
+// Saving also counts as activity in the game, keeping the janitor away; must be called with the game locked
+func saveGame(ctx context.Context, gameID string, board *game.Board) {
+	if isSandbox(gameID) {
+		return // Sandbox games only live in memory
+	}
+	touchGame(gameID, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := gameStore.SaveGame(ctx, gameID, board); err != nil {
+		log.Printf("saving game %s: %v", gameID, err)
+	}
+}
+
+// staleReads marks a route as tolerant of slightly outdated data
+// Its store reads may then be served by a read replica instead of the primary
+func staleReads(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		c.SetRequest(req.WithContext(store.AllowStale(req.Context())))
+		return next(c)
+	}
+}
+
+// Page size limits for game listings
+const (
+	defaultGamePageSize = 20
+	maxGamePageSize     = 100
+)
+
+// List stored games, most recently active first
+func listGames(c echo.Context) error {
+	limit := defaultGamePageSize
+	if param := c.QueryParam("limit"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed <= 0 || parsed > maxGamePageSize {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+		}
+		limit = parsed
+	}
+
+	offset := 0
+	if param := c.QueryParam("offset"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid offset"})
+		}
+		offset = parsed
+	}
+
+	records, err := gameStore.ListGames(c.Request().Context(), limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list games"})
+	}
+
+	now := time.Now()
+	summaries := make([]GameSync, 0, len(records))
+	profiles := make(map[string]rating.Profile) // Players often show up in several games of a page
+	for _, record := range records {
+		if record.Corrupted {
+			log.Printf("game %s: %v", record.ID, store.ErrCorrupted)
+			summaries = append(summaries, GameSync{GameID: record.ID, Corrupted: true})
+			continue
+		}
+		summary := summarizeGame(record.ID, record.Board, "", now)
+		summary.Ratings = playerRatings(c.Request().Context(), record.Board, profiles)
+		summaries = append(summaries, summary)
+	}
+
+	return c.JSON(http.StatusOK, summaries)
+}
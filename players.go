@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"go-game/archive"
+	"go-game/game"
+	"go-game/rating"
+	"go-game/store"
+	"go-game/users"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// archiveStore indexes the finished games by player (Postgres if DATABASE_URL is set, memory otherwise)
+var archiveStore archive.Store
+
+// newArchiveStoreFromEnv opens the player game index next to the games
+func newArchiveStoreFromEnv() (archive.Store, error) {
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		return archive.NewPostgresStore(url)
+	}
+	return archive.NewMemoryStore(), nil
+}
+
+// Player page settings
+const (
+	playerRatingHistory = 20 // Latest rating changes shown
+	playerRecentGames   = 10 // Latest games shown
+)
+
+// Page sizes of a player's games
+const (
+	defaultPlayerGamePage = 20
+	maxPlayerGamePage     = 100
+)
+
+// archiveEntries describes a finished game for the index, once for each account playing it;
+// annulled games and games without accounts have none
+func archiveEntries(gameID string, board *game.Board, now time.Time) []archive.Entry {
+	if board.Result == nil || board.Annulled() {
+		return nil
+	}
+	_, rated := ratedGame(gameID, board, now)
+	names := [3]string{"", board.Info.BlackName, board.Info.WhiteName}
+
+	var entries []archive.Entry
+	for color := 1; color <= 2; color++ {
+		if board.Players[color] == "" {
+			continue
+		}
+		outcome := archive.OutcomeLoss
+		switch board.Result.Winner {
+		case color:
+			outcome = archive.OutcomeWin
+		case 0:
+			outcome = archive.OutcomeDraw
+		}
+		entries = append(entries, archive.Entry{
+			GameID:       gameID,
+			UserID:       board.Players[color],
+			Color:        color,
+			Opponent:     board.Players[3-color],
+			OpponentName: names[3-color],
+			Outcome:      outcome,
+			Result:       board.Result.String(),
+			Size:         board.Size,
+			Moves:        len(board.MoveHistory),
+			Thinking:     board.Pace.Thinking[1] + board.Pace.Thinking[2],
+			Rated:        rated,
+			FinishedAt:   now,
+		})
+	}
+	return entries
+}
+
+// archiveGame adds a game that just ended to the games of its players
+// Must be called with the game locked
+func archiveGame(gameID string, board *game.Board) {
+	entries := archiveEntries(gameID, board, time.Now())
+	if len(entries) == 0 || isSandbox(gameID) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := archiveStore.Add(ctx, gameID, entries); err != nil {
+		log.Printf("indexing game %s: %v", gameID, err)
+	}
+}
+
+// unarchiveGame takes a game out of the games of its players
+func unarchiveGame(ctx context.Context, gameID string) {
+	if err := archiveStore.DeleteGame(ctx, gameID); err != nil {
+		log.Printf("removing game %s from the index: %v", gameID, err)
+	}
+}
+
+// forEachPlayerGame calls fn with the games of an account in the index, most recently
+// finished first, until fn returns false
+func forEachPlayerGame(ctx context.Context, userID string, fn func(entry archive.Entry) bool) error {
+	for offset := 0; ; offset += archivePageSize {
+		entries, err := archiveStore.List(ctx, userID, archive.Filter{}, archivePageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if !fn(entry) {
+				return nil
+			}
+		}
+		if len(entries) < archivePageSize {
+			return nil
+		}
+	}
+}
+
+// ColorRecord is how a player fared with one color
+type ColorRecord struct {
+	Games  int `json:"games"`
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Draws  int `json:"draws"` // Jigo, or games ended without a winner
+}
+
+// add counts a finished game
+func (r *ColorRecord) add(outcome string) {
+	r.Games++
+	switch outcome {
+	case archive.OutcomeWin:
+		r.Wins++
+	case archive.OutcomeDraw:
+		r.Draws++
+	default:
+		r.Losses++
+	}
+}
+
+// BoardSizeCount is how many games a player played on a board size
+type BoardSizeCount struct {
+	Size  int `json:"size"`
+	Games int `json:"games"`
+}
+
+// PlayerStats is the public page of a player: the profile with statistics over their finished games
+type PlayerStats struct {
+	Player          users.Profile    `json:"player"`
+	RatingHistory   []rating.Change  `json:"ratingHistory"` // Latest rating changes, newest first
+	Games           int              `json:"games"`         // Finished games, annulled ones left out
+	Black           ColorRecord      `json:"black"`
+	White           ColorRecord      `json:"white"`
+	BoardSizes      []BoardSizeCount `json:"boardSizes"`      // Most played first
+	AverageMoves    float64          `json:"averageMoves"`    // Moves per game, passes included
+	AverageDuration time.Duration    `json:"averageDuration"` // Thinking time of both players per game
+	RecentGames     []GameSync       `json:"recentGames"`     // Latest finished games, most recent first
+}
+
+// playerAccount reads the account of a player route (":id"), answering the request if it can't
+func playerAccount(c echo.Context) (users.User, bool, error) {
+	user, err := userStore.Get(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, users.ErrNotFound) {
+		return users.User{}, false, c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+	if err != nil {
+		return users.User{}, false, c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return user, true, nil
+}
+
+// Profile of a player with their rating history and statistics over their finished games
+func getPlayer(c echo.Context) error {
+	ctx := c.Request().Context()
+	user, ok, err := playerAccount(c)
+	if !ok {
+		return err
+	}
+
+	stats := PlayerStats{BoardSizes: []BoardSizeCount{}, RecentGames: []GameSync{}}
+	if stats.Player, err = ratedProfile(ctx, user); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if stats.RatingHistory, err = ratingStore.History(ctx, user.ID, "", playerRatingHistory); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if stats.RatingHistory == nil {
+		stats.RatingHistory = []rating.Change{}
+	}
+
+	sizes := make(map[int]int)
+	moves, timed := 0, 0
+	var thinking time.Duration
+	var recent []string
+	err = forEachPlayerGame(ctx, user.ID, func(entry archive.Entry) bool {
+		stats.Games++
+		if entry.Color == 1 {
+			stats.Black.add(entry.Outcome)
+		} else {
+			stats.White.add(entry.Outcome)
+		}
+		sizes[entry.Size]++
+		moves += entry.Moves
+		if entry.Thinking > 0 {
+			thinking += entry.Thinking
+			timed++
+		}
+		if len(recent) < playerRecentGames {
+			recent = append(recent, entry.GameID)
+		}
+		return true
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list games"})
+	}
+
+	for size, games := range sizes {
+		stats.BoardSizes = append(stats.BoardSizes, BoardSizeCount{Size: size, Games: games})
+	}
+	sort.Slice(stats.BoardSizes, func(i, j int) bool {
+		a, b := stats.BoardSizes[i], stats.BoardSizes[j]
+		return a.Games > b.Games || a.Games == b.Games && a.Size > b.Size
+	})
+	if stats.Games > 0 {
+		stats.AverageMoves = float64(moves) / float64(stats.Games)
+	}
+	if timed > 0 {
+		stats.AverageDuration = thinking / time.Duration(timed)
+	}
+
+	// The recent games are shown as listings show them, so they are read from the store
+	now := time.Now()
+	profiles := make(map[string]rating.Profile)
+	for _, gameID := range recent {
+		board, err := gameStore.LoadGame(ctx, gameID)
+		if err != nil {
+			log.Printf("loading game %s: %v", gameID, err)
+			continue
+		}
+		summary := summarizeGame(gameID, board, "", now)
+		summary.Ratings = playerRatings(ctx, board, profiles)
+		stats.RecentGames = append(stats.RecentGames, summary)
+	}
+	return c.JSON(http.StatusOK, stats)
+}
+
+// Finished games of a player, most recent first (?outcome=win, loss or draw, ?opponent= an
+// account ID or username, ?limit=, ?offset=)
+func listPlayerGames(c echo.Context) error {
+	ctx := c.Request().Context()
+	user, ok, err := playerAccount(c)
+	if !ok {
+		return err
+	}
+
+	var filter archive.Filter
+	switch outcome := c.QueryParam("outcome"); outcome {
+	case "", archive.OutcomeWin, archive.OutcomeLoss, archive.OutcomeDraw:
+		filter.Outcome = outcome
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid outcome"})
+	}
+	if param := c.QueryParam("opponent"); param != "" {
+		opponent, err := userStore.Get(ctx, param)
+		if errors.Is(err, users.ErrNotFound) {
+			opponent, err = userStore.FindByUsername(ctx, param)
+		}
+		if errors.Is(err, users.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Opponent not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		filter.Opponent = opponent.ID
+	}
+
+	limit := defaultPlayerGamePage
+	if param := c.QueryParam("limit"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed <= 0 || parsed > maxPlayerGamePage {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+		}
+		limit = parsed
+	}
+	offset := 0
+	if param := c.QueryParam("offset"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid offset"})
+		}
+		offset = parsed
+	}
+
+	entries, err := archiveStore.List(ctx, user.ID, filter, limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list games"})
+	}
+	if entries == nil {
+		entries = []archive.Entry{}
+	}
+	return c.JSON(http.StatusOK, entries)
+}
+
+// Index the finished games already stored by their players, e.g. games from before the index
+// existed; games indexed already are replaced, so running it again is harmless
+func reindexPlayerGames(c echo.Context) error {
+	ctx := c.Request().Context()
+	indexed := 0
+	for offset := 0; ; offset += archivePageSize {
+		records, err := gameStore.ListGames(ctx, archivePageSize, offset)
+		if err != nil {
+			log.Printf("indexing games: %v", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list games"})
+		}
+		for _, record := range records {
+			if record.Corrupted || record.Board.Phase != game.PhaseFinished {
+				continue
+			}
+			entries := archiveEntries(record.ID, record.Board, record.UpdatedAt)
+			if len(entries) == 0 {
+				continue
+			}
+			if err := archiveStore.Add(ctx, record.ID, entries); err != nil {
+				log.Printf("indexing game %s: %v", record.ID, err)
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to index the games"})
+			}
+			indexed++
+		}
+		if len(records) < archivePageSize {
+			break
+		}
+	}
+
+	log.Printf("admin: indexed %d finished games by player", indexed)
+	return c.JSON(http.StatusOK, map[string]int{"indexed": indexed})
+}
+
+// storedGame reads a game from the store for an export, logging why it can't
+func storedGame(ctx context.Context, gameID string) (*game.Board, bool) {
+	board, err := gameStore.LoadGame(ctx, gameID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, false // Deleted since it was indexed
+	}
+	if err != nil {
+		log.Printf("exporting game %s: %v", gameID, err)
+		return nil, false
+	}
+	return board, true
+}
@@ -0,0 +1,166 @@
+// Package plugin lets Go extensions compiled into the server follow the lifecycle of games
+// and add routes of their own, so forks can add features (school integrations, custom
+// variants, ...) without patching the core handlers
+//
+// An extension registers itself from an init function in a file of its own:
+//
+//	func init() {
+//		plugin.Register(plugin.Plugin{
+//			Name:  "school",
+//			Hooks: plugin.Hooks{GameFinished: reportToSchool},
+//			Routes: func(g *echo.Group) {
+//				g.GET("/classes", listClasses)
+//			},
+//		})
+//	}
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"go-game/game"
+	"log"
+	"regexp"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Game is the game a hook is called for
+type Game struct {
+	ID    string
+	Board *game.Board // A copy taken when the event happened, shared by the plugins: read it, don't change it
+}
+
+// Hooks are called for the lifecycle events of every game; leave out the ones not needed
+// They are called one at a time on a worker of their own, in the order the events happened,
+// so they may take their time and call into the server, but a slow hook delays the others
+type Hooks struct {
+	GameCreated  func(ctx context.Context, g Game)
+	Move         func(ctx context.Context, g Game, move game.Move) // A stone was played or a player passed
+	GameFinished func(ctx context.Context, g Game)                 // g.Board.Result holds the result
+}
+
+// Plugin is an extension of the server
+type Plugin struct {
+	Name string // Lowercase letters, digits and dashes; the routes are served under /plugins/<name>
+	Hooks
+	Routes func(g *echo.Group) // Registers the plugin's routes (optional)
+}
+
+// queueSize is how many hook calls may wait for the worker before new ones are dropped
+const queueSize = 1024
+
+var (
+	mu      sync.Mutex
+	plugins []Plugin
+	queue   = make(chan func(ctx context.Context), queueSize)
+)
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Register adds a plugin to the server; it panics if the name is invalid or taken,
+// as that is a mistake in the build rather than something to handle at run time
+func Register(p Plugin) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !validName.MatchString(p.Name) {
+		panic(fmt.Sprintf("plugin: invalid name %q", p.Name))
+	}
+	for _, registered := range plugins {
+		if registered.Name == p.Name {
+			panic(fmt.Sprintf("plugin: %q registered twice", p.Name))
+		}
+	}
+	plugins = append(plugins, p)
+}
+
+// Plugins lists the registered plugins, in the order they were registered
+func Plugins() []Plugin {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return append([]Plugin(nil), plugins...)
+}
+
+// Mount registers the routes of every plugin under /plugins/<name>
+func Mount(e *echo.Echo) {
+	for _, p := range Plugins() {
+		if p.Routes != nil {
+			p.Routes(e.Group("/plugins/" + p.Name))
+		}
+	}
+}
+
+// Run calls the hooks as events are queued, until ctx is cancelled
+func Run(ctx context.Context) {
+	for {
+		select {
+		case call := <-queue:
+			call(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// GameCreated queues the GameCreated hooks for a new game
+func GameCreated(gameID string, board *game.Board) {
+	notify(gameID, board, func(h Hooks) bool { return h.GameCreated != nil }, func(ctx context.Context, h Hooks, g Game) {
+		h.GameCreated(ctx, g)
+	})
+}
+
+// Moved queues the Move hooks for the last move of a game
+func Moved(gameID string, board *game.Board) {
+	if len(board.MoveHistory) == 0 {
+		return
+	}
+	move := board.MoveHistory[len(board.MoveHistory)-1]
+	notify(gameID, board, func(h Hooks) bool { return h.Move != nil }, func(ctx context.Context, h Hooks, g Game) {
+		h.Move(ctx, g, move)
+	})
+}
+
+// GameFinished queues the GameFinished hooks for a game that has ended
+func GameFinished(gameID string, board *game.Board) {
+	notify(gameID, board, func(h Hooks) bool { return h.GameFinished != nil }, func(ctx context.Context, h Hooks, g Game) {
+		h.GameFinished(ctx, g)
+	})
+}
+
+// notify queues a call of the hooks the plugins have for an event
+// The board is copied now, as the caller holds the lock it is changed under
+func notify(gameID string, board *game.Board, has func(Hooks) bool, call func(context.Context, Hooks, Game)) {
+	var hooked []Plugin
+	for _, p := range Plugins() {
+		if has(p.Hooks) {
+			hooked = append(hooked, p)
+		}
+	}
+	if len(hooked) == 0 {
+		return // Nothing to copy the board for
+	}
+
+	g := Game{ID: gameID, Board: board.Clone()}
+	select {
+	case queue <- func(ctx context.Context) {
+		for _, p := range hooked {
+			safely(p.Name, func() { call(ctx, p.Hooks, g) })
+		}
+	}:
+	default:
+		log.Printf("plugin: hook queue full, dropping an event of game %s", gameID)
+	}
+}
+
+// safely runs a hook, so a panicking plugin doesn't take the server down
+func safely(name string, hook func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("plugin %s: hook panicked: %v", name, r)
+		}
+	}()
+	hook()
+}
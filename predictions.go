@@ -0,0 +1,164 @@
+package main
+
+import (
+	"go-game/game"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Prediction limits
+const (
+	maxPredictionsPerMove   = 10000 // Spectators predicting one move
+	maxPredictionSpectators = 40    // Characters in a spectator ID
+)
+
+// PredictionRound collects the spectators' guesses for the next move of a game
+type PredictionRound struct {
+	Version int            // Game version the guesses are for; a move closes the round
+	Votes   map[string]int // Spectator ID -> predicted position
+}
+
+// predictions holds the current round of each live game (guarded by gamesMu, each round by its game's lock)
+var predictions = make(map[string]*PredictionRound)
+
+// Prediction request structure
+type PredictionRequest struct {
+	Spectator  string `json:"spectator"`  // Anonymous ID chosen by the client, one vote per move each
+	Position   int    `json:"position"`   // Predicted point
+	Coordinate string `json:"coordinate"` // Predicted point in standard notation; used instead of position if set
+}
+
+// HeatPoint is how many spectators expect the next stone on a point
+type HeatPoint struct {
+	Position   int    `json:"position"`
+	Coordinate string `json:"coordinate"`
+	Votes      int    `json:"votes"`
+}
+
+// PredictionHeatmap aggregates the guesses for the next move, without saying who made them
+type PredictionHeatmap struct {
+	MoveNumber int         `json:"moveNumber"` // Number of the move being predicted
+	Votes      int         `json:"votes"`      // Spectators who guessed
+	Points     []HeatPoint `json:"points"`     // Points with at least one vote, most popular first
+}
+
+// predictionRound returns the open round of a game, starting a new one after each move
+// Must be called with the game locked
+func predictionRound(gameID string, board *game.Board) *PredictionRound {
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	round := predictions[gameID]
+	if round == nil || round.Version != board.Version() {
+		round = &PredictionRound{Version: board.Version(), Votes: make(map[string]int)}
+		predictions[gameID] = round
+	}
+	return round
+}
+
+// heatmap aggregates a round
+func (r *PredictionRound) heatmap(size int) PredictionHeatmap {
+	counts := make(map[int]int)
+	for _, position := range r.Votes {
+		counts[position]++
+	}
+
+	heatmap := PredictionHeatmap{MoveNumber: r.Version + 1, Votes: len(r.Votes), Points: make([]HeatPoint, 0, len(counts))}
+	for position, votes := range counts {
+		heatmap.Points = append(heatmap.Points, HeatPoint{Position: position, Coordinate: game.FormatCoordinate(position, size), Votes: votes})
+	}
+	sort.Slice(heatmap.Points, func(i, j int) bool {
+		a, b := heatmap.Points[i], heatmap.Points[j]
+		return a.Votes > b.Votes || a.Votes == b.Votes && a.Position < b.Position
+	})
+	return heatmap
+}
+
+// checkSpectator rejects requests made by a player of the game, by seat token or account,
+// or saying they are one (?player=1 or 2)
+// Players must never see what the audience expects them to play
+func checkSpectator(c echo.Context, board *game.Board) error {
+	if player := c.QueryParam("player"); player == "1" || player == "2" || requestSeat(c, board) != 0 {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Only spectators can take part in predictions"})
+	}
+	return nil
+}
+
+// Predict the next move of a game, as a spectator
+// The updated heatmap is broadcast to spectators
+func postPrediction(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Parse the request
+	var predictionReq PredictionRequest
+	if err := c.Bind(&predictionReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	spectator := strings.TrimSpace(predictionReq.Spectator)
+	if spectator == "" || utf8.RuneCountInString(spectator) > maxPredictionSpectators {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid spectator"})
+	}
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+	if err := checkSpectator(c, board); err != nil {
+		return err
+	}
+	if board.Concealed() {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not available while stones are hidden"})
+	}
+	if board.Phase != game.PhasePlaying {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Game is not being played"})
+	}
+
+	position := predictionReq.Position
+	if predictionReq.Coordinate != "" {
+		parsed, err := game.ParseCoordinate(predictionReq.Coordinate, board.Size)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		position = parsed
+	}
+	if !board.IsLegal(position) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Predictions must be legal moves"})
+	}
+
+	round := predictionRound(gameID, board)
+	if _, voted := round.Votes[spectator]; !voted && len(round.Votes) >= maxPredictionsPerMove {
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "Too many predictions for this move"})
+	}
+	round.Votes[spectator] = position // A spectator may change their mind until the move is played
+	noteSpectator(gameID, spectator)
+
+	heatmap := round.heatmap(board.Size)
+	hub.Broadcast(Event{Type: EventPredictions, GameID: gameID, Data: heatmap})
+	return c.JSON(http.StatusOK, heatmap)
+}
+
+// Heatmap of the spectators' predictions for the next move (spectators only)
+func getPredictions(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+	if err := checkSpectator(c, board); err != nil {
+		return err
+	}
+	if board.Concealed() {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not available while stones are hidden"})
+	}
+
+	return c.JSON(http.StatusOK, predictionRound(gameID, board).heatmap(board.Size))
+}
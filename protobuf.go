@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"go-game/game"
+	"go-game/pb"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// mimeProtobuf is the content type of Protocol Buffers requests and responses
+const mimeProtobuf = "application/x-protobuf"
+
+// accepts reports whether the client's Accept header lists one of the media types
+func accepts(c echo.Context, mediaTypes ...string) bool {
+	for _, accepted := range strings.Split(c.Request().Header.Get(echo.HeaderAccept), ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if slices.Contains(mediaTypes, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsProtobuf reports whether the client asked for Protocol Buffers responses
+func wantsProtobuf(c echo.Context) bool {
+	return accepts(c, mimeProtobuf, "application/protobuf")
+}
+
+// sentProtobuf reports whether the request body is a Protocol Buffers message
+func sentProtobuf(c echo.Context) bool {
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	return mediaType == mimeProtobuf || mediaType == "application/protobuf"
+}
+
+// protobuf sends a message as the response
+func protobuf(c echo.Context, status int, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.Blob(status, mimeProtobuf, data)
+}
+
+// headerSpectators carries the number of spectators following a game live
+const headerSpectators = "X-Spectators"
+
+// respondBoard sends a board state in the format the client asked for, with the spectator count in the headers
+// The v1 routes answer with the v1 game response instead of the whole board
+func respondBoard(c echo.Context, status int, gameID string, board *game.Board) error {
+	c.Response().Header().Set(headerSpectators, strconv.Itoa(liveSpectators(gameID)))
+	switch {
+	case apiVersion(c) == 1:
+		return c.JSON(status, newV1Game(c, gameID, board))
+	case wantsProtobuf(c):
+		return protobuf(c, status, pb.FromBoard(board))
+	case wantsMsgpack(c):
+		return msgpackBlob(c, status, board)
+	}
+	return c.JSON(status, board)
+}
+
+// bindMove reads a move request sent as JSON, form values or a Protocol Buffers message
+func bindMove(c echo.Context, moveReq *MoveRequest) error {
+	if !sentProtobuf(c) {
+		return c.Bind(moveReq)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return err
+	}
+	var msg pb.MoveRequest
+	if err := proto.Unmarshal(body, &msg); err != nil {
+		return err
+	}
+	*moveReq = MoveRequest{
+		Position:   int(msg.Position),
+		Coordinate: msg.Coordinate,
+		Pass:       msg.Pass,
+		Player:     msg.Player,
+	}
+	return nil
+}
+
+// eventMessage converts an event to its message
+// Payloads without a message of their own are carried as JSON
+func eventMessage(event Event) *pb.Event {
+	msg := &pb.Event{
+		Seq:    event.Seq,
+		Time:   timestamppb.New(event.Time),
+		Type:   event.Type,
+		GameId: event.GameID,
+	}
+	switch data := event.Data.(type) {
+	case nil:
+	case game.Move:
+		msg.Data = &pb.Event_Move{Move: pb.FromMove(data)}
+	case *game.Result:
+		if data != nil {
+			msg.Data = &pb.Event_Result{Result: pb.FromResult(data)}
+		}
+	case ResultDescription:
+		msg.Data = &pb.Event_Result{Result: &pb.Result{
+			Winner: pb.Color(data.Winner),
+			Reason: data.Method,
+			Margin: data.Margin,
+			Text:   data.Notation,
+		}}
+	case *game.Delta:
+		if data != nil {
+			msg.Data = &pb.Event_Delta{Delta: pb.FromDelta(data)}
+		}
+	default:
+		encoded, _ := json.Marshal(data)
+		msg.Data = &pb.Event_Json{Json: encoded}
+	}
+	return msg
+}
+
+// eventPageMessage converts a page of the event log to its message
+func eventPageMessage(page EventPageResponse) *pb.EventPage {
+	msg := &pb.EventPage{Events: make([]*pb.Event, len(page.Events)), Cursor: page.Cursor, HasMore: page.HasMore}
+	for i, event := range page.Events {
+		msg.Events[i] = eventMessage(event)
+	}
+	return msg
+}
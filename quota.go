@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"go-game/analysis"
+	"go-game/budget"
+	"go-game/game"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// engineBudgetWindow is how often the engine budgets are refilled
+const engineBudgetWindow = time.Hour
+
+// engineBudgets accounts the engine time of hints, reviews and estimates
+// (limits from ENGINE_TIER, ENGINE_BUDGET_GLOBAL and ENGINE_BUDGET_USER, set in main)
+var engineBudgets = budget.NewLedger(budget.Limits{}, engineBudgetWindow)
+
+// Estimators analysis falls back on as the budgets run out, most expensive first
+const (
+	EstimatorEngine   = "engine"   // An external GTP engine
+	EstimatorPlayouts = "playouts" // Random playouts on the analysis engine
+	EstimatorCount    = "count"    // Counting the position as it stands, free
+)
+
+// Engine time thresholds
+const (
+	minPlayoutGrant   = 100 * time.Millisecond // Less would finish too few playouts to say anything
+	engineReservation = 5 * time.Second        // Set aside to start an external engine; the actual time is charged after
+	topUsageUsers     = 50                     // Users listed by the admin usage report
+)
+
+// budgetUser is who engine time is charged to: the signed in user, or else the client's address
+func budgetUser(c echo.Context) string {
+	if user, ok := currentUser(c); ok {
+		return "user:" + user.ID
+	}
+	return c.RealIP()
+}
+
+// budgetedEstimate runs playouts within the engine budget of user for up to budget of wall time
+// With less engine time left, the playouts stop sooner; with none, the position is only counted
+// degraded is true if the estimate is cheaper than asked for
+func budgetedEstimate(ctx context.Context, user string, board *game.Board, playouts int, wall time.Duration) (estimate *analysis.Estimate, estimator string, degraded bool, err error) {
+	// The workers play in parallel, so the pool spends up to workers times the wall time
+	workers := time.Duration(analysisEngine.Workers())
+	want := wall * workers
+	grant := engineBudgets.Reserve(user, want, time.Now())
+	if grant.Time < minPlayoutGrant {
+		engineBudgets.Settle(grant, 0, time.Now())
+		return analysis.Count(board), EstimatorCount, true, nil
+	}
+
+	estimate, err = analysisEngine.Estimate(ctx, board, playouts, grant.Time/workers)
+	used := grant.Time
+	if estimate != nil {
+		used = estimate.WorkTime
+	}
+	engineBudgets.Settle(grant, used, time.Now())
+	return estimate, EstimatorPlayouts, grant.Time < want, err
+}
+
+// estimateNotation writes black's lead the way results are written, e.g. "B+3.5"
+func estimateNotation(lead float64) string {
+	result := game.Result{Winner: 1, Reason: game.ReasonScore, Margin: math.Round(math.Abs(lead)*2) / 2}
+	switch {
+	case result.Margin == 0:
+		result.Winner = 0
+	case lead < 0:
+		result.Winner = 2
+	}
+	return result.String()
+}
+
+// EngineQuota is the caller's share of the engine budgets
+type EngineQuota struct {
+	budget.Usage
+	Tier string `json:"tier,omitempty"` // Deployment tier the limits come from
+}
+
+// engineTier names the tier the budgets were configured with (set in main)
+var engineTier string
+
+// Show how much engine time the caller has used and has left in the current window
+func getEngineQuota(c echo.Context) error {
+	return c.JSON(http.StatusOK, EngineQuota{Usage: engineBudgets.Usage(budgetUser(c), time.Now()), Tier: engineTier})
+}
+
+// Engine usage report structure
+type EngineUsageReport struct {
+	Limits budget.Limits      `json:"limits"`
+	Usage  budget.Usage       `json:"usage"` // Global figures (the user fields are empty)
+	Users  []budget.UserUsage `json:"users"` // Heaviest users first
+}
+
+// Report the engine time used in the current window, overall and by the heaviest users
+func getEngineUsage(c echo.Context) error {
+	now := time.Now()
+	limit := topUsageUsers
+	if param := c.QueryParam("limit"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+		}
+		limit = parsed
+	}
+
+	return c.JSON(http.StatusOK, EngineUsageReport{
+		Limits: engineBudgets.Limits(),
+		Usage:  engineBudgets.Usage("", now),
+		Users:  engineBudgets.Top(limit, now),
+	})
+}
@@ -0,0 +1,144 @@
+// Package ratelimit keeps clients from flooding the server, with a token bucket per client
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate is how many requests a client may make per period, in bursts of up to Count
+type Rate struct {
+	Count  int
+	Period time.Duration
+}
+
+// Off is a rate without a limit
+var Off = Rate{}
+
+// Limited reports whether the rate limits anything
+func (r Rate) Limited() bool {
+	return r.Count > 0 && r.Period > 0
+}
+
+// String writes the rate the way ParseRate reads it, e.g. "60/1m0s"
+func (r Rate) String() string {
+	if !r.Limited() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", r.Count, r.Period)
+}
+
+// Units ParseRate accepts instead of a duration
+var units = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// ParseRate reads a rate like "60/m", "10/s" or "100/15m"; "" and "off" (or a count of 0) mean no limit
+func ParseRate(value string) (Rate, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "off" {
+		return Off, nil
+	}
+
+	count, per, found := strings.Cut(value, "/")
+	if !found {
+		return Off, fmt.Errorf("invalid rate %q, expected e.g. \"60/m\"", value)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 0 {
+		return Off, fmt.Errorf("invalid rate %q: bad count", value)
+	}
+	per = strings.TrimSpace(per)
+	period, ok := units[per]
+	if !ok {
+		if period, err = time.ParseDuration(per); err != nil || period <= 0 {
+			return Off, fmt.Errorf("invalid rate %q: bad period", value)
+		}
+	}
+	return Rate{Count: n, Period: period}, nil
+}
+
+// bucket holds the tokens of one client
+type bucket struct {
+	tokens float64
+	at     time.Time // When tokens was last brought up to date
+}
+
+// Limiter hands out tokens per key (a client address, a user, ...) at a fixed rate
+// Each key starts with a full bucket of Count tokens, refilled evenly over the period
+type Limiter struct {
+	mu        sync.Mutex
+	rate      Rate
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter creates a limiter; with a rate that is off it allows everything
+func NewLimiter(rate Rate) *Limiter {
+	return &Limiter{rate: rate, buckets: make(map[string]*bucket)}
+}
+
+// Rate returns the rate the limiter enforces
+func (l *Limiter) Rate() Rate {
+	return l.rate
+}
+
+// refill is how many tokens a bucket gains over elapsed
+func (l *Limiter) refill(elapsed time.Duration) float64 {
+	return float64(l.rate.Count) * elapsed.Seconds() / l.rate.Period.Seconds()
+}
+
+// Allow takes a token for key if there is one
+// If not, retryAfter is how long until the next token
+func (l *Limiter) Allow(key string, now time.Time) (allowed bool, retryAfter time.Duration) {
+	return l.AllowN(key, 1, now)
+}
+
+// AllowN takes n tokens for key if there are that many, or none at all
+// If not, retryAfter is how long until there are; more than Count tokens are never allowed
+func (l *Limiter) AllowN(key string, n int, now time.Time) (allowed bool, retryAfter time.Duration) {
+	if !l.rate.Limited() || n <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	b, known := l.buckets[key]
+	if !known {
+		b = &bucket{tokens: float64(l.rate.Count), at: now}
+		l.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.at); elapsed > 0 {
+		b.tokens = math.Min(b.tokens+l.refill(elapsed), float64(l.rate.Count))
+		b.at = now
+	}
+
+	if b.tokens < float64(n) {
+		missing := float64(n) - b.tokens
+		return false, time.Duration(missing * float64(l.rate.Period) / float64(l.rate.Count))
+	}
+	b.tokens -= float64(n)
+	return true, 0
+}
+
+// sweep drops the buckets that have filled up again, once per period, so keys seen
+// once don't stay forever; must be called with mu held
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.rate.Period {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+l.refill(now.Sub(b.at)) >= float64(l.rate.Count) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"go-game/ratelimit"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Rate limits keep a single client from flooding the server with games or moves
+// Every request is counted against the client's address, and against its user if signed in,
+// so neither a shared address nor many addresses let one client past the limits
+
+// Default rates, generous enough for blitz games and a few clients behind one address
+const (
+	defaultGameRateIP   = "30/m"
+	defaultGameRateUser = "10/m"
+	defaultMoveRateIP   = "300/m"
+	defaultMoveRateUser = "120/m"
+)
+
+// RateLimit limits one kind of request per client address and per user
+type RateLimit struct {
+	PerIP   *ratelimit.Limiter
+	PerUser *ratelimit.Limiter
+}
+
+// Rate limits of game creation and moves (set in main)
+var (
+	gameRateLimit = RateLimit{PerIP: ratelimit.NewLimiter(ratelimit.Off), PerUser: ratelimit.NewLimiter(ratelimit.Off)}
+	moveRateLimit = RateLimit{PerIP: ratelimit.NewLimiter(ratelimit.Off), PerUser: ratelimit.NewLimiter(ratelimit.Off)}
+)
+
+// rateLimitFromEnv reads a rate limit from the environment variables prefix+"_IP" and
+// prefix+"_USER", e.g. MOVE_RATE_LIMIT_IP="300/m"; "off" turns a limit off
+func rateLimitFromEnv(prefix, defaultIP, defaultUser string) (RateLimit, error) {
+	var limit RateLimit
+	for _, setting := range []struct {
+		variable string
+		fallback string
+		limiter  **ratelimit.Limiter
+	}{{prefix + "_IP", defaultIP, &limit.PerIP}, {prefix + "_USER", defaultUser, &limit.PerUser}} {
+		value, set := os.LookupEnv(setting.variable)
+		if !set {
+			value = setting.fallback
+		}
+		rate, err := ratelimit.ParseRate(value)
+		if err != nil {
+			return limit, fmt.Errorf("%s: %w", setting.variable, err)
+		}
+		*setting.limiter = ratelimit.NewLimiter(rate)
+	}
+	return limit, nil
+}
+
+// allow takes a token for a request from an address and a user (empty if anonymous)
+// If the request is over a limit, retryAfter is how long until it would be allowed
+func (l RateLimit) allow(ip, userID string, now time.Time) (allowed bool, retryAfter time.Duration) {
+	return l.allowN(ip, userID, 1, now)
+}
+
+// allowN takes n tokens at once, for a request that counts as n requests (e.g. a batch of moves)
+func (l RateLimit) allowN(ip, userID string, n int, now time.Time) (allowed bool, retryAfter time.Duration) {
+	if allowed, retryAfter = l.PerIP.AllowN(ip, n, now); !allowed || userID == "" {
+		return allowed, retryAfter
+	}
+	return l.PerUser.AllowN(userID, n, now)
+}
+
+// limitRate rejects requests over a rate limit with 429 Too Many Requests
+// Admins are not limited
+func limitRate(limit *RateLimit) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if allowed, err := chargeRate(c, limit, 1); !allowed {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// chargeRate counts a request as n requests against a rate limit, and answers it with
+// 429 Too Many Requests if that is over the limit; admins are not limited
+// When it is not allowed, the request has been answered and err is the result of that
+func chargeRate(c echo.Context, limit *RateLimit, n int) (allowed bool, err error) {
+	if isAdmin(c) {
+		return true, nil
+	}
+
+	var userID string
+	if user, ok := currentUser(c); ok {
+		userID = user.ID
+	}
+	allowed, retryAfter := limit.allowN(c.RealIP(), userID, n, time.Now())
+	if !allowed {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		c.Response().Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+		return false, c.JSON(http.StatusTooManyRequests, map[string]string{"error": "Too many requests, slow down"})
+	}
+	return true, nil
+}
@@ -0,0 +1,28 @@
+package rating
+
+// K-factors: how many points a single game can move an Elo rating
+// New tracks move faster, so players quickly reach their level
+const (
+	provisionalK = 40 // Until the track has establishedGames games
+	establishedK = 20
+)
+
+// kFactor returns the K-factor of a rating
+func kFactor(r Rating) float64 {
+	if r.Games < establishedGames {
+		return provisionalK
+	}
+	return establishedK
+}
+
+// Elo returns the ratings of black and white after a game in which black scored score
+// (1 = win, 0.5 = jigo, 0 = loss), with black's handicap advantage in rating points
+// Each rating moves by its own K-factor, so a new player's games barely move an established one
+func Elo(black, white Rating, advantage, score float64) (Rating, Rating) {
+	expected := ExpectedScore(black.Value, white.Value, advantage)
+	black.Value += kFactor(black) * (score - expected)
+	white.Value += kFactor(white) * ((1 - score) - (1 - expected))
+	black.Games++
+	white.Games++
+	return black, white
+}
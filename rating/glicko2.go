@@ -0,0 +1,126 @@
+package rating
+
+import "math"
+
+// Glicko-2 (Glickman, "Example of the Glicko-2 system") keeps, next to the rating, how
+// uncertain it is (the deviation) and how erratic the player is (the volatility). Games are
+// rated together at the end of each rating period, and the deviation of players who didn't
+// play grows, so someone coming back after months moves quickly to their new level
+// Ratings stay on the Elo scale; the algorithm works on its own scale internally
+
+// Glicko-2 parameters
+const (
+	InitialDeviation  = 350  // Deviation of a player without games, also the largest there is
+	InitialVolatility = 0.06 // Volatility of a player without games
+
+	glickoScale     = 173.7178 // 400 / ln(10): rating points per unit of the internal scale
+	glickoTau       = 0.5      // How much the volatility may change in one period
+	glickoTolerance = 0.000001 // Precision of the volatility iteration
+)
+
+// Result is one game of a rating period, from the point of view of the player being rated
+type Result struct {
+	Opponent Rating  // The opponent's rating before the period, with the handicap advantage applied
+	Score    float64 // 1 = win, 0.5 = jigo, 0 = loss
+}
+
+// withDefaults fills in the deviation and volatility of ratings that have none yet
+func (r Rating) withDefaults() Rating {
+	if r.Deviation == 0 {
+		r.Deviation = InitialDeviation
+	}
+	if r.Volatility == 0 {
+		r.Volatility = InitialVolatility
+	}
+	return r
+}
+
+// Glicko2 rates a player's games of one rating period and returns the new rating with
+// each game's share of the change; the shares add up to the whole change, so a game can
+// later be taken back on its own
+func Glicko2(player Rating, results []Result) (Rating, []float64) {
+	player = player.withDefaults()
+	if len(results) == 0 {
+		return Idle(player), nil
+	}
+
+	mu := (player.Value - InitialRating) / glickoScale
+	phi := player.Deviation / glickoScale
+
+	// Estimated variance of the rating from the games alone, and the improvement they suggest
+	g := make([]float64, len(results))
+	expected := make([]float64, len(results))
+	variance, improvement := 0.0, 0.0
+	for i, result := range results {
+		opponent := result.Opponent.withDefaults()
+		g[i] = glickoG(opponent.Deviation / glickoScale)
+		expected[i] = 1 / (1 + math.Exp(-g[i]*(mu-(opponent.Value-InitialRating)/glickoScale)))
+		variance += g[i] * g[i] * expected[i] * (1 - expected[i])
+		improvement += g[i] * (result.Score - expected[i])
+	}
+	variance = 1 / variance
+	delta := variance * improvement
+
+	volatility := glickoVolatility(phi, player.Volatility, variance, delta)
+	prePhi := math.Sqrt(phi*phi + volatility*volatility)
+	newPhi := 1 / math.Sqrt(1/(prePhi*prePhi)+1/variance)
+
+	shares := make([]float64, len(results))
+	for i, result := range results {
+		shares[i] = glickoScale * newPhi * newPhi * g[i] * (result.Score - expected[i])
+		player.Value += shares[i]
+	}
+	player.Deviation = math.Min(glickoScale*newPhi, InitialDeviation)
+	player.Volatility = volatility
+	player.Games += len(results)
+	return player, shares
+}
+
+// Idle returns the rating of a player who played no game in a rating period: only the
+// deviation grows, as the rating becomes less certain
+func Idle(player Rating) Rating {
+	player = player.withDefaults()
+	phi := player.Deviation / glickoScale
+	player.Deviation = math.Min(glickoScale*math.Sqrt(phi*phi+player.Volatility*player.Volatility), InitialDeviation)
+	return player
+}
+
+// glickoG weighs a game by how certain the opponent's rating is
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// glickoVolatility finds the new volatility with the Illinois algorithm (step 5 of the paper)
+func glickoVolatility(phi, sigma, variance, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + variance + ex
+		return ex*(delta*delta-phi*phi-variance-ex)/(2*d*d) - (x-a)/(glickoTau*glickoTau)
+	}
+
+	low := a
+	var high float64
+	if delta*delta > phi*phi+variance {
+		high = math.Log(delta*delta - phi*phi - variance)
+	} else {
+		k := 1.0
+		for f(a-k*glickoTau) < 0 {
+			k++
+		}
+		high = a - k*glickoTau
+	}
+
+	fLow, fHigh := f(low), f(high)
+	for math.Abs(high-low) > glickoTolerance {
+		c := low + (low-high)*fLow/(fHigh-fLow)
+		fC := f(c)
+		if fC*fHigh <= 0 {
+			low, fLow = high, fHigh
+		} else {
+			fLow /= 2
+		}
+		high, fHigh = c, fC
+	}
+	return math.Exp(low / 2)
+}
@@ -0,0 +1,106 @@
+package rating
+
+import (
+	"math"
+	"testing"
+)
+
+// near checks a value against the expected one within a tolerance
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func TestGlicko2(t *testing.T) {
+	tests := []struct {
+		name       string
+		player     Rating
+		results    []Result
+		value      float64
+		deviation  float64
+		volatility float64
+	}{
+		{
+			// The worked example of Glickman's "Example of the Glicko-2 system"
+			name:   "paper example",
+			player: Rating{Value: 1500, Deviation: 200, Volatility: 0.06},
+			results: []Result{
+				{Opponent: Rating{Value: 1400, Deviation: 30}, Score: 1},
+				{Opponent: Rating{Value: 1550, Deviation: 100}, Score: 0},
+				{Opponent: Rating{Value: 1700, Deviation: 300}, Score: 0},
+			},
+			value:      1464.06,
+			deviation:  151.52,
+			volatility: 0.05999,
+		},
+		{
+			name:       "new player beats a new player",
+			player:     Rating{Value: InitialRating},
+			results:    []Result{{Opponent: Rating{Value: InitialRating}, Score: 1}},
+			value:      1662.31,
+			deviation:  290.32,
+			volatility: 0.06,
+		},
+		{
+			name:       "jigo between equals changes nothing but the deviation",
+			player:     Rating{Value: 1800, Deviation: 100, Volatility: 0.06},
+			results:    []Result{{Opponent: Rating{Value: 1800, Deviation: 100}, Score: 0.5}},
+			value:      1800,
+			deviation:  96.92,
+			volatility: 0.06,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, shares := Glicko2(tt.player, tt.results)
+			if !near(got.Value, tt.value, 0.01) {
+				t.Errorf("value = %.2f, want %.2f", got.Value, tt.value)
+			}
+			if !near(got.Deviation, tt.deviation, 0.01) {
+				t.Errorf("deviation = %.2f, want %.2f", got.Deviation, tt.deviation)
+			}
+			if !near(got.Volatility, tt.volatility, 0.00001) {
+				t.Errorf("volatility = %.5f, want %.5f", got.Volatility, tt.volatility)
+			}
+			if got.Games != tt.player.Games+len(tt.results) {
+				t.Errorf("games = %d, want %d", got.Games, tt.player.Games+len(tt.results))
+			}
+
+			// Each game's share can be taken back on its own, so together they are the whole change
+			total := 0.0
+			for _, share := range shares {
+				total += share
+			}
+			if !near(tt.player.Value+total, got.Value, 0.000001) {
+				t.Errorf("shares add up to %.4f, the rating changed by %.4f", total, got.Value-tt.player.Value)
+			}
+		})
+	}
+}
+
+func TestGlicko2Idle(t *testing.T) {
+	tests := []struct {
+		name      string
+		player    Rating
+		deviation float64
+	}{
+		{name: "deviation grows", player: Rating{Value: 1500, Deviation: 50, Volatility: 0.06}, deviation: 51.07},
+		{name: "capped at the initial deviation", player: Rating{Value: 1500, Deviation: 349.9, Volatility: 0.06}, deviation: InitialDeviation},
+		{name: "new player", player: Rating{Value: 1500}, deviation: InitialDeviation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, shares := Glicko2(tt.player, nil)
+			if shares != nil {
+				t.Errorf("shares = %v, want none", shares)
+			}
+			if got.Value != tt.player.Value || got.Games != tt.player.Games {
+				t.Errorf("rating = %+v, only the deviation may change", got)
+			}
+			if !near(got.Deviation, tt.deviation, 0.01) {
+				t.Errorf("deviation = %.2f, want %.2f", got.Deviation, tt.deviation)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Package rating computes player ratings from game results
+package rating
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// HandicapModel turns the handicap and komi of a game into an advantage for black,
+// in rating points, so handicap games can be rated like even ones
+// A handicap game is expected to be even when the advantage makes up the rating gap
+type HandicapModel struct {
+	PointsPerStone float64 // Rating points one stone of handicap is worth (about one rank)
+	KomiPerStone   float64 // Points of komi one stone is worth
+	EvenKomi       float64 // Komi of an even game
+}
+
+// DefaultHandicapModel values one stone as one rank of 100 points, and as twice the usual komi
+var DefaultHandicapModel = HandicapModel{
+	PointsPerStone: 100,
+	KomiPerStone:   13,
+	EvenKomi:       6.5,
+}
+
+// Stones is black's advantage in stones
+// With n handicap stones black gets n-1 extra moves (white plays first afterwards),
+// and every point of komi below the even komi is worth 1/KomiPerStone of a stone
+// Taking black first with 0.5 komi thus counts as half a stone, and 2 stones with 0.5 komi as 1.5
+func (m HandicapModel) Stones(handicap int, komi float64) float64 {
+	extraMoves := max(handicap-1, 0)
+	return float64(extraMoves) + (m.EvenKomi-komi)/m.KomiPerStone
+}
+
+// Advantage is black's advantage in rating points
+func (m HandicapModel) Advantage(handicap int, komi float64) float64 {
+	return m.Stones(handicap, komi) * m.PointsPerStone
+}
+
+// Handicap suggests the handicap and komi that make a game even for a rating gap
+// (black's rating minus white's; the weaker player takes black)
+// Half stones are given as komi, and gaps beyond maxStones are capped
+func (m HandicapModel) Handicap(gap float64, maxStones int) (handicap int, komi float64) {
+	stones := math.Min(math.Max(-gap/m.PointsPerStone, 0), float64(maxStones))
+
+	// Round to half stones: whole stones are extra moves, a half stone is black taking 0.5 komi
+	halves := int(math.Round(stones * 2))
+	extraMoves := halves / 2
+
+	komi = m.EvenKomi
+	if halves%2 == 1 {
+		komi = 0.5
+	}
+	if extraMoves > 0 {
+		handicap = extraMoves + 1
+	}
+	return handicap, komi
+}
+
+// ExpectedScore is black's expected score (1 = win, 0 = loss) in a game between
+// players of the given ratings, with black's handicap advantage added to black's rating
+// Ratings are on the usual logistic scale where 400 points make a 10 to 1 favorite
+func ExpectedScore(black, white, advantage float64) float64 {
+	return 1 / (1 + math.Pow(10, (white-black-advantage)/400))
+}
+
+// ParseHandicapModel reads a model from a configuration string such as
+// "pointsPerStone=100,komiPerStone=13,evenKomi=6.5"; missing values keep their defaults
+func ParseHandicapModel(config string) (HandicapModel, error) {
+	model := DefaultHandicapModel
+
+	for _, entry := range strings.Split(config, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(entry, "=")
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !found || err != nil {
+			return model, fmt.Errorf("invalid handicap model setting %q", entry)
+		}
+
+		switch strings.TrimSpace(name) {
+		case "pointsPerStone":
+			model.PointsPerStone = number
+		case "komiPerStone":
+			model.KomiPerStone = number
+		case "evenKomi":
+			model.EvenKomi = number
+		default:
+			return model, fmt.Errorf("unknown handicap model setting %q", name)
+		}
+	}
+
+	if model.PointsPerStone <= 0 || model.KomiPerStone <= 0 {
+		return model, fmt.Errorf("handicap model values per stone must be positive")
+	}
+	return model, nil
+}
@@ -0,0 +1,191 @@
+package rating
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps ratings in memory, for servers without a database
+type MemoryStore struct {
+	mu       sync.Mutex
+	profiles map[string]Profile
+	history  []Change // Oldest first
+	queue    []Game   // Waiting for the end of their rating period
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{profiles: make(map[string]Profile)}
+}
+
+func (s *MemoryStore) Profile(ctx context.Context, userID string) (Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile := make(Profile, len(s.profiles[userID]))
+	for track, rating := range s.profiles[userID] {
+		profile[track] = rating
+	}
+	return profile, nil
+}
+
+// known checks whether a game was rated or queued; must be called with s.mu held
+func (s *MemoryStore) known(gameID string) bool {
+	for _, change := range s.history {
+		if change.GameID == gameID {
+			return true
+		}
+	}
+	for _, queued := range s.queue {
+		if queued.GameID == gameID {
+			return true
+		}
+	}
+	return false
+}
+
+// set replaces a rating; must be called with s.mu held
+func (s *MemoryStore) set(userID string, track Track, rating Rating) {
+	if s.profiles[userID] == nil {
+		s.profiles[userID] = make(Profile)
+	}
+	s.profiles[userID][track] = rating
+}
+
+func (s *MemoryStore) Record(ctx context.Context, game Game, update Update) ([2]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.known(game.GameID) {
+		return [2]Change{}, ErrRecorded
+	}
+
+	before := [2]Rating{s.profiles[game.Black].Get(game.Track), s.profiles[game.White].Get(game.Track)}
+	var after [2]Rating
+	after[0], after[1] = update(before[0], before[1], game)
+	s.set(game.Black, game.Track, after[0])
+	s.set(game.White, game.Track, after[1])
+
+	recorded := changes(game, before, after)
+	s.history = append(s.history, recorded[:]...)
+	return recorded, nil
+}
+
+func (s *MemoryStore) Queue(ctx context.Context, game Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.known(game.GameID) {
+		return ErrRecorded
+	}
+	s.queue = append(s.queue, game)
+	return nil
+}
+
+func (s *MemoryStore) ClosePeriod(ctx context.Context, end time.Time) ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var games, later []Game
+	for _, queued := range s.queue {
+		if queued.Time.Before(end) {
+			games = append(games, queued)
+		} else {
+			later = append(later, queued)
+		}
+	}
+	s.queue = later
+
+	before := make(map[playerTrack]Rating)
+	for _, game := range games {
+		for _, userID := range []string{game.Black, game.White} {
+			if rating, rated := s.profiles[userID][game.Track]; rated {
+				before[playerTrack{userID, game.Track}] = rating
+			}
+		}
+	}
+	after, recorded := ratePeriod(games, before)
+
+	// Everyone else only grows more uncertain
+	for userID, profile := range s.profiles {
+		for track, rating := range profile {
+			if _, played := after[playerTrack{userID, track}]; !played && rating.Deviation > 0 {
+				profile[track] = Idle(rating)
+			}
+		}
+	}
+	for player, rating := range after {
+		s.set(player.userID, player.track, rating)
+	}
+
+	s.history = append(s.history, recorded...)
+	return recorded, nil
+}
+
+func (s *MemoryStore) Revert(ctx context.Context, gameID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, queued := range s.queue {
+		if queued.GameID == gameID {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return nil
+		}
+	}
+
+	for i, change := range s.history {
+		if change.GameID != gameID || change.Annulled {
+			continue
+		}
+		rating := s.profiles[change.UserID][change.Track]
+		rating.Value -= change.After - change.Before
+		rating.Games--
+		s.profiles[change.UserID][change.Track] = rating
+		s.history[i].Annulled = true
+	}
+	return nil
+}
+
+func (s *MemoryStore) Standings(ctx context.Context) ([]Standing, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	games := make(map[string]map[Track]map[Speed]int)
+	for _, change := range s.history {
+		if change.Annulled {
+			continue
+		}
+		if games[change.UserID] == nil {
+			games[change.UserID] = make(map[Track]map[Speed]int)
+		}
+		if games[change.UserID][change.Track] == nil {
+			games[change.UserID][change.Track] = make(map[Speed]int)
+		}
+		games[change.UserID][change.Track][change.Speed]++
+	}
+
+	standings := make([]Standing, 0, len(s.profiles))
+	for userID, profile := range s.profiles {
+		ratings := make(Profile, len(profile))
+		for track, rating := range profile {
+			ratings[track] = rating
+		}
+		standings = append(standings, Standing{UserID: userID, Ratings: ratings, Games: games[userID]})
+	}
+	return standings, nil
+}
+
+func (s *MemoryStore) History(ctx context.Context, userID string, track Track, limit int) ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []Change
+	for i := len(s.history) - 1; i >= 0 && len(list) < limit; i-- {
+		change := s.history[i]
+		if change.UserID == userID && (track == "" || change.Track == track) {
+			list = append(list, change)
+		}
+	}
+	return list, nil
+}
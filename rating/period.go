@@ -0,0 +1,70 @@
+package rating
+
+import "sort"
+
+// Rating periods: with Glicko-2, finished games are queued and rated together when the
+// period they were played in closes, every player from the ratings they had before it
+
+// playerTrack identifies one rating of a player
+type playerTrack struct {
+	userID string
+	track  Track
+}
+
+// ratePeriod rates the games of a period with Glicko-2, given the ratings before the period
+// of the players in them (the initial rating for the others), and returns the new ratings
+// of the players and what each game changed
+// The changes of a player's games follow each other, each one adding its share of the change
+func ratePeriod(games []Game, before map[playerTrack]Rating) (map[playerTrack]Rating, []Change) {
+	sort.SliceStable(games, func(i, j int) bool { return games[i].Time.Before(games[j].Time) })
+	for _, game := range games {
+		for _, player := range []playerTrack{{game.Black, game.Track}, {game.White, game.Track}} {
+			if _, rated := before[player]; !rated {
+				before[player] = Rating{Value: InitialRating}
+			}
+		}
+	}
+
+	// The results of each player, with the handicap advantage applied to the opponent
+	results := make(map[playerTrack][]Result)
+	for _, game := range games {
+		black, white := playerTrack{game.Black, game.Track}, playerTrack{game.White, game.Track}
+		blackOpponent, whiteOpponent := before[white], before[black]
+		blackOpponent.Value -= game.Advantage
+		whiteOpponent.Value += game.Advantage
+		results[black] = append(results[black], Result{Opponent: blackOpponent, Score: game.Score})
+		results[white] = append(results[white], Result{Opponent: whiteOpponent, Score: 1 - game.Score})
+	}
+
+	after := make(map[playerTrack]Rating, len(results))
+	shares := make(map[playerTrack][]float64, len(results))
+	for player, played := range results {
+		after[player], shares[player] = Glicko2(before[player], played)
+	}
+
+	// Walk the games again in the same order, so each result finds its share
+	running := make(map[playerTrack]float64, len(results))
+	next := make(map[playerTrack]int, len(results))
+	changes := make([]Change, 0, 2*len(games))
+	for _, game := range games {
+		for _, side := range []struct {
+			player   playerTrack
+			opponent string
+			score    float64
+		}{
+			{playerTrack{game.Black, game.Track}, game.White, game.Score},
+			{playerTrack{game.White, game.Track}, game.Black, 1 - game.Score},
+		} {
+			if _, started := running[side.player]; !started {
+				running[side.player] = before[side.player].Value
+			}
+			change := Change{UserID: side.player.userID, GameID: game.GameID, Track: game.Track, Speed: game.Speed,
+				Opponent: side.opponent, Score: side.score, Before: running[side.player], Time: game.Time}
+			running[side.player] += shares[side.player][next[side.player]]
+			next[side.player]++
+			change.After = running[side.player]
+			changes = append(changes, change)
+		}
+	}
+	return after, changes
+}
@@ -0,0 +1,373 @@
+package rating
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	_ "github.com/lib/pq" // Postgres driver for database/sql
+)
+
+// schema creates the tables the store needs
+// A game's changes are unique per player, so a game is never rated twice even by two servers
+const schema = `
+CREATE TABLE IF NOT EXISTS ratings (
+	user_id TEXT NOT NULL,
+	track   TEXT NOT NULL,
+	value   DOUBLE PRECISION NOT NULL,
+	games   INTEGER NOT NULL,
+	PRIMARY KEY (user_id, track)
+);
+CREATE TABLE IF NOT EXISTS rating_history (
+	user_id     TEXT NOT NULL,
+	game_id     TEXT NOT NULL,
+	track       TEXT NOT NULL,
+	opponent_id TEXT NOT NULL,
+	score       DOUBLE PRECISION NOT NULL,
+	before      DOUBLE PRECISION NOT NULL,
+	after       DOUBLE PRECISION NOT NULL,
+	annulled    BOOLEAN NOT NULL DEFAULT FALSE,
+	recorded_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (user_id, game_id)
+);
+CREATE INDEX IF NOT EXISTS rating_history_user ON rating_history (user_id, recorded_at DESC);
+CREATE INDEX IF NOT EXISTS rating_history_game ON rating_history (game_id);
+ALTER TABLE ratings ADD COLUMN IF NOT EXISTS deviation DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE ratings ADD COLUMN IF NOT EXISTS volatility DOUBLE PRECISION NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS rating_queue (
+	game_id   TEXT PRIMARY KEY,
+	track     TEXT NOT NULL,
+	black_id  TEXT NOT NULL,
+	white_id  TEXT NOT NULL,
+	advantage DOUBLE PRECISION NOT NULL,
+	score     DOUBLE PRECISION NOT NULL,
+	played_at TIMESTAMPTZ NOT NULL
+);
+ALTER TABLE rating_history ADD COLUMN IF NOT EXISTS speed TEXT NOT NULL DEFAULT '';
+ALTER TABLE rating_queue ADD COLUMN IF NOT EXISTS speed TEXT NOT NULL DEFAULT '';
+`
+
+// periodLock is the advisory lock servers take to close a rating period, so only one does
+const periodLock = 7_205_331_114
+
+// columns are the history columns, in the order History reads them
+const columns = `user_id, game_id, track, speed, opponent_id, score, before, after, annulled, recorded_at`
+
+// PostgresStore keeps ratings in Postgres
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to the database and creates the schema
+func NewPostgresStore(url string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close releases the database connections
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks the database can be reached
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *PostgresStore) Profile(ctx context.Context, userID string) (Profile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT track, value, deviation, volatility, games FROM ratings WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profile := make(Profile)
+	for rows.Next() {
+		var track Track
+		var rating Rating
+		if err := rows.Scan(&track, &rating.Value, &rating.Deviation, &rating.Volatility, &rating.Games); err != nil {
+			return nil, err
+		}
+		profile[track] = rating
+	}
+	return profile, rows.Err()
+}
+
+func (s *PostgresStore) Record(ctx context.Context, game Game, update Update) ([2]Change, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return [2]Change{}, err
+	}
+	defer tx.Rollback()
+
+	if known, err := isKnown(ctx, tx, game.GameID); err != nil || known {
+		if err == nil {
+			err = ErrRecorded
+		}
+		return [2]Change{}, err
+	}
+
+	// The rows are locked until the commit, so games of the same player are rated one at a time
+	var before [2]Rating
+	for i, userID := range []string{game.Black, game.White} {
+		rating, _, err := lockRating(ctx, tx, userID, game.Track)
+		if err != nil {
+			return [2]Change{}, err
+		}
+		before[i] = rating
+	}
+
+	var after [2]Rating
+	after[0], after[1] = update(before[0], before[1], game)
+	recorded := changes(game, before, after)
+	for i, change := range recorded {
+		if err := saveRating(ctx, tx, change.UserID, change.Track, after[i]); err != nil {
+			return [2]Change{}, err
+		}
+	}
+	if err := saveChanges(ctx, tx, recorded[:]); err != nil {
+		return [2]Change{}, err
+	}
+	return recorded, tx.Commit()
+}
+
+func (s *PostgresStore) Queue(ctx context.Context, game Game) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if known, err := isKnown(ctx, tx, game.GameID); err != nil || known {
+		if err == nil {
+			err = ErrRecorded
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO rating_queue (game_id, track, speed, black_id, white_id, advantage, score, played_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		game.GameID, game.Track, game.Speed, game.Black, game.White, game.Advantage, game.Score, game.Time); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) ClosePeriod(ctx context.Context, end time.Time) ([]Change, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, periodLock).Scan(&locked); err != nil || !locked {
+		return nil, err // Another server is closing it
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM rating_queue WHERE played_at < $1
+		RETURNING game_id, track, speed, black_id, white_id, advantage, score, played_at`, end)
+	if err != nil {
+		return nil, err
+	}
+	var games []Game
+	for rows.Next() {
+		var game Game
+		if err := rows.Scan(&game.GameID, &game.Track, &game.Speed, &game.Black, &game.White, &game.Advantage, &game.Score, &game.Time); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		games = append(games, game)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	before := make(map[playerTrack]Rating)
+	for _, game := range games {
+		for _, userID := range []string{game.Black, game.White} {
+			rating, found, err := lockRating(ctx, tx, userID, game.Track)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				before[playerTrack{userID, game.Track}] = rating
+			}
+		}
+	}
+	after, recorded := ratePeriod(games, before)
+
+	// Everyone else only grows more uncertain (see Idle); the players are overwritten right after
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE ratings SET deviation = LEAST($1, $2 * sqrt(power(deviation / $2, 2) + power(volatility, 2)))
+		WHERE deviation > 0`, InitialDeviation, glickoScale); err != nil {
+		return nil, err
+	}
+	for player, rating := range after {
+		if err := saveRating(ctx, tx, player.userID, player.track, rating); err != nil {
+			return nil, err
+		}
+	}
+	if err := saveChanges(ctx, tx, recorded); err != nil {
+		return nil, err
+	}
+	return recorded, tx.Commit()
+}
+
+// isKnown checks whether a game was rated or queued
+func isKnown(ctx context.Context, tx *sql.Tx, gameID string) (bool, error) {
+	var known bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM rating_history WHERE game_id = $1)
+		    OR EXISTS (SELECT 1 FROM rating_queue WHERE game_id = $1)`, gameID).Scan(&known)
+	return known, err
+}
+
+// lockRating reads a rating, locking it until the end of the transaction
+// Players without one get the initial rating, and found is false
+func lockRating(ctx context.Context, tx *sql.Tx, userID string, track Track) (rating Rating, found bool, err error) {
+	rating = Rating{Value: InitialRating}
+	err = tx.QueryRowContext(ctx, `SELECT value, deviation, volatility, games FROM ratings WHERE user_id = $1 AND track = $2 FOR UPDATE`,
+		userID, track).Scan(&rating.Value, &rating.Deviation, &rating.Volatility, &rating.Games)
+	if err == sql.ErrNoRows {
+		return rating, false, nil
+	}
+	return rating, err == nil, err
+}
+
+// saveRating replaces a rating
+func saveRating(ctx context.Context, tx *sql.Tx, userID string, track Track, rating Rating) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO ratings (user_id, track, value, deviation, volatility, games) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, track) DO UPDATE SET
+			value = EXCLUDED.value, deviation = EXCLUDED.deviation, volatility = EXCLUDED.volatility, games = EXCLUDED.games`,
+		userID, track, rating.Value, rating.Deviation, rating.Volatility, rating.Games)
+	return err
+}
+
+// saveChanges adds changes to the history
+func saveChanges(ctx context.Context, tx *sql.Tx, changes []Change) error {
+	for _, change := range changes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO rating_history (`+columns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, FALSE, $9)`,
+			change.UserID, change.GameID, change.Track, change.Speed, change.Opponent, change.Score, change.Before, change.After, change.Time); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStore) Revert(ctx context.Context, gameID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Queued games just leave the queue; rated ones lose what they added, and stop counting
+	if _, err := tx.ExecContext(ctx, `DELETE FROM rating_queue WHERE game_id = $1`, gameID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE ratings r SET value = r.value - (h.after - h.before), games = r.games - 1
+		FROM rating_history h
+		WHERE h.game_id = $1 AND NOT h.annulled AND r.user_id = h.user_id AND r.track = h.track`, gameID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE rating_history SET annulled = TRUE WHERE game_id = $1`, gameID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) History(ctx context.Context, userID string, track Track, limit int) ([]Change, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+columns+` FROM rating_history
+		WHERE user_id = $1 AND ($2 = '' OR track = $2)
+		ORDER BY recorded_at DESC LIMIT $3`, userID, track, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Change
+	for rows.Next() {
+		var change Change
+		if err := rows.Scan(&change.UserID, &change.GameID, &change.Track, &change.Speed, &change.Opponent, &change.Score,
+			&change.Before, &change.After, &change.Annulled, &change.Time); err != nil {
+			return nil, err
+		}
+		list = append(list, change)
+	}
+	return list, rows.Err()
+}
+
+func (s *PostgresStore) Standings(ctx context.Context) ([]Standing, error) {
+	byUser := make(map[string]*Standing)
+	standing := func(userID string) *Standing {
+		if byUser[userID] == nil {
+			byUser[userID] = &Standing{UserID: userID, Ratings: make(Profile)}
+		}
+		return byUser[userID]
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT user_id, track, value, deviation, volatility, games FROM ratings`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID string
+		var track Track
+		var rating Rating
+		if err := rows.Scan(&userID, &track, &rating.Value, &rating.Deviation, &rating.Volatility, &rating.Games); err != nil {
+			return nil, err
+		}
+		standing(userID).Ratings[track] = rating
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	speeds, err := s.db.QueryContext(ctx, `
+		SELECT user_id, track, speed, COUNT(*) FROM rating_history WHERE NOT annulled GROUP BY user_id, track, speed`)
+	if err != nil {
+		return nil, err
+	}
+	defer speeds.Close()
+	for speeds.Next() {
+		var userID string
+		var track Track
+		var speed Speed
+		var games int
+		if err := speeds.Scan(&userID, &track, &speed, &games); err != nil {
+			return nil, err
+		}
+		player := standing(userID)
+		if player.Games == nil {
+			player.Games = make(map[Track]map[Speed]int)
+		}
+		if player.Games[track] == nil {
+			player.Games[track] = make(map[Speed]int)
+		}
+		player.Games[track][speed] = games
+	}
+	if err := speeds.Err(); err != nil {
+		return nil, err
+	}
+
+	standings := make([]Standing, 0, len(byUser))
+	for _, player := range byUser {
+		standings = append(standings, *player)
+	}
+	return standings, nil
+}
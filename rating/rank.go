@@ -0,0 +1,74 @@
+package rating
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Ranks are placed on the rating scale the way the EGF does: 100 points per rank,
+// with 1 kyu at 2000 and 1 dan at 2100; professional ranks are 30 points apart from 2700
+const (
+	kyuRating = 2000
+	danRating = 2100
+	proRating = 2700
+)
+
+// RankRating converts a rank as written in game records ("5k", "2d", "1p", "3 dan", ...)
+// to a rating; ok is false if the rank can't be read
+func RankRating(rank string) (rating float64, ok bool) {
+	rank = strings.ToLower(strings.TrimRight(strings.TrimSpace(rank), "?*")) // KGS marks uncertain ranks with "?"
+	end := strings.IndexFunc(rank, func(r rune) bool { return r < '0' || r > '9' })
+	if end <= 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(rank[:end])
+	if err != nil || n < 1 {
+		return 0, false
+	}
+
+	switch strings.TrimSpace(rank[end:]) {
+	case "k", "kyu":
+		if n > 30 {
+			return 0, false
+		}
+		return float64(kyuRating - 100*(n-1)), true
+	case "d", "dan":
+		if n > 9 {
+			return 0, false
+		}
+		return float64(danRating + 100*(n-1)), true
+	case "p", "pro":
+		if n > 9 {
+			return 0, false
+		}
+		return float64(proRating + 30*(n-1)), true
+	default:
+		return 0, false
+	}
+}
+
+// provisionalDeviation is the Glicko-2 deviation above which a rank is shown as uncertain
+const provisionalDeviation = 110
+
+// Rank converts a rating to the amateur rank it stands for on the same scale ("6k", "2d"),
+// from 30 kyu to 9 dan; ranks that aren't settled yet are marked with "?", as on KGS
+// A rating is settled once its deviation is low enough, or, for ratings without one
+// (Elo), once it has had enough games
+func (r Rating) Rank() string {
+	var rank string
+	if r.Value >= danRating {
+		rank = strconv.Itoa(min(int((r.Value-danRating)/100)+1, 9)) + "d"
+	} else {
+		rank = strconv.Itoa(min(int(math.Ceil((danRating-r.Value)/100)), 30)) + "k"
+	}
+
+	settled := r.Games >= establishedGames
+	if r.Deviation > 0 {
+		settled = r.Deviation <= provisionalDeviation
+	}
+	if !settled {
+		rank += "?"
+	}
+	return rank
+}
@@ -0,0 +1,85 @@
+package rating
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrRecorded is returned when a game that already counted, or is waiting to, is recorded again
+var ErrRecorded = errors.New("game already rated")
+
+// Game is a finished game between two accounts, as the ratings see it
+type Game struct {
+	GameID    string
+	Track     Track
+	Speed     Speed
+	Black     string  // Account playing black
+	White     string  // Account playing white
+	Advantage float64 // Black's handicap advantage in rating points
+	Score     float64 // Black's score: 1 = win, 0.5 = jigo, 0 = loss
+	Time      time.Time
+}
+
+// Update computes the ratings of black and white after a game from their ratings before it
+type Update func(black, white Rating, game Game) (Rating, Rating)
+
+// EloUpdate rates games with Elo
+func EloUpdate(black, white Rating, game Game) (Rating, Rating) {
+	return Elo(black, white, game.Advantage, game.Score)
+}
+
+// Change is what a game did to a player's rating
+type Change struct {
+	UserID   string    `json:"userId"`
+	GameID   string    `json:"gameId"`
+	Track    Track     `json:"track"`
+	Speed    Speed     `json:"speed,omitempty"`
+	Opponent string    `json:"opponent"` // Account of the opponent
+	Score    float64   `json:"score"`    // 1 = win, 0.5 = jigo, 0 = loss
+	Before   float64   `json:"before"`
+	After    float64   `json:"after"`
+	Annulled bool      `json:"annulled,omitempty"` // The result was annulled and the change taken back
+	Time     time.Time `json:"time"`
+}
+
+// changes returns the changes a game made to the ratings of its players
+func changes(game Game, before, after [2]Rating) [2]Change {
+	return [2]Change{
+		{UserID: game.Black, GameID: game.GameID, Track: game.Track, Speed: game.Speed, Opponent: game.White,
+			Score: game.Score, Before: before[0].Value, After: after[0].Value, Time: game.Time},
+		{UserID: game.White, GameID: game.GameID, Track: game.Track, Speed: game.Speed, Opponent: game.Black,
+			Score: 1 - game.Score, Before: before[1].Value, After: after[1].Value, Time: game.Time},
+	}
+}
+
+// Standing is where a player stands, for the leaderboards
+type Standing struct {
+	UserID  string
+	Ratings Profile
+	Games   map[Track]map[Speed]int // Rated games played on each track at each speed, annulled ones left out
+}
+
+// Store keeps the ratings of the players and their history
+type Store interface {
+	// Profile returns a player's ratings on every track they played (empty if they never did)
+	Profile(ctx context.Context, userID string) (Profile, error)
+	// Record rates a game with update, in one step for both players, and returns the changes;
+	// ErrRecorded if the game was already rated or queued
+	Record(ctx context.Context, game Game, update Update) ([2]Change, error)
+	// Queue keeps a game for the end of its rating period (Glicko-2); ErrRecorded if the game
+	// was already rated or queued
+	Queue(ctx context.Context, game Game) error
+	// ClosePeriod rates the games queued before end with Glicko-2 and makes the ratings of the
+	// players who didn't play less certain, then returns the changes; periods closed by
+	// another server at the same time return no changes
+	ClosePeriod(ctx context.Context, end time.Time) ([]Change, error)
+	// Revert takes back what a game did to the ratings of its players, e.g. when its result is
+	// annulled; the changes stay in the history, marked annulled. Queued games are dropped, and
+	// games never rated are ignored
+	Revert(ctx context.Context, gameID string) error
+	// History returns a player's rating changes, newest first, on one track or all of them ("")
+	History(ctx context.Context, userID string, track Track, limit int) ([]Change, error)
+	// Standings returns the ratings of every rated player, with how many games they played at each speed
+	Standings(ctx context.Context) ([]Standing, error)
+}
@@ -0,0 +1,146 @@
+package rating
+
+import "encoding/json"
+
+// Track is a separate rating kept for one board size, since strength differs a lot between sizes
+type Track string
+
+// Rating tracks
+const (
+	Track9  Track = "9x9"
+	Track13 Track = "13x13"
+	Track19 Track = "19x19"
+)
+
+// Tracks lists every track, smallest board first
+var Tracks = []Track{Track9, Track13, Track19}
+
+// ParseTrack reads a track name ("9x9", "13x13" or "19x19")
+func ParseTrack(name string) (Track, bool) {
+	for _, track := range Tracks {
+		if string(track) == name {
+			return track, true
+		}
+	}
+	return "", false
+}
+
+// TrackFor returns the track a game on the given board size counts for
+// Odd sizes go to the nearest standard size
+func TrackFor(size int) Track {
+	switch {
+	case size <= 11:
+		return Track9
+	case size <= 16:
+		return Track13
+	default:
+		return Track19
+	}
+}
+
+// Speed is how fast a game is played; ratings aren't kept per speed, but leaderboards are
+type Speed string
+
+// Game speeds
+const (
+	SpeedBlitz          Speed = "blitz"          // A few seconds per move
+	SpeedLive           Speed = "live"           // Played in one sitting
+	SpeedCorrespondence Speed = "correspondence" // Days per move
+	SpeedUntimed        Speed = "untimed"        // No clock
+)
+
+// Speeds lists every speed, fastest first
+var Speeds = []Speed{SpeedBlitz, SpeedLive, SpeedCorrespondence, SpeedUntimed}
+
+// InitialRating is the rating of a player without rated games
+const InitialRating = 1500
+
+// Rating is a player's rating on one track
+// Deviation and volatility are only kept by Glicko-2 (0 with Elo)
+type Rating struct {
+	Value      float64 `json:"value"`
+	Deviation  float64 `json:"deviation,omitempty"`  // Uncertainty of the value: the true rating is within about twice this
+	Volatility float64 `json:"volatility,omitempty"` // How erratic the player's results are
+	Games      int     `json:"games"`                // Rated games played on the track
+}
+
+// MarshalJSON adds the rank the rating stands for
+func (r Rating) MarshalJSON() ([]byte, error) {
+	type plain Rating
+	return json.Marshal(struct {
+		plain
+		Rank string `json:"rank"`
+	}{plain(r), r.Rank()})
+}
+
+// establishedGames is how many games a track needs before it stands on its own
+// Until then it is blended with the player's other tracks
+const establishedGames = 20
+
+// Profile holds a player's ratings on every track
+type Profile map[Track]Rating
+
+// Get returns the rating on a track as it is, or the initial rating if the player has no games there
+func (p Profile) Get(track Track) Rating {
+	if rating, ok := p[track]; ok {
+		return rating
+	}
+	return Rating{Value: InitialRating}
+}
+
+// Blended returns the rating to use on a track
+// A track with few games leans on the player's other tracks, weighted by how many games they have,
+// so a strong 19x19 player starting on 9x9 isn't matched as a beginner
+func (p Profile) Blended(track Track) float64 {
+	own := p.Get(track)
+	if own.Games >= establishedGames {
+		return own.Value
+	}
+
+	otherSum, otherGames := 0.0, 0
+	for other, rating := range p {
+		if other != track && rating.Games > 0 {
+			otherSum += rating.Value * float64(rating.Games)
+			otherGames += rating.Games
+		}
+	}
+	if otherGames == 0 {
+		return own.Value
+	}
+
+	// The own track's weight grows with its games; the rest comes from the other tracks
+	weight := float64(own.Games) / establishedGames
+	return weight*own.Value + (1-weight)*otherSum/float64(otherGames)
+}
+
+// Overall sums up the ratings of every track, each weighing as much as its games
+func (p Profile) Overall() Rating {
+	var overall Rating
+	for _, rating := range p {
+		if rating.Games == 0 {
+			continue
+		}
+		weight := float64(rating.Games)
+		overall.Value += rating.Value * weight
+		overall.Deviation += rating.Deviation * weight
+		overall.Volatility += rating.Volatility * weight
+		overall.Games += rating.Games
+	}
+	if overall.Games == 0 {
+		return Rating{Value: InitialRating}
+	}
+	total := float64(overall.Games)
+	overall.Value /= total
+	overall.Deviation /= total
+	overall.Volatility /= total
+	return overall
+}
+
+// Ratings returns the blended rating on every track
+func (p Profile) Ratings() map[Track]float64 {
+	ratings := make(map[Track]float64, len(Tracks))
+	for _, track := range Tracks {
+		ratings[track] = p.Blended(track)
+	}
+	return ratings
+}
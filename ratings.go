@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-game/game"
+	"go-game/rating"
+	"go-game/users"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// handicapModel values handicap stones and komi when rating games (set up from RATING_HANDICAP_MODEL)
+var handicapModel = rating.DefaultHandicapModel
+
+// ratingStore keeps the ratings of the accounts and their history (Postgres if DATABASE_URL is set, memory otherwise)
+var ratingStore rating.Store
+
+// Rating systems
+const (
+	RatingGlicko2 = "glicko2" // Games are rated together at the end of each rating period (the default)
+	RatingElo     = "elo"     // Each game is rated as soon as it ends
+)
+
+// ratingSystem rates the games (set up from RATING_SYSTEM), and ratingPeriod is how long
+// Glicko-2 rating periods last (RATING_PERIOD)
+var (
+	ratingSystem = RatingGlicko2
+	ratingPeriod = defaultRatingPeriod
+)
+
+// defaultRatingPeriod is a day, so active players have a few games in every period
+const defaultRatingPeriod = 24 * time.Hour
+
+// ratingSystemFromEnv reads the rating system: RATING_SYSTEM is "glicko2" (the default) or
+// "elo", and RATING_PERIOD the length of the Glicko-2 rating periods, e.g. "24h"
+func ratingSystemFromEnv() (string, time.Duration, error) {
+	period, err := envDuration("RATING_PERIOD", defaultRatingPeriod)
+	if err != nil {
+		return "", 0, err
+	}
+	switch system := os.Getenv("RATING_SYSTEM"); system {
+	case "", RatingGlicko2:
+		return RatingGlicko2, period, nil
+	case RatingElo:
+		return RatingElo, period, nil
+	default:
+		return "", 0, fmt.Errorf("RATING_SYSTEM must be %q or %q, got %q", RatingGlicko2, RatingElo, system)
+	}
+}
+
+// Rating history page sizes
+const (
+	defaultRatingHistory = 50
+	maxRatingHistory     = 500
+)
+
+// Speed of timed games, from the time a player gets for a game of blitzByoYomiMoves moves in byo-yomi
+const (
+	blitzGameTime     = 10 * time.Minute // Games with less time than this per player are blitz
+	blitzByoYomiMoves = 20
+)
+
+// gameSpeed returns how fast a game is played, from its clock
+func gameSpeed(board *game.Board) rating.Speed {
+	clock := board.Clock
+	switch {
+	case clock == nil:
+		return rating.SpeedUntimed
+	case isCorrespondence(board):
+		return rating.SpeedCorrespondence
+	case clock.MainTime+blitzByoYomiMoves*clock.ByoYomiTime < blitzGameTime:
+		return rating.SpeedBlitz
+	default:
+		return rating.SpeedLive
+	}
+}
+
+// newRatingStoreFromEnv opens the rating store next to the games
+func newRatingStoreFromEnv() (rating.Store, error) {
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		return rating.NewPostgresStore(url)
+	}
+	return rating.NewMemoryStore(), nil
+}
+
+// ratedGame describes a finished game for the ratings; ok is false for games that don't count:
+// unrated ones, games without a winner or a count, team games, variants, and games not
+// played by two different accounts
+func ratedGame(gameID string, board *game.Board, now time.Time) (rated rating.Game, ok bool) {
+	result := board.Result
+	switch {
+	case result == nil || !board.IsRated() || board.IsTeamGame():
+		return rating.Game{}, false
+	case board.Variant != "" && board.Variant != game.VariantStandard:
+		return rating.Game{}, false
+	case board.Players[1] == "" || board.Players[2] == "" || board.Players[1] == board.Players[2]:
+		return rating.Game{}, false
+	case result.Winner == 0 && result.Reason != game.ReasonScore:
+		return rating.Game{}, false // Only jigo counts as a draw
+	}
+
+	score := 0.5
+	switch result.Winner {
+	case 1:
+		score = 1
+	case 2:
+		score = 0
+	}
+	return rating.Game{
+		GameID:    gameID,
+		Track:     rating.TrackFor(board.Size),
+		Speed:     gameSpeed(board),
+		Black:     board.Players[1],
+		White:     board.Players[2],
+		Advantage: handicapModel.Advantage(board.HandicapStones(), board.Komi),
+		Score:     score,
+		Time:      now,
+	}, true
+}
+
+// rateGame updates the ratings of the players of a game that just ended, or queues the game
+// for the end of the rating period with Glicko-2
+// Must be called with the game locked
+func rateGame(gameID string, board *game.Board) {
+	rated, ok := ratedGame(gameID, board, time.Now())
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if ratingSystem == RatingGlicko2 {
+		if err := ratingStore.Queue(ctx, rated); err != nil && !errors.Is(err, rating.ErrRecorded) {
+			log.Printf("queuing game %s for rating: %v", gameID, err)
+		}
+		return
+	}
+
+	recorded, err := ratingStore.Record(ctx, rated, rating.EloUpdate)
+	if errors.Is(err, rating.ErrRecorded) {
+		return
+	}
+	if err != nil {
+		log.Printf("rating game %s: %v", gameID, err)
+		return
+	}
+	hub.Broadcast(Event{Type: EventRated, GameID: gameID, Data: recorded[:]})
+}
+
+// runRatingPeriods closes a Glicko-2 rating period at every interval until the context is cancelled
+func runRatingPeriods(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			closeRatingPeriod(ctx, now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// closeRatingPeriod rates the games queued before end and tells each game's audience what changed
+func closeRatingPeriod(ctx context.Context, end time.Time) {
+	recorded, err := ratingStore.ClosePeriod(ctx, end)
+	if err != nil {
+		log.Printf("closing the rating period: %v", err)
+		return
+	}
+
+	byGame := make(map[string][]rating.Change)
+	var gameIDs []string
+	for _, change := range recorded {
+		if byGame[change.GameID] == nil {
+			gameIDs = append(gameIDs, change.GameID)
+		}
+		byGame[change.GameID] = append(byGame[change.GameID], change)
+	}
+	for _, gameID := range gameIDs {
+		hub.Broadcast(Event{Type: EventRated, GameID: gameID, Data: byGame[gameID]})
+	}
+	if len(gameIDs) > 0 {
+		log.Printf("rated %d games at the end of the rating period", len(gameIDs))
+	}
+}
+
+// unrateGame takes back what a game did to the ratings of its players
+func unrateGame(ctx context.Context, gameID string) {
+	if err := ratingStore.Revert(ctx, gameID); err != nil {
+		log.Printf("reverting the ratings of game %s: %v", gameID, err)
+	}
+}
+
+// ratedProfile returns the public part of an account with its ratings
+func ratedProfile(ctx context.Context, user users.User) (users.Profile, error) {
+	profile := user.Profile()
+	ratings, err := ratingStore.Profile(ctx, user.ID)
+	if err != nil {
+		return users.Profile{}, err
+	}
+	if len(ratings) > 0 {
+		profile.Ratings = ratings
+	}
+	return profile, nil
+}
+
+// playerRatings returns the ratings of the accounts playing a game on its track, reading
+// the profiles it doesn't have yet into profiles; players without an account are left at 0
+func playerRatings(ctx context.Context, board *game.Board, profiles map[string]rating.Profile) [3]float64 {
+	var ratings [3]float64
+	for player := 1; player <= 2; player++ {
+		userID := board.Players[player]
+		if userID == "" {
+			continue
+		}
+		profile, known := profiles[userID]
+		if !known {
+			var err error
+			if profile, err = ratingStore.Profile(ctx, userID); err != nil {
+				log.Printf("loading the ratings of %s: %v", userID, err)
+				continue
+			}
+			profiles[userID] = profile
+		}
+		ratings[player] = profile.Blended(rating.TrackFor(board.Size))
+	}
+	return ratings
+}
+
+// Rating history of an account, newest first (?track= for one board size, ?limit=)
+func getRatingHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+	user, err := userStore.Get(ctx, c.Param("id"))
+	if errors.Is(err, users.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	var track rating.Track
+	if param := c.QueryParam("track"); param != "" {
+		parsed, ok := rating.ParseTrack(param)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid track"})
+		}
+		track = parsed
+	}
+	limit := defaultRatingHistory
+	if param := c.QueryParam("limit"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed <= 0 || parsed > maxRatingHistory {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+		}
+		limit = parsed
+	}
+
+	history, err := ratingStore.History(ctx, user.ID, track, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if history == nil {
+		history = []rating.Change{}
+	}
+	return c.JSON(http.StatusOK, history)
+}
+
+// maxHandicapStones is the largest handicap suggested
+const maxHandicapStones = 9
+
+// Handicap suggestion response structure
+type HandicapSuggestion struct {
+	Handicap      int     `json:"handicap"`      // Handicap stones for black (0 = none)
+	Komi          float64 `json:"komi"`          // Komi for white
+	Advantage     float64 `json:"advantage"`     // Black's advantage in rating points
+	ExpectedScore float64 `json:"expectedScore"` // Black's expected score with that handicap (0.5 = even)
+}
+
+// Suggest the handicap that makes a game even between two ratings (?black=&white=)
+// Passing ?handicap= and ?komi= instead gives the expected result of those settings
+func suggestHandicap(c echo.Context) error {
+	black, err := strconv.ParseFloat(c.QueryParam("black"), 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid black rating"})
+	}
+	white, err := strconv.ParseFloat(c.QueryParam("white"), 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid white rating"})
+	}
+
+	var suggestion HandicapSuggestion
+	if c.QueryParam("handicap") != "" || c.QueryParam("komi") != "" {
+		suggestion.Komi = game.DefaultKomi
+		if param := c.QueryParam("handicap"); param != "" {
+			if suggestion.Handicap, err = strconv.Atoi(param); err != nil || suggestion.Handicap < 0 || suggestion.Handicap > maxHandicapStones {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid handicap"})
+			}
+		}
+		if param := c.QueryParam("komi"); param != "" {
+			if suggestion.Komi, err = strconv.ParseFloat(param, 64); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid komi"})
+			}
+		}
+	} else {
+		suggestion.Handicap, suggestion.Komi = handicapModel.Handicap(black-white, maxHandicapStones)
+	}
+
+	suggestion.Advantage = handicapModel.Advantage(suggestion.Handicap, suggestion.Komi)
+	suggestion.ExpectedScore = rating.ExpectedScore(black, white, suggestion.Advantage)
+	return c.JSON(http.StatusOK, suggestion)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"go-game/game"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// QueuedMove is a move a client recorded while it was offline
+type QueuedMove struct {
+	MoveRequest
+	ExpectedVersion int       `json:"expectedVersion"` // Game version the client saw when it made the move
+	ClientTime      time.Time `json:"clientTime"`      // When the move was made on the client
+}
+
+// Batch move request structure
+type MoveBatchRequest struct {
+	Moves []QueuedMove `json:"moves"`
+}
+
+// Most moves a client may queue in one batch
+const maxMoveBatch = 50
+
+// Outcomes of a queued move
+const (
+	MoveApplied  = "applied"  // The move was played
+	MoveConflict = "conflict" // The game changed since the client saw it, so the move was dropped
+	MoveRejected = "rejected" // The move was illegal or the game could not accept it
+)
+
+// MoveOutcome tells the client what happened to one of its queued moves
+type MoveOutcome struct {
+	Index   int    `json:"index"`           // Position of the move in the submitted batch
+	Status  string `json:"status"`          // applied, conflict or rejected
+	Error   string `json:"error,omitempty"` // Why the move was not applied
+	Version int    `json:"version"`         // Game version after handling this move
+}
+
+// ReconciliationReport is returned after a batch of queued moves has been processed
+type ReconciliationReport struct {
+	Outcomes []MoveOutcome `json:"outcomes"` // One entry per submitted move, in the order they were handled
+	Version  int           `json:"version"`  // Game version after the whole batch
+	Board    *game.Board   `json:"board"`    // Current game state so the client can resynchronize (filtered with ?player= in hidden-information variants)
+}
+
+// Apply a batch of moves queued by a client while it was offline
+// Each move is only played if the game is still at the version the client expected
+func submitMoveBatch(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Parse the batch
+	var batchReq MoveBatchRequest
+	if err := c.Bind(&batchReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	if len(batchReq.Moves) > maxMoveBatch {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Too many moves, at most %d per batch", maxMoveBatch)})
+	}
+
+	// Every queued move counts against the move rate limit
+	if allowed, err := chargeRate(c, &moveRateLimit, len(batchReq.Moves)); !allowed {
+		return err
+	}
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	// Handle the moves in the order the client made them
+	order := make([]int, len(batchReq.Moves))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return batchReq.Moves[order[a]].ClientTime.Before(batchReq.Moves[order[b]].ClientTime)
+	})
+
+	report := ReconciliationReport{Outcomes: make([]MoveOutcome, 0, len(order))}
+	for _, index := range order {
+		queued := batchReq.Moves[index]
+		queued.Player = teamMember(board, seatToken(c), queued.Player)
+		outcome := MoveOutcome{Index: index}
+		phase := board.Phase
+
+		if err := checkRequestSeat(c, board, board.CurrentPlayer); err != nil {
+			// Not the client's turn (any more), or not its game
+			outcome.Status = MoveRejected
+			outcome.Error = err.Error()
+		} else if queued.ExpectedVersion != board.Version() {
+			// Someone else moved in the meantime, the move was made on an outdated board
+			outcome.Status = MoveConflict
+			outcome.Error = "game has changed since this move was made"
+		} else if err := applyMove(board, queued.MoveRequest); err != nil {
+			outcome.Status = MoveRejected
+			outcome.Error = err.Error()
+			announceViolation(gameID, err)
+			announceResult(gameID, board, phase)
+		} else {
+			outcome.Status = MoveApplied
+			announceMove(gameID, board)
+			playConditional(c.Request().Context(), gameID, board) // The opponent may have a reply ready
+		}
+
+		outcome.Version = board.Version()
+		report.Outcomes = append(report.Outcomes, outcome)
+	}
+
+	saveGame(c.Request().Context(), gameID, board)
+	scheduleBotMove(c.Request().Context(), gameID, board)
+
+	report.Version = board.Version()
+	report.Board = board.ViewFor(viewerOf(c, board))
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,303 @@
+// Package redis is a small Redis client: enough of RESP for the game store and cache,
+// with a pool of connections
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client settings
+const (
+	defaultTimeout = 5 * time.Second // For calls without a context deadline
+	maxIdle        = 8               // Connections kept open between calls
+)
+
+// Error is an error reply from the server
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// errProtocol is returned for replies that aren't valid RESP
+var errProtocol = errors.New("redis: invalid reply")
+
+// Client sends commands to one Redis server
+// Replies are decoded as string (simple strings), int64, []byte (bulk strings), []any (arrays)
+// and nil (missing values); error replies are returned as Error
+type Client struct {
+	addr     string
+	useTLS   bool
+	password string
+	username string
+	db       int
+
+	idle chan *conn
+}
+
+// conn is a connection with its reader
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// Open connects to the server of a URL, e.g. redis://:password@localhost:6379/0
+// (rediss:// for TLS), and checks that it answers
+func Open(rawURL string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("redis: unsupported URL scheme %q", u.Scheme)
+	}
+
+	c := &Client{addr: u.Host, useTLS: u.Scheme == "rediss", idle: make(chan *conn, maxIdle)}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("redis: invalid database %q", db)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	if _, err := c.Do(ctx, "PING"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close closes the idle connections
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.idle:
+			cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// Do sends one command and returns its reply
+func (c *Client) Do(ctx context.Context, args ...any) (any, error) {
+	replies, err := c.Pipeline(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := replies[0].(Error); ok {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+// Pipeline sends several commands in one round trip and returns their replies, in order
+// Error replies are returned among the others, as values of type Error
+func (c *Client) Pipeline(ctx context.Context, commands ...[]any) ([]any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	cn.SetDeadline(deadline)
+
+	replies, err := cn.roundTrip(commands)
+	if err != nil {
+		cn.Close() // The connection may be in the middle of a reply
+		return nil, err
+	}
+	c.put(cn)
+	return replies, nil
+}
+
+// get takes an idle connection, or opens a new one
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+
+	var dialer net.Dialer
+	if _, ok := ctx.Deadline(); !ok {
+		dialer.Timeout = defaultTimeout
+	}
+	var nc net.Conn
+	var err error
+	if c.useTLS {
+		host, _, _ := net.SplitHostPort(c.addr)
+		nc, err = (&tls.Dialer{NetDialer: &dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", c.addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", c.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc)}
+
+	// Sign in and pick the database once per connection
+	var setup [][]any
+	switch {
+	case c.password != "" && c.username != "":
+		setup = append(setup, []any{"AUTH", c.username, c.password})
+	case c.password != "":
+		setup = append(setup, []any{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		setup = append(setup, []any{"SELECT", c.db})
+	}
+	if len(setup) > 0 {
+		if deadline, ok := ctx.Deadline(); ok {
+			cn.SetDeadline(deadline)
+		} else {
+			cn.SetDeadline(time.Now().Add(defaultTimeout))
+		}
+		replies, err := cn.roundTrip(setup)
+		if err == nil {
+			for _, reply := range replies {
+				if replyErr, ok := reply.(Error); ok {
+					err = replyErr
+					break
+				}
+			}
+		}
+		if err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+// put returns a connection to the pool, closing it if the pool is full
+func (c *Client) put(cn *conn) {
+	select {
+	case c.idle <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+// roundTrip writes commands and reads their replies
+func (cn *conn) roundTrip(commands [][]any) ([]any, error) {
+	w := bufio.NewWriter(cn.Conn)
+	for _, args := range commands {
+		if err := writeCommand(w, args); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]any, len(commands))
+	for i := range replies {
+		reply, err := readReply(cn.r)
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+// writeCommand writes a command as an array of bulk strings
+func writeCommand(w *bufio.Writer, args []any) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		var b []byte
+		switch v := arg.(type) {
+		case string:
+			b = []byte(v)
+		case []byte:
+			b = v
+		case int:
+			b = strconv.AppendInt(nil, int64(v), 10)
+		case int64:
+			b = strconv.AppendInt(nil, v, 10)
+		case float64:
+			b = strconv.AppendFloat(nil, v, 'f', -1, 64)
+		default:
+			return fmt.Errorf("redis: unsupported argument type %T", arg)
+		}
+		fmt.Fprintf(w, "$%d\r\n", len(b))
+		w.Write(b)
+		w.WriteString("\r\n")
+	}
+	return nil
+}
+
+// readReply reads one reply
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, errProtocol
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return Error(body), nil
+	case ':':
+		n, err := strconv.ParseInt(body, 10, 64)
+		if err != nil {
+			return nil, errProtocol
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 {
+			return nil, errProtocol
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 {
+			return nil, errProtocol
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, errProtocol
+}
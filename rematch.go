@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"go-game/auth"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Rematches: after a game, either player asks for another with the same settings and the
+// colors swapped. The request is a challenge sent to the other player, who accepts it like
+// any challenge, or by asking for the rematch too
+
+// Ask for a rematch of a finished game, or accept the one the opponent asked for
+func requestRematch(c echo.Context) error {
+	gameID := c.Param("id")
+	user, signedIn, err := account(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !signedIn {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Rematches need an account"})
+	}
+
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	finished, players, size, variant := board.Result != nil, board.Players, board.Size, board.Variant
+	var mainTime, byoYomiTime, perMove time.Duration
+	var byoYomiPeriods int
+	if board.Clock != nil {
+		mainTime, byoYomiTime, byoYomiPeriods = board.Clock.MainTime, board.Clock.ByoYomiTime, board.Clock.ByoYomiPeriods
+		if perMove = board.Clock.PerMove; perMove > 0 {
+			mainTime = 0 // Correspondence clocks give each move the time again
+		}
+	}
+	unlock()
+
+	color := 0
+	for player := 1; player <= 2; player++ {
+		if players[player] == user.ID {
+			color = player
+		}
+	}
+	if color == 0 {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Only the players of a game can ask for a rematch"})
+	}
+	opponentID := players[3-color]
+	if opponentID == "" || opponentID == user.ID {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Rematches are for games between two accounts"})
+	}
+	if !finished {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "The game isn't over yet"})
+	}
+
+	// The opponent asked first: asking back accepts
+	if pending, found := findRematch(gameID, opponentID); found {
+		ctx := context.WithoutCancel(c.Request().Context()) // The game outlives the request
+		challenge, status, err := acceptChallengeAs(ctx, pending.ID, user)
+		if err != nil {
+			return c.JSON(status, map[string]string{"error": err.Error()})
+		}
+		setGameLocation(c, challenge.GameID)
+		return c.JSON(http.StatusOK, challenge)
+	}
+	if pending, found := findRematch(gameID, user.ID); found {
+		c.Response().Header().Set(echo.HeaderLocation, pending.Link)
+		return c.JSON(http.StatusOK, pending)
+	}
+
+	opponent, found, err := lookupAccount(c.Request().Context(), auth.User{ID: opponentID})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !found {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "The opponent's account is gone"})
+	}
+	profile := opponent.Profile()
+
+	challenge, ok := addChallenge(&Challenge{
+		Challenger:     user.Profile(),
+		Opponent:       &profile,
+		Size:           size,
+		MainTime:       int(mainTime / time.Second),
+		ByoYomiTime:    int(byoYomiTime / time.Second),
+		ByoYomiPeriods: byoYomiPeriods,
+		DaysPerMove:    int(perMove / day),
+		Variant:        variant,
+		Color:          3 - color,
+		Rematch:        gameID,
+	}, time.Now())
+	if !ok {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Too many open challenges, cancel one first"})
+	}
+	c.Response().Header().Set(echo.HeaderLocation, challenge.Link)
+	return c.JSON(http.StatusCreated, challenge)
+}
+
+// findRematch returns the open rematch challenge a player sent for a game
+func findRematch(gameID, challengerID string) (Challenge, bool) {
+	challengesMu.Lock()
+	defer challengesMu.Unlock()
+
+	pruneChallenges(time.Now())
+	for _, challenge := range challenges {
+		if challenge.Status == ChallengeOpen && challenge.Rematch == gameID && challenge.Challenger.ID == challengerID {
+			return *challenge, true
+		}
+	}
+	return Challenge{}, false
+}
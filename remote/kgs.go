@@ -0,0 +1,123 @@
+package remote
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// KGSArchives is where KGS publishes the monthly archives of its users
+const KGSArchives = "https://www.gokgs.com/servlet/archives/en_US"
+
+// Limits of the archives read, which keep zip bombs from exhausting memory
+const (
+	maxArchiveRecords = 10000    // Game records in an archive
+	maxArchiveContent = 64 << 20 // Total size of the records, uncompressed
+)
+
+// kgsUser matches KGS user names: a letter then letters and digits, at most 10 characters
+var kgsUser = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]{0,9}$`)
+
+// KGSArchiveURL is the address of a user's zip archive for one month, from the archives at base (see KGSArchives)
+func KGSArchiveURL(base, user string, year int, month time.Month) (string, error) {
+	if !kgsUser.MatchString(user) {
+		return "", fmt.Errorf("invalid KGS user %q", user)
+	}
+	if month < time.January || month > time.December || year < 2000 {
+		return "", fmt.Errorf("invalid month %d-%02d", year, month)
+	}
+	return fmt.Sprintf("%s/%s-%d-%d.zip", strings.TrimRight(base, "/"), user, year, month), nil
+}
+
+// IsKGSURL checks that an address points to KGS, so imports can't be used to reach other hosts
+func IsKGSURL(address string) bool {
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == "gokgs.com" || strings.HasSuffix(host, ".gokgs.com")
+}
+
+// kgsClient only follows redirects within KGS
+var kgsClient = &http.Client{
+	Timeout: client.Timeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if !IsKGSURL(req.URL.String()) || len(via) >= 10 {
+			return errors.New("redirected away from KGS")
+		}
+		return nil
+	},
+}
+
+// Record is a game record read from an archive
+type Record struct {
+	Name string // File name within the archive
+	SGF  string
+}
+
+// FetchKGS downloads a KGS archive (.zip) or a single game record (.sgf)
+func FetchKGS(ctx context.Context, address string) ([]Record, error) {
+	data, err := fetch(ctx, kgsClient, address, MaxArchiveSize)
+	if err != nil {
+		return nil, err
+	}
+
+	u, _ := url.Parse(address)
+	if strings.EqualFold(path.Ext(u.Path), ".sgf") {
+		if len(data) > MaxRecordSize {
+			return nil, errors.New("the game record is too large")
+		}
+		return []Record{{Name: path.Base(u.Path), SGF: string(data)}}, nil
+	}
+	return ReadArchive(data)
+}
+
+// ReadArchive lists the game records (.sgf files) of a zip archive, in the order they appear
+func ReadArchive(data []byte) ([]Record, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a zip archive: %w", err)
+	}
+
+	records := make([]Record, 0)
+	total := 0
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || !strings.EqualFold(path.Ext(file.Name), ".sgf") {
+			continue
+		}
+		if len(records) == maxArchiveRecords {
+			return nil, fmt.Errorf("the archive holds more than %d games", maxArchiveRecords)
+		}
+		if file.UncompressedSize64 > MaxRecordSize {
+			return nil, fmt.Errorf("%s is too large", file.Name)
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		content, err := io.ReadAll(io.LimitReader(reader, MaxRecordSize+1)) // The header may lie about the size
+		reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		if len(content) > MaxRecordSize {
+			return nil, fmt.Errorf("%s is too large", file.Name)
+		}
+		if total += len(content); total > maxArchiveContent {
+			return nil, errors.New("the archive is too large once uncompressed")
+		}
+		records = append(records, Record{Name: file.Name, SGF: string(content)})
+	}
+	return records, nil
+}
@@ -0,0 +1,34 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// OGSAPI is the address of the online-go.com REST API
+const OGSAPI = "https://online-go.com/api/v1"
+
+// ParseOGSGame reads an OGS game ID, given as the number or as the address of the game page
+// ("https://online-go.com/game/12345")
+func ParseOGSGame(game string) (int64, error) {
+	game = strings.TrimSpace(game)
+	if u, err := url.Parse(game); err == nil && u.Host != "" {
+		segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+		game = segments[len(segments)-1]
+	}
+
+	id, err := strconv.ParseInt(game, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid OGS game %q", game)
+	}
+	return id, nil
+}
+
+// FetchOGS downloads the SGF record of an OGS game from the API at base (see OGSAPI)
+func FetchOGS(ctx context.Context, base string, id int64) (string, error) {
+	record, err := fetch(ctx, client, fmt.Sprintf("%s/games/%d/sgf", strings.TrimRight(base, "/"), id), MaxRecordSize)
+	return string(record), err
+}
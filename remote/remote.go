@@ -0,0 +1,64 @@
+// Package remote fetches game records from other Go servers, so players can review
+// the games they played elsewhere
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Size limits of fetched files
+const (
+	MaxRecordSize  = 1 << 20  // One game record
+	MaxArchiveSize = 32 << 20 // An archive of game records
+)
+
+// Errors telling the client why a record couldn't be fetched
+var (
+	ErrNotFound    = errors.New("the game doesn't exist")
+	ErrPrivate     = errors.New("the game is private")
+	ErrUnavailable = errors.New("the server is unavailable")
+)
+
+// client fetches the records; servers that take longer than the timeout count as unavailable
+var client = &http.Client{Timeout: 15 * time.Second}
+
+// userAgent identifies this server to the servers it fetches from
+const userAgent = "go-game (game import)"
+
+// fetch downloads a file with the given client of up to limit bytes, mapping the HTTP status to the errors above
+func fetch(ctx context.Context, client *http.Client, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized:
+		return nil, ErrPrivate
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, errors.New("the file is too large")
+	}
+	return data, nil
+}
@@ -0,0 +1,205 @@
+// Package render draws board positions as images, for link previews, chat bots and emails
+package render
+
+import (
+	"go-game/game"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Cell size limits in pixels (the distance between two lines)
+const (
+	DefaultCellSize = 32
+	MinCellSize     = 16
+	MaxCellSize     = 64
+)
+
+// PNG draws the board, with coordinates around it, and writes it as a PNG image
+// cellSize is the distance between lines in pixels (0 = DefaultCellSize)
+func PNG(w io.Writer, board *game.Board, cellSize int, style Style) error {
+	theme := style.Theme
+	if cellSize == 0 {
+		cellSize = DefaultCellSize
+	}
+	cellSize = max(MinCellSize, min(cellSize, MaxCellSize))
+	cell := float64(cellSize)
+
+	// One cell of margin on each side holds the coordinates
+	side := (board.Size + 1) * cellSize
+	img := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(img, img.Bounds(), image.NewUniform(theme.Board), image.Point{}, draw.Src)
+
+	// point returns the pixel center of an intersection (the middle of the one pixel wide lines)
+	point := func(row, col int) (float64, float64) {
+		return cell*float64(col+1) + 0.5, cell*float64(row+1) + 0.5
+	}
+
+	// Grid lines
+	first, last := cellSize, board.Size*cellSize
+	for i := 0; i < board.Size; i++ {
+		at := (i + 1) * cellSize
+		draw.Draw(img, image.Rect(first, at, last+1, at+1), image.NewUniform(theme.Lines), image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(at, first, at+1, last+1), image.NewUniform(theme.Lines), image.Point{}, draw.Src)
+	}
+
+	// Star points
+	for position := range board.StarPoints() {
+		x, y := point(position/board.Size, position%board.Size)
+		fillCircle(img, x, y, cell*0.1, theme.Lines)
+	}
+
+	// Stones; the last move keeps only its own marker
+	lastMove := -1
+	if board.LastMove != nil {
+		lastMove = board.LastMove.Position
+	}
+	for position, stone := range board.Grid {
+		x, y := point(position/board.Size, position%board.Size)
+		switch stone {
+		case 1:
+			fillCircle(img, x, y, cell*0.47, theme.BlackStone)
+		case 2:
+			fillCircle(img, x, y, cell*0.47, theme.WhiteBorder)
+			fillCircle(img, x, y, cell*0.47-1, theme.WhiteStone)
+		}
+		if style.StoneMarkers && stone != 0 && position != lastMove {
+			drawShape(img, stoneShapes[stone], x, y, cell*0.12, theme.stoneOpposite(stone))
+		}
+	}
+
+	// Last move marker, larger than stone markers
+	if lastMove >= 0 {
+		x, y := point(lastMove/board.Size, lastMove%board.Size)
+		drawShape(img, style.LastMove, x, y, cell*0.2, theme.markerOn(board.Grid[lastMove]))
+	}
+
+	drawCoordinates(img, board.Size, cellSize, theme.Lines)
+	return png.Encode(w, img)
+}
+
+// drawCoordinates writes the column letters above and below the grid and the row numbers
+// (counted from the bottom) on both sides
+func drawCoordinates(img *image.RGBA, size, cellSize int, c color.RGBA) {
+	face := basicfont.Face7x13
+	drawer := &font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face}
+
+	label := func(text string, centerX, centerY int) {
+		width := drawer.MeasureString(text).Ceil()
+		drawer.Dot = fixed.P(centerX-width/2, centerY+face.Ascent/2-1)
+		drawer.DrawString(text)
+	}
+
+	edge, far := cellSize/2, size*cellSize+cellSize/2
+	for i := 0; i < size && i < len(game.ColumnLetters); i++ {
+		at := (i + 1) * cellSize
+		letter := string(game.ColumnLetters[i])
+		label(letter, at, edge)
+		label(letter, at, far)
+
+		number := strconv.Itoa(size - i)
+		label(number, edge, at)
+		label(number, far, at)
+	}
+}
+
+// fillCircle draws a filled circle with smoothed edges
+func fillCircle(img *image.RGBA, cx, cy, radius float64, c color.RGBA) {
+	bounds := image.Rect(int(cx-radius-1), int(cy-radius-1), int(cx+radius+2), int(cy+radius+2)).Intersect(img.Bounds())
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			distance := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+			coverage := math.Max(0, math.Min(1, radius+0.5-distance))
+			if coverage == 0 {
+				continue
+			}
+
+			under := img.RGBAAt(x, y)
+			img.SetRGBA(x, y, color.RGBA{
+				R: blend(under.R, c.R, coverage),
+				G: blend(under.G, c.G, coverage),
+				B: blend(under.B, c.B, coverage),
+				A: 255,
+			})
+		}
+	}
+}
+
+// drawShape draws a marker shape (see Shapes) fitting in a circle of the given radius
+func drawShape(img *image.RGBA, shape string, cx, cy, radius float64, c color.RGBA) {
+	if shape == ShapeCircle {
+		fillCircle(img, cx, cy, radius*0.75, c) // Same area as the other shapes, roughly
+		return
+	}
+	for _, polygon := range polygons(shape, cx, cy, radius) {
+		fillPolygon(img, polygon, c)
+	}
+}
+
+// polygonSamples is how many points per pixel side fillPolygon samples to smooth the edges
+const polygonSamples = 4
+
+// fillPolygon draws a filled convex polygon with smoothed edges
+func fillPolygon(img *image.RGBA, corners [][2]float64, c color.RGBA) {
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, corner := range corners {
+		minX, maxX = math.Min(minX, corner[0]), math.Max(maxX, corner[0])
+		minY, maxY = math.Min(minY, corner[1]), math.Max(maxY, corner[1])
+	}
+	bounds := image.Rect(int(minX)-1, int(minY)-1, int(maxX)+2, int(maxY)+2).Intersect(img.Bounds())
+
+	// inside checks a point is on the same side of every edge
+	inside := func(x, y float64) bool {
+		sign := 0.0
+		for i, from := range corners {
+			to := corners[(i+1)%len(corners)]
+			cross := (to[0]-from[0])*(y-from[1]) - (to[1]-from[1])*(x-from[0])
+			if cross*sign < 0 {
+				return false
+			}
+			if cross != 0 {
+				sign = cross
+			}
+		}
+		return true
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			hits := 0
+			for sy := 0; sy < polygonSamples; sy++ {
+				for sx := 0; sx < polygonSamples; sx++ {
+					if inside(float64(x)+(float64(sx)+0.5)/polygonSamples, float64(y)+(float64(sy)+0.5)/polygonSamples) {
+						hits++
+					}
+				}
+			}
+			if hits == 0 {
+				continue
+			}
+
+			coverage := float64(hits) / (polygonSamples * polygonSamples)
+			under := img.RGBAAt(x, y)
+			img.SetRGBA(x, y, color.RGBA{
+				R: blend(under.R, c.R, coverage),
+				G: blend(under.G, c.G, coverage),
+				B: blend(under.B, c.B, coverage),
+				A: 255,
+			})
+		}
+	}
+}
+
+// blend mixes two color channels
+func blend(under, over uint8, coverage float64) uint8 {
+	return uint8(math.Round(float64(under)*(1-coverage) + float64(over)*coverage))
+}
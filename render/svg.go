@@ -0,0 +1,157 @@
+package render
+
+import (
+	"fmt"
+	"go-game/game"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+)
+
+// Overlays are the optional marks drawn on top of an SVG board
+type Overlays struct {
+	MoveNumbers bool      // Number every stone with the move that placed it
+	Territory   []int     // Owner of each point (see Board.Territory); nil = no shading
+	Ownership   []float64 // Analysis ownership of each point, +1 black to -1 white; nil = none
+	DeadStones  []int     // Stones to cross out as dead
+}
+
+// SVG draws the board, with coordinates and the requested overlays, as an SVG document
+// Distances are in cells (the space between two lines), so the image scales to any size
+func SVG(w io.Writer, board *game.Board, overlays Overlays, style Style) error {
+	theme := style.Theme
+	var out strings.Builder
+	side := board.Size + 1
+
+	fmt.Fprintf(&out, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n", side, side)
+	fmt.Fprintf(&out, `<rect width="%d" height="%d" fill="%s"/>`+"\n", side, side, hex(theme.Board))
+
+	// Grid lines
+	fmt.Fprintf(&out, `<g stroke="%s" stroke-width="0.03">`+"\n", hex(theme.Lines))
+	for i := 1; i <= board.Size; i++ {
+		fmt.Fprintf(&out, `<line x1="1" y1="%d" x2="%d" y2="%d"/><line x1="%d" y1="1" x2="%d" y2="%d"/>`+"\n", i, board.Size, i, i, i, board.Size)
+	}
+	out.WriteString("</g>\n")
+
+	// point returns the coordinates of an intersection
+	point := func(position int) (int, int) {
+		return position%board.Size + 1, position/board.Size + 1
+	}
+
+	for position := range board.StarPoints() {
+		x, y := point(position)
+		fmt.Fprintf(&out, `<circle cx="%d" cy="%d" r="0.1" fill="%s"/>`+"\n", x, y, hex(theme.Lines))
+	}
+
+	// Coordinates: letters above and below, row numbers (from the bottom) on both sides
+	fmt.Fprintf(&out, `<g font-size="0.4" text-anchor="middle" dominant-baseline="central" fill="%s">`+"\n", hex(theme.Lines))
+	for i := 0; i < board.Size && i < len(game.ColumnLetters); i++ {
+		letter, number := string(game.ColumnLetters[i]), board.Size-i
+		fmt.Fprintf(&out, `<text x="%d" y="0.5">%s</text><text x="%d" y="%g">%s</text>`+"\n", i+1, letter, i+1, float64(side)-0.5, letter)
+		fmt.Fprintf(&out, `<text x="0.5" y="%d">%d</text><text x="%g" y="%d">%d</text>`+"\n", i+1, number, float64(side)-0.5, i+1, number)
+	}
+	out.WriteString("</g>\n")
+
+	// Analysis ownership, as squares sized by how sure the estimate is
+	if overlays.Ownership != nil {
+		for position, ownership := range overlays.Ownership {
+			if position >= len(board.Grid) || math.Abs(ownership) < 0.1 {
+				continue
+			}
+			x, y := point(position)
+			fill := theme.BlackStone
+			if ownership < 0 {
+				fill = theme.WhiteStone
+			}
+			half := 0.35 * math.Abs(ownership)
+			fmt.Fprintf(&out, `<rect x="%.3f" y="%.3f" width="%.3f" height="%.3f" fill="%s" fill-opacity="0.6"/>`+"\n",
+				float64(x)-half, float64(y)-half, 2*half, 2*half, hex(fill))
+		}
+	}
+
+	// Stones; the last move keeps only its own marker, and numbered stones their number
+	lastMove := -1
+	if board.LastMove != nil {
+		lastMove = board.LastMove.Position
+	}
+	for position, stone := range board.Grid {
+		x, y := point(position)
+		switch stone {
+		case 1:
+			fmt.Fprintf(&out, `<circle cx="%d" cy="%d" r="0.47" fill="%s"/>`+"\n", x, y, hex(theme.BlackStone))
+		case 2:
+			fmt.Fprintf(&out, `<circle cx="%d" cy="%d" r="0.46" fill="%s" stroke="%s" stroke-width="0.03"/>`+"\n", x, y, hex(theme.WhiteStone), hex(theme.WhiteBorder))
+		}
+		if style.StoneMarkers && stone != 0 && position != lastMove && !overlays.MoveNumbers {
+			writeShape(&out, stoneShapes[stone], float64(x), float64(y), 0.12, theme.stoneOpposite(stone))
+		}
+	}
+
+	// Territory, as small squares of the owner's color
+	if overlays.Territory != nil {
+		for position, owner := range overlays.Territory {
+			if owner == 0 || position >= len(board.Grid) {
+				continue
+			}
+			x, y := point(position)
+			fill := theme.BlackStone
+			if owner == 2 {
+				fill = theme.WhiteStone
+			}
+			fmt.Fprintf(&out, `<rect x="%g" y="%g" width="0.36" height="0.36" fill="%s" stroke="%s" stroke-width="0.02"/>`+"\n",
+				float64(x)-0.18, float64(y)-0.18, hex(fill), hex(theme.WhiteBorder))
+		}
+	}
+
+	// Dead stones are crossed out
+	for _, position := range overlays.DeadStones {
+		if position < 0 || position >= len(board.Grid) {
+			continue
+		}
+		x, y := point(position)
+		fmt.Fprintf(&out, `<path d="M%g %gL%g %gM%g %gL%g %g" stroke="%s" stroke-width="0.08"/>`+"\n",
+			float64(x)-0.25, float64(y)-0.25, float64(x)+0.25, float64(y)+0.25,
+			float64(x)+0.25, float64(y)-0.25, float64(x)-0.25, float64(y)+0.25, hex(theme.markerOn(board.Grid[position])))
+	}
+
+	// Move numbers, or a marker on the last move
+	if overlays.MoveNumbers {
+		out.WriteString(`<g font-size="0.45" text-anchor="middle" dominant-baseline="central">` + "\n")
+		for position, number := range board.MoveNumbers {
+			if number == 0 || board.Grid[position] == 0 {
+				continue
+			}
+			x, y := point(position)
+			fmt.Fprintf(&out, `<text x="%d" y="%d" fill="%s">%d</text>`+"\n", x, y, hex(theme.stoneOpposite(board.Grid[position])), number)
+		}
+		out.WriteString("</g>\n")
+	} else if lastMove >= 0 {
+		x, y := point(lastMove)
+		writeShape(&out, style.LastMove, float64(x), float64(y), 0.2, theme.markerOn(board.Grid[lastMove]))
+	}
+
+	out.WriteString("</svg>\n")
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// writeShape draws a marker shape (see Shapes) fitting in a circle of the given radius
+func writeShape(out *strings.Builder, shape string, cx, cy, radius float64, c color.RGBA) {
+	if shape == ShapeCircle {
+		fmt.Fprintf(out, `<circle cx="%g" cy="%g" r="%g" fill="%s"/>`+"\n", cx, cy, radius*0.75, hex(c))
+		return
+	}
+	for _, polygon := range polygons(shape, cx, cy, radius) {
+		points := make([]string, len(polygon))
+		for i, corner := range polygon {
+			points[i] = fmt.Sprintf("%.3f,%.3f", corner[0], corner[1])
+		}
+		fmt.Fprintf(out, `<polygon points="%s" fill="%s"/>`+"\n", strings.Join(points, " "), hex(c))
+	}
+}
+
+// hex formats a color for SVG
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
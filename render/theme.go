@@ -0,0 +1,153 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+	"sort"
+)
+
+// Theme is the palette a board is drawn with
+type Theme struct {
+	Board       color.RGBA
+	Lines       color.RGBA // Grid, star points and coordinates
+	BlackStone  color.RGBA
+	WhiteStone  color.RGBA
+	WhiteBorder color.RGBA
+	Marker      color.RGBA // Last move and dead stones
+	StoneMarker bool       // Draw markers on stones in the opposite stone color instead of Marker
+}
+
+// Themes lists the palettes by name
+// "high-contrast" is black and white only; "colorblind" replaces red, which red-blind
+// viewers can hardly tell from a black stone, with an orange visible on both colors
+var Themes = map[string]Theme{
+	"classic": {
+		Board:       color.RGBA{220, 179, 92, 255},
+		Lines:       color.RGBA{40, 30, 10, 255},
+		BlackStone:  color.RGBA{25, 25, 25, 255},
+		WhiteStone:  color.RGBA{245, 245, 240, 255},
+		WhiteBorder: color.RGBA{90, 90, 90, 255},
+		Marker:      color.RGBA{220, 40, 40, 255},
+	},
+	"high-contrast": {
+		Board:       color.RGBA{255, 255, 255, 255},
+		Lines:       color.RGBA{0, 0, 0, 255},
+		BlackStone:  color.RGBA{0, 0, 0, 255},
+		WhiteStone:  color.RGBA{255, 255, 255, 255},
+		WhiteBorder: color.RGBA{0, 0, 0, 255},
+		Marker:      color.RGBA{0, 0, 0, 255},
+		StoneMarker: true,
+	},
+	"colorblind": {
+		Board:       color.RGBA{232, 214, 170, 255},
+		Lines:       color.RGBA{40, 30, 10, 255},
+		BlackStone:  color.RGBA{25, 25, 25, 255},
+		WhiteStone:  color.RGBA{245, 245, 240, 255},
+		WhiteBorder: color.RGBA{90, 90, 90, 255},
+		Marker:      color.RGBA{230, 159, 0, 255},
+	},
+}
+
+// DefaultTheme is the theme used when none is asked for
+const DefaultTheme = "classic"
+
+// ThemeNames lists the names of the themes, sorted
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Shapes the last move can be marked with
+const (
+	ShapeCircle   = "circle"
+	ShapeSquare   = "square"
+	ShapeTriangle = "triangle"
+	ShapeCross    = "cross"
+	ShapeNone     = "none"
+
+	shapeDiamond = "diamond" // Marks white stones (see Style.StoneMarkers)
+)
+
+// Shapes lists the last move shapes
+var Shapes = []string{ShapeCircle, ShapeSquare, ShapeTriangle, ShapeCross, ShapeNone}
+
+// stoneShapes are the shapes of the stone markers (index 1 = black, 2 = white)
+var stoneShapes = [3]string{"", ShapeSquare, shapeDiamond}
+
+// Style selects how a board is drawn
+type Style struct {
+	Theme        Theme
+	LastMove     string // Shape of the last move marker (see Shapes)
+	StoneMarkers bool   // Mark black stones with a square and white stones with a diamond, so they differ by more than color
+}
+
+// DefaultStyle is the classic look
+var DefaultStyle = Style{Theme: Themes[DefaultTheme], LastMove: ShapeCircle}
+
+// NewStyle builds a style from a theme name and a last move shape ("" for the defaults)
+func NewStyle(theme, lastMove string, stoneMarkers bool) (Style, error) {
+	style := DefaultStyle
+	style.StoneMarkers = stoneMarkers
+	if theme != "" {
+		var ok bool
+		if style.Theme, ok = Themes[theme]; !ok {
+			return style, fmt.Errorf("unknown theme %q (one of %v)", theme, ThemeNames())
+		}
+	}
+	if lastMove != "" {
+		if !slices.Contains(Shapes, lastMove) {
+			return style, fmt.Errorf("unknown last move shape %q (one of %v)", lastMove, Shapes)
+		}
+		style.LastMove = lastMove
+	}
+	return style, nil
+}
+
+// markerOn is the color of a marker drawn on a stone
+func (t Theme) markerOn(stone int) color.RGBA {
+	switch {
+	case !t.StoneMarker:
+		return t.Marker
+	case stone == 1:
+		return t.WhiteStone
+	default:
+		return t.BlackStone
+	}
+}
+
+// stoneOpposite is the color that stands out on a stone
+func (t Theme) stoneOpposite(stone int) color.RGBA {
+	if stone == 1 {
+		return t.WhiteStone
+	}
+	return t.BlackStone
+}
+
+// polygons returns the corners of the polygons a shape is drawn with, centered on (cx, cy)
+// and fitting in a circle of the given radius; circles and "none" have none
+func polygons(shape string, cx, cy, radius float64) [][][2]float64 {
+	switch shape {
+	case ShapeSquare:
+		half := radius / math.Sqrt2
+		return [][][2]float64{{{cx - half, cy - half}, {cx + half, cy - half}, {cx + half, cy + half}, {cx - half, cy + half}}}
+	case shapeDiamond:
+		return [][][2]float64{{{cx, cy - radius}, {cx + radius, cy}, {cx, cy + radius}, {cx - radius, cy}}}
+	case ShapeTriangle:
+		dx, dy := radius*math.Sqrt(3)/2, radius/2
+		return [][][2]float64{{{cx, cy - radius}, {cx + dx, cy + dy}, {cx - dx, cy + dy}}}
+	case ShapeCross:
+		// Two bars, a fifth of the radius thick, crossing diagonally
+		arm, thick := radius/math.Sqrt2, radius*0.2
+		return [][][2]float64{
+			{{cx - arm - thick, cy - arm + thick}, {cx - arm + thick, cy - arm - thick}, {cx + arm + thick, cy + arm - thick}, {cx + arm - thick, cy + arm + thick}},
+			{{cx + arm - thick, cy - arm - thick}, {cx + arm + thick, cy - arm + thick}, {cx - arm + thick, cy + arm + thick}, {cx - arm - thick, cy + arm - thick}},
+		}
+	}
+	return nil
+}
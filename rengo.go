@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"go-game/game"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Consultation request structure
+type ConsultationRequest struct {
+	Member string `json:"member"` // Team member asking (start only; taken from the seat token once members hold seats)
+	End    bool   `json:"end"`    // End the consultation instead of starting one
+}
+
+// ConsultationState is the consultation time of both teams at a given moment
+type ConsultationState struct {
+	Consulting int              `json:"consulting"` // Team consulting (0 = none, 1 = black, 2 = white)
+	TimeLeft   [3]time.Duration `json:"timeLeft"`   // Consultation time left per team (index 1 = black, 2 = white)
+	Clock      *game.ClockState `json:"clock"`      // Game clock, paused while a team consults (nil for untimed games)
+}
+
+// consultationState takes a snapshot of the consultation time of a game
+func consultationState(board *game.Board, now time.Time) ConsultationState {
+	state := ConsultationState{Consulting: board.Consultation.Active, Clock: board.ClockState(now)}
+	for player := 1; player <= 2; player++ {
+		state.TimeLeft[player] = board.ConsultationLeft(player, now)
+	}
+	return state
+}
+
+// Start or end a consultation of the team to move in a rengo game
+// The team's game clock is paused until the consultation ends, the team moves,
+// or its consultation time runs out
+func consultTeam(c echo.Context) error {
+	gameID := c.Param("id")
+
+	var consultReq ConsultationRequest
+	if err := c.Bind(&consultReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	// Only the team to move consults, with the token of a member or their seat
+	if err := checkRequestSeat(c, board, board.CurrentPlayer); err != nil {
+		return c.JSON(seatStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	now := time.Now()
+	var err error
+	if consultReq.End {
+		err = board.EndConsultation(now)
+	} else {
+		err = board.StartConsultation(teamMember(board, seatToken(c), consultReq.Member), now)
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	saveGame(c.Request().Context(), gameID, board)
+
+	state := consultationState(board, now)
+	hub.Broadcast(Event{Type: EventConsult, GameID: gameID, Data: state})
+	return c.JSON(http.StatusOK, state)
+}
+
+// announceViolation tells everyone following a game, the opponent included, that a team
+// member tried to move out of rotation, if that is why a move was rejected
+func announceViolation(gameID string, err error) {
+	var moverErr *game.MoverError
+	if errors.As(err, &moverErr) && moverErr.Violation != nil {
+		hub.Broadcast(Event{Type: EventViolation, GameID: gameID, Data: moverErr.Violation})
+	}
+}
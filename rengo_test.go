@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"go-game/archive"
+	"go-game/game"
+	"go-game/rating"
+	"go-game/store"
+	"net/http"
+	"testing"
+)
+
+// rengoGame makes a live pair go game whose members hold seats, and returns its ID
+// with the tokens of the members
+func rengoGame(t *testing.T) (string, map[string]string) {
+	t.Helper()
+	if gameStore == nil {
+		gameStore, ratingStore, archiveStore = store.NewMemoryStore(), rating.NewMemoryStore(), archive.NewMemoryStore()
+	}
+
+	board := game.NewBoard(9)
+	if err := board.SetTeams([]string{"ann", "bob"}, []string{"cat", "dan"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := assignSeats(board, 0); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := assignMemberSeats(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := board.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	gameID := newGameID()
+	gamesMu.Lock()
+	unlock := addGame(gameID, board)
+	gamesMu.Unlock()
+	unlock()
+	t.Cleanup(func() { forgetGame(gameID) })
+	return gameID, tokens
+}
+
+func TestTeamMembersMoveWithTheirOwnTokens(t *testing.T) {
+	gameID, tokens := rengoGame(t)
+
+	// Bob can't play ann's turn by giving her name
+	rec := callHandler(makeMove, gameID, `{"position":40,"player":"ann"}`, caller{token: tokens["bob"]})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+	}
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		t.Fatal("game not found")
+	}
+	violations, moves := board.Violations, len(board.MoveHistory)
+	unlock()
+	if moves != 0 {
+		t.Errorf("%d moves played, want none", moves)
+	}
+	if len(violations) != 1 || violations[0].Member != "bob" || violations[0].Expected != "ann" {
+		t.Errorf("violations = %+v, want bob moving for ann", violations)
+	}
+
+	// The opponent's members hold white's seat, not black's
+	if rec := callHandler(makeMove, gameID, `{"position":40}`, caller{token: tokens["cat"]}); rec.Code != http.StatusForbidden {
+		t.Errorf("white member moving for black: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	// Ann moves with her token, whatever name is given
+	if rec := callHandler(makeMove, gameID, `{"position":40,"player":"bob"}`, caller{token: tokens["ann"]}); rec.Code != http.StatusOK {
+		t.Fatalf("ann moving: status = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := callHandler(makeMove, gameID, `{"position":30}`, caller{token: tokens["cat"]}); rec.Code != http.StatusOK {
+		t.Fatalf("cat moving: status = %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestMemberOf(t *testing.T) {
+	board := game.NewBoard(9)
+	if err := board.SetTeams([]string{"ann", "bob"}, []string{"cat"}); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := assignMemberSeats(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for member, team := range map[string]int{"ann": 1, "bob": 1, "cat": 2} {
+		if got, seat := board.MemberOf(tokens[member]); got != member || seat != team {
+			t.Errorf("%s's token holds %q of team %d", member, got, seat)
+		}
+		if seat := board.SeatOf(tokens[member]); seat != team {
+			t.Errorf("%s's token holds seat %d, want %d", member, seat, team)
+		}
+	}
+	if member, _ := board.MemberOf(newSeatToken()); member != "" {
+		t.Errorf("an unknown token holds %q", member)
+	}
+	if err := board.SetMemberSeat("eve", newSeatToken()); err == nil {
+		t.Error("a seat was given to someone not on a team")
+	}
+	var moverErr *game.MoverError
+	if err := board.CheckMover(teamMember(board, tokens["bob"], "ann")); !errors.As(err, &moverErr) {
+		t.Errorf("bob moved for ann: %v", err)
+	}
+}
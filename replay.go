@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"go-game/game"
+	"go-game/store"
+	"log"
+	"time"
+)
+
+// Replay pace limits
+const (
+	minReplayPace = 100 * time.Millisecond
+	maxReplayPace = time.Minute
+)
+
+// Why a replay ended
+const (
+	ReplayFinished = "finished" // Every move was sent
+	ReplayStopped  = "stopped"  // The client stopped it, or started it again
+)
+
+// ReplayStep is a finished game right after one of its moves, as a replay sends it
+// The first one is the position the replay starts from, which has no move at the start of the game
+type ReplayStep struct {
+	MoveNumber     int             `json:"moveNumber"`           // Moves played so far
+	Total          int             `json:"total"`                // Moves in the game
+	Player         int             `json:"player,omitempty"`     // Who made the move (1 = black, 2 = white)
+	Position       int             `json:"position"`             // Where the stone went (-1 for a pass)
+	Coordinate     string          `json:"coordinate,omitempty"` // Same in standard notation, e.g. "D4" or "pass"
+	Captured       []int           `json:"captured"`             // Stones the move took off the board
+	CapturedStones [3]int          `json:"capturedStones"`       // Prisoners of each player so far
+	Board          string          `json:"board"`                // Stones after the move, packed (see Board.PackedGrid)
+	Spent          time.Duration   `json:"spent,omitempty"`      // Thinking time the move took
+	Clock          *game.StepClock `json:"clock,omitempty"`      // Time both players had left after the move (timed games)
+}
+
+// newReplayStep describes a step of a replay
+func newReplayStep(step game.Step, size, total int) ReplayStep {
+	replayStep := ReplayStep{
+		MoveNumber:     step.Number,
+		Total:          total,
+		Position:       -1,
+		Captured:       step.Move.CapturedPositions,
+		CapturedStones: step.CapturedStones,
+		Board:          base64.StdEncoding.EncodeToString(game.PackGrid(step.Grid)),
+		Spent:          step.Move.Spent,
+		Clock:          step.Clock,
+	}
+	if step.Move.Player != 0 {
+		replayStep.Player = step.Move.Player
+		replayStep.Position = step.Move.Position
+		replayStep.Coordinate = game.FormatCoordinate(step.Move.Position, size)
+	}
+	if replayStep.Captured == nil {
+		replayStep.Captured = []int{}
+	}
+	return replayStep
+}
+
+// replayPace reads the pace of a replay request, in milliseconds; ok is false if it's out of bounds
+func replayPace(milliseconds int) (pace time.Duration, ok bool) {
+	if milliseconds < 0 || milliseconds > int(maxReplayPace/time.Millisecond) {
+		return 0, false
+	}
+	pace = time.Duration(milliseconds) * time.Millisecond
+	return pace, pace == 0 || pace >= minReplayPace
+}
+
+// replayControl is a request about a replay under way, from the client's request loop
+type replayControl struct {
+	kind string        // "step", "pace" or "stop"
+	pace time.Duration // New pace ("pace" only; 0 = one move per step request)
+}
+
+// replay is a replay under way on a connection
+type replay struct {
+	control chan replayControl
+	done    chan struct{} // Closed when the replay ends
+}
+
+// replaySteps reads a finished game, live or stored, and starts walking its moves
+func replaySteps(ctx context.Context, gameID string) (*game.Steps, int, error) {
+	board, unlock, live := lockGame(gameID)
+	if live {
+		defer unlock()
+	} else {
+		stored, err := gameStore.LoadGame(ctx, gameID)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, 0, errors.New("game not found")
+		}
+		if err != nil {
+			log.Printf("loading game %s: %v", gameID, err)
+			return nil, 0, errors.New("failed to load the game")
+		}
+		board = stored
+	}
+	if board.Phase != game.PhaseFinished {
+		return nil, 0, errors.New("only finished games can be replayed")
+	}
+	return game.NewSteps(board), board.Size, nil
+}
+
+// startReplay replays a finished game to the client from a move (req.From), one move every
+// req.Pace milliseconds, or one move per step request if the pace is 0
+// Replaying a game again starts over, stopping the replay under way
+func (s *socketClient) startReplay(ctx context.Context, req SocketRequest) {
+	pace, ok := replayPace(req.Pace)
+	if !ok {
+		s.sendError(req.GameID, "Invalid pace")
+		return
+	}
+
+	steps, size, err := replaySteps(ctx, req.GameID)
+	if err != nil {
+		s.sendError(req.GameID, err.Error())
+		return
+	}
+	if req.From < 0 || req.From > steps.Len() {
+		s.sendError(req.GameID, "Invalid move number")
+		return
+	}
+	for range req.From {
+		steps.Next()
+	}
+
+	// The replay under way says it stopped before the new one starts
+	s.mu.Lock()
+	previous := s.replays[req.GameID]
+	s.mu.Unlock()
+	if previous != nil && s.controlReplay(req.GameID, replayControl{kind: "stop"}) {
+		<-previous.done
+	}
+
+	r := &replay{control: make(chan replayControl), done: make(chan struct{})}
+	s.mu.Lock()
+	s.replays[req.GameID] = r
+	s.mu.Unlock()
+
+	go s.runReplay(ctx, req.GameID, r, steps, size, pace)
+}
+
+// controlReplay passes a request on to the replay of a game, reporting whether there was one
+func (s *socketClient) controlReplay(gameID string, control replayControl) bool {
+	s.mu.Lock()
+	r := s.replays[gameID]
+	s.mu.Unlock()
+	if r == nil {
+		return false
+	}
+
+	select {
+	case r.control <- control:
+		return true
+	case <-r.done:
+		return false // It ended in the meantime
+	}
+}
+
+// runReplay sends the moves of a replay until they run out, the client stops it or the connection closes
+func (s *socketClient) runReplay(ctx context.Context, gameID string, r *replay, steps *game.Steps, size int, pace time.Duration) {
+	defer func() {
+		s.mu.Lock()
+		if s.replays[gameID] == r {
+			delete(s.replays, gameID)
+		}
+		s.mu.Unlock()
+		close(r.done)
+	}()
+
+	ticker := time.NewTicker(maxReplayPace)
+	defer ticker.Stop()
+	var ticks <-chan time.Time
+	setPace := func(next time.Duration) {
+		ticks = nil
+		if next > 0 {
+			ticker.Reset(next)
+			ticks = ticker.C
+		}
+	}
+	setPace(pace)
+
+	endReplay := func(reason string) {
+		s.send(Event{Time: time.Now(), Type: SocketReplayEnd, GameID: gameID, Data: map[string]string{"reason": reason}})
+	}
+	// sendStep sends a step, or says the replay is over after the last one; false ends the replay
+	sendStep := func(step game.Step) bool {
+		if s.send(Event{Time: time.Now(), Type: SocketReplay, GameID: gameID, Data: newReplayStep(step, size, steps.Len())}) != nil {
+			return false
+		}
+		if steps.Done() {
+			endReplay(ReplayFinished)
+			return false
+		}
+		return true
+	}
+	next := func() bool {
+		step, _ := steps.Next()
+		return sendStep(step)
+	}
+
+	if !sendStep(steps.Position()) {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticks:
+			if !next() {
+				return
+			}
+		case control := <-r.control:
+			switch control.kind {
+			case "step":
+				if !next() {
+					return
+				}
+			case "pace":
+				setPace(control.pace)
+			case "stop":
+				endReplay(ReplayStopped)
+				return
+			}
+		}
+	}
+}
+
+// stepReplay sends the next move of a replay
+func (s *socketClient) stepReplay(req SocketRequest) {
+	if !s.controlReplay(req.GameID, replayControl{kind: "step"}) {
+		s.sendError(req.GameID, "No replay of this game")
+	}
+}
+
+// paceReplay changes the pace of a replay; 0 pauses it, leaving it to step requests
+func (s *socketClient) paceReplay(req SocketRequest) {
+	pace, ok := replayPace(req.Pace)
+	if !ok {
+		s.sendError(req.GameID, "Invalid pace")
+		return
+	}
+	if !s.controlReplay(req.GameID, replayControl{kind: "pace", pace: pace}) {
+		s.sendError(req.GameID, "No replay of this game")
+	}
+}
+
+// stopReplay ends a replay
+func (s *socketClient) stopReplay(req SocketRequest) {
+	if !s.controlReplay(req.GameID, replayControl{kind: "stop"}) {
+		s.sendError(req.GameID, "No replay of this game")
+	}
+}
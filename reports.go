@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-game/federation"
+	"go-game/game"
+	"go-game/store"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Tournament report request structure
+// A tournament is reported as its rounds, each listing the IDs of the games played in it
+type TournamentReportRequest struct {
+	Format   string              `json:"format"` // "egf" or "aga"
+	Name     string              `json:"name"`
+	Location string              `json:"location"`
+	Date     string              `json:"date"`
+	Players  []federation.Player `json:"players"`
+	Rounds   [][]string          `json:"rounds"`
+}
+
+// Generate a rating report for a finished tournament, in a format a federation accepts
+func tournamentReport(c echo.Context) error {
+	var reportReq TournamentReportRequest
+	if err := c.Bind(&reportReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	tournament := federation.Tournament{
+		Name:     reportReq.Name,
+		Location: reportReq.Location,
+		Date:     reportReq.Date,
+		Rounds:   len(reportReq.Rounds),
+		Players:  reportReq.Players,
+	}
+
+	for round, gameIDs := range reportReq.Rounds {
+		for _, gameID := range gameIDs {
+			board, unlock, exists := lockGame(gameID)
+			if exists {
+				board = board.Clone()
+				unlock()
+			} else {
+				stored, err := gameStore.LoadGame(c.Request().Context(), gameID)
+				if errors.Is(err, store.ErrCorrupted) {
+					log.Printf("loading game %s: %v", gameID, err)
+					return c.JSON(http.StatusConflict, map[string]string{"error": fmt.Sprintf("Game %s failed its integrity check", gameID)})
+				}
+				if err != nil {
+					return c.JSON(http.StatusNotFound, map[string]string{"error": fmt.Sprintf("Game %s not found", gameID)})
+				}
+				board = stored
+			}
+
+			g, err := tournamentGame(board, round+1)
+			if err != nil {
+				return c.JSON(http.StatusConflict, map[string]string{"error": fmt.Sprintf("Game %s: %s", gameID, err)})
+			}
+			tournament.Games = append(tournament.Games, g)
+		}
+	}
+
+	var report bytes.Buffer
+	var err error
+	switch reportReq.Format {
+	case "egf":
+		err = tournament.WriteEGF(&report)
+	case "aga":
+		err = tournament.WriteAGA(&report)
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Format must be egf or aga"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	filename := map[string]string{"egf": "results.h9", "aga": "results.txt"}[reportReq.Format]
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return c.Blob(http.StatusOK, "text/plain; charset=utf-8", report.Bytes())
+}
+
+// tournamentGame describes a finished game for a rating report
+// Players are identified by the names in the game information
+func tournamentGame(board *game.Board, round int) (federation.Game, error) {
+	if board.Result == nil {
+		return federation.Game{}, fmt.Errorf("the game hasn't finished")
+	}
+	if !board.IsRated() {
+		return federation.Game{}, fmt.Errorf("the game isn't rated")
+	}
+
+	return federation.Game{
+		Round:    round,
+		Black:    board.Info.BlackName,
+		White:    board.Info.WhiteName,
+		Winner:   board.Result.Winner,
+		Handicap: board.HandicapStones(),
+		Komi:     board.Komi,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"go-game/game"
+	"go-game/i18n"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ResultDescription is the result of a game as data, with the text to show people
+// Clients with their own translations can build the text from Key and the data instead
+type ResultDescription struct {
+	Winner   int     `json:"winner"`           // 1 = black, 2 = white, 0 = nobody
+	Method   string  `json:"method"`           // How the game ended (see the game.Reason* constants)
+	Margin   float64 `json:"margin,omitempty"` // Points won by, for counted games
+	Notation string  `json:"notation"`         // Short notation used in game records, e.g. "B+3.5"
+	Key      string  `json:"key"`              // Message key of Text
+	Language string  `json:"language"`         // Language of Text
+	Text     string  `json:"text"`             // e.g. "Black wins by 3.5 points"
+}
+
+// resultKeys maps each way a game can end to its message
+var resultKeys = map[string]string{
+	game.ReasonScore:   i18n.ResultScore,
+	game.ReasonResign:  i18n.ResultResign,
+	game.ReasonTimeout: i18n.ResultTime,
+	game.ReasonCapture: i18n.ResultCapture,
+	game.ReasonNoMoves: i18n.ResultNoMoves,
+}
+
+// colorKeys maps each player to the message of its color name
+var colorKeys = [3]string{1: i18n.ColorBlack, 2: i18n.ColorWhite}
+
+// describeResult describes a result in a language
+func describeResult(result *game.Result, lang i18n.Language) ResultDescription {
+	description := ResultDescription{
+		Winner:   result.Winner,
+		Method:   result.Reason,
+		Margin:   result.Margin,
+		Notation: result.String(),
+		Key:      resultKeys[result.Reason],
+		Language: string(lang),
+	}
+
+	switch {
+	case result.Reason == game.ReasonNoResult:
+		description.Key = i18n.ResultVoid
+	case result.Reason == game.ReasonAnnulled:
+		description.Key = i18n.ResultAnnul
+	case result.Winner != 1 && result.Winner != 2:
+		description.Key = i18n.ResultJigo
+	case description.Key == "":
+		description.Key = i18n.ResultResign // Unknown ways of winning read as the opponent giving up
+	}
+
+	if result.Winner == 1 || result.Winner == 2 {
+		description.Text = i18n.T(lang, description.Key,
+			"winner", i18n.T(lang, colorKeys[result.Winner]),
+			"loser", i18n.T(lang, colorKeys[3-result.Winner]),
+			"margin", strconv.FormatFloat(result.Margin, 'f', -1, 64))
+	} else {
+		description.Text = i18n.T(lang, description.Key)
+	}
+	return description
+}
+
+// languageOf picks the language of a request: ?lang= if supported, then the Accept-Language header
+func languageOf(c echo.Context) i18n.Language {
+	if lang, ok := i18n.Parse(c.QueryParam("lang")); ok {
+		return lang
+	}
+	return i18n.Negotiate(c.Request().Header.Get("Accept-Language"))
+}
+
+// Describe the result of a finished game in the language of the request
+func getResult(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+	if board.Result == nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Game is not finished"})
+	}
+
+	return c.JSON(http.StatusOK, describeResult(board.Result, languageOf(c)))
+}
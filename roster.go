@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-game/federation"
+	"go-game/roster"
+	"log"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxRosterSize is how many lines a roster upload may have
+const maxRosterSize = 5000
+
+// rosterStore keeps the entrants registered for tournaments (Postgres if DATABASE_URL is set, memory otherwise)
+var rosterStore roster.Store
+
+// newRosterStoreFromEnv opens the registration store next to the games
+func newRosterStoreFromEnv() (roster.Store, error) {
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		return roster.NewPostgresStore(url)
+	}
+	return roster.NewMemoryStore(), nil
+}
+
+// rosterImports makes roster imports wait for each other, so an entrant on two rosters
+// uploaded at once is only registered and invited once
+var rosterImports sync.Mutex
+
+// publicBaseURL is where users reach the server, e.g. "https://go.example.com" (from PUBLIC_BASE_URL)
+// Links sent out of band are built from it, never from the Host header a client sent;
+// without it, entrants aren't invited
+var publicBaseURL string
+
+// publicBaseURLFromEnv reads PUBLIC_BASE_URL, an absolute http or https URL
+func publicBaseURLFromEnv() (string, error) {
+	value := os.Getenv("PUBLIC_BASE_URL")
+	if value == "" {
+		return "", nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("PUBLIC_BASE_URL must be an http or https URL, e.g. https://go.example.com")
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// AccountDirectory finds the accounts players already have on this server
+type AccountDirectory interface {
+	// FindAccount returns the ID of the account with the email address, or else the name ("" if none)
+	FindAccount(ctx context.Context, email, name string) (string, error)
+}
+
+// noAccounts is the directory of a server without player accounts: every entrant is new
+type noAccounts struct{}
+
+func (noAccounts) FindAccount(ctx context.Context, email, name string) (string, error) {
+	return "", nil
+}
+
+// accountDirectory is where roster imports look for existing accounts
+var accountDirectory AccountDirectory = noAccounts{}
+
+// Invitation asks an entrant without an account to create one
+type Invitation struct {
+	To         string // Email address
+	Name       string
+	Tournament string
+	Link       string // Where to sign up
+}
+
+// Mailer sends invitation emails
+type Mailer interface {
+	SendInvitation(ctx context.Context, invitation Invitation) error
+}
+
+// logMailer writes invitations to the log, for servers without a mail relay
+type logMailer struct{}
+
+func (logMailer) SendInvitation(ctx context.Context, invitation Invitation) error {
+	log.Printf("invitation for %s <%s> to %s: %s", invitation.Name, invitation.To, invitation.Tournament, invitation.Link)
+	return nil
+}
+
+// smtpMailer sends invitations through a mail relay
+type smtpMailer struct {
+	addr string    // host:port
+	from string    // Sender address
+	auth smtp.Auth // nil for relays that don't ask for a login
+}
+
+func (m smtpMailer) SendInvitation(ctx context.Context, invitation Invitation) error {
+	subject := mime.QEncoding.Encode("utf-8", "Invitation to "+invitation.Tournament)
+	body := fmt.Sprintf("Hello %s,\r\n\r\nYou have been registered for %s.\r\nCreate your account to take part: %s\r\n",
+		invitation.Name, invitation.Tournament, invitation.Link)
+	message := "From: " + m.from + "\r\nTo: " + invitation.To + "\r\nSubject: " + subject +
+		"\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n" + body
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{invitation.To}, []byte(message))
+}
+
+// newMailer sends mail through SMTP_ADDR (host:port) as SMTP_FROM, logging in with
+// SMTP_USERNAME and SMTP_PASSWORD if set; without a relay, invitations are only logged
+func newMailer() Mailer {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return logMailer{}
+	}
+
+	mailer := smtpMailer{addr: addr, from: os.Getenv("SMTP_FROM")}
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		mailer.auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return mailer
+}
+
+// mailer sends the invitations of roster imports
+var mailer = newMailer()
+
+// RosterEntry is the outcome of one roster line
+type RosterEntry struct {
+	Line      int    `json:"line"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`              // See the Roster* constants
+	AccountID string `json:"accountId,omitempty"` // Account the entrant was matched with
+	Error     string `json:"error,omitempty"`     // Why the line was rejected or skipped
+}
+
+// Outcomes of roster lines
+const (
+	RosterRegistered = "registered" // Matched with an existing account and registered
+	RosterInvited    = "invited"    // Registered, and invited to create an account
+	RosterNoEmail    = "no_email"   // Registered, but can't be invited without an email address
+	RosterUninvited  = "uninvited"  // Registered, but not invited: the server has no PUBLIC_BASE_URL to link to
+	RosterDuplicate  = "duplicate"  // Already registered for the tournament, or listed twice
+	RosterRejected   = "rejected"   // Invalid entry
+)
+
+// Roster import response structure
+type RosterImportReport struct {
+	Tournament string         `json:"tournament"`
+	DryRun     bool           `json:"dryRun"` // Nothing was registered or sent
+	Entries    []RosterEntry  `json:"entries"`
+	Counts     map[string]int `json:"counts"` // Entries per status
+}
+
+// Pre-register the entrants of a tournament from a CSV or JSON roster (see federation.ParseRosterCSV)
+// Entrants are matched with existing accounts; those without one are emailed an invitation
+// ?dryRun=true only reports what the import would do
+func importRoster(c echo.Context) error {
+	tournament, err := url.PathUnescape(c.Param("name"))
+	if err != nil || strings.TrimSpace(tournament) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tournament"})
+	}
+	dryRun := c.QueryParam("dryRun") == "true"
+
+	// Parse the roster
+	var lines []federation.RosterLine
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case "text/csv":
+		lines, err = federation.ParseRosterCSV(c.Request().Body)
+	case "application/json":
+		lines, err = federation.ParseRosterJSON(c.Request().Body)
+	default:
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]string{"error": "Send the roster as text/csv or application/json"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if len(lines) > maxRosterSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": fmt.Sprintf("Rosters are limited to %d entrants", maxRosterSize)})
+	}
+	federation.CheckRoster(lines)
+
+	ctx := c.Request().Context()
+	report := RosterImportReport{Tournament: tournament, DryRun: dryRun, Entries: make([]RosterEntry, 0, len(lines)), Counts: make(map[string]int)}
+	invitations := make([]Invitation, 0)
+
+	rosterImports.Lock()
+	defer rosterImports.Unlock()
+
+	// The entrants registered already, by lower case name
+	existing, err := rosterStore.List(ctx, tournament)
+	if err != nil {
+		log.Printf("loading the roster of %s: %v", tournament, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load the roster"})
+	}
+	registered := make(map[string]bool, len(existing)+len(lines))
+	for _, registration := range existing {
+		registered[strings.ToLower(registration.Name)] = true
+	}
+	added := make([]roster.Registration, 0, len(lines))
+	for _, line := range lines {
+		entry := RosterEntry{Line: line.Line, Name: line.Name}
+		var duplicate *federation.DuplicateError
+		switch exists := registered[strings.ToLower(line.Name)]; {
+		case errors.As(line.Err, &duplicate):
+			entry.Status, entry.Error = RosterDuplicate, line.Err.Error()
+		case line.Err != nil:
+			entry.Status, entry.Error = RosterRejected, line.Err.Error()
+		case exists:
+			entry.Status, entry.Error = RosterDuplicate, "Already registered for the tournament"
+		}
+		if entry.Status != "" {
+			report.Entries = append(report.Entries, entry)
+			report.Counts[entry.Status]++
+			continue
+		}
+
+		registration := roster.Registration{Entrant: line.Entrant, RegisteredAt: time.Now()}
+		if registration.AccountID, err = accountDirectory.FindAccount(ctx, line.Email, line.Name); err != nil {
+			log.Printf("looking up account of %s: %v", line.Name, err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to look up accounts"})
+		}
+		switch {
+		case registration.AccountID != "":
+			entry.Status, entry.AccountID = RosterRegistered, registration.AccountID
+		case line.Email == "":
+			entry.Status = RosterNoEmail
+		case publicBaseURL == "":
+			entry.Status = RosterUninvited
+		default:
+			entry.Status, registration.Invited = RosterInvited, true
+			invitations = append(invitations, Invitation{To: line.Email, Name: line.Name, Tournament: tournament, Link: publicBaseURL + "/"})
+		}
+		registered[strings.ToLower(line.Name)] = true
+		added = append(added, registration)
+		report.Entries = append(report.Entries, entry)
+		report.Counts[entry.Status]++
+	}
+	if !dryRun {
+		if err := rosterStore.Add(ctx, tournament, added); err != nil {
+			log.Printf("registering the roster of %s: %v", tournament, err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to register the entrants"})
+		}
+	}
+
+	// A roster can be long and mail relays slow, so invitations go out after the response
+	if !dryRun {
+		go sendInvitations(context.WithoutCancel(ctx), invitations)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// sendInvitations mails the invitations one by one, logging the ones that fail
+func sendInvitations(ctx context.Context, invitations []Invitation) {
+	for _, invitation := range invitations {
+		if err := mailer.SendInvitation(ctx, invitation); err != nil {
+			log.Printf("inviting %s to %s: %v", invitation.To, invitation.Tournament, err)
+		}
+	}
+}
+
+// List the entrants registered for a tournament, by name
+// Email addresses and accounts stay private, so only the federation details are listed
+func listRoster(c echo.Context) error {
+	tournament, err := url.PathUnescape(c.Param("name"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tournament"})
+	}
+
+	registrations, err := rosterStore.List(c.Request().Context(), tournament)
+	if err != nil {
+		log.Printf("loading the roster of %s: %v", tournament, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load the roster"})
+	}
+	entrants := make([]federation.Player, 0, len(registrations))
+	for _, registration := range registrations {
+		entrants = append(entrants, registration.Player)
+	}
+
+	sort.Slice(entrants, func(i, j int) bool { return entrants[i].Name < entrants[j].Name })
+	return c.JSON(http.StatusOK, entrants)
+}
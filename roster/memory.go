@@ -0,0 +1,47 @@
+package roster
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// MemoryStore keeps the registrations in memory, for servers without a database
+type MemoryStore struct {
+	mu          sync.Mutex
+	tournaments map[string]map[string]Registration // By tournament, then lower case name
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tournaments: make(map[string]map[string]Registration)}
+}
+
+func (s *MemoryStore) List(ctx context.Context, tournament string) ([]Registration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Registration, 0, len(s.tournaments[tournament]))
+	for _, registration := range s.tournaments[tournament] {
+		list = append(list, registration)
+	}
+	return list, nil
+}
+
+func (s *MemoryStore) Add(ctx context.Context, tournament string, registrations []Registration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	registered := s.tournaments[tournament]
+	if registered == nil {
+		registered = make(map[string]Registration)
+		s.tournaments[tournament] = registered
+	}
+	for _, registration := range registrations {
+		key := strings.ToLower(registration.Name)
+		if _, exists := registered[key]; !exists {
+			registered[key] = registration
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package roster
+
+import (
+	"context"
+	"database/sql"
+
+	_ "github.com/lib/pq" // Postgres driver for database/sql
+)
+
+// schema creates the tables the store needs
+const schema = `
+CREATE TABLE IF NOT EXISTS tournament_entrants (
+	tournament    TEXT NOT NULL,
+	name          TEXT NOT NULL,
+	player_id     TEXT NOT NULL,
+	rank          TEXT NOT NULL,
+	country       TEXT NOT NULL,
+	club          TEXT NOT NULL,
+	email         TEXT NOT NULL,
+	account_id    TEXT NOT NULL,
+	invited       BOOLEAN NOT NULL,
+	registered_at TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS tournament_entrants_name ON tournament_entrants (tournament, lower(name));
+`
+
+// columns are the registration columns, in the order List reads them
+const columns = `name, player_id, rank, country, club, email, account_id, invited, registered_at`
+
+// PostgresStore keeps the registrations in Postgres
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to the database and creates the schema
+func NewPostgresStore(url string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close releases the database connections
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks the database can be reached
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *PostgresStore) List(ctx context.Context, tournament string) ([]Registration, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+columns+` FROM tournament_entrants WHERE tournament = $1`, tournament)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Registration, 0)
+	for rows.Next() {
+		var r Registration
+		if err := rows.Scan(&r.Name, &r.ID, &r.Rank, &r.Country, &r.Club, &r.Email, &r.AccountID, &r.Invited, &r.RegisteredAt); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+func (s *PostgresStore) Add(ctx context.Context, tournament string, registrations []Registration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, r := range registrations {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO tournament_entrants (tournament, `+columns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT DO NOTHING`,
+			tournament, r.Name, r.ID, r.Rank, r.Country, r.Club, r.Email, r.AccountID, r.Invited, r.RegisteredAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
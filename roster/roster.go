@@ -0,0 +1,25 @@
+// Package roster keeps the entrants pre-registered for tournaments
+package roster
+
+import (
+	"context"
+	"go-game/federation"
+	"time"
+)
+
+// Registration is an entrant pre-registered for a tournament
+type Registration struct {
+	federation.Entrant
+	AccountID    string    `json:"accountId,omitempty"` // Existing account the entrant was matched with
+	Invited      bool      `json:"invited"`             // An invitation to create an account was sent
+	RegisteredAt time.Time `json:"registeredAt"`
+}
+
+// Store keeps the registrations
+// Entrants are told apart by name, compared case-insensitively, within a tournament
+type Store interface {
+	// List returns the registrations of a tournament
+	List(ctx context.Context, tournament string) ([]Registration, error)
+	// Add registers entrants for a tournament, leaving out those already registered
+	Add(ctx context.Context, tournament string, registrations []Registration) error
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go-game/users"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Sandbox games let developers try out the whole API without leaving traces:
+// they are never saved, rated, featured or sent to the firehose, and are purged
+// some time after they were created
+// Throwaway sandbox accounts play them: they only live in memory, every game they
+// play in is a sandbox game, and they are purged like the games
+
+// sandboxPrefix starts the ID of every sandbox game, so they can be told apart without the lock
+const sandboxPrefix = "sandbox-"
+
+// Sandbox limits
+const (
+	defaultSandboxTTL    = time.Hour
+	maxSandboxGames      = 1000        // Sandbox games alive at once
+	maxSandboxAccounts   = 1000        // Sandbox accounts alive at once
+	sandboxPurgeInterval = time.Minute // How often expired sandbox games are purged
+)
+
+// sandboxTTL is how long a sandbox game lives (SANDBOX_TTL, set in main)
+var sandboxTTL = defaultSandboxTTL
+
+// sandboxGames records when each live sandbox game was created (guarded by gamesMu)
+var sandboxGames = make(map[string]time.Time)
+
+// Sandbox accounts alive, with when each was created, and the accounts themselves
+var (
+	sandboxAccountsMu sync.Mutex
+	sandboxAccounts   = make(map[string]time.Time)
+	sandboxUsers      = users.NewMemoryStore()
+)
+
+// isSandbox checks if a game or account ID belongs to the sandbox
+func isSandbox(id string) bool {
+	return strings.HasPrefix(id, sandboxPrefix)
+}
+
+// sandboxUserStore keeps sandbox accounts in memory next to the real ones, so they never reach the database
+type sandboxUserStore struct {
+	users.Store
+}
+
+// storeOf returns the store that keeps the account with an ID or username
+func (s sandboxUserStore) storeOf(key string) users.Store {
+	if isSandbox(strings.ToLower(key)) {
+		return sandboxUsers
+	}
+	return s.Store
+}
+
+func (s sandboxUserStore) Create(ctx context.Context, user users.User) error {
+	return s.storeOf(user.ID).Create(ctx, user)
+}
+
+func (s sandboxUserStore) Get(ctx context.Context, id string) (users.User, error) {
+	return s.storeOf(id).Get(ctx, id)
+}
+
+func (s sandboxUserStore) FindByUsername(ctx context.Context, username string) (users.User, error) {
+	return s.storeOf(username).FindByUsername(ctx, username)
+}
+
+func (s sandboxUserStore) Update(ctx context.Context, user users.User) error {
+	return s.storeOf(user.ID).Update(ctx, user)
+}
+
+// Ping checks the store of the real accounts can be reached, if it depends on a server
+func (s sandboxUserStore) Ping(ctx context.Context) error {
+	return pingStore(ctx, s.Store)
+}
+
+// Sandbox account request structure
+type SandboxAccountRequest struct {
+	DisplayName string `json:"displayName"` // Defaults to the username
+}
+
+// SandboxAccountResponse is a new sandbox account, signed in
+type SandboxAccountResponse struct {
+	SignInResponse
+	ExpiresAt time.Time `json:"expiresAt"` // When the account is purged, and its tokens stop working
+}
+
+// Create a throwaway account to try out the API with, signed in
+// It has no password, so its tokens are the only way to use it
+func createSandboxAccount(c echo.Context) error {
+	var accountReq SandboxAccountRequest
+	if err := c.Bind(&accountReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	var id [6]byte
+	rand.Read(id[:])
+	now := time.Now().UTC()
+	user := users.User{
+		ID:          sandboxPrefix + hex.EncodeToString(id[:]),
+		DisplayName: accountReq.DisplayName,
+		CreatedAt:   now,
+	}
+	user.Username = user.ID
+	if err := user.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	sandboxAccountsMu.Lock()
+	if len(sandboxAccounts) >= maxSandboxAccounts {
+		sandboxAccountsMu.Unlock()
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Too many sandbox accounts, try again later"})
+	}
+	sandboxAccounts[user.ID] = now
+	sandboxAccountsMu.Unlock()
+
+	if err := userStore.Create(c.Request().Context(), user); err != nil {
+		sandboxAccountsMu.Lock()
+		delete(sandboxAccounts, user.ID)
+		sandboxAccountsMu.Unlock()
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	tokens, err := issueTokens(user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, SandboxAccountResponse{SignInResponse: SignInResponse{User: user, Tokens: tokens}, ExpiresAt: now.Add(sandboxTTL)})
+}
+
+// parseSandboxTTL reads the lifetime of sandbox games, e.g. "30m" (empty = one hour)
+func parseSandboxTTL(config string) (time.Duration, error) {
+	if strings.TrimSpace(config) == "" {
+		return defaultSandboxTTL, nil
+	}
+	ttl, err := time.ParseDuration(strings.TrimSpace(config))
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid sandbox lifetime %q", config)
+	}
+	return ttl, nil
+}
+
+// runSandboxPurger removes expired sandbox games at every interval until the context is cancelled
+func runSandboxPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			purgeSandboxGames(now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// purgeSandboxGames forgets the sandbox games created more than sandboxTTL ago, with everything attached to them
+func purgeSandboxGames(now time.Time) {
+	gamesMu.Lock()
+	expired := make([]string, 0)
+	for gameID, created := range sandboxGames {
+		if now.Sub(created) >= sandboxTTL {
+			expired = append(expired, gameID)
+		}
+	}
+	gamesMu.Unlock()
+
+	for _, gameID := range expired {
+		if _, unlock, exists := lockGame(gameID); exists {
+			forgetGame(gameID)
+			unlock()
+		}
+		if err := chatStore.DeleteGame(context.Background(), gameID); err != nil {
+			log.Printf("deleting the chat of sandbox game %s: %v", gameID, err)
+		}
+	}
+
+	purgeSandboxAccounts(now)
+}
+
+// purgeSandboxAccounts deletes the sandbox accounts created more than sandboxTTL ago, and signs them out
+func purgeSandboxAccounts(now time.Time) {
+	sandboxAccountsMu.Lock()
+	expired := make([]string, 0)
+	for userID, created := range sandboxAccounts {
+		if now.Sub(created) >= sandboxTTL {
+			expired = append(expired, userID)
+			delete(sandboxAccounts, userID)
+		}
+	}
+	sandboxAccountsMu.Unlock()
+
+	ctx := context.Background()
+	for _, userID := range expired {
+		tokenIssuer.Revoke(userID, now)
+		if err := sessionStore.DeleteUser(ctx, userID, ""); err != nil {
+			log.Printf("signing out sandbox account %s: %v", userID, err)
+		}
+		sandboxUsers.Delete(ctx, userID)
+	}
+}
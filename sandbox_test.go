@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-game/auth"
+	"go-game/sessions"
+	"go-game/users"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// sandboxServer sets up the stores and the token issuer sandbox accounts need, and
+// returns the store of the real accounts
+func sandboxServer(t *testing.T) users.Store {
+	t.Helper()
+	issuer, err := auth.NewIssuer([]byte(strings.Repeat("j", 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	real := users.NewMemoryStore()
+
+	previousIssuer, previousUsers, previousSessions := tokenIssuer, userStore, sessionStore
+	tokenIssuer, userStore, sessionStore = issuer, sandboxUserStore{Store: real}, sessions.NewMemoryStore()
+	t.Cleanup(func() { tokenIssuer, userStore, sessionStore = previousIssuer, previousUsers, previousSessions })
+	return real
+}
+
+func TestSandboxAccountsArePurged(t *testing.T) {
+	real := sandboxServer(t)
+	ctx := context.Background()
+
+	req := httptest.NewRequest(http.MethodPost, "/sandbox/accounts", strings.NewReader(`{"displayName":"Tester"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := createSandboxAccount(echo.New().NewContext(req, rec)); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, %v: %s", rec.Code, err, rec.Body)
+	}
+	var created SandboxAccountResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	userID := created.User.ID
+
+	if !isSandbox(userID) || created.User.DisplayName != "Tester" {
+		t.Errorf("account = %+v, want a sandbox account", created.User)
+	}
+	if _, err := real.Get(ctx, userID); !errors.Is(err, users.ErrNotFound) {
+		t.Errorf("the sandbox account reached the real accounts: %v", err)
+	}
+	if _, err := userStore.Get(ctx, userID); err != nil {
+		t.Errorf("the sandbox account can't be found: %v", err)
+	}
+	if _, err := tokenIssuer.Verify(created.AccessToken, auth.KindAccess, time.Now()); err != nil {
+		t.Errorf("access token rejected: %v", err)
+	}
+
+	// Not yet expired
+	purgeSandboxAccounts(time.Now())
+	if _, err := userStore.Get(ctx, userID); err != nil {
+		t.Errorf("the account was purged early: %v", err)
+	}
+
+	purgeSandboxAccounts(time.Now().Add(sandboxTTL + time.Second))
+	if _, err := userStore.Get(ctx, userID); !errors.Is(err, users.ErrNotFound) {
+		t.Errorf("the expired account is still there: %v", err)
+	}
+	if _, err := tokenIssuer.Verify(created.AccessToken, auth.KindAccess, time.Now().Add(time.Second)); err == nil {
+		t.Error("the expired account's token still works")
+	}
+}
+
+func TestSandboxAccountsOnlyPlaySandboxGames(t *testing.T) {
+	sandboxServer(t)
+	account := users.User{ID: sandboxPrefix + "0123456789ab", Username: sandboxPrefix + "0123456789ab", DisplayName: "Tester"}
+	if err := userStore.Create(context.Background(), account); err != nil {
+		t.Fatal(err)
+	}
+
+	gameID, _, _, unlock, err := createGame(context.Background(), NewGameRequest{Size: 9, Color: 1}, account, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	t.Cleanup(func() {
+		forgetGame(gameID)
+		gamesMu.Lock()
+		delete(sandboxGames, gameID)
+		gamesMu.Unlock()
+	})
+	if !isSandbox(gameID) {
+		t.Errorf("game %s of a sandbox account isn't a sandbox game", gameID)
+	}
+}
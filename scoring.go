@@ -0,0 +1,148 @@
+package main
+
+import (
+	"go-game/game"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Dead stone request structure
+type DeadStoneRequest struct {
+	Position int `json:"position"` // Any stone of the group to mark dead (or alive again)
+}
+
+// Score acceptance request structure
+type AcceptScoreRequest struct {
+	Player int `json:"player"` // Player accepting the score (1 = black, 2 = white); defaults to the seat of the request
+}
+
+// Score response structure
+type ScoreResponse struct {
+	Score         game.Score `json:"score"`         // Count of the position with the current marking
+	DeadStones    []int      `json:"deadStones"`    // Stones currently marked as dead
+	ScoreAccepted [3]bool    `json:"scoreAccepted"` // Which players accepted (index 1 = black, 2 = white)
+}
+
+// newScoreResponse builds the scoring summary of a game
+func newScoreResponse(board *game.Board) ScoreResponse {
+	return ScoreResponse{
+		Score:         board.Score(),
+		DeadStones:    board.DeadStones,
+		ScoreAccepted: board.ScoreAccepted,
+	}
+}
+
+// Get the current score of a game
+func getScore(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+	if board.Concealed() {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not available while stones are hidden"})
+	}
+
+	return c.JSON(http.StatusOK, newScoreResponse(board))
+}
+
+// Mark a group as dead (or alive again) during scoring
+func markDeadStones(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	// Only the players may mark stones, with the token of their seat
+	if err := checkRequestSeat(c, board, requestSeat(c, board)); err != nil {
+		return c.JSON(seatStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	// Parse the request
+	var deadReq DeadStoneRequest
+	if err := c.Bind(&deadReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := board.ToggleDead(deadReq.Position); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	saveGame(c.Request().Context(), gameID, board)
+
+	response := newScoreResponse(board)
+	hub.Broadcast(Event{Type: EventScoring, GameID: gameID, Data: response})
+	return c.JSON(http.StatusOK, response)
+}
+
+// Accept the score; the result is final once both players have accepted
+func acceptScore(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	// Parse the request
+	var acceptReq AcceptScoreRequest
+	if err := c.Bind(&acceptReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	// Players only accept for their own seat
+	player := acceptReq.Player
+	if player == 0 {
+		player = requestSeat(c, board)
+	}
+	if err := checkRequestSeat(c, board, player); err != nil {
+		return c.JSON(seatStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	phase := board.Phase
+	if err := board.AcceptScore(player); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	saveGame(c.Request().Context(), gameID, board)
+
+	hub.Broadcast(Event{Type: EventScoring, GameID: gameID, Data: newScoreResponse(board)})
+	announceResult(gameID, board, phase)
+	verifyScoreAsync(c.Request().Context(), gameID, board)
+	return respondBoard(c, http.StatusOK, gameID, board)
+}
+
+// Resume play after the players disagreed on the dead stones
+func resumePlay(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+
+	// Only the players may go back to playing
+	if err := checkRequestSeat(c, board, requestSeat(c, board)); err != nil {
+		return c.JSON(seatStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	if err := board.ResumePlay(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	saveGame(c.Request().Context(), gameID, board)
+
+	hub.Broadcast(Event{Type: EventPlayResumed, GameID: gameID, Data: board.CurrentPlayer})
+	scheduleBotMove(c.Request().Context(), gameID, board)
+	return respondBoard(c, http.StatusOK, gameID, board.ViewFor(viewerOf(c, board)))
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"go-game/archive"
+	"go-game/auth"
+	"go-game/game"
+	"go-game/rating"
+	"go-game/store"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Seats of the games in these tests
+const (
+	whiteUserID = "white-user" // Account playing white
+	otherUserID = "other-user" // Signed in, but not playing
+)
+
+// scoringSetup describes a game to put into the scoring phase
+type scoringSetup struct {
+	unseated bool // Open to anyone, like older and imported games
+	hotseat  bool // Both colors played on one device
+}
+
+// scoringGame makes a live game that has just entered the scoring phase, with white played
+// by an account, and returns its ID and the seat tokens
+func scoringGame(t *testing.T, setup scoringSetup) (string, [3]string) {
+	t.Helper()
+	if gameStore == nil {
+		gameStore, ratingStore, archiveStore = store.NewMemoryStore(), rating.NewMemoryStore(), archive.NewMemoryStore()
+	}
+
+	board := game.NewBoard(9)
+	var tokens [3]string
+	if !setup.unseated {
+		var err error
+		if tokens, err = assignSeats(board, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if setup.hotseat {
+		if err := board.SetHotseat(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := board.SitDown(2, whiteUserID); err != nil {
+		t.Fatal(err)
+	}
+	if err := board.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for _, play := range []func() error{func() error { return board.MakeMove(40) }, board.Pass, board.Pass} {
+		if err := play(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if board.Phase != game.PhaseScoring {
+		t.Fatalf("phase = %s, want scoring", board.Phase)
+	}
+
+	gameID := newGameID()
+	gamesMu.Lock()
+	unlock := addGame(gameID, board)
+	gamesMu.Unlock()
+	unlock()
+	t.Cleanup(func() { forgetGame(gameID) })
+	return gameID, tokens
+}
+
+// caller is who sends a request: a seat token and a signed in account, either may be empty
+type caller struct {
+	token  string
+	userID string
+}
+
+// callHandler sends a POST request with a JSON body to a handler of a game route
+func callHandler(handler echo.HandlerFunc, gameID, body string, from caller) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if from.token != "" {
+		req.Header.Set(headerSeatToken, from.token)
+	}
+	rec := httptest.NewRecorder()
+
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(gameID)
+	if from.userID != "" {
+		c.Set(userKey, auth.User{ID: from.userID})
+	}
+	handler(c)
+	return rec
+}
+
+// scoreAccepted reads which players accepted the score of a live game
+func scoreAccepted(t *testing.T, gameID string) [3]bool {
+	t.Helper()
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		t.Fatal("game not found")
+	}
+	defer unlock()
+	return board.ScoreAccepted
+}
+
+func TestAcceptScoreChecksTheSeat(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    scoringSetup
+		from     func(tokens [3]string) caller
+		body     string
+		status   int
+		accepted [3]bool
+	}{
+		{
+			name:   "anonymous",
+			from:   func([3]string) caller { return caller{} },
+			body:   `{"player":1}`,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "account not playing",
+			from:   func([3]string) caller { return caller{userID: otherUserID} },
+			body:   `{"player":2}`,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "black accepting for white",
+			from:   func(tokens [3]string) caller { return caller{token: tokens[1]} },
+			body:   `{"player":2}`,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "white account accepting for black",
+			from:   func([3]string) caller { return caller{userID: whiteUserID} },
+			body:   `{"player":1}`,
+			status: http.StatusForbidden,
+		},
+		{
+			name:     "black by seat token",
+			from:     func(tokens [3]string) caller { return caller{token: tokens[1]} },
+			body:     `{"player":1}`,
+			status:   http.StatusOK,
+			accepted: [3]bool{false, true, false},
+		},
+		{
+			name:     "seat taken from the token",
+			from:     func(tokens [3]string) caller { return caller{token: tokens[2]} },
+			body:     `{}`,
+			status:   http.StatusOK,
+			accepted: [3]bool{false, false, true},
+		},
+		{
+			name:     "seat taken from the account",
+			from:     func([3]string) caller { return caller{userID: whiteUserID} },
+			body:     `{}`,
+			status:   http.StatusOK,
+			accepted: [3]bool{false, false, true},
+		},
+		{
+			name:     "hotseat device accepting for both",
+			setup:    scoringSetup{hotseat: true},
+			from:     func(tokens [3]string) caller { return caller{token: tokens[1]} },
+			body:     `{"player":2}`,
+			status:   http.StatusOK,
+			accepted: [3]bool{false, false, true},
+		},
+		{
+			name:     "game without seats",
+			setup:    scoringSetup{unseated: true},
+			from:     func([3]string) caller { return caller{} },
+			body:     `{"player":1}`,
+			status:   http.StatusOK,
+			accepted: [3]bool{false, true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameID, tokens := scoringGame(t, tt.setup)
+
+			rec := callHandler(acceptScore, gameID, tt.body, tt.from(tokens))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if got := scoreAccepted(t, gameID); got != tt.accepted {
+				t.Errorf("accepted = %v, want %v", got, tt.accepted)
+			}
+		})
+	}
+}
+
+func TestScoringNeedsAPlayer(t *testing.T) {
+	handlers := []struct {
+		name    string
+		handler echo.HandlerFunc
+		body    string
+	}{
+		{name: "mark dead stones", handler: markDeadStones, body: `{"position":40}`},
+		{name: "resume play", handler: resumePlay, body: `{}`},
+	}
+	callers := []struct {
+		name   string
+		from   func(tokens [3]string) caller
+		status int
+	}{
+		{name: "anonymous", from: func([3]string) caller { return caller{} }, status: http.StatusUnauthorized},
+		{name: "account not playing", from: func([3]string) caller { return caller{userID: otherUserID} }, status: http.StatusUnauthorized},
+		{name: "unknown token", from: func([3]string) caller { return caller{token: newSeatToken()} }, status: http.StatusUnauthorized},
+		{name: "black by seat token", from: func(tokens [3]string) caller { return caller{token: tokens[1]} }, status: http.StatusOK},
+		{name: "white by account", from: func([3]string) caller { return caller{userID: whiteUserID} }, status: http.StatusOK},
+	}
+
+	for _, h := range handlers {
+		for _, c := range callers {
+			t.Run(h.name+"/"+c.name, func(t *testing.T) {
+				gameID, tokens := scoringGame(t, scoringSetup{})
+
+				rec := callHandler(h.handler, gameID, h.body, c.from(tokens))
+				if rec.Code != c.status {
+					t.Errorf("status = %d, want %d: %s", rec.Code, c.status, rec.Body)
+				}
+			})
+		}
+	}
+}
+
+func TestScoreIsFinalOnceBothSeatsAccept(t *testing.T) {
+	gameID, tokens := scoringGame(t, scoringSetup{})
+
+	for _, from := range []caller{{token: tokens[1]}, {userID: whiteUserID}} {
+		if rec := callHandler(acceptScore, gameID, `{}`, from); rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+	}
+
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		t.Fatal("game not found")
+	}
+	defer unlock()
+	if board.Phase != game.PhaseFinished || board.Result == nil || board.Result.Reason != game.ReasonScore {
+		t.Errorf("phase = %s, result = %v, want finished by score", board.Phase, board.Result)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"go-game/game"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Headers carrying seat tokens: handed out in the response that creates a game,
+// and sent back with every move
+const (
+	headerBlackToken = "X-Black-Token"
+	headerWhiteToken = "X-White-Token"
+	headerSeatToken  = "X-Seat-Token"
+	headerTeamTokens = "X-Team-Tokens" // Team games: the token of each member, as name=token&name=token
+)
+
+// Seat errors
+var (
+	errSeatToken = errors.New("a seat token, or the account of a player, is needed to play in this game")
+	errWrongSeat = errors.New("your seat can't play this move")
+)
+
+// newSeatToken generates a random token for a seat
+func newSeatToken() string {
+	var token [24]byte
+	rand.Read(token[:])
+	return base64.RawURLEncoding.EncodeToString(token[:])
+}
+
+// SeatTokens are the tokens handed out when a game is created
+type SeatTokens struct {
+	Colors  [3]string         // Token of each seat (index 1 = black, 2 = white, "" for the bot's seat)
+	Members map[string]string // Token of each team member by name (team games only)
+}
+
+// assignSeats gives each seat not played by the bot a new token, and returns the tokens
+// (index 1 = black, 2 = white, "" for the bot's seat)
+// botColor is the bot's color, 0 for games without a bot; it can only be done during setup
+func assignSeats(board *game.Board, botColor int) ([3]string, error) {
+	var tokens [3]string
+	for player := 1; player <= 2; player++ {
+		if player == botColor {
+			continue // Only the server moves for the bot
+		}
+		tokens[player] = newSeatToken()
+		if err := board.SetSeat(player, tokens[player]); err != nil {
+			return tokens, err
+		}
+	}
+	return tokens, nil
+}
+
+// assignMemberSeats gives each member of a team game a new token, and returns the tokens by name
+// It can only be done during setup
+func assignMemberSeats(board *game.Board) (map[string]string, error) {
+	if !board.IsTeamGame() {
+		return nil, nil
+	}
+
+	tokens := make(map[string]string)
+	for player := 1; player <= 2; player++ {
+		for _, member := range board.Teams[player] {
+			tokens[member] = newSeatToken()
+			if err := board.SetMemberSeat(member, tokens[member]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// setSeatTokens hands out the seat tokens of a created game in the headers
+func setSeatTokens(c echo.Context, tokens SeatTokens) {
+	for player, header := range []string{1: headerBlackToken, 2: headerWhiteToken} {
+		if tokens.Colors[player] != "" {
+			c.Response().Header().Set(header, tokens.Colors[player])
+		}
+	}
+	if len(tokens.Members) > 0 {
+		members := make(url.Values)
+		for member, token := range tokens.Members {
+			members.Set(member, token)
+		}
+		c.Response().Header().Set(headerTeamTokens, members.Encode())
+	}
+}
+
+// checkSeat makes sure the holder of a token, or the signed in account (userID, "" if none),
+// may act for a player
+// Games without seats (older and imported ones) are open to anyone; in hotseat games
+// either seat plays both colors, as both are on the same device
+func checkSeat(board *game.Board, token, userID string, player int) error {
+	if !board.Seated() {
+		return nil
+	}
+
+	seat := board.SeatOf(token)
+	if seat != player {
+		if account := board.SeatOfUser(userID, player); account != 0 {
+			seat = account
+		}
+	}
+	switch {
+	case seat == 0:
+		return errSeatToken
+	case seat != player && !board.Hotseat:
+		return errWrongSeat
+	}
+	return nil
+}
+
+// teamMember returns the team member a move is made by: once the members of a team game
+// hold seats, the one whose token the move was sent with, whatever name it gives
+func teamMember(board *game.Board, token, named string) string {
+	if !board.MembersSeated() {
+		return named
+	}
+	member, _ := board.MemberOf(token)
+	return member
+}
+
+// seatStatus is the HTTP status of a seat error
+func seatStatus(err error) int {
+	if errors.Is(err, errSeatToken) {
+		return http.StatusUnauthorized
+	}
+	return http.StatusForbidden
+}
+
+// seatToken returns the seat token a request was sent with
+func seatToken(c echo.Context) string {
+	return c.Request().Header.Get(headerSeatToken)
+}
+
+// checkRequestSeat makes sure a request may act for a player, by its seat token or its account
+func checkRequestSeat(c echo.Context, board *game.Board, player int) error {
+	user, _ := currentUser(c)
+	return checkSeat(board, seatToken(c), user.ID, player)
+}
+
+// requestSeat returns the seat a request holds, by its seat token or its account (0 = none)
+func requestSeat(c echo.Context, board *game.Board) int {
+	if seat := board.SeatOf(seatToken(c)); seat != 0 {
+		return seat
+	}
+	user, _ := currentUser(c)
+	return board.SeatOfUser(user.ID, 0)
+}
+
+// viewerFor returns the player the holder of a token, or the account (userID, "" if none),
+// may look at a game as: the player asked for if they may act for it, or else a spectator (0)
+func viewerFor(board *game.Board, token, userID string, player int) int {
+	if (player == 1 || player == 2) && checkSeat(board, token, userID, player) == nil {
+		return player
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"go-game/auth"
+	"go-game/game"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// phantomGame starts a seated phantom go game with white played by an account, and
+// returns it with its seat tokens
+func phantomGame(t *testing.T, seated bool) (*game.Board, [3]string) {
+	t.Helper()
+	board := game.NewBoard(9)
+	if err := board.SetVariant(game.VariantPhantom); err != nil {
+		t.Fatal(err)
+	}
+	var tokens [3]string
+	if seated {
+		var err error
+		if tokens, err = assignSeats(board, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := board.SitDown(2, whiteUserID); err != nil {
+		t.Fatal(err)
+	}
+	if err := board.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return board, tokens
+}
+
+func TestViewerOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		unseated bool
+		query    string
+		from     func(tokens [3]string) caller
+		viewer   int
+	}{
+		{name: "anonymous", from: func([3]string) caller { return caller{} }, viewer: 0},
+		{name: "anonymous asking for a seat", query: "?player=2", from: func([3]string) caller { return caller{} }, viewer: 0},
+		{name: "black asking for white's seat", query: "?player=2", from: func(tokens [3]string) caller { return caller{token: tokens[1]} }, viewer: 0},
+		{name: "white account asking for black's seat", query: "?player=1", from: func([3]string) caller { return caller{userID: whiteUserID} }, viewer: 0},
+		{name: "account not playing", query: "?player=2", from: func([3]string) caller { return caller{userID: otherUserID} }, viewer: 0},
+		{name: "black by seat token", from: func(tokens [3]string) caller { return caller{token: tokens[1]} }, viewer: 1},
+		{name: "black naming their seat", query: "?player=1", from: func(tokens [3]string) caller { return caller{token: tokens[1]} }, viewer: 1},
+		{name: "white by account", from: func([3]string) caller { return caller{userID: whiteUserID} }, viewer: 2},
+		{name: "invalid player", query: "?player=7", from: func(tokens [3]string) caller { return caller{token: tokens[1]} }, viewer: 0},
+		{name: "game without seats", unseated: true, query: "?player=1", from: func([3]string) caller { return caller{} }, viewer: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board, tokens := phantomGame(t, !tt.unseated)
+			from := tt.from(tokens)
+
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			if from.token != "" {
+				req.Header.Set(headerSeatToken, from.token)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+			if from.userID != "" {
+				c.Set(userKey, auth.User{ID: from.userID})
+			}
+
+			if got := viewerOf(c, board); got != tt.viewer {
+				t.Errorf("viewer = %d, want %d", got, tt.viewer)
+			}
+		})
+	}
+}
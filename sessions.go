@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"go-game/auth"
+	"go-game/sessions"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// sessionStore keeps the sessions of browsers signed in with a cookie
+// (Postgres if DATABASE_URL is set, memory otherwise)
+var sessionStore sessions.Store
+
+// Session cookie settings
+const (
+	sessionCookie   = "go_session"
+	sessionLifetime = 30 * 24 * time.Hour // Since the last request
+	sessionTouch    = time.Hour           // How stale the last use may get before it's recorded again
+)
+
+// sessionKey is the context key under which authenticate stores the session of a request
+const sessionKey = "session"
+
+// errCrossOrigin rejects cookie requests made by other sites
+var errCrossOrigin = errors.New("requests signed in with a cookie must come from this site")
+
+// newSessionStoreFromEnv opens the session store next to the accounts
+func newSessionStoreFromEnv() (sessions.Store, error) {
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		return sessions.NewPostgresStore(url)
+	}
+	return sessions.NewMemoryStore(), nil
+}
+
+// Session response structure
+type SessionResponse struct {
+	sessions.Session
+	Current bool `json:"current"` // The session of the request
+}
+
+// sessionUser looks up the session of a request's cookie and extends it
+// The cookie is dropped when the session has expired or was revoked
+func sessionUser(c echo.Context) (auth.User, bool, error) {
+	cookie, err := c.Cookie(sessionCookie)
+	if err != nil || cookie.Value == "" {
+		return auth.User{}, false, nil
+	}
+
+	ctx := c.Request().Context()
+	session, err := sessionStore.Find(ctx, sessions.Hash(cookie.Value))
+	if errors.Is(err, sessions.ErrNotFound) {
+		clearSessionCookie(c)
+		return auth.User{}, false, nil
+	}
+	if err != nil {
+		return auth.User{}, false, err
+	}
+	if !sameOrigin(c) {
+		return auth.User{}, false, errCrossOrigin
+	}
+
+	if now := time.Now(); now.Sub(session.LastSeen) >= sessionTouch {
+		session.LastSeen, session.ExpiresAt = now, now.Add(sessionLifetime)
+		if err := sessionStore.Touch(ctx, session.ID, session.LastSeen, session.ExpiresAt); err != nil {
+			return auth.User{}, false, err
+		}
+		setSessionCookie(c, cookie.Value, session.ExpiresAt)
+	}
+	c.Set(sessionKey, session)
+	return auth.User{ID: session.UserID, Name: session.UserName}, true, nil
+}
+
+// sameOrigin checks that a request that changes something (or opens a WebSocket) comes from
+// this site's pages, or a frontend the CORS policy trusts; the SameSite cookie attribute
+// covers browsers, this covers older ones
+func sameOrigin(c echo.Context) bool {
+	r := c.Request()
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		if !c.IsWebSocket() {
+			return true
+		}
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true // Not sent by a browser script
+	}
+	if corsPolicy.Trusts(origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// currentSession returns the session of a request signed in with a cookie
+func currentSession(c echo.Context) (sessions.Session, bool) {
+	session, ok := c.Get(sessionKey).(sessions.Session)
+	return session, ok
+}
+
+// setSessionCookie gives the browser the secret of its session
+func setSessionCookie(c echo.Context, secret string, expires time.Time) {
+	c.SetCookie(&http.Cookie{
+		Name:     sessionCookie,
+		Value:    secret,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true, // Out of reach of scripts
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearSessionCookie makes the browser forget its session
+func clearSessionCookie(c echo.Context) {
+	c.SetCookie(&http.Cookie{
+		Name:     sessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Sign in with a username and password and get a session cookie, for the browser frontend
+func createSession(c echo.Context) error {
+	var loginReq LoginRequest
+	if err := c.Bind(&loginReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	user, err := checkLogin(c, loginReq)
+	if errors.Is(err, errWrongLogin) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	session, secret := sessions.New(user.ID, user.DisplayName, c.Request().UserAgent(), time.Now(), sessionLifetime)
+	if err := sessionStore.Create(c.Request().Context(), session); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	setSessionCookie(c, secret, session.ExpiresAt)
+	return c.JSON(http.StatusOK, user)
+}
+
+// Sign out: end the session of the cookie
+func deleteSession(c echo.Context) error {
+	if session, ok := currentSession(c); ok {
+		err := sessionStore.Delete(c.Request().Context(), session.UserID, session.ID)
+		if err != nil && !errors.Is(err, sessions.ErrNotFound) {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+	clearSessionCookie(c)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// List the browsers the user is signed in on
+func listSessions(c echo.Context) error {
+	user, _ := currentUser(c)
+	list, err := sessionStore.List(c.Request().Context(), user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	current, _ := currentSession(c)
+	response := make([]SessionResponse, len(list))
+	for i, session := range list {
+		response[i] = SessionResponse{Session: session, Current: session.ID == current.ID}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// Revoke one of the user's sessions, e.g. on a lost device
+func revokeSession(c echo.Context) error {
+	user, _ := currentUser(c)
+	err := sessionStore.Delete(c.Request().Context(), user.ID, c.Param("sid"))
+	if errors.Is(err, sessions.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Session not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if current, ok := currentSession(c); ok && current.ID == c.Param("sid") {
+		clearSessionCookie(c)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Revoke all the user's sessions except the one of the request
+func revokeOtherSessions(c echo.Context) error {
+	user, _ := currentUser(c)
+	current, _ := currentSession(c)
+	if err := sessionStore.DeleteUser(c.Request().Context(), user.ID, current.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,105 @@
+package sessions
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps sessions in memory, for servers without a database
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session // By ID
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]Session)}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(time.Now())
+	s.sessions[session.ID] = session
+	return nil
+}
+
+func (s *MemoryStore) Find(ctx context.Context, hash string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.Hash != hash {
+			continue
+		}
+		if session.Expired(time.Now()) {
+			delete(s.sessions, id)
+			break
+		}
+		return session, nil
+	}
+	return Session{}, ErrNotFound
+}
+
+func (s *MemoryStore) Touch(ctx context.Context, id string, lastSeen, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	session.LastSeen, session.ExpiresAt = lastSeen, expiresAt
+	s.sessions[id] = session
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, userID string) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var list []Session
+	for _, session := range s.sessions {
+		if session.UserID == userID && !session.Expired(now) {
+			list = append(list, session)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; !ok || session.UserID != userID {
+		return ErrNotFound
+	}
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *MemoryStore) DeleteUser(ctx context.Context, userID, keep string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID && id != keep {
+			delete(s.sessions, id)
+		}
+	}
+	return nil
+}
+
+// prune drops expired sessions; must be called with mu held
+func (s *MemoryStore) prune(now time.Time) {
+	for id, session := range s.sessions {
+		if session.Expired(now) {
+			delete(s.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package sessions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/lib/pq" // Postgres driver for database/sql
+)
+
+// schema creates the tables the store needs
+const schema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	id          TEXT PRIMARY KEY,
+	hash        TEXT NOT NULL UNIQUE,
+	user_id     TEXT NOT NULL,
+	user_name   TEXT NOT NULL,
+	user_agent  TEXT NOT NULL DEFAULT '',
+	created_at  TIMESTAMPTZ NOT NULL,
+	last_seen   TIMESTAMPTZ NOT NULL,
+	expires_at  TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sessions_user ON sessions (user_id);
+`
+
+// columns are the session columns, in the order scan reads them
+const columns = `id, hash, user_id, user_name, user_agent, created_at, last_seen, expires_at`
+
+// PostgresStore keeps sessions in Postgres
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to the database and creates the schema
+func NewPostgresStore(url string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close releases the database connections
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks the database can be reached
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *PostgresStore) Create(ctx context.Context, session Session) error {
+	// Expired sessions are cleared out as new ones come in
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= now()`); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sessions (`+columns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		session.ID, session.Hash, session.UserID, session.UserName, session.UserAgent,
+		session.CreatedAt, session.LastSeen, session.ExpiresAt)
+	return err
+}
+
+func (s *PostgresStore) Find(ctx context.Context, hash string) (Session, error) {
+	var session Session
+	err := s.scan(s.db.QueryRowContext(ctx, `
+		SELECT `+columns+` FROM sessions WHERE hash = $1 AND expires_at > now()`, hash), &session)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Session{}, ErrNotFound
+	}
+	return session, err
+}
+
+func (s *PostgresStore) Touch(ctx context.Context, id string, lastSeen, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE sessions SET last_seen = $2, expires_at = $3 WHERE id = $1`, id, lastSeen, expiresAt)
+	return err
+}
+
+func (s *PostgresStore) List(ctx context.Context, userID string) ([]Session, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+columns+` FROM sessions WHERE user_id = $1 AND expires_at > now()
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Session
+	for rows.Next() {
+		var session Session
+		if err := s.scan(rows, &session); err != nil {
+			return nil, err
+		}
+		list = append(list, session)
+	}
+	return list, rows.Err()
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, userID, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) DeleteUser(ctx context.Context, userID, keep string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1 AND id <> $2`, userID, keep)
+	return err
+}
+
+// scan reads one session
+func (s *PostgresStore) scan(row interface{ Scan(...any) error }, session *Session) error {
+	return row.Scan(&session.ID, &session.Hash, &session.UserID, &session.UserName, &session.UserAgent,
+		&session.CreatedAt, &session.LastSeen, &session.ExpiresAt)
+}
@@ -0,0 +1,78 @@
+// Package sessions keeps the server-side sessions behind browser cookies
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned for unknown, expired and revoked sessions
+var ErrNotFound = errors.New("session not found")
+
+// Session is a signed in browser
+// The secret the browser holds is never stored, only its hash (see Hash)
+type Session struct {
+	ID        string    `json:"id"` // Public, for listing and revoking sessions
+	UserID    string    `json:"userId"`
+	UserName  string    `json:"userName"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	LastSeen  time.Time `json:"lastSeen"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Hash      string    `json:"-"` // SHA-256 of the secret, hex encoded
+}
+
+// Expired reports whether the session has run out at a time
+func (s Session) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// Store keeps sessions
+type Store interface {
+	// Create adds a session
+	Create(ctx context.Context, session Session) error
+	// Find returns the session of a secret's hash, or ErrNotFound if it has expired
+	Find(ctx context.Context, hash string) (Session, error)
+	// Touch moves the last use of a session, and its expiry, forward
+	Touch(ctx context.Context, id string, lastSeen, expiresAt time.Time) error
+	// List returns the sessions of a user that haven't expired, newest first
+	List(ctx context.Context, userID string) ([]Session, error)
+	// Delete revokes a session of a user
+	Delete(ctx context.Context, userID, id string) error
+	// DeleteUser revokes all sessions of a user, except the one with ID keep ("" for none)
+	DeleteUser(ctx context.Context, userID, keep string) error
+}
+
+// New creates a session for a user and returns it with the secret for the cookie
+func New(userID, userName, userAgent string, now time.Time, lifetime time.Duration) (Session, string) {
+	session := Session{
+		ID:        randomString(12),
+		UserID:    userID,
+		UserName:  userName,
+		UserAgent: userAgent,
+		CreatedAt: now,
+		LastSeen:  now,
+		ExpiresAt: now.Add(lifetime),
+	}
+	secret := randomString(32)
+	session.Hash = Hash(secret)
+	return session, secret
+}
+
+// Hash returns what is stored of a secret
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString returns n random bytes, URL-safe encoded
+func randomString(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"go-game/game"
+	"go-game/kifu"
+	"go-game/remote"
+	"go-game/sgf"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxSGFSize limits the size of uploaded game records
+const maxSGFSize = 1 << 20
+
+// Create a game from an SGF game record sent as the request body
+// Setup stones, moves of the main line and game information are restored and the
+// game is left in play, so it can be reviewed or continued
+func importGame(c echo.Context) error {
+	data, err := io.ReadAll(io.LimitReader(c.Request().Body, maxSGFSize+1))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Could not read the game record"})
+	}
+	if len(data) > maxSGFSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "Game record is too large"})
+	}
+	return startImportedGame(c, string(data))
+}
+
+// OGS import request structure
+type OGSImportRequest struct {
+	Game string `json:"game"` // OGS game ID, or the address of the game page
+}
+
+// ogsAPI is where OGS games are fetched from (OGS_API_URL, for mirrors and tests)
+var ogsAPI = cmp.Or(os.Getenv("OGS_API_URL"), remote.OGSAPI)
+
+// Create a game from the record of a game played on online-go.com, for reviewing it here
+func importOGSGame(c echo.Context) error {
+	var importReq OGSImportRequest
+	if err := c.Bind(&importReq); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	id, err := remote.ParseOGSGame(importReq.Game)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	record, err := remote.FetchOGS(c.Request().Context(), ogsAPI, id)
+	if err != nil {
+		return remoteError(c, "OGS", err)
+	}
+	return startImportedGame(c, record)
+}
+
+// remoteError reports why a game record couldn't be fetched from another server
+func remoteError(c echo.Context, server string, err error) error {
+	switch {
+	case errors.Is(err, remote.ErrNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": server + ": " + err.Error()})
+	case errors.Is(err, remote.ErrPrivate):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": server + ": " + err.Error()})
+	case errors.Is(err, remote.ErrUnavailable):
+		log.Printf("fetching a game from %s: %v", server, err)
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": server + ": " + remote.ErrUnavailable.Error()})
+	default:
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": server + ": " + err.Error()})
+	}
+}
+
+// startImportedGame creates a new game from an SGF record
+// Only the first game of a collection is imported
+func startImportedGame(c echo.Context, record string) error {
+	roots, err := sgf.Parse(record)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	board, err := sgf.ToBoard(roots[0])
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	gamesMu.Lock()
+	gameID := newGameID()
+	unlock := addGame(gameID, board)
+	gamesMu.Unlock()
+	defer unlock()
+
+	saveGame(c.Request().Context(), gameID, board)
+
+	announceCreated(gameID, board)
+
+	setGameLocation(c, gameID)
+	return c.JSON(http.StatusOK, board)
+}
+
+// Export a game as an SGF game record
+// With ?anchors=true every move is labeled with its review link anchor (see getReplay)
+func exportGame(c echo.Context) error {
+	gameID := c.Param("id")
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+	if board.Concealed() {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not available while stones are hidden"})
+	}
+
+	root, err := sgf.FromBoard(board)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if c.QueryParam("anchors") == "true" {
+		sgf.AddAnchors(root)
+		link := c.Scheme() + "://" + c.Request().Host + "/game/" + gameID
+		root.Set(sgf.LinkProperty, link)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+gameID+`.sgf"`)
+	return c.Blob(http.StatusOK, "application/x-go-sgf", []byte(sgf.Format(root)))
+}
+
+// Maximum moves per kifu figure
+const maxMovesPerFigure = 500
+
+// Lay out the game record as printable kifu figures (?movesPerFigure=, default 100)
+func exportKifu(c echo.Context) error {
+	gameID := c.Param("id")
+
+	movesPerFigure := kifu.DefaultMovesPerFigure
+	if param := c.QueryParam("movesPerFigure"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed <= 0 || parsed > maxMovesPerFigure {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid moves per figure"})
+		}
+		movesPerFigure = parsed
+	}
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+	if board.Concealed() {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not available while stones are hidden"})
+	}
+
+	return c.JSON(http.StatusOK, kifu.FromBoard(board, movesPerFigure))
+}
+
+// ReplayResponse is the position a review link points at
+type ReplayResponse struct {
+	Anchor    string      `json:"anchor"` // Normalized anchor, e.g. "move=57&var=2"
+	Move      int         `json:"move"`
+	Variation int         `json:"variation"`
+	Comment   string      `json:"comment,omitempty"` // Comment recorded on the move
+	Board     *game.Board `json:"board"`
+}
+
+// Resolve a review link anchor (?move=57&var=2, as in /game/:id#move=57&var=2) to the position
+// at that move; var=0 or no var is the main line, other variations are numbered as in the SGF export
+func getReplay(c echo.Context) error {
+	gameID := c.Param("id")
+
+	anchor, err := sgf.ParseAnchor(c.QueryString())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid anchor"})
+	}
+
+	// Find the game
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	defer unlock()
+	if board.Concealed() {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not available while stones are hidden"})
+	}
+
+	root, err := sgf.FromBoard(board)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	position, node, err := sgf.PositionAt(root, anchor)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, ReplayResponse{
+		Anchor:    anchor.String(),
+		Move:      anchor.Move,
+		Variation: anchor.Variation,
+		Comment:   node.Get("C"),
+		Board:     position,
+	})
+}
@@ -0,0 +1,145 @@
+package sgf
+
+import (
+	"fmt"
+	"go-game/game"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Anchor points at a move of a game tree, as written in review links: "move=57&var=2"
+// Variation 0 is the main line; the others are numbered from 1 in the order they appear in the record
+// Move 0 is the position before the first move
+type Anchor struct {
+	Move      int
+	Variation int
+}
+
+// String writes the anchor as a link fragment, leaving out the variation on the main line
+func (a Anchor) String() string {
+	if a.Variation == 0 {
+		return "move=" + strconv.Itoa(a.Move)
+	}
+	return fmt.Sprintf("move=%d&var=%d", a.Move, a.Variation)
+}
+
+// ParseAnchor reads an anchor written by String, with or without the leading "#"
+func ParseAnchor(fragment string) (Anchor, error) {
+	values, err := url.ParseQuery(strings.TrimPrefix(fragment, "#"))
+	if err != nil {
+		return Anchor{}, fmt.Errorf("sgf: invalid anchor %q", fragment)
+	}
+
+	var a Anchor
+	for key, target := range map[string]*int{"move": &a.Move, "var": &a.Variation} {
+		if value := values.Get(key); value != "" {
+			if *target, err = strconv.Atoi(value); err != nil || *target < 0 {
+				return Anchor{}, fmt.Errorf("sgf: invalid anchor %q", fragment)
+			}
+		}
+	}
+	return a, nil
+}
+
+// Private properties of exported records
+const (
+	AnchorProperty = "XA" // Anchor of a move, e.g. XA[move=57&var=2]
+	LinkProperty   = "XL" // Root only: address of the game, to which anchors are appended as "#" fragments
+)
+
+// walk visits every node of the tree in the order they appear in the record, with the
+// variation the node belongs to and the number of moves from the root up to it
+func walk(root *Node, visit func(node *Node, variation, moves int)) {
+	next := 0 // Number of the last variation found
+	var visitLine func(node *Node, variation, moves int)
+	visitLine = func(node *Node, variation, moves int) {
+		if _, _, ok := nodeMove(node); ok {
+			moves++
+		}
+		visit(node, variation, moves)
+
+		for i, child := range node.Children {
+			if i == 0 {
+				visitLine(child, variation, moves)
+				continue
+			}
+			next++
+			visitLine(child, next, moves)
+		}
+	}
+	visitLine(root, 0, 0)
+}
+
+// AddAnchors labels every move of the tree with its anchor, so clients reading the record
+// can link to any move of any variation
+func AddAnchors(root *Node) {
+	walk(root, func(node *Node, variation, moves int) {
+		if _, _, ok := nodeMove(node); ok {
+			node.Set(AnchorProperty, Anchor{Move: moves, Variation: variation}.String())
+		}
+	})
+}
+
+// Resolve returns the nodes from the root to the move an anchor points at
+func Resolve(root *Node, a Anchor) ([]*Node, error) {
+	// Find where the variation starts, and the line leading to it
+	var start *Node
+	parents := make(map[*Node]*Node)
+	walk(root, func(node *Node, variation, moves int) {
+		for _, child := range node.Children {
+			parents[child] = node
+		}
+		if start == nil && variation == a.Variation {
+			start = node
+		}
+	})
+	if start == nil {
+		return nil, fmt.Errorf("sgf: the record has no variation %d", a.Variation)
+	}
+
+	// The line is the path down to the variation, then its own moves
+	line := make([]*Node, 0)
+	for node := start; node != nil; node = parents[node] {
+		line = append([]*Node{node}, line...)
+	}
+	for node := mainChild(start); node != nil; node = mainChild(node) {
+		line = append(line, node)
+	}
+
+	// Cut it at the anchored move (the root alone for the start position)
+	moves := 0
+	for i, node := range line {
+		if _, _, ok := nodeMove(node); ok {
+			moves++
+		}
+		if moves == a.Move {
+			return line[:i+1], nil
+		}
+	}
+	return nil, fmt.Errorf("sgf: variation %d ends before move %d", a.Variation, a.Move)
+}
+
+// PositionAt rebuilds the game as it stood at the move an anchor points at
+func PositionAt(root *Node, a Anchor) (*game.Board, *Node, error) {
+	path, err := Resolve(root, a)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Replay a copy of the tree holding only the anchored line
+	nodes := make([]*Node, len(path))
+	for i, node := range path {
+		nodes[i] = &Node{Properties: node.Properties}
+		if i > 0 {
+			nodes[i-1].Children = []*Node{nodes[i]}
+		}
+	}
+
+	board, err := ToBoard(nodes[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	board.SGF = "" // The record of a single line is of no use
+	return board, path[len(path)-1], nil
+}
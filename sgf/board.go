@@ -0,0 +1,212 @@
+package sgf
+
+import (
+	"fmt"
+	"go-game/game"
+	"strconv"
+	"strings"
+)
+
+// MaxSize is the largest board that can be imported (SGF points use one letter per coordinate)
+const MaxSize = 25
+
+// ToBoard rebuilds a game from the root node of a game tree: the board size, komi,
+// game information, setup stones and the moves of the main line
+// The game is left in play so it can be reviewed or continued
+func ToBoard(root *Node) (*game.Board, error) {
+	if gm := root.Get("GM"); gm != "" && gm != "1" {
+		return nil, fmt.Errorf("sgf: not a go game record (GM[%s])", gm)
+	}
+
+	size := 19
+	if sz := root.Get("SZ"); sz != "" {
+		parsed, err := strconv.Atoi(strings.TrimSpace(sz))
+		if err != nil {
+			return nil, fmt.Errorf("sgf: unsupported board size %q", sz)
+		}
+		size = parsed
+	}
+	if size < 2 || size > MaxSize {
+		return nil, fmt.Errorf("sgf: board size must be between 2 and %d", MaxSize)
+	}
+
+	board := game.NewBoard(size)
+	board.SGF = Format(root) // Keeps variations, comments and markup for export
+	board.Komi = 0           // Records without KM are played without komi
+	if km := root.Get("KM"); km != "" {
+		komi, err := strconv.ParseFloat(strings.TrimSpace(km), 64)
+		if err != nil {
+			return nil, fmt.Errorf("sgf: invalid komi %q", km)
+		}
+		board.Komi = komi
+	}
+
+	board.Info = game.GameInfo{
+		BlackName: root.Get("PB"),
+		WhiteName: root.Get("PW"),
+		BlackRank: root.Get("BR"),
+		WhiteRank: root.Get("WR"),
+		Event:     root.Get("EV"),
+		Round:     root.Get("RO"),
+		Date:      root.Get("DT"),
+		Place:     root.Get("PC"),
+		Rules:     root.Get("RU"),
+		Result:    root.Get("RE"),
+	}
+	if ha := root.Get("HA"); ha != "" {
+		board.Info.Handicap, _ = strconv.Atoi(strings.TrimSpace(ha))
+	}
+
+	// Setup stones (handicap or a given position)
+	for color, id := range [3]string{1: "AB", 2: "AW"} {
+		if id == "" {
+			continue
+		}
+		for _, value := range root.Values(id) {
+			points, err := parsePoints(value, size)
+			if err != nil {
+				return nil, err
+			}
+			for _, position := range points {
+				if err := board.AddSetupStone(position, color); err != nil {
+					return nil, fmt.Errorf("sgf: %s: %w", id, err)
+				}
+			}
+		}
+	}
+
+	// The first player is given by PL, or by whoever makes the first move
+	switch root.Get("PL") {
+	case "B":
+		board.CurrentPlayer = 1
+	case "W":
+		board.CurrentPlayer = 2
+	default:
+		for node := root; node != nil; node = mainChild(node) {
+			if color, _, ok := nodeMove(node); ok {
+				board.CurrentPlayer = color
+				break
+			}
+		}
+	}
+
+	if err := board.Start(); err != nil {
+		return nil, err
+	}
+
+	// Play the main line
+	moveNumber := 0
+	for node := root; node != nil; node = mainChild(node) {
+		if node != root && (node.Has("AB") || node.Has("AW") || node.Has("AE")) {
+			return nil, fmt.Errorf("sgf: setup stones after the first move are not supported")
+		}
+
+		color, value, ok := nodeMove(node)
+		if !ok {
+			continue
+		}
+		moveNumber++
+
+		// Play continues if a move follows two passes
+		if board.Phase == game.PhaseScoring {
+			if err := board.ResumePlay(); err != nil {
+				return nil, fmt.Errorf("sgf: move %d: %w", moveNumber, err)
+			}
+		}
+		if color != board.CurrentPlayer {
+			return nil, fmt.Errorf("sgf: move %d is played out of turn", moveNumber)
+		}
+
+		if isPass(value, size) {
+			if err := board.Pass(); err != nil {
+				return nil, fmt.Errorf("sgf: move %d: %w", moveNumber, err)
+			}
+			continue
+		}
+
+		position, err := parsePoint(value, size)
+		if err != nil {
+			return nil, fmt.Errorf("sgf: move %d: %w", moveNumber, err)
+		}
+		if err := board.MakeMove(position); err != nil {
+			return nil, fmt.Errorf("sgf: move %d: %w", moveNumber, err)
+		}
+		if board.Phase != game.PhasePlaying {
+			break // The game ended, e.g. on a long cycle
+		}
+	}
+
+	return board, nil
+}
+
+// mainChild returns the node that continues the main line (nil at the end)
+func mainChild(node *Node) *Node {
+	if len(node.Children) == 0 {
+		return nil
+	}
+	return node.Children[0]
+}
+
+// nodeMove returns the move of a node, if it has one
+func nodeMove(node *Node) (int, string, bool) {
+	if node.Has("B") {
+		return 1, node.Get("B"), true
+	}
+	if node.Has("W") {
+		return 2, node.Get("W"), true
+	}
+	return 0, "", false
+}
+
+// isPass checks for the two ways of writing a pass: an empty value, or "tt" on boards up to 19x19
+func isPass(value string, size int) bool {
+	return value == "" || (value == "tt" && size <= 19)
+}
+
+// parsePoint converts an SGF point ("pd": column p, row d) to a board position
+func parsePoint(value string, size int) (int, error) {
+	if len(value) != 2 {
+		return 0, fmt.Errorf("invalid point %q", value)
+	}
+
+	col, row := int(value[0])-'a', int(value[1])-'a'
+	if col < 0 || col >= size || row < 0 || row >= size {
+		return 0, fmt.Errorf("point %q is off the board", value)
+	}
+	return row*size + col, nil
+}
+
+// parsePoints expands a point or a compressed rectangle of points ("aa:cc")
+func parsePoints(value string, size int) ([]int, error) {
+	from, to, compressed := strings.Cut(value, ":")
+	if !compressed {
+		position, err := parsePoint(value, size)
+		if err != nil {
+			return nil, err
+		}
+		return []int{position}, nil
+	}
+
+	start, err := parsePoint(from, size)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parsePoint(to, size)
+	if err != nil {
+		return nil, err
+	}
+
+	startRow, startCol := start/size, start%size
+	endRow, endCol := end/size, end%size
+	if endRow < startRow || endCol < startCol {
+		return nil, fmt.Errorf("invalid point rectangle %q", value)
+	}
+
+	points := make([]int, 0, (endRow-startRow+1)*(endCol-startCol+1))
+	for row := startRow; row <= endRow; row++ {
+		for col := startCol; col <= endCol; col++ {
+			points = append(points, row*size+col)
+		}
+	}
+	return points, nil
+}
@@ -0,0 +1,28 @@
+package sgf
+
+import "testing"
+
+// FuzzImport parses an uploaded game record and rebuilds the game from it, as POST /game/import does
+func FuzzImport(f *testing.F) {
+	for _, record := range []string{
+		"(;GM[1]FF[4]SZ[9];B[ee];W[dc];B[];W[])",
+		"(;SZ[19]KM[6.5]HA[2]AB[dd][pp];W[qd](;B[oc])(;B[qc]))",
+		"(;SZ[5]AB[aa]AW[ba][ab];B[tt])",
+		"(;SZ[9];B[zz])",
+		"(;C[a \\] in a comment])",
+		"(;",
+		"",
+	} {
+		f.Add(record)
+	}
+	f.Fuzz(func(t *testing.T, record string) {
+		roots, err := Parse(record)
+		if err != nil {
+			return
+		}
+		if len(roots) == 0 {
+			t.Fatal("parsed without error, but no game")
+		}
+		ToBoard(roots[0])
+	})
+}
@@ -0,0 +1,241 @@
+// Package sgf reads game records in the Smart Game Format (FF[4])
+// https://www.red-bean.com/sgf/
+package sgf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Property is one SGF property with all its values, e.g. AB[dd][pp]
+type Property struct {
+	ID     string
+	Values []string
+}
+
+// Node is one node of a game tree; the first child continues the main line,
+// any other children are variations
+type Node struct {
+	Properties []Property // In the order they appear in the file
+	Children   []*Node
+}
+
+// Get returns the first value of a property ("" if the node doesn't have it)
+func (n *Node) Get(id string) string {
+	for _, prop := range n.Properties {
+		if prop.ID == id && len(prop.Values) > 0 {
+			return prop.Values[0]
+		}
+	}
+	return ""
+}
+
+// Values returns every value of a property
+func (n *Node) Values(id string) []string {
+	for _, prop := range n.Properties {
+		if prop.ID == id {
+			return prop.Values
+		}
+	}
+	return nil
+}
+
+// Has checks if the node has a property
+func (n *Node) Has(id string) bool {
+	for _, prop := range n.Properties {
+		if prop.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Set replaces the values of a property, or adds it at the end if the node doesn't have it
+func (n *Node) Set(id string, values ...string) {
+	for i, prop := range n.Properties {
+		if prop.ID == id {
+			n.Properties[i].Values = values
+			return
+		}
+	}
+	n.Properties = append(n.Properties, Property{ID: id, Values: values})
+}
+
+// ErrEmpty is returned when the input holds no game tree
+var ErrEmpty = errors.New("sgf: no game tree found")
+
+// Parse reads an SGF collection and returns the root node of each game tree in it
+func Parse(data string) ([]*Node, error) {
+	p := &parser{data: data}
+
+	roots := make([]*Node, 0, 1)
+	for {
+		p.skipSpace()
+		if p.done() {
+			break
+		}
+		if p.peek() != '(' {
+			// Text outside of game trees is allowed and ignored
+			p.pos++
+			continue
+		}
+
+		root, err := p.tree()
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+
+	if len(roots) == 0 {
+		return nil, ErrEmpty
+	}
+	return roots, nil
+}
+
+// parser walks through the SGF text
+type parser struct {
+	data string
+	pos  int
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.data) }
+func (p *parser) peek() byte { return p.data[p.pos] }
+
+func (p *parser) skipSpace() {
+	for !p.done() && strings.IndexByte(" \t\r\n", p.peek()) >= 0 {
+		p.pos++
+	}
+}
+
+// errorf reports a syntax error at the current position
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("sgf: offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// tree reads "(" sequence of nodes, then sub-trees ")" and returns its first node
+func (p *parser) tree() (*Node, error) {
+	p.pos++ // "("
+
+	var first, last *Node
+	for {
+		p.skipSpace()
+		if p.done() {
+			return nil, p.errorf("unterminated game tree")
+		}
+
+		switch p.peek() {
+		case ';':
+			node, err := p.node()
+			if err != nil {
+				return nil, err
+			}
+			if first == nil {
+				first = node
+			} else {
+				last.Children = append(last.Children, node)
+			}
+			last = node
+
+		case '(':
+			if last == nil {
+				return nil, p.errorf("variation before the first node")
+			}
+			child, err := p.tree()
+			if err != nil {
+				return nil, err
+			}
+			last.Children = append(last.Children, child)
+
+		case ')':
+			p.pos++
+			if first == nil {
+				return nil, p.errorf("empty game tree")
+			}
+			return first, nil
+
+		default:
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+	}
+}
+
+// node reads ";" followed by its properties
+func (p *parser) node() (*Node, error) {
+	p.pos++ // ";"
+
+	node := &Node{}
+	for {
+		p.skipSpace()
+		if p.done() || !isIdentChar(p.peek()) {
+			return node, nil
+		}
+
+		// Property identifiers are upper case; FF[3] files may mix in lower case letters,
+		// which are ignored (e.g. "AddBlack" means AB)
+		var id strings.Builder
+		for !p.done() && isIdentChar(p.peek()) {
+			if c := p.peek(); c >= 'A' && c <= 'Z' {
+				id.WriteByte(c)
+			}
+			p.pos++
+		}
+
+		prop := Property{ID: id.String()}
+		for {
+			p.skipSpace()
+			if p.done() || p.peek() != '[' {
+				break
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			prop.Values = append(prop.Values, value)
+		}
+		if len(prop.Values) == 0 {
+			return nil, p.errorf("property %s has no value", prop.ID)
+		}
+
+		node.Properties = append(node.Properties, prop)
+	}
+}
+
+// value reads "[...]", handling backslash escapes and soft line breaks
+func (p *parser) value() (string, error) {
+	p.pos++ // "["
+
+	var value strings.Builder
+	for !p.done() {
+		c := p.peek()
+		p.pos++
+
+		switch c {
+		case ']':
+			return value.String(), nil
+		case '\\':
+			if p.done() {
+				return "", p.errorf("unterminated property value")
+			}
+			escaped := p.peek()
+			p.pos++
+			// An escaped line break is a soft break and disappears
+			if escaped == '\n' || escaped == '\r' {
+				if !p.done() && (p.peek() == '\n' || p.peek() == '\r') && p.peek() != escaped {
+					p.pos++
+				}
+				continue
+			}
+			value.WriteByte(escaped)
+		default:
+			value.WriteByte(c)
+		}
+	}
+
+	return "", p.errorf("unterminated property value")
+}
+
+func isIdentChar(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
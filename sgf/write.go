@@ -0,0 +1,168 @@
+package sgf
+
+import (
+	"fmt"
+	"go-game/game"
+	"strconv"
+	"strings"
+)
+
+// Format writes game trees back to SGF text
+// Properties keep their order, and variations, comments and markup are written as they are
+func Format(roots ...*Node) string {
+	var out strings.Builder
+	for _, root := range roots {
+		writeTree(&out, root)
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
+
+// writeTree writes "(" node sequence and variations ")" starting at node
+func writeTree(out *strings.Builder, node *Node) {
+	out.WriteByte('(')
+	for {
+		writeNode(out, node)
+		if len(node.Children) != 1 {
+			break
+		}
+		node = node.Children[0]
+	}
+	for _, child := range node.Children {
+		out.WriteByte('\n')
+		writeTree(out, child)
+	}
+	out.WriteByte(')')
+}
+
+// writeNode writes ";" and the properties of a node
+func writeNode(out *strings.Builder, node *Node) {
+	out.WriteByte(';')
+	for _, prop := range node.Properties {
+		out.WriteString(prop.ID)
+		for _, value := range prop.Values {
+			out.WriteByte('[')
+			out.WriteString(escapeValue(value))
+			out.WriteByte(']')
+		}
+	}
+}
+
+// escapeValue escapes the characters that would end a property value early
+func escapeValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `]`, `\]`).Replace(value)
+}
+
+// FromBoard builds the game tree of a game
+// Games imported from SGF keep their original tree, with its variations, comments and markup;
+// moves played since the import are added to the end of its main line
+func FromBoard(board *game.Board) (*Node, error) {
+	if board.SGF != "" {
+		roots, err := Parse(board.SGF)
+		if err != nil {
+			return nil, err
+		}
+		root := roots[0]
+
+		// Find the end of the recorded main line and how many moves it holds
+		last, recorded := root, 0
+		for node := root; node != nil; node = mainChild(node) {
+			if _, _, ok := nodeMove(node); ok {
+				recorded++
+			}
+			last = node
+		}
+		if recorded > len(board.MoveHistory) {
+			return nil, fmt.Errorf("sgf: game has fewer moves than its record")
+		}
+
+		appendMoves(last, board, board.MoveHistory[recorded:])
+		return root, nil
+	}
+
+	root := &Node{}
+	add := func(id string, values ...string) {
+		root.Properties = append(root.Properties, Property{ID: id, Values: values})
+	}
+	addText := func(id, value string) {
+		if value != "" {
+			add(id, value)
+		}
+	}
+
+	add("GM", "1")
+	add("FF", "4")
+	add("CA", "UTF-8")
+	add("SZ", strconv.Itoa(board.Size))
+	add("KM", strconv.FormatFloat(board.Komi, 'f', -1, 64))
+	addText("PB", board.Info.BlackName)
+	addText("PW", board.Info.WhiteName)
+	addText("BR", board.Info.BlackRank)
+	addText("WR", board.Info.WhiteRank)
+	addText("EV", board.Info.Event)
+	addText("RO", board.Info.Round)
+	addText("DT", board.Info.Date)
+	addText("PC", board.Info.Place)
+	addText("RU", board.Info.Rules)
+	if board.Info.Handicap > 0 {
+		add("HA", strconv.Itoa(board.Info.Handicap))
+	}
+
+	result := board.Info.Result
+	if result == "" {
+		result = resultValue(board.Result)
+	}
+	addText("RE", result)
+
+	for color, id := range [3]string{1: "AB", 2: "AW"} {
+		if id == "" || len(board.SetupStones[color]) == 0 {
+			continue
+		}
+		points := make([]string, len(board.SetupStones[color]))
+		for i, position := range board.SetupStones[color] {
+			points[i] = formatPoint(position, board.Size)
+		}
+		add(id, points...)
+	}
+
+	// Say who starts when it isn't obvious from the setup, e.g. white after handicap stones
+	if len(board.MoveHistory) > 0 && board.MoveHistory[0].Player == 2 {
+		add("PL", "W")
+	}
+
+	appendMoves(root, board, board.MoveHistory)
+	return root, nil
+}
+
+// appendMoves adds moves after node, which must be the end of the main line
+func appendMoves(node *Node, board *game.Board, moves []game.Move) {
+	for _, move := range moves {
+		id := "B"
+		if move.Player == 2 {
+			id = "W"
+		}
+
+		value := "" // FF[4] pass
+		if move.Position >= 0 {
+			value = formatPoint(move.Position, board.Size)
+		}
+
+		child := &Node{Properties: []Property{{ID: id, Values: []string{value}}}}
+		node.Children = append(node.Children, child)
+		node = child
+	}
+}
+
+// formatPoint converts a board position to an SGF point ("pd": column p, row d)
+func formatPoint(position, size int) string {
+	row, col := position/size, position%size
+	return string([]byte{byte('a' + col), byte('a' + row)})
+}
+
+// resultValue writes a game result as an SGF RE value
+func resultValue(result *game.Result) string {
+	if result == nil {
+		return ""
+	}
+	return result.String()
+}
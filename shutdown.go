@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"go-game/game"
+	"log"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Graceful shutdown: on SIGINT/SIGTERM the server stops taking connections, tells the
+// WebSocket clients, lets the requests in progress finish, saves every live game and
+// only then exits
+
+// defaultShutdownTimeout is how long requests in progress get to finish (SHUTDOWN_TIMEOUT)
+const defaultShutdownTimeout = 30 * time.Second
+
+// flushTimeout bounds saving all the live games on the way out
+const flushTimeout = time.Minute
+
+// stopping is closed when the server starts shutting down
+var stopping = make(chan struct{})
+
+// shutdown stops the server gracefully
+// Requests still running after timeout have their context cancelled (cancelRequests)
+// and the server is closed on them; the games are saved either way
+func shutdown(e *echo.Echo, cancelRequests context.CancelFunc, timeout time.Duration) {
+	log.Printf("shutting down, waiting up to %s for requests in progress", timeout)
+	close(stopping) // WebSocket clients are told and disconnected
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		log.Printf("shutdown: %v", err)
+		e.Close()
+	}
+	stopRedirectServer(ctx)
+	stopGRPCServer(ctx)
+	cancelRequests()
+
+	saved := flushGames()
+	log.Printf("shutdown: saved %d live games", saved)
+}
+
+// flushGames saves every live game and returns how many there were
+// Games are saved after every change already; this catches the saves that failed
+func flushGames() int {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	saved := 0
+	forEachGame(func(gameID string, board *game.Board) {
+		if ctx.Err() != nil || isSandbox(gameID) {
+			return
+		}
+		saveGame(ctx, gameID, board)
+		saved++
+	})
+	return saved
+}
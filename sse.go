@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Server-Sent Events: GET /game/:id/events streams one game, for clients behind proxies that
+// break WebSockets. It is fed by the same hub as /ws and sends the same messages: the hub
+// events of the game, the board after every change, and the clock while it runs
+// Each hub event carries its seq as the SSE ID, so a reconnecting EventSource catches up on
+// what it missed through Last-Event-ID; the other messages have no ID
+
+// sseHeartbeatInterval is how often an idle stream gets a comment, so proxies keep it open
+const sseHeartbeatInterval = 15 * time.Second
+
+// isEventStream reports whether a request asks for Server-Sent Events
+func isEventStream(c echo.Context) bool {
+	return c.Request().Header.Get(echo.HeaderAccept) == "text/event-stream"
+}
+
+// Stream the events of a game (?player= and the seat's token to follow it as a player)
+// EventSource can't set headers, so the seat token may come in ?seat_token= instead
+func streamGameEvents(c echo.Context) error {
+	gameID := c.Param("id")
+	player := 0
+	if value := c.QueryParam("player"); value != "" {
+		var err error
+		if player, err = strconv.Atoi(value); err != nil || player < 0 || player > 2 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid player"})
+		}
+	}
+	token := seatToken(c)
+	if token == "" {
+		token = c.QueryParam("seat_token")
+	}
+	cursor, resuming := lastEventID(c)
+
+	// Follow the game before reading the board, so no change falls in between
+	events := hub.Subscribe()
+	defer hub.Unsubscribe(events)
+	hub.Follow(events, gameID)
+
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Game not found"})
+	}
+	var err error
+	if player != 0 {
+		user, _ := currentUser(c)
+		err = checkSeat(board, token, user.ID, player)
+	}
+	unlock()
+	if err != nil {
+		return c.JSON(seatStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	if player == 0 {
+		countSpectator(gameID, 1)
+		defer countSpectator(gameID, -1)
+	}
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	response.Header().Set("X-Accel-Buffering", "no") // Keeps nginx from holding the stream back
+	response.WriteHeader(http.StatusOK)
+
+	stream := &sseStream{response: response, gameID: gameID, player: player}
+	if resuming {
+		if err := stream.catchUp(cursor); err != nil {
+			return nil
+		}
+	}
+	if err := stream.sendBoard(SocketJoined); err != nil {
+		return nil
+	}
+	stream.serve(c.Request().Context(), events)
+	return nil
+}
+
+// lastEventID returns the ID of the last event a reconnecting client received
+func lastEventID(c echo.Context) (int64, bool) {
+	value := c.Request().Header.Get("Last-Event-ID")
+	if value == "" {
+		value = c.QueryParam("lastEventId")
+	}
+	cursor, err := strconv.ParseInt(value, 10, 64)
+	return cursor, err == nil && cursor >= 0
+}
+
+// sseStream is the open stream of a game
+type sseStream struct {
+	response *echo.Response
+	gameID   string
+	player   int
+	lastSeq  int64 // Seq of the last hub event sent, so events aren't sent twice after catching up
+}
+
+// serve sends the game's events as they happen, until the client goes away or the server stops
+func (s *sseStream) serve(ctx context.Context, events chan Event) {
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+	clock := time.NewTicker(clockTickInterval)
+	defer clock.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stopping:
+			s.send(Event{Time: time.Now(), Type: SocketShutdown})
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := s.forward(event); err != nil || event.Type == EventGameDeleted {
+				return
+			}
+		case now := <-clock.C:
+			if err := s.tickClock(now); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(s.response, ":\n\n"); err != nil {
+				return
+			}
+			s.response.Flush()
+		}
+	}
+}
+
+// catchUp sends the game's events the client missed since the cursor, if the hub still has them
+func (s *sseStream) catchUp(cursor int64) error {
+	s.lastSeq = cursor
+	missed, _, complete := hub.EventsSince(cursor)
+	if !complete {
+		return nil // Too far behind; the board that follows has the whole state
+	}
+	for _, event := range missed {
+		if !s.wants(event) {
+			continue
+		}
+		if err := s.send(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forward sends a hub event if the client should see it, followed by the board if the event changed it
+func (s *sseStream) forward(event Event) error {
+	if event.Seq <= s.lastSeq || !s.wants(event) {
+		return nil // Already sent while catching up, or not for this client
+	}
+	if err := s.send(event); err != nil {
+		return err
+	}
+	if changesBoard(event.Type) {
+		return s.sendBoard(SocketBoard)
+	}
+	return nil
+}
+
+// wants reports whether an event is for the client: lobby events are left to /ws and /events
+func (s *sseStream) wants(event Event) bool {
+	return event.GameID == s.gameID && !(s.player != 0 && spectatorOnlyEvents[event.Type])
+}
+
+// tickClock sends the clock of the game while it runs
+func (s *sseStream) tickClock(now time.Time) error {
+	board, unlock, exists := lockGame(s.gameID)
+	if !exists {
+		return nil
+	}
+	if board.Result != nil || board.Clock == nil || board.Clock.Running == 0 {
+		unlock()
+		return nil
+	}
+	tick := Event{Time: now, Type: SocketClock, GameID: s.gameID, Data: board.ClockState(now)}
+	unlock()
+	return s.send(tick)
+}
+
+// sendBoard sends the board of the game as the client's seat may see it
+func (s *sseStream) sendBoard(messageType string) error {
+	// Encoded under the lock, as the board keeps changing
+	board, unlock, exists := lockGame(s.gameID)
+	if !exists {
+		return nil // Purged in the meantime
+	}
+	data, err := json.Marshal(Event{Time: time.Now(), Type: messageType, GameID: s.gameID, Data: board.ViewFor(s.player)})
+	unlock()
+	if err != nil {
+		return err
+	}
+	return s.write(0, messageType, data)
+}
+
+// send encodes and sends a message
+func (s *sseStream) send(message Event) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return s.write(message.Seq, message.Type, data)
+}
+
+// write sends one SSE message, named after its type; hub events carry their seq as the ID
+func (s *sseStream) write(seq int64, messageType string, data []byte) error {
+	var err error
+	if seq > 0 {
+		_, err = fmt.Fprintf(s.response, "id: %d\nevent: %s\ndata: %s\n\n", seq, messageType, data)
+		s.lastSeq = seq
+	} else {
+		_, err = fmt.Fprintf(s.response, "event: %s\ndata: %s\n\n", messageType, data)
+	}
+	if err != nil {
+		return err
+	}
+	s.response.Flush()
+	return nil
+}
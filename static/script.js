@@ -1,5 +1,6 @@
 let gameState = null;
-let gameId = 'local'; // For now, use a fixed game ID
+let gameId = null; // Set by the server when a game is created
+let seatTokens = {}; // Tokens of the seats this page plays (1 = black, 2 = white)
 
 // Initialize the board UI
 function initBoard() {
@@ -33,6 +34,11 @@ async function newGame() {
         });
         
         if (response.ok) {
+            gameId = response.headers.get('X-Game-ID');
+            seatTokens = {
+                1: response.headers.get('X-Black-Token'),
+                2: response.headers.get('X-White-Token')
+            };
             gameState = await response.json();
             updateUI();
             setStatus('New game started!');
@@ -44,6 +50,14 @@ async function newGame() {
     }
 }
 
+// Headers for a move, with the token of the player to move (this page plays both colors)
+function moveHeaders() {
+    return {
+        'Content-Type': 'application/json',
+        'X-Seat-Token': seatTokens[gameState.CurrentPlayer] || ''
+    };
+}
+
 // Make a move at the specified position
 async function makeMove(position) {
     if (!gameState) {
@@ -54,7 +68,7 @@ async function makeMove(position) {
     try {
         const response = await fetch(`/game/${gameId}/move`, {
             method: 'POST',
-            headers: { 'Content-Type': 'application/json' },
+            headers: moveHeaders(),
             body: JSON.stringify({ position: position })
         });
         
@@ -81,7 +95,7 @@ async function pass() {
     try {
         const response = await fetch(`/game/${gameId}/move`, {
             method: 'POST',
-            headers: { 'Content-Type': 'application/json' },
+            headers: moveHeaders(),
             body: JSON.stringify({ pass: true })
         });
         
@@ -113,6 +127,11 @@ function updateUI() {
         else if (stone === 2) cell.classList.add('white');
     });
     
+    // Mark the last move
+    if (gameState.LastMove) {
+        cells[gameState.LastMove.Position].classList.add('last-move');
+    }
+    
     // Update current player
     const currentPlayerEl = document.getElementById('currentPlayer');
     const isBlack = gameState.CurrentPlayer === 1;
@@ -120,12 +139,14 @@ function updateUI() {
     currentPlayerEl.className = isBlack ? 'current-player current-black' : 'current-player current-white';
     
     // Update captured stones
-    document.getElementById('capturedBlack').textContent = gameState.CapturedStones[1] || 0;
-    document.getElementById('capturedWhite').textContent = gameState.CapturedStones[2] || 0;
+    document.getElementById('capturedBlack').textContent = gameState.Scoring.Black.Prisoners;
+    document.getElementById('capturedWhite').textContent = gameState.Scoring.White.Prisoners;
     
-    // Check for game over
-    if (gameState.IsGameOver) {
-        setStatus('Game Over! Both players passed.');
+    // Show what the current phase of the game allows
+    if (gameState.Phase === 'scoring') {
+        setStatus('Both players passed. Time to count the score.');
+    } else if (gameState.Phase === 'finished') {
+        setStatus('Game Over!');
     }
 }
 
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"go-game/game"
+	"sync"
+	"time"
+)
+
+// CachedStore is a read-through cache in front of another store
+// Hot games (spectator polls, analysis) are served from memory; every save goes
+// through to the underlying store and refreshes the cached copy, so this server
+// never reads its own stale writes
+type CachedStore struct {
+	inner      Store
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// cacheEntry is a serialized snapshot, so callers can't modify the cached copy
+type cacheEntry struct {
+	state   []byte
+	expires time.Time
+}
+
+// NewCachedStore wraps a store with a cache holding up to maxEntries games for ttl each
+func NewCachedStore(inner Store, ttl time.Duration, maxEntries int) *CachedStore {
+	return &CachedStore{
+		inner:      inner,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]cacheEntry),
+	}
+}
+
+// put caches a snapshot, evicting the entry closest to expiry if the cache is full
+func (s *CachedStore) put(id string, state []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[id]; !exists && len(s.entries) >= s.maxEntries {
+		oldest := ""
+		for key, entry := range s.entries {
+			if oldest == "" || entry.expires.Before(s.entries[oldest].expires) {
+				oldest = key
+			}
+		}
+		delete(s.entries, oldest)
+	}
+
+	s.entries[id] = cacheEntry{state: state, expires: time.Now().Add(s.ttl)}
+}
+
+// Invalidate drops a game from the cache, e.g. after it was changed by another server
+func (s *CachedStore) Invalidate(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, id)
+}
+
+// Ping checks the underlying store can be reached
+func (s *CachedStore) Ping(ctx context.Context) error {
+	return Ping(ctx, s.inner)
+}
+
+// SaveGame writes through to the underlying store and refreshes the cache
+func (s *CachedStore) SaveGame(ctx context.Context, id string, board *game.Board) error {
+	if err := s.inner.SaveGame(ctx, id, board); err != nil {
+		s.Invalidate(id) // Unknown state now, let the next read go to the store
+		return err
+	}
+
+	state, err := json.Marshal(board)
+	if err != nil {
+		s.Invalidate(id)
+		return nil // Saved fine, just not cached
+	}
+	s.put(id, state)
+	return nil
+}
+
+// DeleteGame deletes the game from the underlying store and the cache
+func (s *CachedStore) DeleteGame(ctx context.Context, id string) error {
+	defer s.Invalidate(id)
+	return s.inner.DeleteGame(ctx, id)
+}
+
+// LoadGame serves the game from the cache, loading it from the store on a miss
+func (s *CachedStore) LoadGame(ctx context.Context, id string) (*game.Board, error) {
+	s.mu.Lock()
+	entry, cached := s.entries[id]
+	s.mu.Unlock()
+
+	if cached && time.Now().Before(entry.expires) {
+		board := &game.Board{}
+		if err := json.Unmarshal(entry.state, board); err == nil {
+			return board, nil
+		}
+	}
+
+	board, err := s.inner.LoadGame(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if state, err := json.Marshal(board); err == nil {
+		s.put(id, state)
+	}
+	return board, nil
+}
+
+// ListGames is not cached; listings change with every game and are already replica-friendly
+func (s *CachedStore) ListGames(ctx context.Context, limit, offset int) ([]GameRecord, error) {
+	return s.inner.ListGames(ctx, limit, offset)
+}
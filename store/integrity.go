@@ -0,0 +1,59 @@
+package store
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go-game/game"
+	"hash"
+	"math"
+)
+
+// ErrCorrupted is returned when a stored game doesn't match its checksum,
+// because the storage got corrupted or someone edited the record
+var ErrCorrupted = errors.New("stored game failed its integrity check")
+
+// Checksum returns the head of a hash chain over a game's move log
+// The chain starts from the game settings and setup stones, adds every move in order
+// and ends with the result, so changing, dropping or reordering any of them changes the head
+// With a key every link is an HMAC, so only holders of the key can forge a valid checksum;
+// without one the chain still catches corruption
+func Checksum(board *game.Board, key []byte) string {
+	newHash := sha256.New
+	if key != nil {
+		newHash = func() hash.Hash { return hmac.New(sha256.New, key) }
+	}
+
+	// link hashes the previous head together with new data
+	link := func(previous []byte, data ...[]byte) []byte {
+		h := newHash()
+		h.Write(previous)
+		for _, part := range data {
+			h.Write(part)
+		}
+		return h.Sum(nil)
+	}
+
+	head := link(nil, []byte(board.Variant), number(board.Size), number(int(math.Float64bits(board.Komi))))
+	for color := 1; color <= 2; color++ {
+		for _, position := range board.SetupStones[color] {
+			head = link(head, []byte("setup"), number(color), number(position))
+		}
+	}
+	for _, move := range board.MoveHistory {
+		head = link(head, []byte("move"), number(move.Player), number(move.Position))
+	}
+
+	result, _ := json.Marshal(board.Result)
+	head = link(head, []byte("result"), result)
+
+	return hex.EncodeToString(head)
+}
+
+// number encodes an integer for hashing
+func number(n int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(n))
+}
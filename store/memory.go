@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"go-game/game"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps game snapshots in memory
+// It is the default when no database is configured; nothing survives a restart
+type MemoryStore struct {
+	mu    sync.Mutex
+	games map[string]memoryRecord
+}
+
+// memoryRecord is a serialized snapshot, so later changes to the live board don't leak in
+type memoryRecord struct {
+	state     []byte
+	updatedAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{games: make(map[string]memoryRecord)}
+}
+
+// SaveGame stores a snapshot of the board
+func (s *MemoryStore) SaveGame(ctx context.Context, id string, board *game.Board) error {
+	state, err := json.Marshal(board)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.games[id] = memoryRecord{state: state, updatedAt: time.Now()}
+	return nil
+}
+
+// DeleteGame forgets a game
+func (s *MemoryStore) DeleteGame(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.games, id)
+	return nil
+}
+
+// LoadGame decodes the latest snapshot of a game
+func (s *MemoryStore) LoadGame(ctx context.Context, id string) (*game.Board, error) {
+	s.mu.Lock()
+	record, exists := s.games[id]
+	s.mu.Unlock()
+
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	board := &game.Board{}
+	if err := json.Unmarshal(record.state, board); err != nil {
+		return nil, err
+	}
+	return board, nil
+}
+
+// ListGames decodes a page of snapshots, most recently updated first
+func (s *MemoryStore) ListGames(ctx context.Context, limit, offset int) ([]GameRecord, error) {
+	// Snapshots are never modified in place, so they can be decoded after unlocking
+	s.mu.Lock()
+	ids := make([]string, 0, len(s.games))
+	snapshots := make([]memoryRecord, 0, len(s.games))
+	for id, record := range s.games {
+		ids = append(ids, id)
+		snapshots = append(snapshots, record)
+	}
+	s.mu.Unlock()
+
+	order := make([]int, len(ids))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return snapshots[order[a]].updatedAt.After(snapshots[order[b]].updatedAt)
+	})
+
+	records := make([]GameRecord, 0, limit)
+	for i := offset; i < len(order) && len(records) < limit; i++ {
+		snapshot := snapshots[order[i]]
+
+		board := &game.Board{}
+		if err := json.Unmarshal(snapshot.state, board); err != nil {
+			return nil, err
+		}
+		records = append(records, GameRecord{ID: ids[order[i]], Board: board, UpdatedAt: snapshot.updatedAt})
+	}
+
+	return records, nil
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-game/game"
+	"sync/atomic"
+	"time"
+
+	_ "github.com/lib/pq" // Postgres driver for database/sql
+)
+
+// schema creates the tables the store needs
+const schema = `
+CREATE TABLE IF NOT EXISTS games (
+	id         TEXT PRIMARY KEY,
+	state      JSONB NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS games_updated_at ON games (updated_at DESC);
+ALTER TABLE games ADD COLUMN IF NOT EXISTS checksum TEXT NOT NULL DEFAULT '';
+`
+
+// PostgresStore keeps games in Postgres
+// Writes always go to the primary; reads marked with AllowStale are spread over the
+// read replicas, everything else reads from the primary so it sees its own writes
+// Every game is saved with the checksum of its move log, which is verified when it is read back
+type PostgresStore struct {
+	primary      *sql.DB
+	replicas     []*sql.DB
+	next         atomic.Uint64 // Round-robin counter for picking a replica
+	integrityKey []byte        // Key for the checksums (nil = plain hashes)
+}
+
+// NewPostgresStore connects to the primary and any read replicas and creates the schema
+// integrityKey keys the move log checksums; nil still detects corruption, but not deliberate edits
+func NewPostgresStore(primaryURL string, replicaURLs []string, integrityKey []byte) (*PostgresStore, error) {
+	primary, err := sql.Open("postgres", primaryURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := primary.Exec(schema); err != nil {
+		primary.Close()
+		return nil, err
+	}
+
+	s := &PostgresStore{primary: primary, integrityKey: integrityKey}
+	for _, url := range replicaURLs {
+		replica, err := sql.Open("postgres", url)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.replicas = append(s.replicas, replica)
+	}
+
+	return s, nil
+}
+
+// Close releases every database connection
+func (s *PostgresStore) Close() error {
+	err := s.primary.Close()
+	for _, replica := range s.replicas {
+		replica.Close()
+	}
+	return err
+}
+
+// Ping checks the primary database can be reached
+// Replicas aren't checked: reads fall back to the primary when they lag or fail
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.primary.PingContext(ctx)
+}
+
+// reader picks the database to read from
+func (s *PostgresStore) reader(ctx context.Context) *sql.DB {
+	if len(s.replicas) == 0 || !StaleAllowed(ctx) {
+		return s.primary
+	}
+	return s.replicas[s.next.Add(1)%uint64(len(s.replicas))]
+}
+
+// SaveGame upserts the game snapshot and its checksum on the primary
+func (s *PostgresStore) SaveGame(ctx context.Context, id string, board *game.Board) error {
+	state, err := json.Marshal(board)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.primary.ExecContext(ctx, `
+		INSERT INTO games (id, state, checksum, updated_at) VALUES ($1, $2, $3, now())
+		ON CONFLICT (id) DO UPDATE SET state = EXCLUDED.state, checksum = EXCLUDED.checksum, updated_at = EXCLUDED.updated_at`,
+		id, state, Checksum(board, s.integrityKey))
+	return err
+}
+
+// DeleteGame deletes a game on the primary
+func (s *PostgresStore) DeleteGame(ctx context.Context, id string) error {
+	_, err := s.primary.ExecContext(ctx, `DELETE FROM games WHERE id = $1`, id)
+	return err
+}
+
+// decode unmarshals a stored snapshot and checks it against its checksum
+// Games saved before checksums were introduced have none and are trusted as they are
+func (s *PostgresStore) decode(id string, state []byte, checksum string) (*game.Board, error) {
+	board := &game.Board{}
+	if err := json.Unmarshal(state, board); err != nil {
+		return nil, err
+	}
+	if checksum != "" && checksum != Checksum(board, s.integrityKey) {
+		return board, fmt.Errorf("%w: game %s", ErrCorrupted, id)
+	}
+	return board, nil
+}
+
+// LoadGame reads a game snapshot
+func (s *PostgresStore) LoadGame(ctx context.Context, id string) (*game.Board, error) {
+	var (
+		state    []byte
+		checksum string
+	)
+	err := s.reader(ctx).QueryRowContext(ctx, `SELECT state, checksum FROM games WHERE id = $1`, id).Scan(&state, &checksum)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	board, err := s.decode(id, state, checksum)
+	if err != nil {
+		return nil, err
+	}
+	return board, nil
+}
+
+// ListGames reads a page of games, most recently updated first
+// Games that fail their integrity check are listed with Corrupted set
+func (s *PostgresStore) ListGames(ctx context.Context, limit, offset int) ([]GameRecord, error) {
+	rows, err := s.reader(ctx).QueryContext(ctx, `
+		SELECT id, state, checksum, updated_at FROM games
+		ORDER BY updated_at DESC
+		LIMIT $1 OFFSET $2`,
+		limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]GameRecord, 0, limit)
+	for rows.Next() {
+		var (
+			id        string
+			state     []byte
+			checksum  string
+			updatedAt time.Time
+		)
+		if err := rows.Scan(&id, &state, &checksum, &updatedAt); err != nil {
+			return nil, err
+		}
+
+		board, err := s.decode(id, state, checksum)
+		if err != nil && !errors.Is(err, ErrCorrupted) {
+			return nil, err
+		}
+		records = append(records, GameRecord{ID: id, Board: board, UpdatedAt: updatedAt, Corrupted: err != nil})
+	}
+
+	return records, rows.Err()
+}
@@ -0,0 +1,251 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-game/game"
+	"go-game/redis"
+	"strconv"
+	"time"
+)
+
+// Redis keys
+const (
+	redisGamePrefix = "game:"  // Snapshot of a game, a JSON string
+	redisGameIndex  = "games"  // Sorted set of game IDs by last update (Unix milliseconds)
+	redisCacheTag   = "cache:" // Prefix of cached snapshots, kept apart from stored ones
+)
+
+// RedisStore keeps game snapshots in Redis
+// Unfinished games expire once nobody has saved them for ttl (abandoned games); finished
+// games are kept, as they are records. Expired games drop out of the listing as it is read
+type RedisStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisStore stores games in a Redis server, expiring abandoned ones after ttl (0 = never)
+func NewRedisStore(client *redis.Client, ttl time.Duration) *RedisStore {
+	return &RedisStore{client: client, ttl: ttl}
+}
+
+// Ping checks the Redis server can be reached
+func (s *RedisStore) Ping(ctx context.Context) error {
+	_, err := s.client.Do(ctx, "PING")
+	return err
+}
+
+// SaveGame stores a snapshot of the board and moves it to the top of the listing
+func (s *RedisStore) SaveGame(ctx context.Context, id string, board *game.Board) error {
+	state, err := json.Marshal(board)
+	if err != nil {
+		return err
+	}
+
+	// SET without EX drops the expiry of games that just finished
+	set := []any{"SET", redisGamePrefix + id, state}
+	if s.ttl > 0 && board.Phase != game.PhaseFinished {
+		set = append(set, "PX", s.ttl.Milliseconds())
+	}
+	replies, err := s.client.Pipeline(ctx,
+		[]any{"MULTI"},
+		set,
+		[]any{"ZADD", redisGameIndex, time.Now().UnixMilli(), id},
+		[]any{"EXEC"},
+	)
+	if err != nil {
+		return err
+	}
+	// Commands that fail inside the transaction report their errors in EXEC's reply
+	results, _ := replies[len(replies)-1].([]any)
+	for _, reply := range append(replies, results...) {
+		if err, ok := reply.(redis.Error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteGame deletes a game's snapshot and takes it out of the listing
+func (s *RedisStore) DeleteGame(ctx context.Context, id string) error {
+	replies, err := s.client.Pipeline(ctx,
+		[]any{"DEL", redisGamePrefix + id},
+		[]any{"ZREM", redisGameIndex, id},
+	)
+	if err != nil {
+		return err
+	}
+	for _, reply := range replies {
+		if err, ok := reply.(redis.Error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadGame decodes the latest snapshot of a game
+func (s *RedisStore) LoadGame(ctx context.Context, id string) (*game.Board, error) {
+	reply, err := s.client.Do(ctx, "GET", redisGamePrefix+id)
+	if err != nil {
+		return nil, err
+	}
+	state, ok := reply.([]byte)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	board := &game.Board{}
+	if err := json.Unmarshal(state, board); err != nil {
+		return nil, err
+	}
+	return board, nil
+}
+
+// ListGames reads a page of games, most recently updated first
+func (s *RedisStore) ListGames(ctx context.Context, limit, offset int) ([]GameRecord, error) {
+	reply, err := s.client.Do(ctx, "ZREVRANGE", redisGameIndex, offset, offset+limit-1, "WITHSCORES")
+	if err != nil {
+		return nil, err
+	}
+	items, _ := reply.([]any)
+	if len(items) == 0 {
+		return []GameRecord{}, nil
+	}
+
+	// Pairs of ID and score
+	ids := make([]string, 0, len(items)/2)
+	updated := make([]time.Time, 0, len(items)/2)
+	get := []any{"MGET"}
+	for i := 0; i+1 < len(items); i += 2 {
+		id, _ := items[i].([]byte)
+		score, _ := items[i+1].([]byte)
+		millis, err := parseScore(score)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, string(id))
+		updated = append(updated, time.UnixMilli(millis))
+		get = append(get, redisGamePrefix+string(id))
+	}
+
+	reply, err = s.client.Do(ctx, get...)
+	if err != nil {
+		return nil, err
+	}
+	states, _ := reply.([]any)
+
+	records := make([]GameRecord, 0, len(ids))
+	expired := []any{"ZREM", redisGameIndex}
+	for i, id := range ids {
+		var state []byte
+		if i < len(states) {
+			state, _ = states[i].([]byte)
+		}
+		if state == nil {
+			expired = append(expired, id)
+			continue
+		}
+
+		board := &game.Board{}
+		if err := json.Unmarshal(state, board); err != nil {
+			return nil, err
+		}
+		records = append(records, GameRecord{ID: id, Board: board, UpdatedAt: updated[i]})
+	}
+
+	// Forget the games that expired since they were listed
+	if len(expired) > 2 {
+		if _, err := s.client.Do(ctx, expired...); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// parseScore reads a sorted set score holding Unix milliseconds
+func parseScore(score []byte) (int64, error) {
+	millis, err := strconv.ParseFloat(string(score), 64)
+	if err != nil {
+		return 0, errors.New("redis: invalid game index score")
+	}
+	return int64(millis), nil
+}
+
+// RedisCache is a read-through cache in Redis in front of another store
+// Unlike CachedStore it is shared by every server, so a save on one refreshes the copy
+// all of them read; cached snapshots expire after ttl. Redis failures fall back to the store
+type RedisCache struct {
+	inner  Store
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisCache wraps a store with a Redis cache holding games for ttl each
+func NewRedisCache(inner Store, client *redis.Client, ttl time.Duration) *RedisCache {
+	return &RedisCache{inner: inner, client: client, ttl: ttl}
+}
+
+// Ping checks both the Redis server and the underlying store can be reached
+func (s *RedisCache) Ping(ctx context.Context) error {
+	if _, err := s.client.Do(ctx, "PING"); err != nil {
+		return err
+	}
+	return Ping(ctx, s.inner)
+}
+
+// SaveGame writes through to the underlying store and refreshes the cache
+func (s *RedisCache) SaveGame(ctx context.Context, id string, board *game.Board) error {
+	if err := s.inner.SaveGame(ctx, id, board); err != nil {
+		s.invalidate(ctx, id) // Unknown state now, let the next read go to the store
+		return err
+	}
+	s.put(ctx, id, board)
+	return nil
+}
+
+// DeleteGame deletes the game from the underlying store and the cache
+func (s *RedisCache) DeleteGame(ctx context.Context, id string) error {
+	defer s.invalidate(ctx, id)
+	return s.inner.DeleteGame(ctx, id)
+}
+
+// LoadGame serves the game from the cache, loading it from the store on a miss
+func (s *RedisCache) LoadGame(ctx context.Context, id string) (*game.Board, error) {
+	if reply, err := s.client.Do(ctx, "GET", redisCacheTag+redisGamePrefix+id); err == nil {
+		if state, ok := reply.([]byte); ok {
+			board := &game.Board{}
+			if err := json.Unmarshal(state, board); err == nil {
+				return board, nil
+			}
+		}
+	}
+
+	board, err := s.inner.LoadGame(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.put(ctx, id, board)
+	return board, nil
+}
+
+// ListGames is not cached; listings change with every game and are already replica-friendly
+func (s *RedisCache) ListGames(ctx context.Context, limit, offset int) ([]GameRecord, error) {
+	return s.inner.ListGames(ctx, limit, offset)
+}
+
+// put caches a snapshot; if that fails the old copy is dropped, so it can't be served stale
+func (s *RedisCache) put(ctx context.Context, id string, board *game.Board) {
+	state, err := json.Marshal(board)
+	if err == nil {
+		_, err = s.client.Do(ctx, "SET", redisCacheTag+redisGamePrefix+id, state, "PX", s.ttl.Milliseconds())
+	}
+	if err != nil {
+		s.invalidate(ctx, id)
+	}
+}
+
+// invalidate drops a game from the cache
+func (s *RedisCache) invalidate(ctx context.Context, id string) {
+	s.client.Do(ctx, "DEL", redisCacheTag+redisGamePrefix+id)
+}
@@ -0,0 +1,63 @@
+// Package store persists games outside of the server's memory
+package store
+
+import (
+	"context"
+	"errors"
+	"go-game/game"
+	"time"
+)
+
+// ErrNotFound is returned when a game doesn't exist in the store
+var ErrNotFound = errors.New("game not found")
+
+// GameRecord is a stored game
+type GameRecord struct {
+	ID        string
+	Board     *game.Board
+	UpdatedAt time.Time // When the game was last saved
+	Corrupted bool      // The game failed its integrity check (see ErrCorrupted)
+}
+
+// Store keeps game snapshots
+type Store interface {
+	// SaveGame creates or replaces the snapshot of a game
+	SaveGame(ctx context.Context, id string, board *game.Board) error
+
+	// LoadGame returns the latest snapshot of a game
+	LoadGame(ctx context.Context, id string) (*game.Board, error)
+
+	// ListGames returns games ordered by most recently updated first
+	ListGames(ctx context.Context, limit, offset int) ([]GameRecord, error)
+
+	// DeleteGame removes a game; deleting a game that isn't stored is not an error
+	DeleteGame(ctx context.Context, id string) error
+}
+
+// Pinger is implemented by stores that depend on a server, to check it can be reached
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks a store can be reached; stores that don't depend on a server always can
+func Ping(ctx context.Context, s Store) error {
+	if pinger, ok := s.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// staleKey is the context key marking reads that may be served from a lagging replica
+type staleKey struct{}
+
+// AllowStale marks the reads made with ctx as tolerant of slightly outdated data
+// Stores with read replicas use this to take load off the primary
+func AllowStale(ctx context.Context) context.Context {
+	return context.WithValue(ctx, staleKey{}, true)
+}
+
+// StaleAllowed checks if reads made with ctx may return slightly outdated data
+func StaleAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(staleKey{}).(bool)
+	return allowed
+}
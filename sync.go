@@ -0,0 +1,145 @@
+package main
+
+import (
+	"go-game/game"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SyncResponse bundles everything a mobile client needs to catch up in a single call
+type SyncResponse struct {
+	Cursor        int64      `json:"cursor"`        // Pass this back as ?cursor= on the next sync
+	Reset         bool       `json:"reset"`         // True if events were missed and all the user's games are included
+	Games         []GameSync `json:"games"`         // Games of the user that changed since the cursor
+	Notifications []Event    `json:"notifications"` // Events of the user's games that happened since the cursor
+}
+
+// GameSync is a compact summary of one game, enough to decide whether it needs attention
+type GameSync struct {
+	GameID         string           `json:"gameId"`
+	Phase          game.Phase       `json:"phase"`               // Stage the game is in
+	CurrentPlayer  int              `json:"currentPlayer"`       // Player who has to move (1 = black, 2 = white)
+	NextMover      string           `json:"nextMover,omitempty"` // Team member who has to move (team games only)
+	MoveCount      int              `json:"moveCount"`           // Number of moves played so far
+	Size           int              `json:"size"`                // Board size
+	Position       string           `json:"position"`            // Current stones as seen by spectators, packed (see Board.PackedGrid)
+	Hotseat        bool             `json:"hotseat"`             // Both colors are played on one device
+	NeedsAttention bool             `json:"needsAttention"`      // True while the game waits for the user: their move, or their acceptance of the score (sync only)
+	Result         *game.Result     `json:"result"`              // Set once the game has ended
+	Clock          *game.ClockState `json:"clock"`               // Remaining time (nil for untimed games)
+	Corrupted      bool             `json:"corrupted,omitempty"` // The stored game failed its integrity check and isn't served
+	Ratings        [3]float64       `json:"ratings,omitzero"`    // Ratings of the accounts playing, on the game's track (listings only; 0 = no account)
+}
+
+// Batched sync of the user's games, notifications and clock states that changed since a cursor
+// Correspondence players poll this instead of keeping one connection open per game
+// Only the games the user plays are included, and never what only spectators may see
+func syncState(c echo.Context) error {
+	user, _ := currentUser(c)
+	var cursor int64
+	if param := c.QueryParam("cursor"); param != "" {
+		parsed, err := strconv.ParseInt(param, 10, 64)
+		if err != nil || parsed < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid cursor"})
+		}
+		cursor = parsed
+	}
+
+	events, next, complete := hub.EventsSince(cursor)
+
+	// Which games the user plays, looked up once per game
+	playing := make(map[string]bool)
+	playsIn := func(gameID string) bool {
+		plays, known := playing[gameID]
+		if !known {
+			if board, unlock, exists := lockGame(gameID); exists {
+				plays = playsGame(user.ID, gameID, board)
+				unlock()
+			}
+			playing[gameID] = plays
+		}
+		return plays
+	}
+
+	now := time.Now()
+	response := SyncResponse{
+		Cursor:        next,
+		Reset:         !complete,
+		Games:         make([]GameSync, 0),
+		Notifications: make([]Event, 0),
+	}
+
+	// Collect the user's games touched by the new events
+	changed := make(map[string]bool)
+	for _, event := range events {
+		if event.GameID == "" || spectatorOnlyEvents[event.Type] || !playsIn(event.GameID) {
+			continue
+		}
+		response.Notifications = append(response.Notifications, event)
+		changed[event.GameID] = true
+	}
+
+	// After missing events the client can't know what changed, so send all of the user's games
+	if !complete {
+		forEachGame(func(gameID string, board *game.Board) {
+			if playsGame(user.ID, gameID, board) {
+				response.Games = append(response.Games, summarizeGame(gameID, board, user.ID, now))
+			}
+		})
+		return c.JSON(http.StatusOK, response)
+	}
+	for gameID := range changed {
+		if board, unlock, exists := lockGame(gameID); exists {
+			response.Games = append(response.Games, summarizeGame(gameID, board, user.ID, now))
+			unlock()
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// playsGame checks if an account plays a game; sandbox games are nobody's to sync
+// Must be called with the game locked
+func playsGame(userID, gameID string, board *game.Board) bool {
+	return !isSandbox(gameID) && board.SeatOfUser(userID, 0) != 0
+}
+
+// summarizeGame builds the sync summary of a game, as the account userID sees it
+// Listings pass no account, and their games need nobody's attention
+func summarizeGame(gameID string, board *game.Board, userID string, now time.Time) GameSync {
+	summary := GameSync{
+		GameID:         gameID,
+		Phase:          board.Phase,
+		CurrentPlayer:  board.CurrentPlayer,
+		NextMover:      board.NextMover(),
+		MoveCount:      len(board.MoveHistory),
+		Size:           board.Size,
+		Position:       board.ViewFor(0).PackedGrid(),
+		Hotseat:        board.Hotseat,
+		NeedsAttention: waitsFor(board, userID),
+		Result:         board.Result,
+	}
+
+	summary.Clock = board.ClockState(now)
+	return summary
+}
+
+// waitsFor checks if a game is waiting for an account: for its move, or for it to accept the score
+func waitsFor(board *game.Board, userID string) bool {
+	switch {
+	case userID == "":
+		return false
+	case board.Phase == game.PhasePlaying:
+		return board.SeatOfUser(userID, board.CurrentPlayer) == board.CurrentPlayer
+	case board.Phase == game.PhaseScoring:
+		for player := 1; player <= 2; player++ {
+			if board.Players[player] == userID && !board.ScoreAccepted[player] {
+				return true
+			}
+		}
+	}
+	return false
+}
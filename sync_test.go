@@ -0,0 +1,87 @@
+package main
+
+import (
+	"go-game/game"
+	"testing"
+	"time"
+)
+
+// Accounts playing the games of the sync tests
+const (
+	syncBlack = "sync-black"
+	syncWhite = "sync-white"
+)
+
+// syncGame starts a game between two accounts and plays the given moves (-1 passes)
+func syncGame(t *testing.T, moves ...int) *game.Board {
+	t.Helper()
+	board := game.NewBoard(9)
+	for player, userID := range map[int]string{1: syncBlack, 2: syncWhite} {
+		if err := board.SitDown(player, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := board.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for _, position := range moves {
+		play := func() error { return board.MakeMove(position) }
+		if position < 0 {
+			play = board.Pass
+		}
+		if err := play(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return board
+}
+
+func TestSyncNeedsAttention(t *testing.T) {
+	tests := []struct {
+		name     string
+		moves    []int
+		accepted int // Player who accepted the score already
+		waiting  map[string]bool
+	}{
+		{name: "black to move", waiting: map[string]bool{syncBlack: true, syncWhite: false}},
+		{name: "white to move", moves: []int{40}, waiting: map[string]bool{syncBlack: false, syncWhite: true}},
+		{name: "black to move again", moves: []int{40, 30}, waiting: map[string]bool{syncBlack: true, syncWhite: false}},
+		{name: "scoring", moves: []int{40, -1, -1}, waiting: map[string]bool{syncBlack: true, syncWhite: true}},
+		{name: "scoring, black accepted", moves: []int{40, -1, -1}, accepted: 1, waiting: map[string]bool{syncBlack: false, syncWhite: true}},
+		{name: "scoring, white accepted", moves: []int{40, -1, -1}, accepted: 2, waiting: map[string]bool{syncBlack: true, syncWhite: false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := syncGame(t, tt.moves...)
+			if tt.accepted != 0 {
+				if err := board.AcceptScore(tt.accepted); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for userID, want := range tt.waiting {
+				if got := summarizeGame("game", board, userID, time.Now()).NeedsAttention; got != want {
+					t.Errorf("%s: needs attention = %v, want %v", userID, got, want)
+				}
+			}
+			for _, userID := range []string{"", "someone-else"} {
+				if summarizeGame("game", board, userID, time.Now()).NeedsAttention {
+					t.Errorf("the game needs the attention of %q", userID)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncFinishedGameNeedsNoAttention(t *testing.T) {
+	board := syncGame(t, 40)
+	if err := board.Resign(2); err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []string{syncBlack, syncWhite} {
+		if summarizeGame("game", board, userID, time.Now()).NeedsAttention {
+			t.Errorf("%s: the finished game needs attention", userID)
+		}
+	}
+}
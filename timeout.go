@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"go-game/game"
+	"go-game/i18n"
+	"go-game/plugin"
+	"go-game/webhooks"
+	"time"
+)
+
+// timeoutCheckInterval is how often the adjudicator looks at the game clocks
+const timeoutCheckInterval = time.Second
+
+// runTimeoutAdjudicator periodically ends games whose running clock has expired
+// Clients only display the clocks, so the server is the one enforcing them
+// It stops when ctx is cancelled (server shutdown)
+func runTimeoutAdjudicator(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			adjudicateTimeouts(ctx, now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// adjudicateTimeouts checks every game once and broadcasts the result of games lost on time
+// Correspondence players close to their deadline are warned on the way
+func adjudicateTimeouts(ctx context.Context, now time.Time) {
+	forEachGame(func(gameID string, board *game.Board) {
+		if board.CheckTimeout(now) {
+			saveGame(ctx, gameID, board)
+			announceResult(gameID, board, game.PhasePlaying) // Only games in play run out of time
+			return
+		}
+		warnDeadlines(gameID, board, now)
+	})
+}
+
+// announceResult broadcasts the result of a game if it has ended, and stops its bot
+// phase is the game's phase before the change, so a game that had already ended
+// (e.g. a move rejected after the end) isn't announced again
+// Must be called with the game locked
+func announceResult(gameID string, board *game.Board, phase game.Phase) {
+	if board.Result != nil && phase != game.PhaseFinished {
+		hub.Broadcast(Event{Type: EventGameOver, GameID: gameID, Data: describeResult(board.Result, i18n.Default)})
+		releaseBot(gameID)
+		rateGame(gameID, board)
+		archiveGame(gameID, board)
+		plugin.GameFinished(gameID, board)
+		publishWebhook(webhooks.EventGameFinished, gameID, board)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// HTTPS without a proxy in front: the server either serves a certificate from files
+// (TLS_CERT_FILE and TLS_KEY_FILE) or gets its own from Let's Encrypt for the domains in
+// TLS_AUTOCERT_DOMAINS, e.g. "go.example.org,www.go.example.org"
+// Certificates obtained are kept in TLS_AUTOCERT_CACHE, so restarts don't ask for new ones;
+// Let's Encrypt reaches the server on port 80 to check the domains are ours, and everything
+// else arriving there is redirected to HTTPS
+
+// TLS modes
+const (
+	TLSOff   = "off"   // Plain HTTP, e.g. behind a proxy that does TLS
+	TLSFiles = "files" // Certificate and key from files
+	TLSAuto  = "auto"  // Certificates from Let's Encrypt
+)
+
+// Listening addresses
+const (
+	defaultHTTPAddr       = ":8080" // Without TLS
+	defaultHTTPSAddr      = ":443"
+	defaultRedirectAddr   = ":80" // ACME challenges and redirects to HTTPS (autocert only)
+	defaultAutocertCache  = "autocert-cache"
+	redirectServerTimeout = 10 * time.Second
+)
+
+// TLSConfig says how the server listens
+type TLSConfig struct {
+	Mode         string   // See the TLS* constants
+	Addr         string   // Address of the API (LISTEN_ADDR)
+	CertFile     string   // Certificate chain, PEM (TLSFiles)
+	KeyFile      string   // Private key, PEM (TLSFiles)
+	Domains      []string // Domains to get certificates for (TLSAuto)
+	CacheDir     string   // Where certificates are kept (TLSAuto)
+	Email        string   // Contact for Let's Encrypt about the certificates, optional (TLSAuto)
+	RedirectAddr string   // Address answering ACME challenges and redirecting to HTTPS (TLSAuto, "off" for none)
+}
+
+// redirectServer answers on port 80 next to an autocert server (nil otherwise)
+var redirectServer *http.Server
+
+// tlsConfigFromEnv reads how the server listens from LISTEN_ADDR, TLS_CERT_FILE, TLS_KEY_FILE,
+// TLS_AUTOCERT_DOMAINS, TLS_AUTOCERT_CACHE, TLS_AUTOCERT_EMAIL and TLS_REDIRECT_ADDR
+func tlsConfigFromEnv() (TLSConfig, error) {
+	config := TLSConfig{
+		Mode:         TLSOff,
+		Addr:         os.Getenv("LISTEN_ADDR"),
+		CertFile:     os.Getenv("TLS_CERT_FILE"),
+		KeyFile:      os.Getenv("TLS_KEY_FILE"),
+		Domains:      splitList(os.Getenv("TLS_AUTOCERT_DOMAINS")),
+		CacheDir:     os.Getenv("TLS_AUTOCERT_CACHE"),
+		Email:        os.Getenv("TLS_AUTOCERT_EMAIL"),
+		RedirectAddr: os.Getenv("TLS_REDIRECT_ADDR"),
+	}
+
+	switch {
+	case len(config.Domains) > 0 && (config.CertFile != "" || config.KeyFile != ""):
+		return config, errors.New("set either TLS_AUTOCERT_DOMAINS or TLS_CERT_FILE and TLS_KEY_FILE, not both")
+	case len(config.Domains) > 0:
+		config.Mode = TLSAuto
+	case config.CertFile != "" && config.KeyFile != "":
+		config.Mode = TLSFiles
+	case config.CertFile != "" || config.KeyFile != "":
+		return config, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if config.Addr == "" {
+		config.Addr = defaultHTTPSAddr
+		if config.Mode == TLSOff {
+			config.Addr = defaultHTTPAddr
+		}
+	}
+	if config.CacheDir == "" {
+		config.CacheDir = defaultAutocertCache
+	}
+	if config.RedirectAddr == "" {
+		config.RedirectAddr = defaultRedirectAddr
+	}
+	return config, nil
+}
+
+// setUpAutocert prepares getting certificates from Let's Encrypt and starts the redirect server
+// Does nothing unless the mode is TLSAuto; call it before startServer
+func setUpAutocert(e *echo.Echo, config TLSConfig) {
+	if config.Mode != TLSAuto {
+		return
+	}
+	e.AutoTLSManager = autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(config.Domains...),
+		Cache:      autocert.DirCache(config.CacheDir),
+		Email:      config.Email,
+	}
+	if config.RedirectAddr == TLSOff {
+		return
+	}
+
+	redirectServer = &http.Server{
+		Addr:              config.RedirectAddr,
+		Handler:           e.AutoTLSManager.HTTPHandler(nil), // Redirects everything but challenges
+		ReadHeaderTimeout: redirectServerTimeout,
+	}
+	go func() {
+		if err := redirectServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("redirect server on %s: %v", config.RedirectAddr, err)
+		}
+	}()
+}
+
+// startServer serves the API as configured, until the server is shut down
+func startServer(e *echo.Echo, config TLSConfig) error {
+	switch config.Mode {
+	case TLSFiles:
+		return e.StartTLS(config.Addr, config.CertFile, config.KeyFile)
+	case TLSAuto:
+		return e.StartAutoTLS(config.Addr)
+	case TLSOff:
+		return e.Start(config.Addr)
+	}
+	return fmt.Errorf("unknown TLS mode %q", config.Mode)
+}
+
+// stopRedirectServer shuts the redirect server down, if there is one
+func stopRedirectServer(ctx context.Context) {
+	if redirectServer == nil {
+		return
+	}
+	if err := redirectServer.Shutdown(ctx); err != nil {
+		redirectServer.Close()
+	}
+}
@@ -0,0 +1,103 @@
+package users
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// MemoryStore keeps accounts in memory, for servers without a database
+type MemoryStore struct {
+	mu    sync.Mutex
+	users map[string]User // By ID
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{users: make(map[string]User)}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, user User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.taken(user) {
+		return ErrTaken
+	}
+	s.users[user.ID] = user
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (s *MemoryStore) FindByUsername(ctx context.Context, username string) (User, error) {
+	return s.find(func(user User) bool { return strings.EqualFold(user.Username, username) })
+}
+
+func (s *MemoryStore) FindByEmail(ctx context.Context, email string) (User, error) {
+	if email == "" {
+		return User{}, ErrNotFound
+	}
+	return s.find(func(user User) bool { return strings.EqualFold(user.Email, email) })
+}
+
+func (s *MemoryStore) Update(ctx context.Context, user User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.ID]; !ok {
+		return ErrNotFound
+	}
+	if s.taken(user) {
+		return ErrTaken
+	}
+	s.users[user.ID] = user
+	return nil
+}
+
+// Delete removes an account; ErrNotFound if there is none with the ID
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.users, id)
+	return nil
+}
+
+// find returns the first account that matches
+func (s *MemoryStore) find(match func(User) bool) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+	return User{}, ErrNotFound
+}
+
+// taken checks if another account has the user's username or email; must be called with mu held
+func (s *MemoryStore) taken(user User) bool {
+	for _, other := range s.users {
+		if other.ID == user.ID {
+			continue
+		}
+		if strings.EqualFold(other.Username, user.Username) || (user.Email != "" && strings.EqualFold(other.Email, user.Email)) {
+			return true
+		}
+	}
+	return false
+}
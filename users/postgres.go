@@ -0,0 +1,114 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// schema creates the tables the store needs
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	id            TEXT PRIMARY KEY,
+	username      TEXT NOT NULL,
+	email         TEXT NOT NULL DEFAULT '',
+	display_name  TEXT NOT NULL,
+	rank          TEXT NOT NULL DEFAULT '',
+	password_hash BYTEA NOT NULL,
+	created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+	password_changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS users_username ON users (lower(username));
+CREATE UNIQUE INDEX IF NOT EXISTS users_email ON users (lower(email)) WHERE email <> '';
+`
+
+// uniqueViolation is the Postgres error code of a duplicate key
+const uniqueViolation = "23505"
+
+// columns are the account columns, in the order scan reads them
+const columns = `id, username, email, display_name, rank, password_hash, created_at, password_changed_at`
+
+// PostgresStore keeps accounts in Postgres
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to the database and creates the schema
+func NewPostgresStore(url string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close releases the database connections
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks the database can be reached
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *PostgresStore) Create(ctx context.Context, user User) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (`+columns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		user.ID, user.Username, user.Email, user.DisplayName, user.Rank, user.PasswordHash, user.CreatedAt, user.PasswordChangedAt)
+	return storeError(err)
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id string) (User, error) {
+	return s.scan(s.db.QueryRowContext(ctx, `SELECT `+columns+` FROM users WHERE id = $1`, id))
+}
+
+func (s *PostgresStore) FindByUsername(ctx context.Context, username string) (User, error) {
+	return s.scan(s.db.QueryRowContext(ctx, `SELECT `+columns+` FROM users WHERE lower(username) = lower($1)`, username))
+}
+
+func (s *PostgresStore) FindByEmail(ctx context.Context, email string) (User, error) {
+	if email == "" {
+		return User{}, ErrNotFound
+	}
+	return s.scan(s.db.QueryRowContext(ctx, `SELECT `+columns+` FROM users WHERE lower(email) = lower($1)`, email))
+}
+
+func (s *PostgresStore) Update(ctx context.Context, user User) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users SET username = $2, email = $3, display_name = $4, rank = $5, password_hash = $6, password_changed_at = $7
+		WHERE id = $1`,
+		user.ID, user.Username, user.Email, user.DisplayName, user.Rank, user.PasswordHash, user.PasswordChangedAt)
+	if err != nil {
+		return storeError(err)
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// scan reads one account
+func (s *PostgresStore) scan(row *sql.Row) (User, error) {
+	var user User
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.DisplayName, &user.Rank, &user.PasswordHash, &user.CreatedAt, &user.PasswordChangedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	return user, err
+}
+
+// storeError turns duplicate keys into ErrTaken
+func storeError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return ErrTaken
+	}
+	return err
+}
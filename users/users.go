@@ -0,0 +1,126 @@
+// Package users keeps the accounts players sign in with
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-game/rating"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Store errors
+var (
+	ErrNotFound = errors.New("user not found")
+	ErrTaken    = errors.New("username or email already in use")
+)
+
+// Limits of the account fields
+const (
+	MinPasswordLength    = 8
+	MaxPasswordLength    = 72 // bcrypt ignores anything longer
+	MaxDisplayNameLength = 40
+)
+
+// validUsername allows letters, digits, dashes and underscores
+var validUsername = regexp.MustCompile(`^[A-Za-z0-9_-]{3,20}$`)
+
+// User is a player's account
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"` // Unique, compared case-insensitively
+	Email        string    `json:"email,omitempty"`
+	DisplayName  string    `json:"displayName"`
+	Rank         string    `json:"rank,omitempty"` // e.g. "5k" or "2d"
+	CreatedAt    time.Time `json:"createdAt"`
+	PasswordHash []byte    `json:"-"`
+
+	// When the password was set; tokens issued before then no longer sign in
+	PasswordChangedAt time.Time `json:"-"`
+}
+
+// Profile is what everyone may see of an account
+type Profile struct {
+	ID          string         `json:"id"`
+	Username    string         `json:"username"`
+	DisplayName string         `json:"displayName"`
+	Rank        string         `json:"rank,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	Ratings     rating.Profile `json:"ratings,omitempty"` // Ratings on the tracks played, where the profile is served with them
+}
+
+// Profile returns the public part of the account
+func (u User) Profile() Profile {
+	return Profile{ID: u.ID, Username: u.Username, DisplayName: u.DisplayName, Rank: u.Rank, CreatedAt: u.CreatedAt}
+}
+
+// SetPassword replaces the password at a time; only a bcrypt hash of it is kept
+func (u *User) SetPassword(password string, now time.Time) error {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return fmt.Errorf("passwords need %d to %d characters", MinPasswordLength, MaxPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PasswordHash, u.PasswordChangedAt = hash, now
+	return nil
+}
+
+// CheckPassword checks a password against the stored hash
+func (u User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword(u.PasswordHash, []byte(password)) == nil
+}
+
+// Validate checks the account fields, tidying up their spacing
+func (u *User) Validate() error {
+	u.Username = strings.TrimSpace(u.Username)
+	u.Email = strings.TrimSpace(u.Email)
+	u.DisplayName = strings.TrimSpace(u.DisplayName)
+	u.Rank = strings.TrimSpace(u.Rank)
+
+	if !validUsername.MatchString(u.Username) {
+		return fmt.Errorf("usernames need 3 to 20 letters, digits, dashes or underscores")
+	}
+	if u.Email != "" {
+		if address, err := mail.ParseAddress(u.Email); err != nil || address.Address != u.Email {
+			return fmt.Errorf("invalid email address")
+		}
+	}
+	if u.DisplayName == "" {
+		u.DisplayName = u.Username
+	}
+	if utf8.RuneCountInString(u.DisplayName) > MaxDisplayNameLength {
+		return fmt.Errorf("display names can have at most %d characters", MaxDisplayNameLength)
+	}
+	if u.Rank != "" {
+		if _, ok := rating.RankRating(u.Rank); !ok {
+			return fmt.Errorf("invalid rank %q (e.g. 5k or 2d)", u.Rank)
+		}
+	}
+	return nil
+}
+
+// Store keeps the accounts
+type Store interface {
+	// Create adds an account; ErrTaken if its username or email is in use
+	Create(ctx context.Context, user User) error
+
+	// Get returns the account with the ID
+	Get(ctx context.Context, id string) (User, error)
+
+	// FindByUsername returns the account with the username, in any case
+	FindByUsername(ctx context.Context, username string) (User, error)
+
+	// FindByEmail returns the account with the email address, in any case
+	FindByEmail(ctx context.Context, email string) (User, error)
+
+	// Update replaces an account; ErrTaken if its new email is in use
+	Update(ctx context.Context, user User) error
+}
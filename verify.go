@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"go-game/game"
+	"go-game/gtp"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// scoreVerifier is the command line of the GTP engine that double-checks final scores,
+// e.g. SCORE_VERIFIER="gnugo --mode gtp"; verification is off when it is empty
+var scoreVerifier = os.Getenv("SCORE_VERIFIER")
+
+// Limits of score verification
+const (
+	scoreVerificationTimeout = time.Minute
+	maxScoreVerifications    = 2 // Engines running at the same time
+)
+
+// scoreVerificationSlots bounds how many verifier engines run at once
+var scoreVerificationSlots = make(chan struct{}, maxScoreVerifications)
+
+// ScoreCheck is the outcome of comparing a final score with the reference engine
+type ScoreCheck struct {
+	GameID    string    `json:"gameId"`
+	Server    string    `json:"server"`          // Our result, e.g. "B+3.5"
+	Reference string    `json:"reference"`       // The engine's final_score
+	Flagged   bool      `json:"flagged"`         // True if the results differ and need review
+	Error     string    `json:"error,omitempty"` // Why the check could not be made
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// scoreChecks holds the latest check of each game
+var (
+	scoreChecks   = make(map[string]ScoreCheck)
+	scoreChecksMu sync.Mutex
+)
+
+// verifyScoreAsync checks the final score of a game counted by the players in the background
+// The board is copied so the check can run without holding the game's lock
+func verifyScoreAsync(ctx context.Context, gameID string, board *game.Board) {
+	if scoreVerifier == "" || board.Result == nil || board.Result.Reason != game.ReasonScore || board.Sandbox {
+		return
+	}
+
+	board = board.Clone()
+	go func() {
+		scoreVerificationSlots <- struct{}{}
+		defer func() { <-scoreVerificationSlots }()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), scoreVerificationTimeout)
+		defer cancel()
+
+		check := verifyScore(ctx, gameID, board)
+
+		scoreChecksMu.Lock()
+		scoreChecks[gameID] = check
+		scoreChecksMu.Unlock()
+
+		if check.Flagged {
+			log.Printf("score of game %s needs review: server %s, reference %s", gameID, check.Server, check.Reference)
+		}
+	}()
+}
+
+// verifyScore asks the reference engine to count a finished game and compares the results
+func verifyScore(ctx context.Context, gameID string, board *game.Board) ScoreCheck {
+	check := ScoreCheck{GameID: gameID, Server: board.Result.String(), CheckedAt: time.Now()}
+
+	engine, err := gtp.StartCommandLine(ctx, scoreVerifier)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	defer engine.Close()
+
+	if err := engine.LoadBoard(ctx, board); err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	reference, err := engine.FinalScore(ctx)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	check.Reference = reference
+	check.Flagged = reference != check.Server
+	return check
+}
+
+// List score checks, most recent first; ?flagged=true only returns the ones needing review
+func listScoreChecks(c echo.Context) error {
+	flaggedOnly := c.QueryParam("flagged") == "true"
+
+	scoreChecksMu.Lock()
+	checks := make([]ScoreCheck, 0, len(scoreChecks))
+	for _, check := range scoreChecks {
+		if !flaggedOnly || check.Flagged {
+			checks = append(checks, check)
+		}
+	}
+	scoreChecksMu.Unlock()
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].CheckedAt.After(checks[j].CheckedAt)
+	})
+	return c.JSON(http.StatusOK, checks)
+}
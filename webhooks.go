@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"go-game/game"
+	"go-game/i18n"
+	"go-game/webhooks"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Outbound webhooks: users register URLs that receive a signed POST when a game they play
+// starts, gets a move or finishes, for tournament trackers, notification services and the like
+// Receivers check X-Webhook-Signature (see webhooks.Sign) with the secret shown at registration
+
+// webhookStore keeps the registered webhooks (Postgres if DATABASE_URL is set, memory otherwise)
+var webhookStore webhooks.Store
+
+// webhookDispatcher delivers the events to the webhooks (set up with the store)
+var webhookDispatcher *webhooks.Dispatcher
+
+// Webhook settings
+const (
+	maxWebhooksPerUser = 10
+	webhookWorkers     = 4 // Deliveries made at the same time
+)
+
+// newWebhookStoreFromEnv opens the webhook store next to the accounts
+func newWebhookStoreFromEnv() (webhooks.Store, error) {
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		return webhooks.NewPostgresStore(url)
+	}
+	return webhooks.NewMemoryStore(), nil
+}
+
+// Webhook request structure
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"` // Omit for every event (see webhooks.Events)
+}
+
+// Webhook response structure, with the secret only when the webhook is created
+type WebhookResponse struct {
+	webhooks.Webhook
+	Secret string `json:"secret,omitempty"`
+}
+
+// Register a webhook for the user's games
+func createWebhook(c echo.Context) error {
+	var req WebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	user, _ := currentUser(c)
+	ctx := c.Request().Context()
+	existing, err := webhookStore.List(ctx, user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if len(existing) >= maxWebhooksPerUser {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Too many webhooks, delete one first"})
+	}
+
+	webhook, err := webhooks.New(user.ID, req.URL, req.Events, time.Now())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := webhookStore.Create(ctx, webhook); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, WebhookResponse{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// List the user's webhooks
+func listWebhooks(c echo.Context) error {
+	user, _ := currentUser(c)
+	list, err := webhookStore.List(c.Request().Context(), user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	response := make([]WebhookResponse, len(list))
+	for i, webhook := range list {
+		response[i] = WebhookResponse{Webhook: webhook}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// Remove one of the user's webhooks
+func deleteWebhook(c echo.Context) error {
+	user, _ := currentUser(c)
+	err := webhookStore.Delete(c.Request().Context(), user.ID, c.Param("wid"))
+	if errors.Is(err, webhooks.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Webhook not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// WebhookGame is what webhook events tell about the game
+type WebhookGame struct {
+	Size      int    `json:"size"`
+	Variant   string `json:"variant"`
+	BlackName string `json:"blackName"`
+	WhiteName string `json:"whiteName"`
+	Black     string `json:"black,omitempty"` // Account playing black, if any
+	White     string `json:"white,omitempty"` // Account playing white, if any
+	MoveCount int    `json:"moveCount"`
+}
+
+// WebhookMove is the data of move events
+type WebhookMove struct {
+	Game       WebhookGame `json:"game"`
+	Player     int         `json:"player"`     // 1 = black, 2 = white
+	Position   int         `json:"position"`   // -1 for a pass
+	Coordinate string      `json:"coordinate"` // Standard notation, "pass" for a pass
+}
+
+// WebhookTurn is the data of turn events
+type WebhookTurn struct {
+	Game     WebhookGame `json:"game"`
+	Player   int         `json:"player"`             // Player to move (1 = black, 2 = white)
+	Deadline *time.Time  `json:"deadline,omitempty"` // When they lose on time (not set while on vacation)
+}
+
+// WebhookResult is the data of finished events
+type WebhookResult struct {
+	Game   WebhookGame       `json:"game"`
+	Result ResultDescription `json:"result"`
+}
+
+// publishWebhook sends an event of a game to the webhooks of its players
+// It is called with the game's lock held, so the data is taken now and delivered later
+func publishWebhook(eventType, gameID string, board *game.Board) {
+	if webhookDispatcher == nil || board.Sandbox {
+		return
+	}
+	var players []string
+	for player, userID := range board.Players {
+		if eventType == webhooks.EventTurnStarted && player != board.CurrentPlayer {
+			continue // Only the player to move hears of their turn
+		}
+		if userID != "" && (len(players) == 0 || players[0] != userID) {
+			players = append(players, userID)
+		}
+	}
+	if len(players) == 0 {
+		return
+	}
+
+	// Like the hub events, webhooks only learn what a spectator may see
+	view := board.ViewFor(0)
+	summary := WebhookGame{
+		Size:      view.Size,
+		Variant:   view.Variant,
+		BlackName: view.Info.BlackName,
+		WhiteName: view.Info.WhiteName,
+		Black:     view.Players[1],
+		White:     view.Players[2],
+		MoveCount: len(view.MoveHistory),
+	}
+
+	var data any
+	switch eventType {
+	case webhooks.EventGameStarted:
+		data = summary
+	case webhooks.EventMovePlayed:
+		if len(view.MoveHistory) == 0 {
+			return
+		}
+		move := view.MoveHistory[len(view.MoveHistory)-1]
+		data = WebhookMove{Game: summary, Player: move.Player, Position: move.Position, Coordinate: game.FormatCoordinate(move.Position, view.Size)}
+	case webhooks.EventTurnStarted:
+		data = WebhookTurn{Game: summary, Player: board.CurrentPlayer, Deadline: turnDeadline(board, time.Now())}
+	case webhooks.EventGameFinished:
+		if view.Result == nil {
+			return
+		}
+		data = WebhookResult{Game: summary, Result: describeResult(view.Result, i18n.Default)}
+	}
+
+	webhookDispatcher.Publish(webhooks.Event{Type: eventType, GameID: gameID, Users: players, Time: time.Now(), Data: data})
+}
@@ -0,0 +1,194 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Delivery headers
+const (
+	HeaderID        = "X-Webhook-ID"        // ID of the delivery, the same on every attempt
+	HeaderEvent     = "X-Webhook-Event"     // Event type
+	HeaderTimestamp = "X-Webhook-Timestamp" // Unix time the delivery was signed at
+	HeaderSignature = "X-Webhook-Signature" // "sha256=" and the signature (see Sign)
+)
+
+// Delivery settings
+const (
+	queueSize      = 1024             // Events waiting for a worker; more are dropped
+	requestTimeout = 10 * time.Second // Bounds each attempt
+)
+
+// retryDelays are the waits before the attempts after the first
+var retryDelays = []time.Duration{5 * time.Second, 30 * time.Second, 2 * time.Minute}
+
+// Event is something that happened in a game, for the webhooks of some users
+type Event struct {
+	Type   string // See the Event* constants
+	GameID string
+	Users  []string // Accounts whose webhooks receive it
+	Time   time.Time
+	Data   any // Encoded as JSON
+}
+
+// Payload is the body of a delivery
+type Payload struct {
+	ID     string    `json:"id"` // ID of the delivery
+	Type   string    `json:"type"`
+	GameID string    `json:"gameId"`
+	Time   time.Time `json:"time"`
+	Data   any       `json:"data"`
+}
+
+// Dispatcher posts events to the webhooks subscribed to them, on workers of its own so
+// slow receivers never hold up the games
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	queue  chan Event
+}
+
+// NewDispatcher creates a dispatcher for the webhooks of a store
+// Unless allowPrivate is set, webhooks can't reach loopback, private and link-local
+// addresses, so users can't make the server call into its own network
+func NewDispatcher(store Store, allowPrivate bool) *Dispatcher {
+	dialer := &net.Dialer{Timeout: requestTimeout}
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+	}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: requestTimeout,
+		MaxIdleConnsPerHost: 2,
+	}
+	return &Dispatcher{
+		store: store,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   requestTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse // Redirects count as failures
+			},
+		},
+		queue: make(chan Event, queueSize),
+	}
+}
+
+// refusePrivate stops connections to addresses inside the server's network
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("webhooks may not call %s", host)
+	}
+	return nil
+}
+
+// Publish queues an event for delivery; it never blocks, dropping the event if the queue is full
+func (d *Dispatcher) Publish(event Event) {
+	if len(event.Users) == 0 {
+		return
+	}
+	select {
+	case d.queue <- event:
+	default:
+		log.Printf("webhooks: queue full, dropping a %s event of game %s", event.Type, event.GameID)
+	}
+}
+
+// Run delivers the queued events on a number of workers, until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context, workers int) {
+	for range workers {
+		go func() {
+			for {
+				select {
+				case event := <-d.queue:
+					d.dispatch(ctx, event)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
+
+// dispatch delivers an event to every webhook of its users subscribed to it
+func (d *Dispatcher) dispatch(ctx context.Context, event Event) {
+	for _, userID := range event.Users {
+		webhooks, err := d.store.List(ctx, userID)
+		if err != nil {
+			log.Printf("webhooks: listing the webhooks of user %s: %v", userID, err)
+			continue
+		}
+		for _, webhook := range webhooks {
+			if !webhook.Subscribes(event.Type) {
+				continue
+			}
+			payload := Payload{ID: randomString(12), Type: event.Type, GameID: event.GameID, Time: event.Time, Data: event.Data}
+			if err := d.deliver(ctx, webhook, payload); err != nil && ctx.Err() == nil {
+				log.Printf("webhooks: delivering %s to webhook %s: %v", event.Type, webhook.ID, err)
+			}
+		}
+	}
+}
+
+// deliver posts a payload to a webhook, retrying failed attempts
+// Any 2xx answer counts as received
+func (d *Dispatcher) deliver(ctx context.Context, webhook Webhook, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = d.post(ctx, webhook, payload, body)
+		if err == nil || attempt == len(retryDelays) {
+			return err
+		}
+		select {
+		case <-time.After(retryDelays[attempt]):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// post makes one delivery attempt, signed at the time of the attempt
+func (d *Dispatcher) post(ctx context.Context, webhook Webhook, payload Payload, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-game-webhooks")
+	req.Header.Set(HeaderID, payload.ID)
+	req.Header.Set(HeaderEvent, payload.Type)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, "sha256="+Sign(webhook.Secret, now, body))
+
+	response, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 4<<10)) // Lets the connection be reused
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return errors.New("answered " + response.Status)
+	}
+	return nil
+}
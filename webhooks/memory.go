@@ -0,0 +1,51 @@
+package webhooks
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryStore keeps webhooks in memory, for servers without a database
+type MemoryStore struct {
+	mu       sync.Mutex
+	webhooks map[string]Webhook // By ID
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{webhooks: make(map[string]Webhook)}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, webhook Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, userID string) ([]Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []Webhook
+	for _, webhook := range s.webhooks {
+		if webhook.UserID == userID {
+			list = append(list, webhook)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if webhook, ok := s.webhooks[id]; !ok || webhook.UserID != userID {
+		return ErrNotFound
+	}
+	delete(s.webhooks, id)
+	return nil
+}
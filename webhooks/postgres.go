@@ -0,0 +1,92 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	_ "github.com/lib/pq" // Postgres driver for database/sql
+)
+
+// schema creates the tables the store needs
+const schema = `
+CREATE TABLE IF NOT EXISTS webhooks (
+	id          TEXT PRIMARY KEY,
+	user_id     TEXT NOT NULL,
+	url         TEXT NOT NULL,
+	events      TEXT NOT NULL,
+	secret      TEXT NOT NULL,
+	created_at  TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS webhooks_user ON webhooks (user_id);
+`
+
+// columns are the webhook columns, in the order List reads them
+const columns = `id, user_id, url, events, secret, created_at`
+
+// PostgresStore keeps webhooks in Postgres
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to the database and creates the schema
+func NewPostgresStore(url string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close releases the database connections
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks the database can be reached
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *PostgresStore) Create(ctx context.Context, webhook Webhook) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhooks (`+columns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		webhook.ID, webhook.UserID, webhook.URL, strings.Join(webhook.Events, ","), webhook.Secret, webhook.CreatedAt)
+	return err
+}
+
+func (s *PostgresStore) List(ctx context.Context, userID string) ([]Webhook, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+columns+` FROM webhooks WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Webhook
+	for rows.Next() {
+		var webhook Webhook
+		var events string
+		if err := rows.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &events, &webhook.Secret, &webhook.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhook.Events = strings.Split(events, ",")
+		list = append(list, webhook)
+	}
+	return list, rows.Err()
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, userID, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Package webhooks keeps the URLs users register to hear about their games, and posts
+// signed events to them
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// ErrNotFound is returned for unknown webhooks, and webhooks of other users
+var ErrNotFound = errors.New("webhook not found")
+
+// Events a webhook can subscribe to
+const (
+	EventGameStarted  = "game.started"  // A game the user plays was created
+	EventMovePlayed   = "move.played"   // A stone was played or a player passed
+	EventTurnStarted  = "turn.started"  // It's the user's move in a correspondence game
+	EventGameFinished = "game.finished" // A game the user plays has ended
+)
+
+// Events lists every event, in the order they happen in a game
+var Events = []string{EventGameStarted, EventMovePlayed, EventTurnStarted, EventGameFinished}
+
+// Limits of the webhook fields
+const (
+	MaxURLLength = 2048
+)
+
+// Webhook is a URL that receives the events of its user's games
+// The secret signs every delivery (see Sign); it is only shown when the webhook is created
+type Webhook struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"` // Subscribed events (see the Event* constants)
+	CreatedAt time.Time `json:"createdAt"`
+	Secret    string    `json:"-"`
+}
+
+// Subscribes reports whether the webhook receives an event
+func (w Webhook) Subscribes(event string) bool {
+	return slices.Contains(w.Events, event)
+}
+
+// Store keeps webhooks
+type Store interface {
+	// Create adds a webhook
+	Create(ctx context.Context, webhook Webhook) error
+	// List returns the webhooks of a user, oldest first
+	List(ctx context.Context, userID string) ([]Webhook, error)
+	// Delete removes a webhook of a user
+	Delete(ctx context.Context, userID, id string) error
+}
+
+// New creates a webhook with a fresh secret, checking its URL and events
+// Without events, it subscribes to all of them
+func New(userID, rawURL string, events []string, now time.Time) (Webhook, error) {
+	if err := validateURL(rawURL); err != nil {
+		return Webhook{}, err
+	}
+	if len(events) == 0 {
+		events = Events
+	}
+	for _, event := range events {
+		if !slices.Contains(Events, event) {
+			return Webhook{}, fmt.Errorf("unknown event %q", event)
+		}
+	}
+	return Webhook{
+		ID:        randomString(12),
+		UserID:    userID,
+		URL:       rawURL,
+		Events:    slices.Compact(slices.Sorted(slices.Values(events))),
+		CreatedAt: now,
+		Secret:    randomString(32),
+	}, nil
+}
+
+// validateURL accepts absolute http and https URLs
+func validateURL(rawURL string) error {
+	if len(rawURL) > MaxURLLength {
+		return fmt.Errorf("webhook URLs can have at most %d characters", MaxURLLength)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User != nil {
+		return errors.New("webhook URLs must be http or https URLs without credentials")
+	}
+	return nil
+}
+
+// Sign returns the signature of a delivery: the HMAC-SHA256 of "<timestamp>.<body>" under the
+// webhook's secret, hex encoded; receivers compute it too and compare
+// The timestamp is signed along, so receivers can turn away replayed deliveries
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// randomString returns n random bytes, URL-safe encoded
+func randomString(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go-game/auth"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vmihailenco/msgpack/v5"
+	"golang.org/x/net/websocket"
+)
+
+// maxSocketMessage is the largest message a client may send, in bytes
+const maxSocketMessage = 64 << 10
+
+// clockTickInterval is how often clients following a timed game are sent its clock
+const clockTickInterval = time.Second
+
+// Messages only sent over WebSockets, next to the hub events (see the Event* constants)
+// They are not part of the event log, so their Seq is 0
+const (
+	SocketJoined = "joined" // The connection follows a game now; the data is the board
+	SocketLeft   = "left"   // The connection no longer follows a game
+	SocketClock  = "clock"  // The clock of a game whose clock is running, every clockTickInterval
+	SocketBoard  = "board"  // The board after a change, as the connection's seat may see it
+	SocketError  = "error"  // A request was rejected; the data has the reason
+
+	SocketShutdown  = "shutdown"  // The server is going away; reconnect in a moment
+	SocketMatched   = "matched"   // Matchmaking started a game for the user; the data is the match
+	SocketChallenge = "challenge" // A challenge the user sent or received changed; the data is the challenge
+	SocketYourTurn  = "your_turn" // It's the user's move in a correspondence game; the data is the turn notice
+	SocketKicked    = "kicked"    // An admin closed the connection; the data has the reason
+
+	SocketReplay    = "replay"     // A step of a replay; the data is the replay step
+	SocketReplayEnd = "replay_end" // A replay is over; the data has the reason
+)
+
+// Socket request structure
+// Clients send JSON text frames, or MessagePack binary frames on the go-game.msgpack subprotocol
+type SocketRequest struct {
+	Type   string `json:"type"`   // "join", "leave", "move", "pass", "resign", or "replay", "replay_step", "replay_pace" and "replay_stop"
+	GameID string `json:"gameId"` // Game the request is about (optional for moves if only one game is followed)
+	Player int    `json:"player"` // Seat to join as (1 = black, 2 = white, 0 = spectator)
+	Token  string `json:"token"`  // Token of the seat (join as a player, unless the signed in account plays it; team members move with their own)
+
+	// Moves, as in MoveRequest
+	Position   int    `json:"position"`
+	Coordinate string `json:"coordinate"`
+	Member     string `json:"member"` // Team member making the move (team games whose members hold no seats)
+
+	// Replays of finished games
+	Pace int `json:"pace"` // Milliseconds between the moves (0 = one move per replay_step request)
+	From int `json:"from"` // Moves played before the first one sent (0 = from the start)
+}
+
+// Spectators following each game over WebSocket
+var (
+	spectatorsMu sync.Mutex
+	spectators   = make(map[string]int)
+)
+
+// Every open connection by ID, so admins can see and close them
+var (
+	socketsMu sync.Mutex
+	sockets   = make(map[string]*socketClient)
+)
+
+// addSocket records an open connection under a new ID
+func addSocket(s *socketClient) {
+	socketsMu.Lock()
+	defer socketsMu.Unlock()
+
+	for {
+		var id [9]byte
+		rand.Read(id[:])
+		s.id = base64.RawURLEncoding.EncodeToString(id[:])
+		if _, taken := sockets[s.id]; !taken {
+			sockets[s.id] = s
+			return
+		}
+	}
+}
+
+// removeSocket forgets a closed connection
+func removeSocket(s *socketClient) {
+	socketsMu.Lock()
+	defer socketsMu.Unlock()
+
+	delete(sockets, s.id)
+}
+
+// Connections of each signed in user, for messages meant for them alone
+var (
+	userSocketsMu sync.Mutex
+	userSockets   = make(map[string]map[*socketClient]bool)
+)
+
+// addUserSocket records a connection of a signed in user
+func addUserSocket(s *socketClient) {
+	userSocketsMu.Lock()
+	defer userSocketsMu.Unlock()
+
+	if userSockets[s.user.ID] == nil {
+		userSockets[s.user.ID] = make(map[*socketClient]bool)
+	}
+	userSockets[s.user.ID][s] = true
+}
+
+// removeUserSocket forgets a closed connection
+func removeUserSocket(s *socketClient) {
+	userSocketsMu.Lock()
+	defer userSocketsMu.Unlock()
+
+	delete(userSockets[s.user.ID], s)
+	if len(userSockets[s.user.ID]) == 0 {
+		delete(userSockets, s.user.ID)
+	}
+}
+
+// notifyUser sends a message to every connection of a user and reports whether they had any
+func notifyUser(userID string, message Event) bool {
+	userSocketsMu.Lock()
+	clients := make([]*socketClient, 0, len(userSockets[userID]))
+	for s := range userSockets[userID] {
+		clients = append(clients, s)
+	}
+	userSocketsMu.Unlock()
+
+	for _, s := range clients {
+		s.send(message)
+	}
+	return len(clients) > 0
+}
+
+// liveSpectators returns how many spectators follow a game over WebSocket right now
+func liveSpectators(gameID string) int {
+	spectatorsMu.Lock()
+	defer spectatorsMu.Unlock()
+
+	return spectators[gameID]
+}
+
+// countSpectator adds to the spectators of a game (delta -1 removes one) and announces the new count
+func countSpectator(gameID string, delta int) {
+	spectatorsMu.Lock()
+	defer spectatorsMu.Unlock()
+
+	count := spectators[gameID] + delta
+	if count > 0 {
+		spectators[gameID] = count
+	} else {
+		delete(spectators, gameID)
+	}
+	hub.Broadcast(Event{Type: EventSpectators, GameID: gameID, Data: count})
+}
+
+// socketClient is one WebSocket connection and the games it follows
+type socketClient struct {
+	id       string // Key in sockets
+	since    time.Time
+	conn     *websocket.Conn
+	protocol string     // Subprotocol, see socketProtocol
+	events   chan Event // Hub listener of the connection
+	user     auth.User  // Signed in user (zero if anonymous)
+	ip       string     // Client address, for the rate limits
+
+	sendMu sync.Mutex // Frames are written by the event pump and the request loop
+
+	mu      sync.Mutex
+	seats   map[string]int     // Seat the client joined each game as (0 = spectator)
+	replays map[string]*replay // Replays under way, by game
+}
+
+// Real-time games over WebSocket: the client joins games as a player or spectator and
+// leaves them again, plays with move, pass and resign requests, and receives the events
+// of the games it follows and the board after every change, as its seat may see it
+// Finished games can be replayed too, at a pace or one move per request (see replay.go)
+// Lobby events (no game ID) reach every connection; spectator-only events never reach players
+// Signed in users send their access token in ?access_token=, as browsers can't set headers on
+// WebSockets, or are known by their session cookie
+func handleWebSocket(c echo.Context) error {
+	protocol := socketProtocol(c.Request())
+	server := websocket.Server{
+		// Origins aren't checked here: sockets can do nothing the REST API can't, and
+		// authenticate turns away cookie sessions from other sites
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			config.Protocol = nil
+			if r.Header.Get("Sec-WebSocket-Protocol") != "" {
+				config.Protocol = []string{protocol}
+			}
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			conn.MaxPayloadBytes = maxSocketMessage
+			client := &socketClient{conn: conn, protocol: protocol, seats: make(map[string]int), replays: make(map[string]*replay)}
+			client.user, _ = currentUser(c)
+			client.ip = c.RealIP()
+			client.serve(c.Request().Context())
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// serve pumps events to the client while reading its requests, until either side goes away
+func (s *socketClient) serve(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer s.conn.Close()
+
+	webSocketConnections.Add(1)
+	defer webSocketConnections.Add(-1)
+
+	s.since = time.Now()
+	addSocket(s)
+	defer removeSocket(s)
+
+	s.events = hub.Subscribe()
+	defer hub.Unsubscribe(s.events)
+	if s.user.ID != "" {
+		addUserSocket(s)
+		defer removeUserSocket(s)
+	}
+	defer s.leaveAll()
+
+	ticker := time.NewTicker(clockTickInterval)
+	defer ticker.Stop()
+
+	go func() {
+		defer cancel()
+		for {
+			var data []byte
+			if err := websocket.Message.Receive(s.conn, &data); err != nil {
+				return
+			}
+			s.handle(ctx, data)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stopping:
+			s.send(Event{Time: time.Now(), Type: SocketShutdown})
+			return
+		case event := <-s.events:
+			if err := s.forward(event); err != nil {
+				return
+			}
+		case now := <-ticker.C:
+			if err := s.tickClocks(now); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// forward sends a hub event if the client should see it, followed by the board if the event changed it
+func (s *socketClient) forward(event Event) error {
+	if event.GameID == "" {
+		return s.send(event) // Lobby event
+	}
+
+	s.mu.Lock()
+	gameID := event.GameID
+	player, following := s.seats[gameID]
+	s.mu.Unlock()
+	if !following || (player != 0 && spectatorOnlyEvents[event.Type]) {
+		return nil // Left the game while the event was queued, or not for players
+	}
+	if err := s.send(event); err != nil {
+		return err
+	}
+
+	if changesBoard(event.Type) {
+		return s.sendBoard(SocketBoard, gameID, player)
+	}
+	return nil
+}
+
+// handle carries out one request of the client
+func (s *socketClient) handle(ctx context.Context, data []byte) {
+	var req SocketRequest
+	if err := s.decode(data, &req); err != nil {
+		s.sendError("", "Invalid request format")
+		return
+	}
+
+	switch req.Type {
+	case "join":
+		s.join(req)
+		return
+	case "leave":
+		s.leave(req.GameID)
+		return
+	case "replay":
+		s.startReplay(ctx, req)
+		return
+	case "replay_step":
+		s.stepReplay(req)
+		return
+	case "replay_pace":
+		s.paceReplay(req)
+		return
+	case "replay_stop":
+		s.stopReplay(req)
+		return
+	}
+
+	gameID, player, err := s.seat(req.GameID)
+	if err != nil {
+		s.sendError(req.GameID, err.Error())
+		return
+	}
+	if player == 0 {
+		s.sendError(gameID, "Spectators can't play")
+		return
+	}
+
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		s.sendError(gameID, "Game not found")
+		return
+	}
+	defer unlock()
+
+	switch req.Type {
+	case "move", "pass":
+		if board.CurrentPlayer != player && !board.Hotseat {
+			s.sendError(gameID, "It is not your turn")
+			return
+		}
+		if allowed, _ := moveRateLimit.allow(s.ip, s.user.ID, time.Now()); !allowed {
+			s.sendError(gameID, "Too many requests, slow down")
+			return
+		}
+		err = playMove(ctx, gameID, board, MoveRequest{
+			Position:   req.Position,
+			Coordinate: req.Coordinate,
+			Pass:       req.Type == "pass",
+			Player:     teamMember(board, req.Token, req.Member),
+		})
+	case "resign":
+		phase := board.Phase
+		if err = board.Resign(player); err == nil {
+			saveGame(ctx, gameID, board)
+			announceResult(gameID, board, phase)
+		}
+	default:
+		err = fmt.Errorf("unknown request type %q", req.Type)
+	}
+	if err != nil {
+		s.sendError(gameID, err.Error())
+	}
+	// The board follows the events the change broadcast
+}
+
+// seat finds the game a request is about and the client's seat in it
+// Without a game ID, the request is about the only game the client follows
+func (s *socketClient) seat(gameID string) (string, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if gameID == "" {
+		if len(s.seats) != 1 {
+			return "", 0, fmt.Errorf("give the game ID when following several games")
+		}
+		for followed := range s.seats {
+			gameID = followed
+		}
+	}
+	player, following := s.seats[gameID]
+	if !following {
+		return "", 0, fmt.Errorf("join the game first")
+	}
+	return gameID, player, nil
+}
+
+// join makes the client follow a game and sends it the board
+// Joining a game again changes the seat
+func (s *socketClient) join(req SocketRequest) {
+	if req.Player < 0 || req.Player > 2 {
+		s.sendError(req.GameID, "Invalid player")
+		return
+	}
+
+	board, unlock, exists := lockGame(req.GameID)
+	var err error
+	if exists {
+		if req.Player != 0 {
+			err = checkSeat(board, req.Token, s.user.ID, req.Player)
+		}
+		unlock()
+	}
+	if !exists {
+		s.sendError(req.GameID, "Game not found")
+		return
+	}
+	if err != nil {
+		s.sendError(req.GameID, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	seat, following := s.seats[req.GameID]
+	s.seats[req.GameID] = req.Player
+	s.mu.Unlock()
+	hub.Follow(s.events, req.GameID)
+	switch {
+	case (!following || seat != 0) && req.Player == 0:
+		countSpectator(req.GameID, 1)
+	case following && seat == 0 && req.Player != 0:
+		countSpectator(req.GameID, -1)
+	}
+	s.sendBoard(SocketJoined, req.GameID, req.Player)
+}
+
+// leave stops following a game
+func (s *socketClient) leave(gameID string) {
+	s.mu.Lock()
+	seat, following := s.seats[gameID]
+	delete(s.seats, gameID)
+	s.mu.Unlock()
+	if !following {
+		s.sendError(gameID, "Not following this game")
+		return
+	}
+	if seat == 0 {
+		countSpectator(gameID, -1)
+	}
+
+	hub.Unfollow(s.events, gameID)
+	s.send(Event{Time: time.Now(), Type: SocketLeft, GameID: gameID})
+}
+
+// leaveAll stops following every game when the connection closes
+func (s *socketClient) leaveAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for gameID, seat := range s.seats {
+		if seat == 0 {
+			countSpectator(gameID, -1)
+		}
+		delete(s.seats, gameID)
+	}
+}
+
+// kick tells the client why it is being disconnected and closes the connection
+// The request loop then fails to read, which ends serve and everything it set up
+func (s *socketClient) kick(reason string) {
+	s.send(Event{Time: time.Now(), Type: SocketKicked, Data: map[string]string{"reason": reason}})
+	s.conn.Close()
+}
+
+// tickClocks sends the clocks of the followed games that are running
+func (s *socketClient) tickClocks(now time.Time) error {
+	s.mu.Lock()
+	gameIDs := make([]string, 0, len(s.seats))
+	for gameID := range s.seats {
+		gameIDs = append(gameIDs, gameID)
+	}
+	s.mu.Unlock()
+
+	var ticks []Event
+	for _, gameID := range gameIDs {
+		board, unlock, exists := lockGame(gameID)
+		if !exists {
+			continue
+		}
+		if board.Result == nil && board.Clock != nil && board.Clock.Running != 0 {
+			ticks = append(ticks, Event{Time: now, Type: SocketClock, GameID: gameID, Data: board.ClockState(now)})
+		}
+		unlock()
+	}
+
+	for _, tick := range ticks {
+		if err := s.send(tick); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode reads a request as JSON, or as MessagePack on the MessagePack subprotocol
+func (s *socketClient) decode(data []byte, req *SocketRequest) error {
+	if s.protocol == socketProtocolMsgpack && !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		decoder := msgpack.NewDecoder(bytes.NewReader(data))
+		decoder.SetCustomStructTag("json")
+		return decoder.Decode(req)
+	}
+	return json.Unmarshal(data, req)
+}
+
+// sendBoard sends the board of a game as the given seat may see it
+func (s *socketClient) sendBoard(messageType, gameID string, player int) error {
+	// Encoded under the lock, as the board keeps changing
+	board, unlock, exists := lockGame(gameID)
+	if !exists {
+		return nil // Purged in the meantime
+	}
+	data, binary, err := encodeSocketMessage(s.protocol, Event{Time: time.Now(), Type: messageType, GameID: gameID, Data: board.ViewFor(player)})
+	unlock()
+	if err != nil {
+		return err
+	}
+	return s.write(data, binary)
+}
+
+// sendError tells the client a request was rejected
+func (s *socketClient) sendError(gameID, message string) {
+	s.send(Event{Time: time.Now(), Type: SocketError, GameID: gameID, Data: map[string]string{"error": message}})
+}
+
+// send encodes and sends a message
+func (s *socketClient) send(message Event) error {
+	data, binary, err := encodeSocketMessage(s.protocol, message)
+	if err != nil {
+		return err
+	}
+	return s.write(data, binary)
+}
+
+// write sends a frame: binary frames carry MessagePack, text frames JSON
+func (s *socketClient) write(data []byte, binary bool) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	if binary {
+		return websocket.Message.Send(s.conn, data)
+	}
+	return websocket.Message.Send(s.conn, string(data))
+}